@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	stdlog "log"
+	"os"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/alibaba0010/postgres-api/internal/config"
+	"github.com/alibaba0010/postgres-api/internal/logger"
+)
+
+func main() {
+	// the configuration logs the files it reads; logs go to stderr
+	log := logger.Bootstrap()
+
+	app := &cli.App{
+		Name:  "config",
+		Usage: "inspect the server configuration",
+		Commands: []*cli.Command{
+			{
+				Name:      "dump",
+				Usage:     "print the configuration the server would run with, secrets redacted",
+				ArgsUsage: "[server flags, e.g. --config config.yaml --app-env production]",
+				// the arguments are the server's own flags
+				SkipFlagParsing: true,
+				Action: func(ctx *cli.Context) error {
+					cfg, err := config.Load(ctx.Args().Slice(), log)
+					if err != nil && cfg.APP_ENV == "" {
+						return err
+					}
+					if dumpErr := cfg.Dump(os.Stdout); dumpErr != nil {
+						return dumpErr
+					}
+					if err != nil {
+						return cli.Exit(fmt.Sprintf("the server would not start: %v", err), 1)
+					}
+					return nil
+				},
+			},
+		},
+	}
+	if err := app.Run(os.Args); err != nil {
+		stdlog.Fatal(err)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"strings"
 
+	"github.com/alibaba0010/postgres-api/internal/config"
 	postgres "github.com/alibaba0010/postgres-api/internal/database"
 	"github.com/alibaba0010/postgres-api/internal/logger"
 	"github.com/alibaba0010/postgres-api/internal/migration"
@@ -17,10 +18,19 @@ import (
 
 func main() {
 	// initialize project's logger (used by internal packages)
-	logger.InitLogger()
+	zlog := logger.Bootstrap()
 
-	// connect to the database using the project's database package
-	db := postgres.ConnectDB()
+	// connect to the database using the project's database package, with the
+	// configuration the server would run with
+	cfg, err := config.Load(nil, zlog)
+	if err != nil {
+		log.Fatal(err)
+	}
+	db, err := postgres.ConnectDB(cfg, zlog)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer postgres.CloseDB(db, zlog)
 
 	db.AddQueryHook(bundebug.NewQueryHook(
 		bundebug.WithEnabled(true),
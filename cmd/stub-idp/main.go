@@ -0,0 +1,39 @@
+// Command stub-idp runs the oidctest identity provider so social login can be
+// tried locally without a real provider account.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/alibaba0010/postgres-api/internal/oidctest"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:9000", "address to listen on")
+	clientID := flag.String("client-id", "restaurant-api", "client ID the API uses")
+	clientSecret := flag.String("client-secret", "stub-secret", "client secret the API uses")
+	subject := flag.String("subject", "stub-user-1", "subject of the signed-in user")
+	email := flag.String("email", "diner@example.com", "email of the signed-in user")
+	name := flag.String("name", "Stub Diner", "name of the signed-in user")
+	flag.Parse()
+
+	issuer := "http://" + *addr
+	provider, err := oidctest.NewProvider(issuer, oidctest.Client{ID: *clientID, Secret: *clientSecret})
+	if err != nil {
+		log.Fatal(err)
+	}
+	provider.SignInAs(oidctest.User{Subject: *subject, Email: *email, EmailVerified: true, Name: *name})
+
+	fmt.Println("Configure the API with:")
+	fmt.Println(strings.Join([]string{
+		"OIDC_PROVIDERS=stub",
+		"OIDC_STUB_ISSUER=" + issuer,
+		"OIDC_STUB_CLIENT_ID=" + *clientID,
+		"OIDC_STUB_CLIENT_SECRET=" + *clientSecret,
+	}, "\n"))
+	log.Fatal(http.ListenAndServe(*addr, provider))
+}
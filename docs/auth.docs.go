@@ -8,7 +8,7 @@ const authPaths = `
 				"Auth"
 			],
 			"summary": "User Signup",
-			"description": "Creates a new user account",
+			"description": "Creates a new customer account. role may be omitted or user; staff join restaurants through invitations (see /auth/invitations/accept).",
 			"operationId": "signup",
 			"parameters": [
 				{
@@ -82,11 +82,28 @@ const authPaths = `
 		}
 	},
 
+	"/auth/verify-email": {
+		"get": {
+			"tags": ["Auth"],
+			"summary": "Confirm a new email",
+			"description": "Switches the account to the address requested with /user/change-email. The token is emailed to the new address and can be used once.",
+			"operationId": "confirmEmailChange",
+			"parameters": [
+				{"name": "token", "in": "query", "description": "Email change token", "required": true, "type": "string"}
+			],
+			"responses": {
+				"200": {"description": "Email changed", "schema": {"type": "object", "properties": {"message": {"type": "string"}, "data": {"$ref": "#/definitions/User"}}}},
+				"400": {"description": "Invalid or expired token", "schema": {"$ref": "#/definitions/Error"}},
+				"409": {"description": "Email already in use", "schema": {"$ref": "#/definitions/Error"}}
+			}
+		}
+	},
+
 	"/auth/signin": {
 		"post": {
 			"tags": ["Auth"],
 			"summary": "Authenticate user",
-			"description": "Authenticates a user and returns a JWT token",
+			"description": "Authenticates a user and returns a JWT token. After three failed attempts for an email each further attempt must wait (doubling from one second); ten failures within 15 minutes lock the email, and fifty lock the client IP, for 15 minutes and the account owner is emailed. Accounts with two-factor authentication, and admins (who must enroll), instead receive an MFAChallengeResponse whose mfa_token is exchanged at /auth/mfa/verify, or /auth/mfa/enroll and /auth/mfa/confirm when enrollment_required is true.",
 			"operationId": "signin",
 			"parameters": [
 				{
@@ -105,11 +122,309 @@ const authPaths = `
 				}
 			],
 			"responses": {
-				"200": {"description": "Authenticated", "schema": {"$ref": "#/definitions/SigninResponse"}},
+				"200": {"description": "Authenticated, or a two-factor challenge (MFAChallengeResponse)", "schema": {"$ref": "#/definitions/SigninResponse"}},
 				"400": {"description": "Validation error", "schema": {"$ref": "#/definitions/Error"}},
 				"401": {"description": "Unauthorized", "schema": {"$ref": "#/definitions/Error"}},
+				"403": {"description": "The account is suspended", "schema": {"$ref": "#/definitions/Error"}},
+				"429": {"description": "Too many failed attempts for this email or IP; retry after the number of seconds in the Retry-After header", "schema": {"$ref": "#/definitions/Error"}},
+				"500": {"description": "Internal server error", "schema": {"$ref": "#/definitions/Error"}}
+			}
+		}
+	},
+
+	"/auth/oidc/{provider}/authorize": {
+		"get": {
+			"tags": ["Auth"],
+			"summary": "Sign in with a social provider",
+			"description": "Redirects the browser to the OpenID Connect provider (authorization code flow with PKCE). Providers are configured with OIDC_PROVIDERS, e.g. google. Sets the HttpOnly oidc_state cookie the callback checks.",
+			"operationId": "oidcAuthorize",
+			"parameters": [
+				{"name": "provider", "in": "path", "description": "Provider name", "required": true, "type": "string"}
+			],
+			"responses": {
+				"302": {"description": "Redirect to the provider"},
+				"404": {"description": "Unknown provider", "schema": {"$ref": "#/definitions/Error"}},
+				"502": {"description": "The provider is unavailable", "schema": {"$ref": "#/definitions/Error"}}
+			}
+		}
+	},
+
+	"/auth/oidc/{provider}/callback": {
+		"get": {
+			"tags": ["Auth"],
+			"summary": "Finish signing in with a social provider",
+			"description": "The provider redirects here. The verified ID token signs in the linked user, or the user with the same verified email (linking the provider), or creates a customer account. Responds like /auth/signin, including the two-factor challenge. When the flow was started from POST /user/identities/{provider} the provider is linked instead and the Identity is returned. The oidc_state cookie set when the flow started must match the state, so the flow can only end in the browser that started it.",
+			"operationId": "oidcCallback",
+			"parameters": [
+				{"name": "provider", "in": "path", "description": "Provider name", "required": true, "type": "string"},
+				{"name": "code", "in": "query", "required": true, "type": "string"},
+				{"name": "state", "in": "query", "required": true, "type": "string"}
+			],
+			"responses": {
+				"200": {"description": "Authenticated, a two-factor challenge, or the linked Identity", "schema": {"$ref": "#/definitions/SigninResponse"}},
+				"401": {"description": "Expired attempt, started in another browser, cancelled at the provider or unverifiable response", "schema": {"$ref": "#/definitions/Error"}},
+				"403": {"description": "The provider did not confirm the email, or the account is suspended", "schema": {"$ref": "#/definitions/Error"}},
+				"409": {"description": "The provider account is linked to another user", "schema": {"$ref": "#/definitions/Error"}},
+				"502": {"description": "The provider is unavailable", "schema": {"$ref": "#/definitions/Error"}}
+			}
+		}
+	},
+
+	"/auth/forgot-password": {
+		"post": {
+			"tags": ["Auth"],
+			"summary": "Request a password reset",
+			"description": "Emails a single-use reset link valid for 15 minutes. The response is identical whether or not the email is registered.",
+			"operationId": "forgotPassword",
+			"parameters": [
+				{
+					"in": "body",
+					"name": "body",
+					"required": true,
+					"schema": {
+						"type": "object",
+						"properties": {
+							"email": {"type": "string", "format": "email"}
+						},
+						"required": ["email"]
+					}
+				}
+			],
+			"responses": {
+				"200": {"description": "Reset link sent if the account exists"},
+				"400": {"description": "Validation error", "schema": {"$ref": "#/definitions/Error"}},
 				"500": {"description": "Internal server error", "schema": {"$ref": "#/definitions/Error"}}
 			}
 		}
 	},
+
+	"/auth/reset-password": {
+		"post": {
+			"tags": ["Auth"],
+			"summary": "Reset password",
+			"description": "Sets a new password using the emailed token and signs the user out of every session",
+			"operationId": "resetPassword",
+			"parameters": [
+				{
+					"in": "body",
+					"name": "body",
+					"required": true,
+					"schema": {
+						"type": "object",
+						"properties": {
+							"token": {"type": "string"},
+							"password": {"type": "string", "format": "password"},
+							"confirmPassword": {"type": "string", "format": "password"}
+						},
+						"required": ["token", "password", "confirmPassword"]
+					}
+				}
+			],
+			"responses": {
+				"200": {"description": "Password reset"},
+				"400": {"description": "Validation error or invalid/expired token", "schema": {"$ref": "#/definitions/Error"}},
+				"500": {"description": "Internal server error", "schema": {"$ref": "#/definitions/Error"}}
+			}
+		}
+	},
+
+	"/auth/invitations/accept": {
+		"post": {
+			"tags": ["Auth"],
+			"summary": "Accept a staff invitation",
+			"description": "Consumes the emailed invite token and grants the invited role in the restaurant. If no account uses the invited email, one is created with name and password (the user account role) and signed in like /auth/signin; otherwise the existing account gets the role and signs in as usual.",
+			"operationId": "acceptInvitation",
+			"parameters": [
+				{"in": "body", "name": "body", "required": true, "schema": {"$ref": "#/definitions/AcceptInvitationInput"}}
+			],
+			"responses": {
+				"200": {"description": "Invitation accepted; a new account is signed in", "schema": {"$ref": "#/definitions/SigninResponse"}},
+				"400": {"description": "Validation error or invalid, revoked or expired invitation", "schema": {"$ref": "#/definitions/Error"}},
+				"404": {"description": "Restaurant not found", "schema": {"$ref": "#/definitions/Error"}},
+				"409": {"description": "Invitation already accepted", "schema": {"$ref": "#/definitions/Error"}}
+			}
+		}
+	},
+
+	"/auth/refresh": {
+		"post": {
+			"tags": ["Auth"],
+			"summary": "Refresh tokens",
+			"description": "Exchanges the refresh token from the refresh_token cookie (or the body) for a new token pair. Each refresh token can be used once. Presenting a used one again within 30 seconds returns the session's current refresh token, so tabs refreshing at the same time all stay signed in; presenting it later revokes the whole session. The new tokens carry the user's current role.",
+			"operationId": "refreshToken",
+			"parameters": [
+				{
+					"in": "body",
+					"name": "body",
+					"required": false,
+					"schema": {
+						"type": "object",
+						"properties": {
+							"refresh_token": {"type": "string"}
+						}
+					}
+				}
+			],
+			"responses": {
+				"200": {"description": "New token pair", "schema": {"$ref": "#/definitions/TokenResponse"}},
+				"401": {"description": "Refresh token missing, invalid, revoked or reused", "schema": {"$ref": "#/definitions/Error"}}
+			}
+		}
+	},
+
+	"/auth/switch-restaurant": {
+		"post": {
+			"tags": ["Auth"],
+			"summary": "Switch active restaurant",
+			"description": "Exchanges the refresh token from the refresh_token cookie (or the body) for a token pair whose rid claim names the restaurant the session acts in. Routes under /restaurants/{id} reject tokens active in another restaurant, and /menus/{id} edits are limited to the active restaurant. An empty restaurant_id clears it. Only members of the restaurant (owners and staff holding a role there) and admins can switch to it.",
+			"operationId": "switchRestaurant",
+			"parameters": [
+				{
+					"in": "body",
+					"name": "body",
+					"required": true,
+					"schema": {
+						"type": "object",
+						"properties": {
+							"restaurant_id": {"type": "string", "format": "uuid"},
+							"refresh_token": {"type": "string"}
+						}
+					}
+				}
+			],
+			"responses": {
+				"200": {"description": "New token pair", "schema": {"$ref": "#/definitions/TokenResponse"}},
+				"400": {"description": "Invalid JSON body", "schema": {"$ref": "#/definitions/Error"}},
+				"401": {"description": "Refresh token missing, invalid, revoked or reused", "schema": {"$ref": "#/definitions/Error"}},
+				"403": {"description": "Not a member of the restaurant", "schema": {"$ref": "#/definitions/Error"}},
+				"404": {"description": "Restaurant not found", "schema": {"$ref": "#/definitions/Error"}}
+			}
+		}
+	},
+
+	"/auth/logout": {
+		"post": {
+			"tags": ["Auth"],
+			"summary": "Log out",
+			"description": "Revokes the current session's refresh tokens and access tokens, including a bearer token sent in the Authorization header, and clears the refresh_token cookie",
+			"operationId": "logout",
+			"parameters": [
+				{
+					"in": "body",
+					"name": "body",
+					"required": false,
+					"schema": {
+						"type": "object",
+						"properties": {
+							"refresh_token": {"type": "string"}
+						}
+					}
+				}
+			],
+			"responses": {
+				"200": {"description": "Logged out"}
+			}
+		}
+	},
+
+	"/auth/logout-all": {
+		"post": {
+			"tags": ["Auth"],
+			"summary": "Log out everywhere",
+			"description": "Revokes every refresh token of the authenticated user. Access tokens already issued are rejected from now on.",
+			"operationId": "logoutAll",
+			"security": [ { "Bearer": [] } ],
+			"responses": {
+				"200": {"description": "Logged out of all sessions"},
+				"401": {"description": "Unauthorized", "schema": {"$ref": "#/definitions/Error"}}
+			}
+		}
+	},
+
+	"/auth/mfa/verify": {
+		"post": {
+			"tags": ["Auth"],
+			"summary": "Complete two-factor sign-in",
+			"description": "Exchanges the mfa_token from sign-in and a TOTP code (or an unused recovery code) for session tokens. Five wrong codes cancel the sign-in.",
+			"operationId": "mfaVerify",
+			"parameters": [
+				{
+					"in": "body",
+					"name": "body",
+					"required": true,
+					"schema": {
+						"type": "object",
+						"properties": {
+							"mfa_token": {"type": "string"},
+							"code": {"type": "string", "example": "123456"},
+							"recovery_code": {"type": "string", "example": "abcde-fghij"}
+						},
+						"required": ["mfa_token"]
+					}
+				}
+			],
+			"responses": {
+				"200": {"description": "Authenticated", "schema": {"$ref": "#/definitions/SigninResponse"}},
+				"400": {"description": "Validation error", "schema": {"$ref": "#/definitions/Error"}},
+				"401": {"description": "Invalid code or expired mfa token", "schema": {"$ref": "#/definitions/Error"}}
+			}
+		}
+	},
+
+	"/auth/mfa/enroll": {
+		"post": {
+			"tags": ["Auth"],
+			"summary": "Enroll in two-factor authentication during sign-in",
+			"description": "For sign-ins with enrollment_required. Returns a TOTP secret, its otpauth URI and one-time recovery codes.",
+			"operationId": "pendingMfaEnroll",
+			"parameters": [
+				{
+					"in": "body",
+					"name": "body",
+					"required": true,
+					"schema": {
+						"type": "object",
+						"properties": {
+							"mfa_token": {"type": "string"}
+						},
+						"required": ["mfa_token"]
+					}
+				}
+			],
+			"responses": {
+				"200": {"description": "Enrollment started", "schema": {"$ref": "#/definitions/MFAEnrollment"}},
+				"401": {"description": "Expired mfa token", "schema": {"$ref": "#/definitions/Error"}},
+				"409": {"description": "Already enrolled", "schema": {"$ref": "#/definitions/Error"}}
+			}
+		}
+	},
+
+	"/auth/mfa/confirm": {
+		"post": {
+			"tags": ["Auth"],
+			"summary": "Confirm enrollment during sign-in",
+			"description": "Enables two-factor authentication with the first code from the authenticator and completes the sign-in",
+			"operationId": "pendingMfaConfirm",
+			"parameters": [
+				{
+					"in": "body",
+					"name": "body",
+					"required": true,
+					"schema": {
+						"type": "object",
+						"properties": {
+							"mfa_token": {"type": "string"},
+							"code": {"type": "string", "example": "123456"}
+						},
+						"required": ["mfa_token", "code"]
+					}
+				}
+			],
+			"responses": {
+				"200": {"description": "Authenticated", "schema": {"$ref": "#/definitions/SigninResponse"}},
+				"400": {"description": "Validation error or no enrollment in progress", "schema": {"$ref": "#/definitions/Error"}},
+				"401": {"description": "Invalid code or expired mfa token", "schema": {"$ref": "#/definitions/Error"}}
+			}
+		}
+	},
 `
@@ -11,6 +11,10 @@ const definitions = `
 				},
 				"message": {
 					"type": "string"
+				},
+				"request_id": {
+					"type": "string",
+					"description": "X-Request-ID of the request, to quote when reporting the error"
 				}
 			},
 			"required": [
@@ -35,6 +39,15 @@ const definitions = `
 				"address": {
 					"type": "string"
 				},
+				"phone": {
+					"type": "string",
+					"example": "+2348012345678"
+				},
+				"deletion_scheduled_at": {
+					"type": "string",
+					"format": "date-time",
+					"description": "Set while a requested account deletion can still be cancelled"
+				},
 				"role": {
 					"type": "string",
 					"example": "user"
@@ -122,6 +135,43 @@ const definitions = `
 			},
 			"required": ["title","data"]
 		},
+		"TokenResponse": {
+			"type": "object",
+			"properties": {
+				"title": { "type": "string", "example": "Token refreshed" },
+				"data": {
+					"type": "object",
+					"properties": {
+						"access_token": { "type": "string" },
+						"refresh_token": { "type": "string" }
+					}
+				}
+			}
+		},
+		"MFAChallengeResponse": {
+			"type": "object",
+			"properties": {
+				"title": { "type": "string", "example": "Two-factor authentication required" },
+				"data": {
+					"type": "object",
+					"properties": {
+						"mfa_required": { "type": "boolean" },
+						"enrollment_required": { "type": "boolean" },
+						"mfa_token": { "type": "string" },
+						"expires_in": { "type": "integer", "example": 300 }
+					}
+				}
+			}
+		},
+		"MFAEnrollment": {
+			"type": "object",
+			"properties": {
+				"secret": { "type": "string" },
+				"otpauth_uri": { "type": "string", "example": "otpauth://totp/Restaurant%20Management%20Platform:jane@example.com?secret=..." },
+				"recovery_codes": { "type": "array", "items": { "type": "string" }, "example": ["abcde-fghij"] },
+				"expires_in": { "type": "integer", "example": 600 }
+			}
+		},
 		"UserInput": {
 			"type": "object",
 			"properties": {
@@ -146,6 +196,22 @@ const definitions = `
 				"password"
 			]
 		},
+		"Session": {
+			"type": "object",
+			"properties": {
+				"id": { "type": "string", "format": "uuid" },
+				"device": { "type": "string", "example": "Chrome on Windows" },
+				"browser": { "type": "string", "example": "Chrome" },
+				"os": { "type": "string", "example": "Windows" },
+				"device_type": { "type": "string", "enum": ["desktop", "mobile", "tablet", "api_client", "unknown"] },
+				"ip_address": { "type": "string" },
+				"user_agent": { "type": "string" },
+				"signed_in_at": { "type": "string", "format": "date-time" },
+				"last_used_at": { "type": "string", "format": "date-time" },
+				"expires_at": { "type": "string", "format": "date-time" },
+				"current": { "type": "boolean" }
+			}
+		},
 		"Restaurant": {
 			"type": "object",
 			"properties": {
@@ -153,6 +219,10 @@ const definitions = `
 					"type": "string",
 					"format": "uuid"
 				},
+				"owner_id": {
+					"type": "string",
+					"format": "uuid"
+				},
 				"name": {
 					"type": "string"
 				},
@@ -211,6 +281,456 @@ const definitions = `
 				"name",
 				"address"
 			]
+		},
+		"RestaurantUpdateInput": {
+			"type": "object",
+			"properties": {
+				"name": { "type": "string", "minLength": 2, "maxLength": 100 },
+				"description": { "type": "string", "maxLength": 500 },
+				"address": { "type": "string", "minLength": 5, "maxLength": 200 },
+				"cuisine_type": { "type": "string", "maxLength": 50 },
+				"rating": { "type": "number", "format": "float", "minimum": 0, "maximum": 5 }
+			}
+		},
+		"Menu": {
+			"type": "object",
+			"properties": {
+				"id": { "type": "string", "format": "uuid" },
+				"restaurant_id": { "type": "string", "format": "uuid" },
+				"name": { "type": "string" },
+				"type": { "type": "string", "enum": ["breakfast", "lunch", "dinner", "all_day"] },
+				"description": { "type": "string" },
+				"is_active": { "type": "boolean" },
+				"items": { "type": "array", "items": { "$ref": "#/definitions/MenuItem" } },
+				"created_at": { "type": "string", "format": "date-time" },
+				"updated_at": { "type": "string", "format": "date-time" }
+			},
+			"required": ["id", "restaurant_id", "name", "type"]
+		},
+		"MenuInput": {
+			"type": "object",
+			"properties": {
+				"name": { "type": "string", "minLength": 2, "maxLength": 100 },
+				"type": { "type": "string", "enum": ["breakfast", "lunch", "dinner", "all_day"] },
+				"description": { "type": "string", "maxLength": 500 },
+				"is_active": { "type": "boolean" }
+			},
+			"required": ["name", "type"]
+		},
+		"MenuItem": {
+			"type": "object",
+			"properties": {
+				"id": { "type": "string", "format": "uuid" },
+				"menu_id": { "type": "string", "format": "uuid" },
+				"restaurant_id": { "type": "string", "format": "uuid" },
+				"category": { "type": "string", "example": "Starters" },
+				"name": { "type": "string" },
+				"description": { "type": "string" },
+				"price_cents": { "type": "integer", "format": "int64", "example": 1250 },
+				"currency": { "type": "string", "example": "USD" },
+				"allergens": { "type": "array", "items": { "type": "string" }, "example": ["nuts", "gluten"] },
+				"dietary_tags": { "type": "array", "items": { "type": "string" }, "example": ["vegetarian"] },
+				"is_available": { "type": "boolean" },
+				"available_from": { "type": "string", "example": "11:00" },
+				"available_until": { "type": "string", "example": "15:00" },
+				"created_at": { "type": "string", "format": "date-time" },
+				"updated_at": { "type": "string", "format": "date-time" }
+			},
+			"required": ["id", "menu_id", "category", "name", "price_cents", "currency"]
+		},
+		"MenuItemInput": {
+			"type": "object",
+			"properties": {
+				"category": { "type": "string", "minLength": 2, "maxLength": 50 },
+				"name": { "type": "string", "minLength": 2, "maxLength": 100 },
+				"description": { "type": "string", "maxLength": 500 },
+				"price_cents": { "type": "integer", "format": "int64", "minimum": 0 },
+				"currency": { "type": "string", "minLength": 3, "maxLength": 3 },
+				"allergens": { "type": "array", "items": { "type": "string" } },
+				"dietary_tags": { "type": "array", "items": { "type": "string" } },
+				"is_available": { "type": "boolean" },
+				"available_from": { "type": "string", "description": "HH:MM, required with available_until" },
+				"available_until": { "type": "string", "description": "HH:MM, required with available_from" }
+			},
+			"required": ["category", "name", "price_cents"]
+		},
+		"Order": {
+			"type": "object",
+			"properties": {
+				"id": { "type": "string", "format": "uuid" },
+				"restaurant_id": { "type": "string", "format": "uuid" },
+				"customer_id": { "type": "string", "format": "uuid" },
+				"type": { "type": "string", "enum": ["dine_in", "takeaway", "delivery"] },
+				"status": { "type": "string", "enum": ["pending", "accepted", "preparing", "ready", "served", "delivered", "paid", "cancelled", "refunded"] },
+				"table_number": { "type": "string" },
+				"notes": { "type": "string" },
+				"subtotal_cents": { "type": "integer", "format": "int64" },
+				"currency": { "type": "string" },
+				"items": {
+					"type": "array",
+					"items": {
+						"type": "object",
+						"properties": {
+							"id": { "type": "string", "format": "uuid" },
+							"menu_item_id": { "type": "string", "format": "uuid" },
+							"name": { "type": "string" },
+							"unit_price_cents": { "type": "integer", "format": "int64" },
+							"quantity": { "type": "integer" },
+							"total_cents": { "type": "integer", "format": "int64" },
+							"notes": { "type": "string" }
+						}
+					}
+				},
+				"transitions": {
+					"type": "array",
+					"items": {
+						"type": "object",
+						"properties": {
+							"from_status": { "type": "string" },
+							"to_status": { "type": "string" },
+							"actor_id": { "type": "string", "format": "uuid" },
+							"actor_role": { "type": "string" },
+							"reason": { "type": "string" },
+							"created_at": { "type": "string", "format": "date-time" }
+						}
+					}
+				},
+				"created_at": { "type": "string", "format": "date-time" },
+				"updated_at": { "type": "string", "format": "date-time" }
+			},
+			"required": ["id", "restaurant_id", "customer_id", "type", "status", "subtotal_cents", "currency"]
+		},
+		"OrderInput": {
+			"type": "object",
+			"properties": {
+				"restaurant_id": { "type": "string", "format": "uuid" },
+				"type": { "type": "string", "enum": ["dine_in", "takeaway", "delivery"] },
+				"table_number": { "type": "string" },
+				"notes": { "type": "string", "maxLength": 500 },
+				"items": {
+					"type": "array",
+					"minItems": 1,
+					"items": {
+						"type": "object",
+						"properties": {
+							"menu_item_id": { "type": "string", "format": "uuid" },
+							"quantity": { "type": "integer", "minimum": 1, "maximum": 100 },
+							"notes": { "type": "string", "maxLength": 200 }
+						},
+						"required": ["menu_item_id", "quantity"]
+					}
+				}
+			},
+			"required": ["restaurant_id", "type", "items"]
+		},
+		"OrderTransitionInput": {
+			"type": "object",
+			"properties": {
+				"status": { "type": "string", "enum": ["accepted", "preparing", "ready", "served", "delivered", "paid", "cancelled", "refunded"] },
+				"reason": { "type": "string", "maxLength": 500 }
+			},
+			"required": ["status"]
+		},
+		"Table": {
+			"type": "object",
+			"properties": {
+				"id": { "type": "string", "format": "uuid" },
+				"restaurant_id": { "type": "string", "format": "uuid" },
+				"label": { "type": "string", "example": "T4" },
+				"capacity": { "type": "integer", "example": 4 },
+				"section": { "type": "string", "example": "terrace" },
+				"is_active": { "type": "boolean" },
+				"created_at": { "type": "string", "format": "date-time" },
+				"updated_at": { "type": "string", "format": "date-time" }
+			},
+			"required": ["id", "restaurant_id", "label", "capacity", "is_active"]
+		},
+		"TableInput": {
+			"type": "object",
+			"properties": {
+				"label": { "type": "string", "minLength": 1, "maxLength": 20 },
+				"capacity": { "type": "integer", "minimum": 1, "maximum": 100 },
+				"section": { "type": "string", "maxLength": 50 },
+				"is_active": { "type": "boolean" }
+			},
+			"required": ["label", "capacity"]
+		},
+		"OpeningHour": {
+			"type": "object",
+			"properties": {
+				"id": { "type": "string", "format": "uuid" },
+				"restaurant_id": { "type": "string", "format": "uuid" },
+				"weekday": { "type": "integer", "minimum": 0, "maximum": 6, "description": "0 = Sunday" },
+				"opens_at": { "type": "string", "example": "12:00" },
+				"closes_at": { "type": "string", "example": "22:30" }
+			}
+		},
+		"OpeningHoursInput": {
+			"type": "object",
+			"properties": {
+				"hours": {
+					"type": "array",
+					"items": {
+						"type": "object",
+						"properties": {
+							"weekday": { "type": "integer", "minimum": 0, "maximum": 6 },
+							"opens_at": { "type": "string", "example": "12:00" },
+							"closes_at": { "type": "string", "example": "22:30" }
+						},
+						"required": ["weekday", "opens_at", "closes_at"]
+					}
+				}
+			},
+			"required": ["hours"]
+		},
+		"AvailabilitySlot": {
+			"type": "object",
+			"properties": {
+				"starts_at": { "type": "string", "format": "date-time" },
+				"ends_at": { "type": "string", "format": "date-time" },
+				"available_tables": { "type": "integer" }
+			}
+		},
+		"Reservation": {
+			"type": "object",
+			"properties": {
+				"id": { "type": "string", "format": "uuid" },
+				"restaurant_id": { "type": "string", "format": "uuid" },
+				"table_id": { "type": "string", "format": "uuid" },
+				"table": { "$ref": "#/definitions/Table" },
+				"customer_id": { "type": "string", "format": "uuid" },
+				"party_size": { "type": "integer" },
+				"starts_at": { "type": "string", "format": "date-time" },
+				"ends_at": { "type": "string", "format": "date-time" },
+				"status": { "type": "string", "enum": ["confirmed", "cancelled", "completed", "no_show"] },
+				"notes": { "type": "string" },
+				"created_at": { "type": "string", "format": "date-time" },
+				"updated_at": { "type": "string", "format": "date-time" }
+			},
+			"required": ["id", "restaurant_id", "table_id", "customer_id", "party_size", "starts_at", "ends_at", "status"]
+		},
+		"ReservationInput": {
+			"type": "object",
+			"properties": {
+				"party_size": { "type": "integer", "minimum": 1, "maximum": 100 },
+				"starts_at": { "type": "string", "format": "date-time" },
+				"duration_minutes": { "type": "integer", "minimum": 30, "maximum": 360, "description": "Defaults to 90" },
+				"notes": { "type": "string", "maxLength": 500 }
+			},
+			"required": ["party_size", "starts_at"]
+		},
+		"Role": {
+			"type": "object",
+			"properties": {
+				"id": { "type": "string", "format": "uuid" },
+				"name": { "type": "string", "example": "waiter" },
+				"description": { "type": "string" },
+				"permissions": { "type": "array", "items": { "type": "string", "enum": ["restaurant:create", "user:manage", "role:manage", "restaurant:write", "restaurant:delete", "menu:write", "order:read", "order:advance", "reservation:manage", "table:manage", "staff:manage", "report:read"] } },
+				"built_in": { "type": "boolean" },
+				"created_at": { "type": "string", "format": "date-time" },
+				"updated_at": { "type": "string", "format": "date-time" }
+			},
+			"required": ["id", "name", "permissions", "built_in"]
+		},
+		"RoleInput": {
+			"type": "object",
+			"properties": {
+				"name": { "type": "string", "minLength": 2, "maxLength": 50 },
+				"description": { "type": "string", "maxLength": 255 },
+				"permissions": { "type": "array", "items": { "type": "string" }, "example": ["order:read", "order:advance"] }
+			},
+			"required": ["name", "permissions"]
+		},
+		"Permission": {
+			"type": "object",
+			"properties": {
+				"name": { "type": "string", "example": "menu:write" },
+				"restaurant_scoped": { "type": "boolean", "description": "Whether assigning a role in a restaurant grants this permission there" }
+			}
+		},
+		"StaffMember": {
+			"type": "object",
+			"properties": {
+				"id": { "type": "string", "format": "uuid", "description": "Assignment ID" },
+				"user_id": { "type": "string", "format": "uuid" },
+				"name": { "type": "string" },
+				"email": { "type": "string" },
+				"role_id": { "type": "string", "format": "uuid" },
+				"role_name": { "type": "string" },
+				"created_at": { "type": "string", "format": "date-time" }
+			}
+		},
+		"StaffInput": {
+			"type": "object",
+			"properties": {
+				"email": { "type": "string", "format": "email" },
+				"role_id": { "type": "string", "format": "uuid" }
+			},
+			"required": ["email", "role_id"]
+		},
+		"Membership": {
+			"type": "object",
+			"properties": {
+				"restaurant_id": { "type": "string", "format": "uuid" },
+				"restaurant_name": { "type": "string" },
+				"roles": { "type": "array", "items": { "type": "string" }, "example": ["owner"] },
+				"active": { "type": "boolean" }
+			}
+		},
+		"Invitation": {
+			"type": "object",
+			"properties": {
+				"id": { "type": "string", "format": "uuid" },
+				"restaurant_id": { "type": "string", "format": "uuid" },
+				"email": { "type": "string", "format": "email" },
+				"role_id": { "type": "string", "format": "uuid" },
+				"role_name": { "type": "string", "example": "waiter" },
+				"status": { "type": "string", "enum": ["pending", "accepted", "revoked", "expired"] },
+				"expires_at": { "type": "string", "format": "date-time" },
+				"created_at": { "type": "string", "format": "date-time" }
+			}
+		},
+		"InvitationInput": {
+			"type": "object",
+			"properties": {
+				"email": { "type": "string", "format": "email" },
+				"role_id": { "type": "string", "format": "uuid" }
+			},
+			"required": ["email", "role_id"]
+		},
+		"AcceptInvitationInput": {
+			"type": "object",
+			"properties": {
+				"token": { "type": "string" },
+				"name": { "type": "string", "description": "Required when no account uses the invited email" },
+				"password": { "type": "string", "format": "password", "description": "Required when no account uses the invited email" },
+				"confirmPassword": { "type": "string", "format": "password" }
+			},
+			"required": ["token"]
+		},
+		"AdminUser": {
+			"type": "object",
+			"properties": {
+				"id": { "type": "string", "format": "uuid" },
+				"name": { "type": "string" },
+				"email": { "type": "string", "format": "email" },
+				"address": { "type": "string" },
+				"phone": { "type": "string" },
+				"role": { "type": "string", "enum": ["user", "management", "admin"] },
+				"status": { "type": "string", "enum": ["active", "suspended"] },
+				"mfa_enabled": { "type": "boolean" },
+				"suspended_at": { "type": "string", "format": "date-time" },
+				"suspended_reason": { "type": "string" },
+				"created_at": { "type": "string", "format": "date-time" },
+				"updated_at": { "type": "string", "format": "date-time" }
+			}
+		},
+		"UserList": {
+			"type": "object",
+			"properties": {
+				"title": { "type": "string", "example": "Success" },
+				"data": { "type": "array", "items": { "$ref": "#/definitions/AdminUser" } },
+				"pagination": {
+					"type": "object",
+					"properties": {
+						"total": { "type": "integer" },
+						"limit": { "type": "integer" },
+						"offset": { "type": "integer" }
+					}
+				}
+			}
+		},
+		"ProfileInput": {
+			"type": "object",
+			"properties": {
+				"name": { "type": "string", "minLength": 3, "maxLength": 50 },
+				"address": { "type": "string", "maxLength": 200, "description": "An empty string clears it" },
+				"phone": { "type": "string", "example": "+2348012345678", "description": "E.164 format; an empty string clears it" }
+			}
+		},
+		"ChangePasswordInput": {
+			"type": "object",
+			"properties": {
+				"currentPassword": { "type": "string", "format": "password" },
+				"password": { "type": "string", "format": "password" },
+				"confirmPassword": { "type": "string", "format": "password" }
+			},
+			"required": ["currentPassword", "password", "confirmPassword"]
+		},
+		"ChangeEmailInput": {
+			"type": "object",
+			"properties": {
+				"email": { "type": "string", "format": "email" },
+				"password": { "type": "string", "format": "password" }
+			},
+			"required": ["email", "password"]
+		},
+		"AccountDeletion": {
+			"type": "object",
+			"properties": {
+				"message": { "type": "string" },
+				"deletion_scheduled_at": { "type": "string", "format": "date-time" }
+			}
+		},
+		"AccountExport": {
+			"type": "object",
+			"properties": {
+				"generated_at": { "type": "string", "format": "date-time" },
+				"profile": { "$ref": "#/definitions/User" },
+				"sessions": {
+					"type": "array",
+					"items": {
+						"type": "object",
+						"properties": {
+							"session_id": { "type": "string" },
+							"ip_address": { "type": "string" },
+							"user_agent": { "type": "string" },
+							"issued_at": { "type": "string", "format": "date-time" },
+							"expires_at": { "type": "string", "format": "date-time" },
+							"rotated_at": { "type": "string", "format": "date-time" }
+						}
+					}
+				},
+				"memberships": { "type": "array", "items": { "$ref": "#/definitions/Membership" } },
+				"identities": { "type": "array", "items": { "$ref": "#/definitions/Identity" } },
+				"orders": { "type": "array", "items": { "$ref": "#/definitions/Order" } },
+				"reservations": { "type": "array", "items": { "$ref": "#/definitions/Reservation" } }
+			}
+		},
+		"Identity": {
+			"type": "object",
+			"properties": {
+				"id": { "type": "string", "format": "uuid" },
+				"user_id": { "type": "string", "format": "uuid" },
+				"provider": { "type": "string", "example": "google" },
+				"email": { "type": "string", "format": "email" },
+				"created_at": { "type": "string", "format": "date-time" },
+				"last_used_at": { "type": "string", "format": "date-time" }
+			}
+		},
+		"APIKey": {
+			"type": "object",
+			"properties": {
+				"id": { "type": "string", "format": "uuid" },
+				"name": { "type": "string", "example": "POS integration" },
+				"prefix": { "type": "string", "example": "rmk_1a2b3c4d5e6f" },
+				"restaurant_id": { "type": "string", "format": "uuid" },
+				"expires_at": { "type": "string", "format": "date-time" },
+				"last_used_at": { "type": "string", "format": "date-time" },
+				"created_at": { "type": "string", "format": "date-time" }
+			}
+		},
+		"CreatedAPIKey": {
+			"type": "object",
+			"properties": {
+				"id": { "type": "string", "format": "uuid" },
+				"name": { "type": "string", "example": "POS integration" },
+				"prefix": { "type": "string", "example": "rmk_1a2b3c4d5e6f" },
+				"restaurant_id": { "type": "string", "format": "uuid" },
+				"expires_at": { "type": "string", "format": "date-time" },
+				"created_at": { "type": "string", "format": "date-time" },
+				"key": { "type": "string", "description": "Shown only once" }
+			}
 		}
 	}
 `
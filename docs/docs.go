@@ -8,7 +8,7 @@ const docTemplate = `{
   "swagger": "2.0",
   "info": {
     "title": "Restaurant Management API",
-    "description": "A Restaurant Management API built using Go",
+    "description": "A Restaurant Management API built using Go. Requests are rate limited per client (300 per minute, 20 per minute on /auth); every response carries RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers, and requests over the limit get 429 with Retry-After. Staff routes under /restaurants/{id} only accept members of that restaurant and tokens not active in another one (see /auth/switch-restaurant). Every response carries an X-Request-ID header, the one sent by the client when it is at most 128 letters, digits and -_.: characters, and error bodies repeat it as request_id.",
     "version": "1.0.0",
     "contact": {
       "email": "yzakariyahali100@gmail.com"
@@ -35,9 +35,15 @@ const docTemplate = `{
       "name": "Authorization",
       "in": "header",
       "description": "Enter the token with the Bearer prefix, e.g. 'Bearer abcde12345'"
+    },
+    "ApiKey": {
+      "type": "apiKey",
+      "name": "Authorization",
+      "in": "header",
+      "description": "Enter a management API key with the ApiKey prefix, e.g. 'ApiKey rmk_...'"
     }
   },
-  "paths": {` + systemPaths + authPaths + usersPaths + restaurantsPaths + `},` + definitions + `,` + tags + `}`
+  "paths": {` + systemPaths + authPaths + usersPaths + restaurantsPaths + menusPaths + ordersPaths + reservationsPaths + rolesPaths + `},` + definitions + `,` + tags + `}`
 
 // SwaggerInfo holds exported Swagger Info so clients can modify it
 var SwaggerInfo = &swag.Spec{
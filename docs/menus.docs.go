@@ -0,0 +1,153 @@
+package docs
+
+// Menus API endpoints documentation
+const menusPaths = `
+	"/restaurants/{id}/menus": {
+		"get": {
+			"tags": ["Menus"],
+			"summary": "List restaurant menus",
+			"description": "Returns the active menus of a restaurant",
+			"operationId": "listMenus",
+			"parameters": [
+				{"name": "id", "in": "path", "description": "Restaurant ID", "required": true, "type": "string"}
+			],
+			"responses": {
+				"200": {"description": "Successful operation", "schema": {"type": "array", "items": {"$ref": "#/definitions/Menu"}}},
+				"404": {"description": "Restaurant not found", "schema": {"$ref": "#/definitions/Error"}},
+				"500": {"description": "Internal server error", "schema": {"$ref": "#/definitions/Error"}}
+			}
+		},
+		"post": {
+			"tags": ["Menus"],
+			"summary": "Create a menu",
+			"description": "Adds a menu to a restaurant managed by the caller",
+			"operationId": "createMenu",
+			"security": [ { "Bearer": [] } ],
+			"parameters": [
+				{"name": "id", "in": "path", "description": "Restaurant ID", "required": true, "type": "string"},
+				{"in": "body", "name": "menu", "required": true, "schema": {"$ref": "#/definitions/MenuInput"}}
+			],
+			"responses": {
+				"201": {"description": "Menu created", "schema": {"$ref": "#/definitions/Menu"}},
+				"400": {"description": "Invalid input", "schema": {"$ref": "#/definitions/Error"}},
+				"401": {"description": "Unauthorized", "schema": {"$ref": "#/definitions/Error"}},
+				"403": {"description": "Forbidden", "schema": {"$ref": "#/definitions/Error"}},
+				"404": {"description": "Restaurant not found", "schema": {"$ref": "#/definitions/Error"}}
+			}
+		}
+	},
+
+	"/menus/{id}": {
+		"get": {
+			"tags": ["Menus"],
+			"summary": "Get a menu",
+			"description": "Returns a menu with all of its items",
+			"operationId": "getMenu",
+			"parameters": [
+				{"name": "id", "in": "path", "description": "Menu ID", "required": true, "type": "string"}
+			],
+			"responses": {
+				"200": {"description": "Successful operation", "schema": {"$ref": "#/definitions/Menu"}},
+				"404": {"description": "Menu not found", "schema": {"$ref": "#/definitions/Error"}}
+			}
+		},
+		"patch": {
+			"tags": ["Menus"],
+			"summary": "Update a menu",
+			"operationId": "updateMenu",
+			"security": [ { "Bearer": [] } ],
+			"parameters": [
+				{"name": "id", "in": "path", "description": "Menu ID", "required": true, "type": "string"},
+				{"in": "body", "name": "menu", "required": true, "schema": {"$ref": "#/definitions/MenuInput"}}
+			],
+			"responses": {
+				"200": {"description": "Menu updated", "schema": {"$ref": "#/definitions/Menu"}},
+				"400": {"description": "Invalid input", "schema": {"$ref": "#/definitions/Error"}},
+				"403": {"description": "Forbidden, or no active restaurant (see /auth/switch-restaurant)", "schema": {"$ref": "#/definitions/Error"}},
+				"404": {"description": "Menu not found", "schema": {"$ref": "#/definitions/Error"}}
+			}
+		},
+		"delete": {
+			"tags": ["Menus"],
+			"summary": "Delete a menu",
+			"description": "Deletes a menu and all of its items",
+			"operationId": "deleteMenu",
+			"security": [ { "Bearer": [] } ],
+			"parameters": [
+				{"name": "id", "in": "path", "description": "Menu ID", "required": true, "type": "string"}
+			],
+			"responses": {
+				"204": {"description": "Menu deleted"},
+				"403": {"description": "Forbidden, or no active restaurant (see /auth/switch-restaurant)", "schema": {"$ref": "#/definitions/Error"}},
+				"404": {"description": "Menu not found", "schema": {"$ref": "#/definitions/Error"}}
+			}
+		}
+	},
+
+	"/menus/{id}/items": {
+		"get": {
+			"tags": ["Menus"],
+			"summary": "List menu items",
+			"operationId": "listMenuItems",
+			"parameters": [
+				{"name": "id", "in": "path", "description": "Menu ID", "required": true, "type": "string"},
+				{"name": "category", "in": "query", "description": "Filter by category", "required": false, "type": "string"}
+			],
+			"responses": {
+				"200": {"description": "Successful operation", "schema": {"type": "array", "items": {"$ref": "#/definitions/MenuItem"}}},
+				"404": {"description": "Menu not found", "schema": {"$ref": "#/definitions/Error"}}
+			}
+		},
+		"post": {
+			"tags": ["Menus"],
+			"summary": "Add a menu item",
+			"operationId": "createMenuItem",
+			"security": [ { "Bearer": [] } ],
+			"parameters": [
+				{"name": "id", "in": "path", "description": "Menu ID", "required": true, "type": "string"},
+				{"in": "body", "name": "item", "required": true, "schema": {"$ref": "#/definitions/MenuItemInput"}}
+			],
+			"responses": {
+				"201": {"description": "Menu item created", "schema": {"$ref": "#/definitions/MenuItem"}},
+				"400": {"description": "Invalid input", "schema": {"$ref": "#/definitions/Error"}},
+				"403": {"description": "Forbidden, or no active restaurant (see /auth/switch-restaurant)", "schema": {"$ref": "#/definitions/Error"}},
+				"404": {"description": "Menu not found", "schema": {"$ref": "#/definitions/Error"}}
+			}
+		}
+	},
+
+	"/menus/{id}/items/{itemId}": {
+		"patch": {
+			"tags": ["Menus"],
+			"summary": "Update a menu item",
+			"operationId": "updateMenuItem",
+			"security": [ { "Bearer": [] } ],
+			"parameters": [
+				{"name": "id", "in": "path", "description": "Menu ID", "required": true, "type": "string"},
+				{"name": "itemId", "in": "path", "description": "Menu item ID", "required": true, "type": "string"},
+				{"in": "body", "name": "item", "required": true, "schema": {"$ref": "#/definitions/MenuItemInput"}}
+			],
+			"responses": {
+				"200": {"description": "Menu item updated", "schema": {"$ref": "#/definitions/MenuItem"}},
+				"400": {"description": "Invalid input", "schema": {"$ref": "#/definitions/Error"}},
+				"403": {"description": "Forbidden, or no active restaurant (see /auth/switch-restaurant)", "schema": {"$ref": "#/definitions/Error"}},
+				"404": {"description": "Menu item not found", "schema": {"$ref": "#/definitions/Error"}}
+			}
+		},
+		"delete": {
+			"tags": ["Menus"],
+			"summary": "Delete a menu item",
+			"operationId": "deleteMenuItem",
+			"security": [ { "Bearer": [] } ],
+			"parameters": [
+				{"name": "id", "in": "path", "description": "Menu ID", "required": true, "type": "string"},
+				{"name": "itemId", "in": "path", "description": "Menu item ID", "required": true, "type": "string"}
+			],
+			"responses": {
+				"204": {"description": "Menu item deleted"},
+				"403": {"description": "Forbidden, or no active restaurant (see /auth/switch-restaurant)", "schema": {"$ref": "#/definitions/Error"}},
+				"404": {"description": "Menu item not found", "schema": {"$ref": "#/definitions/Error"}}
+			}
+		}
+	},
+`
@@ -0,0 +1,99 @@
+package docs
+
+// Orders API endpoints documentation
+const ordersPaths = `
+	"/orders": {
+		"get": {
+			"tags": ["Orders"],
+			"summary": "List my orders",
+			"description": "Returns the authenticated customer's orders, newest first",
+			"operationId": "listMyOrders",
+			"security": [ { "Bearer": [] } ],
+			"parameters": [
+				{"name": "status", "in": "query", "description": "Filter by status", "required": false, "type": "string"},
+				{"name": "limit", "in": "query", "required": false, "type": "integer"},
+				{"name": "offset", "in": "query", "required": false, "type": "integer"}
+			],
+			"responses": {
+				"200": {"description": "Successful operation", "schema": {"type": "array", "items": {"$ref": "#/definitions/Order"}}},
+				"401": {"description": "Unauthorized", "schema": {"$ref": "#/definitions/Error"}}
+			}
+		},
+		"post": {
+			"tags": ["Orders"],
+			"summary": "Place an order",
+			"description": "Creates a pending order. Prices are taken from the restaurant's current menu.",
+			"operationId": "createOrder",
+			"security": [ { "Bearer": [] } ],
+			"parameters": [
+				{"in": "body", "name": "order", "required": true, "schema": {"$ref": "#/definitions/OrderInput"}}
+			],
+			"responses": {
+				"201": {"description": "Order created", "schema": {"$ref": "#/definitions/Order"}},
+				"400": {"description": "Invalid input", "schema": {"$ref": "#/definitions/Error"}},
+				"401": {"description": "Unauthorized", "schema": {"$ref": "#/definitions/Error"}},
+				"404": {"description": "Restaurant not found", "schema": {"$ref": "#/definitions/Error"}}
+			}
+		}
+	},
+
+	"/orders/{id}": {
+		"get": {
+			"tags": ["Orders"],
+			"summary": "Get an order",
+			"description": "Returns an order with its items and status history",
+			"operationId": "getOrder",
+			"security": [ { "Bearer": [] } ],
+			"parameters": [
+				{"name": "id", "in": "path", "description": "Order ID", "required": true, "type": "string"}
+			],
+			"responses": {
+				"200": {"description": "Successful operation", "schema": {"$ref": "#/definitions/Order"}},
+				"401": {"description": "Unauthorized", "schema": {"$ref": "#/definitions/Error"}},
+				"404": {"description": "Order not found", "schema": {"$ref": "#/definitions/Error"}}
+			}
+		}
+	},
+
+	"/orders/{id}/status": {
+		"post": {
+			"tags": ["Orders"],
+			"summary": "Change order status",
+			"description": "Moves an order through pending → accepted → preparing → ready → served/delivered → paid, or to cancelled/refunded. Staff with order:advance advance orders; customers may only cancel a pending order.",
+			"operationId": "transitionOrder",
+			"security": [ { "Bearer": [] } ],
+			"parameters": [
+				{"name": "id", "in": "path", "description": "Order ID", "required": true, "type": "string"},
+				{"in": "body", "name": "body", "required": true, "schema": {"$ref": "#/definitions/OrderTransitionInput"}}
+			],
+			"responses": {
+				"200": {"description": "Status changed", "schema": {"$ref": "#/definitions/Order"}},
+				"400": {"description": "Invalid input", "schema": {"$ref": "#/definitions/Error"}},
+				"403": {"description": "Forbidden", "schema": {"$ref": "#/definitions/Error"}},
+				"404": {"description": "Order not found", "schema": {"$ref": "#/definitions/Error"}},
+				"409": {"description": "Transition not allowed from the current status", "schema": {"$ref": "#/definitions/Error"}}
+			}
+		}
+	},
+
+	"/restaurants/{id}/orders": {
+		"get": {
+			"tags": ["Orders"],
+			"summary": "List restaurant orders",
+			"description": "Returns a restaurant's orders to staff with order:read",
+			"operationId": "listRestaurantOrders",
+			"security": [ { "Bearer": [] } ],
+			"parameters": [
+				{"name": "id", "in": "path", "description": "Restaurant ID", "required": true, "type": "string"},
+				{"name": "status", "in": "query", "description": "Filter by status", "required": false, "type": "string"},
+				{"name": "limit", "in": "query", "required": false, "type": "integer"},
+				{"name": "offset", "in": "query", "required": false, "type": "integer"}
+			],
+			"responses": {
+				"200": {"description": "Successful operation", "schema": {"type": "array", "items": {"$ref": "#/definitions/Order"}}},
+				"403": {"description": "Forbidden", "schema": {"$ref": "#/definitions/Error"}},
+				"404": {"description": "Restaurant not found", "schema": {"$ref": "#/definitions/Error"}}
+			}
+		}
+	},
+`
@@ -0,0 +1,228 @@
+package docs
+
+// Reservations API endpoints documentation
+const reservationsPaths = `
+	"/restaurants/{id}/tables": {
+		"get": {
+			"tags": ["Reservations"],
+			"summary": "List tables",
+			"description": "Returns a restaurant's tables to staff with table:manage",
+			"operationId": "listTables",
+			"security": [ { "Bearer": [] } ],
+			"parameters": [
+				{"name": "id", "in": "path", "description": "Restaurant ID", "required": true, "type": "string"}
+			],
+			"responses": {
+				"200": {"description": "Successful operation", "schema": {"type": "array", "items": {"$ref": "#/definitions/Table"}}},
+				"403": {"description": "Forbidden", "schema": {"$ref": "#/definitions/Error"}},
+				"404": {"description": "Restaurant not found", "schema": {"$ref": "#/definitions/Error"}}
+			}
+		},
+		"post": {
+			"tags": ["Reservations"],
+			"summary": "Add a table",
+			"operationId": "createTable",
+			"security": [ { "Bearer": [] } ],
+			"parameters": [
+				{"name": "id", "in": "path", "description": "Restaurant ID", "required": true, "type": "string"},
+				{"in": "body", "name": "table", "required": true, "schema": {"$ref": "#/definitions/TableInput"}}
+			],
+			"responses": {
+				"201": {"description": "Table created", "schema": {"$ref": "#/definitions/Table"}},
+				"400": {"description": "Invalid input", "schema": {"$ref": "#/definitions/Error"}},
+				"403": {"description": "Forbidden", "schema": {"$ref": "#/definitions/Error"}},
+				"409": {"description": "Label already used", "schema": {"$ref": "#/definitions/Error"}}
+			}
+		}
+	},
+
+	"/restaurants/{id}/tables/{tableId}": {
+		"patch": {
+			"tags": ["Reservations"],
+			"summary": "Update a table",
+			"description": "Partially updates a table. Also available as PUT.",
+			"operationId": "updateTable",
+			"security": [ { "Bearer": [] } ],
+			"parameters": [
+				{"name": "id", "in": "path", "description": "Restaurant ID", "required": true, "type": "string"},
+				{"name": "tableId", "in": "path", "description": "Table ID", "required": true, "type": "string"},
+				{"in": "body", "name": "table", "required": true, "schema": {"$ref": "#/definitions/TableInput"}}
+			],
+			"responses": {
+				"200": {"description": "Table updated", "schema": {"$ref": "#/definitions/Table"}},
+				"400": {"description": "Invalid input", "schema": {"$ref": "#/definitions/Error"}},
+				"403": {"description": "Forbidden", "schema": {"$ref": "#/definitions/Error"}},
+				"404": {"description": "Table not found", "schema": {"$ref": "#/definitions/Error"}}
+			}
+		},
+		"delete": {
+			"tags": ["Reservations"],
+			"summary": "Delete a table",
+			"description": "Deletes a table that has no reservations. Deactivate it instead to keep its history.",
+			"operationId": "deleteTable",
+			"security": [ { "Bearer": [] } ],
+			"parameters": [
+				{"name": "id", "in": "path", "description": "Restaurant ID", "required": true, "type": "string"},
+				{"name": "tableId", "in": "path", "description": "Table ID", "required": true, "type": "string"}
+			],
+			"responses": {
+				"204": {"description": "Table deleted"},
+				"403": {"description": "Forbidden", "schema": {"$ref": "#/definitions/Error"}},
+				"404": {"description": "Table not found", "schema": {"$ref": "#/definitions/Error"}},
+				"409": {"description": "Table has reservations", "schema": {"$ref": "#/definitions/Error"}}
+			}
+		}
+	},
+
+	"/restaurants/{id}/opening-hours": {
+		"get": {
+			"tags": ["Reservations"],
+			"summary": "Get opening hours",
+			"description": "Returns the weekly opening windows. Weekday 0 is Sunday; times are HH:MM in the restaurant's timezone.",
+			"operationId": "getOpeningHours",
+			"parameters": [
+				{"name": "id", "in": "path", "description": "Restaurant ID", "required": true, "type": "string"}
+			],
+			"responses": {
+				"200": {"description": "Successful operation", "schema": {"type": "array", "items": {"$ref": "#/definitions/OpeningHour"}}},
+				"404": {"description": "Restaurant not found", "schema": {"$ref": "#/definitions/Error"}}
+			}
+		},
+		"put": {
+			"tags": ["Reservations"],
+			"summary": "Replace opening hours",
+			"description": "Replaces the whole weekly schedule. Windows on the same weekday must not overlap.",
+			"operationId": "setOpeningHours",
+			"security": [ { "Bearer": [] } ],
+			"parameters": [
+				{"name": "id", "in": "path", "description": "Restaurant ID", "required": true, "type": "string"},
+				{"in": "body", "name": "schedule", "required": true, "schema": {"$ref": "#/definitions/OpeningHoursInput"}}
+			],
+			"responses": {
+				"200": {"description": "Schedule replaced", "schema": {"type": "array", "items": {"$ref": "#/definitions/OpeningHour"}}},
+				"400": {"description": "Invalid input", "schema": {"$ref": "#/definitions/Error"}},
+				"403": {"description": "Forbidden", "schema": {"$ref": "#/definitions/Error"}}
+			}
+		}
+	},
+
+	"/restaurants/{id}/availability": {
+		"get": {
+			"tags": ["Reservations"],
+			"summary": "Check availability",
+			"description": "Returns the start times on a date at which a table can seat the party",
+			"operationId": "getAvailability",
+			"parameters": [
+				{"name": "id", "in": "path", "description": "Restaurant ID", "required": true, "type": "string"},
+				{"name": "date", "in": "query", "description": "Date in the restaurant's timezone (YYYY-MM-DD)", "required": true, "type": "string", "format": "date"},
+				{"name": "party_size", "in": "query", "required": true, "type": "integer"},
+				{"name": "duration_minutes", "in": "query", "description": "Defaults to 90", "required": false, "type": "integer"}
+			],
+			"responses": {
+				"200": {"description": "Successful operation", "schema": {"type": "array", "items": {"$ref": "#/definitions/AvailabilitySlot"}}},
+				"400": {"description": "Invalid input", "schema": {"$ref": "#/definitions/Error"}},
+				"404": {"description": "Restaurant not found", "schema": {"$ref": "#/definitions/Error"}}
+			}
+		}
+	},
+
+	"/restaurants/{id}/reservations": {
+		"get": {
+			"tags": ["Reservations"],
+			"summary": "List restaurant reservations",
+			"description": "Returns a restaurant's reservation book to staff with reservation:manage",
+			"operationId": "listRestaurantReservations",
+			"security": [ { "Bearer": [] } ],
+			"parameters": [
+				{"name": "id", "in": "path", "description": "Restaurant ID", "required": true, "type": "string"},
+				{"name": "status", "in": "query", "description": "Filter by status", "required": false, "type": "string"},
+				{"name": "from", "in": "query", "description": "Starting at or after (RFC 3339)", "required": false, "type": "string", "format": "date-time"},
+				{"name": "to", "in": "query", "description": "Starting before (RFC 3339)", "required": false, "type": "string", "format": "date-time"},
+				{"name": "limit", "in": "query", "required": false, "type": "integer"},
+				{"name": "offset", "in": "query", "required": false, "type": "integer"}
+			],
+			"responses": {
+				"200": {"description": "Successful operation", "schema": {"type": "array", "items": {"$ref": "#/definitions/Reservation"}}},
+				"403": {"description": "Forbidden", "schema": {"$ref": "#/definitions/Error"}},
+				"404": {"description": "Restaurant not found", "schema": {"$ref": "#/definitions/Error"}}
+			}
+		},
+		"post": {
+			"tags": ["Reservations"],
+			"summary": "Book a table",
+			"description": "Reserves the smallest free table that seats the party during opening hours. A confirmation email is sent to the customer.",
+			"operationId": "createReservation",
+			"security": [ { "Bearer": [] } ],
+			"parameters": [
+				{"name": "id", "in": "path", "description": "Restaurant ID", "required": true, "type": "string"},
+				{"in": "body", "name": "reservation", "required": true, "schema": {"$ref": "#/definitions/ReservationInput"}}
+			],
+			"responses": {
+				"201": {"description": "Reservation confirmed", "schema": {"$ref": "#/definitions/Reservation"}},
+				"400": {"description": "Invalid input or outside opening hours", "schema": {"$ref": "#/definitions/Error"}},
+				"401": {"description": "Unauthorized", "schema": {"$ref": "#/definitions/Error"}},
+				"404": {"description": "Restaurant not found", "schema": {"$ref": "#/definitions/Error"}},
+				"409": {"description": "No table free at that time", "schema": {"$ref": "#/definitions/Error"}}
+			}
+		}
+	},
+
+	"/reservations": {
+		"get": {
+			"tags": ["Reservations"],
+			"summary": "List my reservations",
+			"description": "Returns the authenticated customer's reservations",
+			"operationId": "listMyReservations",
+			"security": [ { "Bearer": [] } ],
+			"parameters": [
+				{"name": "status", "in": "query", "description": "Filter by status", "required": false, "type": "string"},
+				{"name": "from", "in": "query", "description": "Starting at or after (RFC 3339)", "required": false, "type": "string", "format": "date-time"},
+				{"name": "to", "in": "query", "description": "Starting before (RFC 3339)", "required": false, "type": "string", "format": "date-time"},
+				{"name": "limit", "in": "query", "required": false, "type": "integer"},
+				{"name": "offset", "in": "query", "required": false, "type": "integer"}
+			],
+			"responses": {
+				"200": {"description": "Successful operation", "schema": {"type": "array", "items": {"$ref": "#/definitions/Reservation"}}},
+				"401": {"description": "Unauthorized", "schema": {"$ref": "#/definitions/Error"}}
+			}
+		}
+	},
+
+	"/reservations/{id}": {
+		"get": {
+			"tags": ["Reservations"],
+			"summary": "Get a reservation",
+			"operationId": "getReservation",
+			"security": [ { "Bearer": [] } ],
+			"parameters": [
+				{"name": "id", "in": "path", "description": "Reservation ID", "required": true, "type": "string"}
+			],
+			"responses": {
+				"200": {"description": "Successful operation", "schema": {"$ref": "#/definitions/Reservation"}},
+				"401": {"description": "Unauthorized", "schema": {"$ref": "#/definitions/Error"}},
+				"404": {"description": "Reservation not found", "schema": {"$ref": "#/definitions/Error"}}
+			}
+		}
+	},
+
+	"/reservations/{id}/status": {
+		"post": {
+			"tags": ["Reservations"],
+			"summary": "Change reservation status",
+			"description": "Customers may cancel their own reservation before it starts. Staff with reservation:manage may also mark it completed or no_show.",
+			"operationId": "updateReservationStatus",
+			"security": [ { "Bearer": [] } ],
+			"parameters": [
+				{"name": "id", "in": "path", "description": "Reservation ID", "required": true, "type": "string"},
+				{"in": "body", "name": "body", "required": true, "schema": {"type": "object", "properties": {"status": {"type": "string", "enum": ["cancelled", "completed", "no_show"]}}, "required": ["status"]}}
+			],
+			"responses": {
+				"200": {"description": "Status changed", "schema": {"$ref": "#/definitions/Reservation"}},
+				"400": {"description": "Invalid input", "schema": {"$ref": "#/definitions/Error"}},
+				"403": {"description": "Forbidden", "schema": {"$ref": "#/definitions/Error"}},
+				"404": {"description": "Reservation not found", "schema": {"$ref": "#/definitions/Error"}},
+				"409": {"description": "Reservation is no longer confirmed", "schema": {"$ref": "#/definitions/Error"}}
+			}
+		}
+	},
+`
@@ -8,8 +8,14 @@ const restaurantsPaths = `
 				"Restaurants"
 			],
 			"summary": "List all restaurants",
-			"description": "Returns a list of restaurants",
+			"description": "Returns a paginated list of restaurants",
 			"operationId": "listRestaurants",
+			"parameters": [
+				{"name": "cuisine_type", "in": "query", "description": "Filter by cuisine type", "required": false, "type": "string"},
+				{"name": "owner_id", "in": "query", "description": "Filter by owner", "required": false, "type": "string"},
+				{"name": "limit", "in": "query", "description": "Page size (default 20, max 100)", "required": false, "type": "integer"},
+				{"name": "offset", "in": "query", "description": "Number of restaurants to skip", "required": false, "type": "integer"}
+			],
 			"responses": {
 				"200": {
 					"description": "Successful operation",
@@ -70,6 +76,12 @@ const restaurantsPaths = `
 						"$ref": "#/definitions/Error"
 					}
 				},
+				"403": {
+					"description": "Forbidden",
+					"schema": {
+						"$ref": "#/definitions/Error"
+					}
+				},
 				"500": {
 					"description": "Internal server error",
 					"schema": {
@@ -79,4 +91,56 @@ const restaurantsPaths = `
 			}
 		}
 	},
+
+	"/restaurants/{id}": {
+		"get": {
+			"tags": ["Restaurants"],
+			"summary": "Get restaurant by ID",
+			"operationId": "getRestaurant",
+			"parameters": [
+				{"name": "id", "in": "path", "description": "Restaurant ID", "required": true, "type": "string"}
+			],
+			"responses": {
+				"200": {"description": "Successful operation", "schema": {"$ref": "#/definitions/Restaurant"}},
+				"404": {"description": "Restaurant not found", "schema": {"$ref": "#/definitions/Error"}},
+				"500": {"description": "Internal server error", "schema": {"$ref": "#/definitions/Error"}}
+			}
+		},
+		"patch": {
+			"tags": ["Restaurants"],
+			"summary": "Update a restaurant",
+			"description": "Partially updates a restaurant. Only the owner or an admin may update it.",
+			"operationId": "updateRestaurant",
+			"security": [ { "Bearer": [] } ],
+			"parameters": [
+				{"name": "id", "in": "path", "description": "Restaurant ID", "required": true, "type": "string"},
+				{"in": "body", "name": "restaurant", "required": true, "schema": {"$ref": "#/definitions/RestaurantUpdateInput"}}
+			],
+			"responses": {
+				"200": {"description": "Restaurant updated", "schema": {"$ref": "#/definitions/Restaurant"}},
+				"400": {"description": "Invalid input", "schema": {"$ref": "#/definitions/Error"}},
+				"401": {"description": "Unauthorized", "schema": {"$ref": "#/definitions/Error"}},
+				"403": {"description": "Forbidden", "schema": {"$ref": "#/definitions/Error"}},
+				"404": {"description": "Restaurant not found", "schema": {"$ref": "#/definitions/Error"}},
+				"500": {"description": "Internal server error", "schema": {"$ref": "#/definitions/Error"}}
+			}
+		},
+		"delete": {
+			"tags": ["Restaurants"],
+			"summary": "Delete a restaurant",
+			"description": "Soft-deletes a restaurant. Only the owner or an admin may delete it.",
+			"operationId": "deleteRestaurant",
+			"security": [ { "Bearer": [] } ],
+			"parameters": [
+				{"name": "id", "in": "path", "description": "Restaurant ID", "required": true, "type": "string"}
+			],
+			"responses": {
+				"204": {"description": "Restaurant deleted"},
+				"401": {"description": "Unauthorized", "schema": {"$ref": "#/definitions/Error"}},
+				"403": {"description": "Forbidden", "schema": {"$ref": "#/definitions/Error"}},
+				"404": {"description": "Restaurant not found", "schema": {"$ref": "#/definitions/Error"}},
+				"500": {"description": "Internal server error", "schema": {"$ref": "#/definitions/Error"}}
+			}
+		}
+	},
 `
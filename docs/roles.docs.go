@@ -0,0 +1,189 @@
+package docs
+
+// Roles and restaurant staff API endpoints documentation
+const rolesPaths = `
+	"/roles": {
+		"get": {
+			"tags": ["Roles"],
+			"summary": "List roles",
+			"description": "Returns every role and the permissions it grants. Requires staff:manage in at least one restaurant.",
+			"operationId": "listRoles",
+			"security": [ { "Bearer": [] } ],
+			"responses": {
+				"200": {"description": "Successful operation", "schema": {"type": "array", "items": {"$ref": "#/definitions/Role"}}},
+				"403": {"description": "Forbidden", "schema": {"$ref": "#/definitions/Error"}}
+			}
+		},
+		"post": {
+			"tags": ["Roles"],
+			"summary": "Create a role",
+			"description": "Defines a custom role. Requires role:manage.",
+			"operationId": "createRole",
+			"security": [ { "Bearer": [] } ],
+			"parameters": [
+				{"in": "body", "name": "role", "required": true, "schema": {"$ref": "#/definitions/RoleInput"}}
+			],
+			"responses": {
+				"201": {"description": "Role created", "schema": {"$ref": "#/definitions/Role"}},
+				"400": {"description": "Invalid input, unknown permission or duplicate name", "schema": {"$ref": "#/definitions/Error"}},
+				"403": {"description": "Forbidden", "schema": {"$ref": "#/definitions/Error"}}
+			}
+		}
+	},
+
+	"/roles/permissions": {
+		"get": {
+			"tags": ["Roles"],
+			"summary": "List permissions",
+			"description": "Returns every permission a role can grant. Only restaurant-scoped permissions take effect when a role is assigned in a restaurant.",
+			"operationId": "listPermissions",
+			"security": [ { "Bearer": [] } ],
+			"responses": {
+				"200": {"description": "Successful operation", "schema": {"type": "array", "items": {"$ref": "#/definitions/Permission"}}},
+				"403": {"description": "Forbidden", "schema": {"$ref": "#/definitions/Error"}}
+			}
+		}
+	},
+
+	"/roles/{id}": {
+		"patch": {
+			"tags": ["Roles"],
+			"summary": "Update a role",
+			"description": "Partially updates a custom role. Built-in roles cannot be changed. Also available as PUT. Requires role:manage.",
+			"operationId": "updateRole",
+			"security": [ { "Bearer": [] } ],
+			"parameters": [
+				{"name": "id", "in": "path", "description": "Role ID", "required": true, "type": "string"},
+				{"in": "body", "name": "role", "required": true, "schema": {"$ref": "#/definitions/RoleInput"}}
+			],
+			"responses": {
+				"200": {"description": "Role updated", "schema": {"$ref": "#/definitions/Role"}},
+				"400": {"description": "Invalid input", "schema": {"$ref": "#/definitions/Error"}},
+				"403": {"description": "Forbidden or built-in role", "schema": {"$ref": "#/definitions/Error"}},
+				"404": {"description": "Role not found", "schema": {"$ref": "#/definitions/Error"}}
+			}
+		},
+		"delete": {
+			"tags": ["Roles"],
+			"summary": "Delete a role",
+			"description": "Deletes a custom role and every assignment of it. Requires role:manage.",
+			"operationId": "deleteRole",
+			"security": [ { "Bearer": [] } ],
+			"parameters": [
+				{"name": "id", "in": "path", "description": "Role ID", "required": true, "type": "string"}
+			],
+			"responses": {
+				"204": {"description": "Role deleted"},
+				"403": {"description": "Forbidden or built-in role", "schema": {"$ref": "#/definitions/Error"}},
+				"404": {"description": "Role not found", "schema": {"$ref": "#/definitions/Error"}}
+			}
+		}
+	},
+
+	"/restaurants/{id}/staff": {
+		"get": {
+			"tags": ["Roles"],
+			"summary": "List restaurant staff",
+			"description": "Returns the roles assigned in the restaurant. The owner holds the built-in owner role implicitly and is not listed. Requires staff:manage in the restaurant.",
+			"operationId": "listStaff",
+			"security": [ { "Bearer": [] } ],
+			"parameters": [
+				{"name": "id", "in": "path", "description": "Restaurant ID", "required": true, "type": "string"}
+			],
+			"responses": {
+				"200": {"description": "Successful operation", "schema": {"type": "array", "items": {"$ref": "#/definitions/StaffMember"}}},
+				"403": {"description": "Forbidden", "schema": {"$ref": "#/definitions/Error"}}
+			}
+		},
+		"post": {
+			"tags": ["Roles"],
+			"summary": "Assign a role",
+			"description": "Grants a role in the restaurant to an existing account. Account roles (user, management, admin) cannot be assigned. Only roles whose permissions the caller holds in the restaurant can be granted, and only an owner of the restaurant can grant the owner role.",
+			"operationId": "assignStaff",
+			"security": [ { "Bearer": [] } ],
+			"parameters": [
+				{"name": "id", "in": "path", "description": "Restaurant ID", "required": true, "type": "string"},
+				{"in": "body", "name": "assignment", "required": true, "schema": {"$ref": "#/definitions/StaffInput"}}
+			],
+			"responses": {
+				"201": {"description": "Role assigned", "schema": {"$ref": "#/definitions/StaffMember"}},
+				"400": {"description": "Invalid input", "schema": {"$ref": "#/definitions/Error"}},
+				"403": {"description": "Forbidden, or the role grants permissions the caller lacks", "schema": {"$ref": "#/definitions/Error"}},
+				"404": {"description": "Role or account not found", "schema": {"$ref": "#/definitions/Error"}},
+				"409": {"description": "Already assigned", "schema": {"$ref": "#/definitions/Error"}}
+			}
+		}
+	},
+
+	"/restaurants/{id}/staff/{assignmentId}": {
+		"delete": {
+			"tags": ["Roles"],
+			"summary": "Revoke a role assignment",
+			"operationId": "removeStaff",
+			"security": [ { "Bearer": [] } ],
+			"parameters": [
+				{"name": "id", "in": "path", "description": "Restaurant ID", "required": true, "type": "string"},
+				{"name": "assignmentId", "in": "path", "description": "Assignment ID", "required": true, "type": "string"}
+			],
+			"responses": {
+				"204": {"description": "Assignment removed"},
+				"403": {"description": "Forbidden", "schema": {"$ref": "#/definitions/Error"}},
+				"404": {"description": "Staff member not found", "schema": {"$ref": "#/definitions/Error"}}
+			}
+		}
+	},
+
+	"/restaurants/{id}/invitations": {
+		"get": {
+			"tags": ["Roles"],
+			"summary": "List staff invitations",
+			"description": "Returns the restaurant's invitations, newest first, with their status. Requires staff:manage in the restaurant.",
+			"operationId": "listInvitations",
+			"security": [ { "Bearer": [] } ],
+			"parameters": [
+				{"name": "id", "in": "path", "description": "Restaurant ID", "required": true, "type": "string"}
+			],
+			"responses": {
+				"200": {"description": "Successful operation", "schema": {"type": "array", "items": {"$ref": "#/definitions/Invitation"}}},
+				"403": {"description": "Forbidden", "schema": {"$ref": "#/definitions/Error"}}
+			}
+		},
+		"post": {
+			"tags": ["Roles"],
+			"summary": "Invite staff",
+			"description": "Emails a signed invite link, valid for 7 days, that grants the role in the restaurant to whoever accepts it at /auth/invitations/accept. The built-in cook, waiter and cashier roles cover the usual staff; account roles (user, management, admin) cannot be granted. Inviting the same email with the same role again replaces the pending invitation. Only roles whose permissions the caller holds in the restaurant can be granted, and only an owner of the restaurant can grant the owner role.",
+			"operationId": "createInvitation",
+			"security": [ { "Bearer": [] } ],
+			"parameters": [
+				{"name": "id", "in": "path", "description": "Restaurant ID", "required": true, "type": "string"},
+				{"in": "body", "name": "invitation", "required": true, "schema": {"$ref": "#/definitions/InvitationInput"}}
+			],
+			"responses": {
+				"201": {"description": "Invitation sent", "schema": {"$ref": "#/definitions/Invitation"}},
+				"400": {"description": "Invalid input", "schema": {"$ref": "#/definitions/Error"}},
+				"403": {"description": "Forbidden, or the role grants permissions the caller lacks", "schema": {"$ref": "#/definitions/Error"}},
+				"404": {"description": "Role not found", "schema": {"$ref": "#/definitions/Error"}},
+				"409": {"description": "The user already has this role here", "schema": {"$ref": "#/definitions/Error"}}
+			}
+		}
+	},
+
+	"/restaurants/{id}/invitations/{invitationId}": {
+		"delete": {
+			"tags": ["Roles"],
+			"summary": "Revoke an invitation",
+			"description": "Stops a pending invitation's link from working",
+			"operationId": "revokeInvitation",
+			"security": [ { "Bearer": [] } ],
+			"parameters": [
+				{"name": "id", "in": "path", "description": "Restaurant ID", "required": true, "type": "string"},
+				{"name": "invitationId", "in": "path", "description": "Invitation ID", "required": true, "type": "string"}
+			],
+			"responses": {
+				"204": {"description": "Invitation revoked"},
+				"403": {"description": "Forbidden", "schema": {"$ref": "#/definitions/Error"}},
+				"404": {"description": "Pending invitation not found", "schema": {"$ref": "#/definitions/Error"}}
+			}
+		}
+	},
+`
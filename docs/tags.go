@@ -18,6 +18,22 @@ const tags = `
 		{
 			"name": "Restaurants",
 			"description": "Operations about restaurants"
+		},
+		{
+			"name": "Menus",
+			"description": "Restaurant menus and menu items"
+		},
+		{
+			"name": "Orders",
+			"description": "Customer orders and their status lifecycle"
+		},
+		{
+			"name": "Reservations",
+			"description": "Tables, opening hours and table bookings"
+		},
+		{
+			"name": "Roles",
+			"description": "Roles, permissions and restaurant staff"
 		}
 	]
 `
@@ -18,85 +18,469 @@ const usersPaths = `
 				"401": { "description": "Unauthorized", "schema": { "$ref": "#/definitions/Error" } },
 				"500": { "description": "Internal server error", "schema": { "$ref": "#/definitions/Error" } }
 			}
+		},
+		"patch": {
+			"tags": ["Users"],
+			"summary": "Update my profile",
+			"description": "Changes the name, address or phone of the current user. Omitted fields are left untouched.",
+			"operationId": "updateProfile",
+			"security": [ { "Bearer": [] } ],
+			"parameters": [
+				{ "in": "body", "name": "body", "required": true, "schema": { "$ref": "#/definitions/ProfileInput" } }
+			],
+			"responses": {
+				"200": { "description": "Profile updated", "schema": { "$ref": "#/definitions/User" } },
+				"400": { "description": "Validation error", "schema": { "$ref": "#/definitions/Error" } },
+				"401": { "description": "Unauthorized", "schema": { "$ref": "#/definitions/Error" } }
+			}
+		},
+		"delete": {
+			"tags": ["Users"],
+			"summary": "Delete my account",
+			"description": "Requires the password. Signs the user out everywhere and schedules the deletion 30 days ahead; signing in and calling /user/cancel-deletion before then keeps the account. Afterwards personal details are erased and upcoming reservations cancelled, while orders are kept without them for accounting. Restaurant owners must delete their restaurants first; admins cannot delete themselves.",
+			"operationId": "deleteAccount",
+			"security": [ { "Bearer": [] } ],
+			"parameters": [
+				{ "in": "body", "name": "body", "required": true, "schema": { "type": "object", "properties": { "password": { "type": "string", "format": "password" } }, "required": ["password"] } }
+			],
+			"responses": {
+				"202": { "description": "Deletion scheduled", "schema": { "$ref": "#/definitions/AccountDeletion" } },
+				"400": { "description": "Wrong password", "schema": { "$ref": "#/definitions/Error" } },
+				"401": { "description": "Unauthorized", "schema": { "$ref": "#/definitions/Error" } },
+				"403": { "description": "Admins cannot delete their own account", "schema": { "$ref": "#/definitions/Error" } },
+				"409": { "description": "Already scheduled, or the user owns restaurants", "schema": { "$ref": "#/definitions/Error" } },
+				"429": { "description": "Too many attempts", "schema": { "$ref": "#/definitions/Error" } }
+			}
 		}
 	},
 
-	"/users": {
+	"/user/cancel-deletion": {
+		"post": {
+			"tags": ["Users"],
+			"summary": "Cancel my account deletion",
+			"operationId": "cancelAccountDeletion",
+			"security": [ { "Bearer": [] } ],
+			"responses": {
+				"200": { "description": "Deletion cancelled", "schema": { "$ref": "#/definitions/User" } },
+				"401": { "description": "Unauthorized", "schema": { "$ref": "#/definitions/Error" } },
+				"409": { "description": "No deletion is scheduled", "schema": { "$ref": "#/definitions/Error" } }
+			}
+		}
+	},
+
+	"/user/export": {
 		"get": {
-			"tags": [
-				"Users"
+			"tags": ["Users"],
+			"summary": "Export my data",
+			"description": "Downloads everything held about the current user as a JSON file: profile, sign-in sessions, restaurant memberships, orders and reservations.",
+			"operationId": "exportAccount",
+			"produces": ["application/json"],
+			"security": [ { "Bearer": [] } ],
+			"responses": {
+				"200": { "description": "Account export, sent as an attachment", "schema": { "$ref": "#/definitions/AccountExport" } },
+				"401": { "description": "Unauthorized", "schema": { "$ref": "#/definitions/Error" } }
+			}
+		}
+	},
+
+	"/user/change-password": {
+		"post": {
+			"tags": ["Users"],
+			"summary": "Change my password",
+			"description": "Requires the current password. Every other session of the user is signed out. Limited to 5 attempts per 15 minutes together with /user/change-email.",
+			"operationId": "changePassword",
+			"security": [ { "Bearer": [] } ],
+			"parameters": [
+				{ "in": "body", "name": "body", "required": true, "schema": { "$ref": "#/definitions/ChangePasswordInput" } }
+			],
+			"responses": {
+				"200": { "description": "Password changed" },
+				"400": { "description": "Validation error or wrong current password", "schema": { "$ref": "#/definitions/Error" } },
+				"401": { "description": "Unauthorized", "schema": { "$ref": "#/definitions/Error" } },
+				"429": { "description": "Too many attempts", "schema": { "$ref": "#/definitions/Error" } }
+			}
+		}
+	},
+
+	"/user/change-email": {
+		"post": {
+			"tags": ["Users"],
+			"summary": "Change my email",
+			"description": "Requires the current password. Emails a link to the new address; the account keeps its current email until the link is opened (GET /auth/verify-email) within 15 minutes.",
+			"operationId": "changeEmail",
+			"security": [ { "Bearer": [] } ],
+			"parameters": [
+				{ "in": "body", "name": "body", "required": true, "schema": { "$ref": "#/definitions/ChangeEmailInput" } }
 			],
-			"summary": "List all users",
-			"description": "Returns a list of users in the system",
+			"responses": {
+				"202": { "description": "Confirmation link sent" },
+				"400": { "description": "Validation error or wrong password", "schema": { "$ref": "#/definitions/Error" } },
+				"401": { "description": "Unauthorized", "schema": { "$ref": "#/definitions/Error" } },
+				"409": { "description": "Email already in use", "schema": { "$ref": "#/definitions/Error" } },
+				"429": { "description": "Too many attempts", "schema": { "$ref": "#/definitions/Error" } }
+			}
+		}
+	},
+
+	"/users": {
+		"get": {
+			"tags": ["Users"],
+			"summary": "List users",
+			"description": "Admin only. Deleted users are not listed.",
 			"operationId": "listUsers",
-			"security": [
-				{
-					"Bearer": []
-				}
+			"security": [ { "Bearer": [] } ],
+			"parameters": [
+				{ "name": "role", "in": "query", "type": "string", "enum": ["user", "management", "admin"] },
+				{ "name": "email", "in": "query", "type": "string", "description": "Case-insensitive substring of the email" },
+				{ "name": "status", "in": "query", "type": "string", "enum": ["active", "suspended"] },
+				{ "name": "created_from", "in": "query", "type": "string", "description": "RFC 3339 timestamp or YYYY-MM-DD date" },
+				{ "name": "created_to", "in": "query", "type": "string", "description": "RFC 3339 timestamp (exclusive) or YYYY-MM-DD date (inclusive)" },
+				{ "name": "sort", "in": "query", "type": "string", "enum": ["created_at", "name", "email", "role"], "default": "created_at" },
+				{ "name": "order", "in": "query", "type": "string", "enum": ["asc", "desc"], "default": "desc" },
+				{ "name": "limit", "in": "query", "type": "integer", "default": 20, "maximum": 100 },
+				{ "name": "offset", "in": "query", "type": "integer", "default": 0 }
 			],
 			"responses": {
-				"200": {
-					"description": "Successful operation",
-					"schema": {
-						"type": "object",
-						"properties": {
-							"title": { "type": "string", "example": "Success" },
-							"data": { "type": "array", "items": { "$ref": "#/definitions/User" } }
-						},
-						"required": ["title","data"]
-					}
-				},
-				"401": {
-					"description": "Unauthorized",
-					"schema": {
-						"$ref": "#/definitions/Error"
-					}
-				},
-				"500": {
-					"description": "Internal server error",
-					"schema": {
-						"$ref": "#/definitions/Error"
-					}
-				}
+				"200": { "description": "Successful operation", "schema": { "$ref": "#/definitions/UserList" } },
+				"400": { "description": "Invalid filter", "schema": { "$ref": "#/definitions/Error" } },
+				"401": { "description": "Unauthorized", "schema": { "$ref": "#/definitions/Error" } },
+				"403": { "description": "Forbidden", "schema": { "$ref": "#/definitions/Error" } },
+				"500": { "description": "Internal server error", "schema": { "$ref": "#/definitions/Error" } }
 			}
 		}
 	},
+
 	"/users/{id}": {
 		"get": {
-			"tags": [
-				"Users"
-			],
+			"tags": ["Users"],
 			"summary": "Get user by ID",
-			"description": "Returns a single user",
+			"description": "Admin only",
 			"operationId": "getUser",
+			"security": [ { "Bearer": [] } ],
 			"parameters": [
-				{
-					"name": "id",
-					"in": "path",
-					"description": "ID of user to return",
-					"required": true,
-					"type": "string"
-				}
+				{ "name": "id", "in": "path", "description": "User ID", "required": true, "type": "string" }
 			],
 			"responses": {
-				"200": {
-					"description": "Successful operation",
-					"schema": {
-						"$ref": "#/definitions/User"
-					}
-				},
-				"404": {
-					"description": "User not found",
-					"schema": {
-						"$ref": "#/definitions/Error"
-					}
-				},
-				"500": {
-					"description": "Internal server error",
+				"200": { "description": "Successful operation", "schema": { "$ref": "#/definitions/AdminUser" } },
+				"403": { "description": "Forbidden", "schema": { "$ref": "#/definitions/Error" } },
+				"404": { "description": "User not found", "schema": { "$ref": "#/definitions/Error" } }
+			}
+		},
+		"delete": {
+			"tags": ["Users"],
+			"summary": "Delete a user",
+			"description": "Admin only. Soft-deletes the user and signs them out everywhere; the email becomes free to sign up again. Admins cannot delete themselves.",
+			"operationId": "deleteUser",
+			"security": [ { "Bearer": [] } ],
+			"parameters": [
+				{ "name": "id", "in": "path", "description": "User ID", "required": true, "type": "string" }
+			],
+			"responses": {
+				"204": { "description": "User deleted" },
+				"403": { "description": "Forbidden", "schema": { "$ref": "#/definitions/Error" } },
+				"404": { "description": "User not found", "schema": { "$ref": "#/definitions/Error" } }
+			}
+		}
+	},
+
+	"/users/{id}/role": {
+		"patch": {
+			"tags": ["Users"],
+			"summary": "Change a user's role",
+			"description": "Admin only. The user's sessions are revoked so the new role applies from their next sign-in. Admins cannot change their own role.",
+			"operationId": "changeUserRole",
+			"security": [ { "Bearer": [] } ],
+			"parameters": [
+				{ "name": "id", "in": "path", "description": "User ID", "required": true, "type": "string" },
+				{ "in": "body", "name": "body", "required": true, "schema": { "type": "object", "properties": { "role": { "type": "string", "enum": ["user", "management", "admin"] } }, "required": ["role"] } }
+			],
+			"responses": {
+				"200": { "description": "Role changed", "schema": { "$ref": "#/definitions/AdminUser" } },
+				"400": { "description": "Validation error", "schema": { "$ref": "#/definitions/Error" } },
+				"403": { "description": "Forbidden", "schema": { "$ref": "#/definitions/Error" } },
+				"404": { "description": "User not found", "schema": { "$ref": "#/definitions/Error" } }
+			}
+		}
+	},
+
+	"/users/{id}/suspend": {
+		"post": {
+			"tags": ["Users"],
+			"summary": "Suspend a user",
+			"description": "Admin only. The user is signed out everywhere, their access tokens stop working and they cannot sign in until reactivated.",
+			"operationId": "suspendUser",
+			"security": [ { "Bearer": [] } ],
+			"parameters": [
+				{ "name": "id", "in": "path", "description": "User ID", "required": true, "type": "string" },
+				{ "in": "body", "name": "body", "required": false, "schema": { "type": "object", "properties": { "reason": { "type": "string", "maxLength": 255 } } } }
+			],
+			"responses": {
+				"200": { "description": "User suspended", "schema": { "$ref": "#/definitions/AdminUser" } },
+				"403": { "description": "Forbidden", "schema": { "$ref": "#/definitions/Error" } },
+				"404": { "description": "User not found", "schema": { "$ref": "#/definitions/Error" } },
+				"409": { "description": "Already suspended", "schema": { "$ref": "#/definitions/Error" } }
+			}
+		}
+	},
+
+	"/users/{id}/reactivate": {
+		"post": {
+			"tags": ["Users"],
+			"summary": "Reactivate a suspended user",
+			"description": "Admin only",
+			"operationId": "reactivateUser",
+			"security": [ { "Bearer": [] } ],
+			"parameters": [
+				{ "name": "id", "in": "path", "description": "User ID", "required": true, "type": "string" }
+			],
+			"responses": {
+				"200": { "description": "User reactivated", "schema": { "$ref": "#/definitions/AdminUser" } },
+				"403": { "description": "Forbidden", "schema": { "$ref": "#/definitions/Error" } },
+				"404": { "description": "User not found", "schema": { "$ref": "#/definitions/Error" } },
+				"409": { "description": "Not suspended", "schema": { "$ref": "#/definitions/Error" } }
+			}
+		}
+	},
+
+	"/user/restaurants": {
+		"get": {
+			"tags": ["Users"],
+			"summary": "List my restaurants",
+			"description": "Lists the restaurants the current user owns or holds a role in. The restaurant the session is active in has active set to true.",
+			"operationId": "listMemberships",
+			"security": [ { "Bearer": [] } ],
+			"responses": {
+				"200": { "description": "Successful operation", "schema": { "type": "array", "items": { "$ref": "#/definitions/Membership" } } },
+				"401": { "description": "Unauthorized", "schema": { "$ref": "#/definitions/Error" } }
+			}
+		}
+	},
+
+	"/user/sessions": {
+		"get": {
+			"tags": ["Users"],
+			"summary": "List my sessions",
+			"description": "Lists the devices the current user is signed in on. The session the request was made from has current set to true.",
+			"operationId": "listSessions",
+			"security": [ { "Bearer": [] } ],
+			"responses": {
+				"200": { "description": "Successful operation", "schema": { "type": "array", "items": { "$ref": "#/definitions/Session" } } },
+				"401": { "description": "Unauthorized", "schema": { "$ref": "#/definitions/Error" } }
+			}
+		}
+	},
+
+	"/user/sessions/{id}": {
+		"delete": {
+			"tags": ["Users"],
+			"summary": "Revoke a session",
+			"description": "Signs the current user out of one device",
+			"operationId": "revokeSession",
+			"security": [ { "Bearer": [] } ],
+			"parameters": [
+				{ "name": "id", "in": "path", "description": "Session ID", "required": true, "type": "string" }
+			],
+			"responses": {
+				"204": { "description": "Session revoked" },
+				"401": { "description": "Unauthorized", "schema": { "$ref": "#/definitions/Error" } },
+				"404": { "description": "Session not found", "schema": { "$ref": "#/definitions/Error" } }
+			}
+		}
+	},
+
+	"/users/{id}/sessions": {
+		"get": {
+			"tags": ["Users"],
+			"summary": "List a user's sessions",
+			"description": "Admin only",
+			"operationId": "adminListUserSessions",
+			"security": [ { "Bearer": [] } ],
+			"parameters": [
+				{ "name": "id", "in": "path", "description": "User ID", "required": true, "type": "string" }
+			],
+			"responses": {
+				"200": { "description": "Successful operation", "schema": { "type": "array", "items": { "$ref": "#/definitions/Session" } } },
+				"403": { "description": "Forbidden", "schema": { "$ref": "#/definitions/Error" } }
+			}
+		}
+	},
+
+	"/users/{id}/sessions/{sessionId}": {
+		"delete": {
+			"tags": ["Users"],
+			"summary": "Revoke a user's session",
+			"description": "Admin only",
+			"operationId": "adminRevokeUserSession",
+			"security": [ { "Bearer": [] } ],
+			"parameters": [
+				{ "name": "id", "in": "path", "description": "User ID", "required": true, "type": "string" },
+				{ "name": "sessionId", "in": "path", "description": "Session ID", "required": true, "type": "string" }
+			],
+			"responses": {
+				"204": { "description": "Session revoked" },
+				"403": { "description": "Forbidden", "schema": { "$ref": "#/definitions/Error" } },
+				"404": { "description": "Session not found", "schema": { "$ref": "#/definitions/Error" } }
+			}
+		}
+	},
+
+	"/user/identities": {
+		"get": {
+			"tags": ["Users"],
+			"summary": "List my linked providers",
+			"operationId": "listIdentities",
+			"security": [ { "Bearer": [] } ],
+			"responses": {
+				"200": { "description": "Successful operation", "schema": { "type": "array", "items": { "$ref": "#/definitions/Identity" } } },
+				"401": { "description": "Unauthorized", "schema": { "$ref": "#/definitions/Error" } }
+			}
+		}
+	},
+
+	"/user/identities/{provider}": {
+		"post": {
+			"tags": ["Users"],
+			"summary": "Link a social provider",
+			"description": "Returns the provider URL to open; after signing in there, /auth/oidc/{provider}/callback links the provider to the current user. The response sets the oidc_state cookie, so the request must be made with credentials from the browser that opens the URL.",
+			"operationId": "linkIdentity",
+			"security": [ { "Bearer": [] } ],
+			"parameters": [
+				{ "name": "provider", "in": "path", "description": "Provider name", "required": true, "type": "string" }
+			],
+			"responses": {
+				"200": { "description": "Authorization URL", "schema": { "type": "object", "properties": { "authorization_url": { "type": "string" } } } },
+				"401": { "description": "Unauthorized", "schema": { "$ref": "#/definitions/Error" } },
+				"404": { "description": "Unknown provider", "schema": { "$ref": "#/definitions/Error" } }
+			}
+		}
+	},
+
+	"/user/identities/{id}": {
+		"delete": {
+			"tags": ["Users"],
+			"summary": "Unlink a social provider",
+			"description": "The password still signs in; accounts created through a provider can set one with /auth/forgot-password.",
+			"operationId": "unlinkIdentity",
+			"security": [ { "Bearer": [] } ],
+			"parameters": [
+				{ "name": "id", "in": "path", "description": "Identity ID", "required": true, "type": "string" }
+			],
+			"responses": {
+				"204": { "description": "Provider unlinked" },
+				"401": { "description": "Unauthorized", "schema": { "$ref": "#/definitions/Error" } },
+				"404": { "description": "Identity not found", "schema": { "$ref": "#/definitions/Error" } }
+			}
+		}
+	},
+
+	"/user/api-keys": {
+		"get": {
+			"tags": ["Users"],
+			"summary": "List my API keys",
+			"description": "Revoked keys are left out. The keys themselves are never returned again, only their prefixes.",
+			"operationId": "listAPIKeys",
+			"security": [ { "Bearer": [] } ],
+			"responses": {
+				"200": { "description": "Successful operation", "schema": { "type": "array", "items": { "$ref": "#/definitions/APIKey" } } },
+				"401": { "description": "Unauthorized", "schema": { "$ref": "#/definitions/Error" } },
+				"403": { "description": "Forbidden", "schema": { "$ref": "#/definitions/Error" } }
+			}
+		},
+		"post": {
+			"tags": ["Users"],
+			"summary": "Create an API key",
+			"description": "Management only. The key acts for the caller in one restaurant and is sent as 'Authorization: ApiKey rmk_...'. It is shown only in this response.",
+			"operationId": "createAPIKey",
+			"security": [ { "Bearer": [] } ],
+			"parameters": [
+				{
+					"in": "body",
+					"name": "body",
+					"required": true,
 					"schema": {
-						"$ref": "#/definitions/Error"
+						"type": "object",
+						"required": ["name", "restaurant_id"],
+						"properties": {
+							"name": { "type": "string", "maxLength": 100, "example": "POS integration" },
+							"restaurant_id": { "type": "string", "format": "uuid" },
+							"expires_in_days": { "type": "integer", "minimum": 1, "maximum": 365, "default": 90 }
+						}
 					}
 				}
+			],
+			"responses": {
+				"201": { "description": "API key created", "schema": { "$ref": "#/definitions/CreatedAPIKey" } },
+				"400": { "description": "Validation error", "schema": { "$ref": "#/definitions/Error" } },
+				"401": { "description": "Unauthorized", "schema": { "$ref": "#/definitions/Error" } },
+				"403": { "description": "Forbidden", "schema": { "$ref": "#/definitions/Error" } }
+			}
+		}
+	},
+
+	"/user/api-keys/{id}": {
+		"delete": {
+			"tags": ["Users"],
+			"summary": "Revoke an API key",
+			"operationId": "revokeAPIKey",
+			"security": [ { "Bearer": [] } ],
+			"parameters": [
+				{ "name": "id", "in": "path", "description": "API key ID", "required": true, "type": "string" }
+			],
+			"responses": {
+				"204": { "description": "API key revoked" },
+				"401": { "description": "Unauthorized", "schema": { "$ref": "#/definitions/Error" } },
+				"403": { "description": "Forbidden", "schema": { "$ref": "#/definitions/Error" } },
+				"404": { "description": "API key not found", "schema": { "$ref": "#/definitions/Error" } }
+			}
+		}
+	},
+
+	"/user/mfa/enroll": {
+		"post": {
+			"tags": ["Users"],
+			"summary": "Start two-factor enrollment",
+			"description": "Returns a TOTP secret, its otpauth URI and one-time recovery codes. Nothing changes until /user/mfa/confirm succeeds.",
+			"operationId": "mfaEnroll",
+			"security": [ { "Bearer": [] } ],
+			"responses": {
+				"200": { "description": "Enrollment started", "schema": { "$ref": "#/definitions/MFAEnrollment" } },
+				"401": { "description": "Unauthorized", "schema": { "$ref": "#/definitions/Error" } },
+				"409": { "description": "Already enabled", "schema": { "$ref": "#/definitions/Error" } }
+			}
+		}
+	},
+
+	"/user/mfa/confirm": {
+		"post": {
+			"tags": ["Users"],
+			"summary": "Confirm two-factor enrollment",
+			"operationId": "mfaConfirm",
+			"security": [ { "Bearer": [] } ],
+			"parameters": [
+				{ "in": "body", "name": "body", "required": true, "schema": { "type": "object", "properties": { "code": { "type": "string", "example": "123456" } }, "required": ["code"] } }
+			],
+			"responses": {
+				"200": { "description": "Two-factor authentication enabled" },
+				"400": { "description": "Invalid code or no enrollment in progress", "schema": { "$ref": "#/definitions/Error" } },
+				"401": { "description": "Unauthorized", "schema": { "$ref": "#/definitions/Error" } }
+			}
+		}
+	},
+
+	"/user/mfa/disable": {
+		"post": {
+			"tags": ["Users"],
+			"summary": "Disable two-factor authentication",
+			"description": "Requires a current TOTP or recovery code. Not available to admins.",
+			"operationId": "mfaDisable",
+			"security": [ { "Bearer": [] } ],
+			"parameters": [
+				{ "in": "body", "name": "body", "required": true, "schema": { "type": "object", "properties": { "code": { "type": "string" } }, "required": ["code"] } }
+			],
+			"responses": {
+				"200": { "description": "Two-factor authentication disabled" },
+				"400": { "description": "Invalid code", "schema": { "$ref": "#/definitions/Error" } },
+				"403": { "description": "Admins cannot disable two-factor authentication", "schema": { "$ref": "#/definitions/Error" } }
 			}
 		}
 	},
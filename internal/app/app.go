@@ -0,0 +1,130 @@
+// Package app wires the application: it builds the services, handlers, guards
+// and router on their dependencies and owns the connections it opened.
+package app
+
+import (
+	"net/http"
+
+	"github.com/uptrace/bun"
+	"go.uber.org/zap"
+
+	"github.com/alibaba0010/postgres-api/internal/cache"
+	"github.com/alibaba0010/postgres-api/internal/clock"
+	"github.com/alibaba0010/postgres-api/internal/config"
+	"github.com/alibaba0010/postgres-api/internal/controllers"
+	"github.com/alibaba0010/postgres-api/internal/database"
+	"github.com/alibaba0010/postgres-api/internal/guards"
+	"github.com/alibaba0010/postgres-api/internal/mailer"
+	"github.com/alibaba0010/postgres-api/internal/middlewares"
+	"github.com/alibaba0010/postgres-api/internal/routes"
+	"github.com/alibaba0010/postgres-api/internal/services"
+)
+
+// App is the application and everything it runs on
+type App struct {
+	Config   config.Config
+	Log      *zap.Logger
+	DB       bun.IDB
+	Cache    cache.Cache
+	Mailer   mailer.Mailer
+	Clock    clock.Clock
+	Signer   services.AccessTokenSigner
+	Services *services.Service
+	Handler  *controllers.Handler
+	Guard    *guards.Guard
+	Router   *routes.Router
+
+	// closers release the connections New opened, last opened first
+	closers []func()
+}
+
+// New loads the access token keys, connects to Postgres and Redis and builds
+// the application on them, sending emails over SMTP
+func New(cfg config.Config, log *zap.Logger) (*App, error) {
+	signer, err := services.LoadAccessTokenSigner(cfg, log)
+	if err != nil {
+		return nil, err
+	}
+	db, err := database.ConnectDB(cfg, log)
+	if err != nil {
+		return nil, err
+	}
+	redis, err := database.ConnectRedis(cfg, log)
+	if err != nil {
+		database.CloseDB(db, log)
+		return nil, err
+	}
+
+	systemClock := clock.System()
+	app := Build(services.Deps{
+		Config: cfg,
+		Log:    log,
+		DB:     db,
+		Cache:  redis,
+		Mailer: mailer.NewSMTP(cfg),
+		Clock:  systemClock,
+		Signer: signer,
+	}, middlewares.NewFallbackRateLimitStore(redis.Client, log, systemClock))
+	app.closers = []func(){
+		redis.Close,
+		func() { database.CloseDB(db, log) },
+	}
+	return app, nil
+}
+
+// NewInMemory builds the application on in-memory fakes: a cache.Memory, a
+// mailer.Memory, refresh tokens and rate limits kept in memory and a signer
+// with a throwaway key, all reading the time from c (usually a clock.Fake). Nothing is shared
+// with other instances. db is the only real dependency; tests pass a
+// transaction they roll back.
+func NewInMemory(cfg config.Config, log *zap.Logger, db bun.IDB, c clock.Clock) (*App, error) {
+	signer, err := services.NewEphemeralAccessTokenSigner()
+	if err != nil {
+		return nil, err
+	}
+	return Build(services.Deps{
+		Config:        cfg,
+		Log:           log,
+		DB:            db,
+		Cache:         cache.NewMemory(c),
+		Mailer:        mailer.NewMemory(),
+		Clock:         c,
+		Signer:        signer,
+		RefreshTokens: services.NewMemoryRefreshTokenStorage(c),
+	}, middlewares.NewMemoryRateLimitStore(c)), nil
+}
+
+// Build wires the application on deps, counting rate limits in limits
+func Build(deps services.Deps, limits middlewares.RateLimitStore) *App {
+	svc := services.New(deps)
+	handler := controllers.New(svc, deps.Config, deps.Log, deps.Clock)
+	guard := guards.New(svc, deps.Config, deps.Log, deps.Clock)
+	limiter := middlewares.NewRateLimiter(limits, svc, deps.Log)
+	return &App{
+		Config:   deps.Config,
+		Log:      deps.Log,
+		DB:       deps.DB,
+		Cache:    deps.Cache,
+		Mailer:   deps.Mailer,
+		Clock:    deps.Clock,
+		Signer:   deps.Signer,
+		Services: svc,
+		Handler:  handler,
+		Guard:    guard,
+		Router:   routes.New(handler, guard, limiter, deps.Config, deps.Log),
+	}
+}
+
+// HTTPHandler returns the router serving the API
+func (a *App) HTTPHandler() http.Handler {
+	return a.Router.ApiRouter()
+}
+
+// Close closes the connections opened by New. Background work must have
+// stopped first (Services.StopBackgroundWork).
+func (a *App) Close() {
+	for _, close := range a.closers {
+		close()
+	}
+	a.closers = nil
+}
@@ -0,0 +1,217 @@
+package app_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alibaba0010/postgres-api/internal/apptest"
+	"github.com/alibaba0010/postgres-api/internal/config"
+	"github.com/alibaba0010/postgres-api/internal/dto"
+	"github.com/alibaba0010/postgres-api/internal/services"
+)
+
+// authLimit is the budget of the /auth endpoints per IP and minute
+const authLimit = 20
+
+func signin(handler http.Handler) *httptest.ResponseRecorder {
+	return signinVia(handler, "203.0.113.7:4000", "")
+}
+
+// signinVia signs in from remoteAddr, naming forwardedFor as the client when
+// it is set
+func signinVia(handler http.Handler, remoteAddr, forwardedFor string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodPost, "/api/v1/auth/signin", strings.NewReader(`{}`))
+	request.RemoteAddr = remoteAddr
+	if forwardedFor != "" {
+		request.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestAuthRateLimitFollowsFakeClock(t *testing.T) {
+	env := apptest.New(t)
+	handler := env.HTTPHandler()
+
+	for i := 0; i < authLimit; i++ {
+		if code := signin(handler).Code; code != http.StatusBadRequest {
+			t.Fatalf("request %d: status %d, want 400 from validation", i+1, code)
+		}
+	}
+	limited := signin(handler)
+	if limited.Code != http.StatusTooManyRequests {
+		t.Fatalf("status %d, want 429", limited.Code)
+	}
+	if limited.Header().Get("Retry-After") == "" {
+		t.Fatal("429 without Retry-After")
+	}
+
+	env.Clock.Advance(time.Minute)
+	if code := signin(handler).Code; code != http.StatusBadRequest {
+		t.Fatalf("status %d after the window passed, want 400", code)
+	}
+}
+
+func TestAuthRateLimitFromConfig(t *testing.T) {
+	env := apptest.New(t, func(cfg *config.Config) {
+		cfg.RATE_LIMIT_AUTH = 2
+		cfg.RATE_LIMIT_AUTH_WINDOW = 10 * time.Second
+	})
+	handler := env.HTTPHandler()
+
+	for i := 0; i < 2; i++ {
+		if code := signin(handler).Code; code != http.StatusBadRequest {
+			t.Fatalf("request %d: status %d, want 400 from validation", i+1, code)
+		}
+	}
+	limited := signin(handler)
+	if limited.Code != http.StatusTooManyRequests {
+		t.Fatalf("status %d, want 429", limited.Code)
+	}
+	if policy := limited.Header().Get("RateLimit-Policy"); policy != "2;w=10" {
+		t.Fatalf("RateLimit-Policy %q, want 2;w=10", policy)
+	}
+
+	env.Clock.Advance(10 * time.Second)
+	if code := signin(handler).Code; code != http.StatusBadRequest {
+		t.Fatalf("status %d after the window passed, want 400", code)
+	}
+}
+
+func TestForgedForwardedForSharesTheIPBudget(t *testing.T) {
+	env := apptest.New(t, func(cfg *config.Config) { cfg.RATE_LIMIT_AUTH = 2 })
+	handler := env.HTTPHandler()
+
+	for i := 0; i < 2; i++ {
+		forged := fmt.Sprintf("198.51.100.%d", i+1)
+		if code := signinVia(handler, "203.0.113.7:4000", forged).Code; code != http.StatusBadRequest {
+			t.Fatalf("request %d: status %d, want 400 from validation", i+1, code)
+		}
+	}
+	if code := signinVia(handler, "203.0.113.7:4000", "198.51.100.99").Code; code != http.StatusTooManyRequests {
+		t.Fatalf("status %d with yet another forged X-Forwarded-For, want 429", code)
+	}
+}
+
+func TestTrustedProxyForwardsClientIPs(t *testing.T) {
+	env := apptest.New(t, func(cfg *config.Config) {
+		cfg.RATE_LIMIT_AUTH = 2
+		cfg.TRUSTED_PROXIES = []string{"10.0.0.0/8"}
+	})
+	handler := env.HTTPHandler()
+
+	for i := 0; i < 2; i++ {
+		if code := signinVia(handler, "10.0.0.5:80", "198.51.100.1").Code; code != http.StatusBadRequest {
+			t.Fatalf("request %d: status %d, want 400 from validation", i+1, code)
+		}
+	}
+	if code := signinVia(handler, "10.0.0.5:80", "198.51.100.1").Code; code != http.StatusTooManyRequests {
+		t.Fatalf("status %d, want 429 once the client used its budget", code)
+	}
+	if code := signinVia(handler, "10.0.0.5:80", "198.51.100.2").Code; code != http.StatusBadRequest {
+		t.Fatalf("status %d for another client behind the proxy, want 400", code)
+	}
+}
+
+func TestAccessTokensExpireByFakeClock(t *testing.T) {
+	env := apptest.New(t)
+	pair, appErr := env.Services.GenerateTokenPair(context.Background(), "0191f000-0000-7000-8000-000000000001", "user", "203.0.113.7", "test")
+	if appErr != nil {
+		t.Fatal(appErr)
+	}
+	if _, appErr := env.Services.VerifyAccessToken(pair.AccessToken); appErr != nil {
+		t.Fatalf("fresh token rejected: %v", appErr)
+	}
+
+	env.Clock.Advance(services.AccessTokenDuration + time.Second)
+	if _, appErr := env.Services.VerifyAccessToken(pair.AccessToken); appErr == nil {
+		t.Fatal("expired token accepted")
+	}
+}
+
+func TestInstancesShareNothing(t *testing.T) {
+	first, second := apptest.New(t), apptest.New(t)
+	for i := 0; i < authLimit; i++ {
+		signin(first.HTTPHandler())
+	}
+	if code := signin(first.HTTPHandler()).Code; code != http.StatusTooManyRequests {
+		t.Fatalf("first instance: status %d, want 429", code)
+	}
+	if code := signin(second.HTTPHandler()).Code; code != http.StatusBadRequest {
+		t.Fatalf("second instance: status %d, want 400; rate limits leaked between instances", code)
+	}
+}
+
+func healthcheck(handler http.Handler, remoteAddr, authorization string) int {
+	request := httptest.NewRequest(http.MethodGet, "/api/v1/healthcheck", nil)
+	request.RemoteAddr = remoteAddr
+	if authorization != "" {
+		request.Header.Set("Authorization", authorization)
+	}
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	return recorder.Code
+}
+
+// limitAPI lowers the global API budget to a few requests
+func limitAPI(cfg *config.Config) {
+	cfg.RATE_LIMIT_API = 3
+}
+
+func TestMadeUpAPIKeysShareTheIPBudget(t *testing.T) {
+	env := apptest.New(t, limitAPI)
+	handler := env.HTTPHandler()
+
+	for i := 0; i < 3; i++ {
+		if code := healthcheck(handler, "203.0.113.7:4000", "ApiKey made-up-"+strconv.Itoa(i)); code != http.StatusOK {
+			t.Fatalf("request %d: status %d, want 200", i+1, code)
+		}
+	}
+	if code := healthcheck(handler, "203.0.113.7:4000", "ApiKey made-up-3"); code != http.StatusTooManyRequests {
+		t.Fatalf("fresh made-up key: status %d, want 429 from the IP's budget", code)
+	}
+	if code := healthcheck(handler, "203.0.113.8:4000", ""); code != http.StatusOK {
+		t.Fatalf("another IP: status %d, want 200", code)
+	}
+}
+
+func TestUnknownAPIKeysShareTheIPBudget(t *testing.T) {
+	env := apptest.NewWithDB(t, limitAPI)
+	handler := env.HTTPHandler()
+
+	// well formed, so they are looked up, but no such keys exist
+	for i := 0; i < 3; i++ {
+		key := fmt.Sprintf("ApiKey rmk_%012d_secret", i)
+		if code := healthcheck(handler, "203.0.113.7:4000", key); code != http.StatusOK {
+			t.Fatalf("request %d: status %d, want 200", i+1, code)
+		}
+	}
+	if code := healthcheck(handler, "203.0.113.7:4000", "ApiKey rmk_999999999999_secret"); code != http.StatusTooManyRequests {
+		t.Fatalf("fresh unknown key: status %d, want 429 from the IP's budget", code)
+	}
+}
+
+func TestLiveAPIKeyHasItsOwnBudget(t *testing.T) {
+	env := apptest.NewWithDB(t, limitAPI)
+	handler := env.HTTPHandler()
+	owner := env.CreateUser(t, "management")
+	restaurant := env.CreateRestaurant(t, owner)
+	created, appErr := env.Services.CreateAPIKey(context.Background(), owner.ID, owner.Role, dto.CreateAPIKeyInput{Name: "POS", RestaurantID: restaurant.ID})
+	if appErr != nil {
+		t.Fatalf("create api key: %v", appErr)
+	}
+
+	for i := 0; i < 3; i++ {
+		healthcheck(handler, "203.0.113.7:4000", "")
+	}
+	if code := healthcheck(handler, "203.0.113.7:4000", "ApiKey "+created.Key); code != http.StatusOK {
+		t.Fatalf("live key behind an exhausted IP: status %d, want 200", code)
+	}
+}
@@ -0,0 +1,95 @@
+package apptest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/alibaba0010/postgres-api/internal/models"
+	"github.com/alibaba0010/postgres-api/internal/services"
+)
+
+// CreateUser inserts a user with the account role. The password hash is not
+// a valid one, so the user cannot sign in with a password.
+func (e *Env) CreateUser(t testing.TB, role string) *models.User {
+	t.Helper()
+	id := uuid.Must(uuid.NewV7()).String()
+	user := &models.User{
+		ID:       id,
+		Name:     "Test " + role,
+		Email:    Email(role),
+		Password: "!",
+		Role:     role,
+	}
+	if _, err := e.DB.NewInsert().Model(user).Exec(context.Background()); err != nil {
+		t.Fatalf("insert user: %v", err)
+	}
+	return user
+}
+
+// CreateRestaurant inserts a restaurant owned by owner
+func (e *Env) CreateRestaurant(t testing.TB, owner *models.User) *models.Restaurant {
+	t.Helper()
+	restaurant := &models.Restaurant{
+		ID:       uuid.Must(uuid.NewV7()).String(),
+		OwnerID:  owner.ID,
+		Name:     "Test Restaurant",
+		Address:  "1 Test Street",
+		Timezone: "UTC",
+	}
+	if _, err := e.DB.NewInsert().Model(restaurant).Exec(context.Background()); err != nil {
+		t.Fatalf("insert restaurant: %v", err)
+	}
+	return restaurant
+}
+
+// CreateRole inserts a custom role with permissions
+func (e *Env) CreateRole(t testing.TB, permissions ...string) *models.Role {
+	t.Helper()
+	id := uuid.Must(uuid.NewV7()).String()
+	role := &models.Role{
+		ID:          id,
+		Name:        "role-" + id[len(id)-12:],
+		Permissions: append([]string{}, permissions...),
+	}
+	if _, err := e.DB.NewInsert().Model(role).Exec(context.Background()); err != nil {
+		t.Fatalf("insert role: %v", err)
+	}
+	return role
+}
+
+// Role returns the role named name, such as the built-in "owner" or "cook"
+func (e *Env) Role(t testing.TB, name string) *models.Role {
+	t.Helper()
+	role := &models.Role{}
+	if err := e.DB.NewSelect().Model(role).Where("name = ?", name).Scan(context.Background()); err != nil {
+		t.Fatalf("select role %q: %v", name, err)
+	}
+	return role
+}
+
+// Assign grants role to user in restaurant
+func (e *Env) Assign(t testing.TB, user *models.User, role *models.Role, restaurant *models.Restaurant) {
+	t.Helper()
+	assignment := &models.RoleAssignment{
+		ID:           uuid.Must(uuid.NewV7()).String(),
+		UserID:       user.ID,
+		RoleID:       role.ID,
+		RestaurantID: restaurant.ID,
+	}
+	if _, err := e.DB.NewInsert().Model(assignment).Exec(context.Background()); err != nil {
+		t.Fatalf("insert role assignment: %v", err)
+	}
+}
+
+// Session signs user in without a password and returns the token pair, acting
+// in no restaurant
+func (e *Env) Session(t testing.TB, user *models.User) *services.TokenPair {
+	t.Helper()
+	pair, appErr := e.Services.GenerateTokenPair(context.Background(), user.ID, user.Role, "203.0.113.7", "apptest")
+	if appErr != nil {
+		t.Fatalf("generate token pair: %v", appErr)
+	}
+	return pair
+}
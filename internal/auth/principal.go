@@ -0,0 +1,99 @@
+// Package auth describes who a request acts for. The guards store the
+// authenticated principal in the request context; handlers, services and
+// the logger read it from there.
+package auth
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Method is how a principal authenticated
+type Method string
+
+const (
+	// MethodAccessToken is a bearer access token
+	MethodAccessToken Method = "access_token"
+	// MethodRefreshToken is the refresh_token cookie, used by the guard when
+	// the access token had expired
+	MethodRefreshToken Method = "refresh_token"
+	// MethodAPIKey is an "Authorization: ApiKey" key
+	MethodAPIKey Method = "api_key"
+)
+
+// Scopes limit what a credential may do on top of the permissions of its user
+const (
+	// ScopeAPI allows the endpoints the user's role and permissions allow
+	ScopeAPI = "api"
+	// ScopeCredentials allows managing the user's own credentials, such as
+	// API keys; only signed-in sessions have it
+	ScopeCredentials = "credentials"
+)
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	UserID string
+	Role   string
+	// SessionID is the refresh token family of a signed-in session; empty for API keys
+	SessionID string
+	// RestaurantID is the tenant the credential acts in: the active
+	// restaurant of a session (possibly none) or the restaurant of an API key
+	RestaurantID string
+	Method       Method
+	// APIKeyID is set when Method is MethodAPIKey
+	APIKeyID string
+	Scopes   []string
+}
+
+// HasScope reports whether the principal's credential has scope
+func (p *Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope)
+}
+
+// SessionScopes are the scopes of a signed-in session
+func SessionScopes() []string {
+	return []string{ScopeAPI, ScopeCredentials}
+}
+
+// APIKeyScopes are the scopes of an API key
+func APIKeyScopes() []string {
+	return []string{ScopeAPI}
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying principal
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// FromContext returns the principal stored by WithPrincipal, or nil when the
+// request was not authenticated
+func FromContext(ctx context.Context) *Principal {
+	principal, _ := ctx.Value(principalKey{}).(*Principal)
+	return principal
+}
+
+// identityHeaders are the headers the guards used to pass the caller on to
+// the handlers. Nothing reads them anymore, but they are still removed from
+// incoming requests so no handler or upstream can be fooled by them.
+var identityHeaders = []string{"X-Session-Id", "X-Restaurant-Id", "X-Api-Key-Id"}
+
+// StripIdentityHeaders removes every X-User-* header, and the other headers
+// that used to carry the caller, from incoming requests. It runs first, at
+// the edge of the router.
+func StripIdentityHeaders(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		for name := range request.Header {
+			if strings.HasPrefix(name, "X-User-") {
+				request.Header.Del(name)
+			}
+		}
+		for _, name := range identityHeaders {
+			request.Header.Del(name)
+		}
+		next.ServeHTTP(writer, request)
+	})
+}
@@ -0,0 +1,273 @@
+package cache
+
+import (
+	"context"
+	"encoding"
+	"fmt"
+	"math"
+	"path"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/alibaba0010/postgres-api/internal/clock"
+)
+
+// Memory is a Cache kept in a map, with expiry read from a clock. It
+// supports the commands of Cache only and ignores Scan's count.
+type Memory struct {
+	mu      sync.Mutex
+	clock   clock.Clock
+	entries map[string]*memoryEntry
+}
+
+type memoryEntry struct {
+	value     string
+	set       map[string]float64
+	expiresAt time.Time
+}
+
+// NewMemory returns an empty cache whose keys expire by c
+func NewMemory(c clock.Clock) *Memory {
+	return &Memory{clock: c, entries: map[string]*memoryEntry{}}
+}
+
+// entry returns a live entry, forgetting it when it has expired
+func (m *Memory) entry(key string) *memoryEntry {
+	entry, ok := m.entries[key]
+	if !ok {
+		return nil
+	}
+	if !entry.expiresAt.IsZero() && !m.clock.Now().Before(entry.expiresAt) {
+		delete(m.entries, key)
+		return nil
+	}
+	return entry
+}
+
+func (m *Memory) expiry(expiration time.Duration) time.Time {
+	if expiration <= 0 {
+		return time.Time{}
+	}
+	return m.clock.Now().Add(expiration)
+}
+
+func (m *Memory) Get(_ context.Context, key string) *redis.StringCmd {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry := m.entry(key)
+	if entry == nil {
+		return redis.NewStringResult("", redis.Nil)
+	}
+	return redis.NewStringResult(entry.value, nil)
+}
+
+func (m *Memory) GetDel(ctx context.Context, key string) *redis.StringCmd {
+	cmd := m.Get(ctx, key)
+	m.mu.Lock()
+	delete(m.entries, key)
+	m.mu.Unlock()
+	return cmd
+}
+
+func (m *Memory) MGet(_ context.Context, keys ...string) *redis.SliceCmd {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	values := make([]interface{}, len(keys))
+	for i, key := range keys {
+		if entry := m.entry(key); entry != nil {
+			values[i] = entry.value
+		}
+	}
+	return redis.NewSliceResult(values, nil)
+}
+
+func (m *Memory) Set(_ context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[key] = &memoryEntry{value: formatValue(value), expiresAt: m.expiry(expiration)}
+	return redis.NewStatusResult("OK", nil)
+}
+
+func (m *Memory) SetNX(_ context.Context, key string, value interface{}, expiration time.Duration) *redis.BoolCmd {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.entry(key) != nil {
+		return redis.NewBoolResult(false, nil)
+	}
+	m.entries[key] = &memoryEntry{value: formatValue(value), expiresAt: m.expiry(expiration)}
+	return redis.NewBoolResult(true, nil)
+}
+
+func (m *Memory) Del(_ context.Context, keys ...string) *redis.IntCmd {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var deleted int64
+	for _, key := range keys {
+		if m.entry(key) != nil {
+			delete(m.entries, key)
+			deleted++
+		}
+	}
+	return redis.NewIntResult(deleted, nil)
+}
+
+func (m *Memory) Incr(_ context.Context, key string) *redis.IntCmd {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry := m.entry(key)
+	if entry == nil {
+		entry = &memoryEntry{value: "0"}
+		m.entries[key] = entry
+	}
+	n, err := strconv.ParseInt(entry.value, 10, 64)
+	if err != nil {
+		return redis.NewIntResult(0, fmt.Errorf("ERR value is not an integer or out of range"))
+	}
+	n++
+	entry.value = strconv.FormatInt(n, 10)
+	return redis.NewIntResult(n, nil)
+}
+
+func (m *Memory) Expire(_ context.Context, key string, expiration time.Duration) *redis.BoolCmd {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry := m.entry(key)
+	if entry == nil {
+		return redis.NewBoolResult(false, nil)
+	}
+	if expiration <= 0 {
+		delete(m.entries, key)
+	} else {
+		entry.expiresAt = m.expiry(expiration)
+	}
+	return redis.NewBoolResult(true, nil)
+}
+
+// TTL reports -2 for a missing key and -1 for a key without expiry, as the
+// Redis client does
+func (m *Memory) TTL(ctx context.Context, key string) *redis.DurationCmd {
+	return m.ttl(key, time.Second)
+}
+
+func (m *Memory) PTTL(ctx context.Context, key string) *redis.DurationCmd {
+	return m.ttl(key, time.Millisecond)
+}
+
+func (m *Memory) ttl(key string, precision time.Duration) *redis.DurationCmd {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry := m.entry(key)
+	switch {
+	case entry == nil:
+		return redis.NewDurationResult(-2, nil)
+	case entry.expiresAt.IsZero():
+		return redis.NewDurationResult(-1, nil)
+	}
+	return redis.NewDurationResult(entry.expiresAt.Sub(m.clock.Now()).Truncate(precision), nil)
+}
+
+func (m *Memory) Scan(_ context.Context, _ uint64, match string, _ int64) *redis.ScanCmd {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var keys []string
+	for key := range m.entries {
+		if m.entry(key) == nil {
+			continue
+		}
+		if ok, _ := path.Match(match, key); ok || match == "" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return redis.NewScanCmdResult(keys, 0, nil)
+}
+
+func (m *Memory) ZAdd(_ context.Context, key string, members ...redis.Z) *redis.IntCmd {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry := m.entry(key)
+	if entry == nil {
+		entry = &memoryEntry{set: map[string]float64{}}
+		m.entries[key] = entry
+	}
+	var added int64
+	for _, member := range members {
+		name := formatValue(member.Member)
+		if _, ok := entry.set[name]; !ok {
+			added++
+		}
+		entry.set[name] = member.Score
+	}
+	return redis.NewIntResult(added, nil)
+}
+
+func (m *Memory) ZCard(_ context.Context, key string) *redis.IntCmd {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry := m.entry(key)
+	if entry == nil {
+		return redis.NewIntResult(0, nil)
+	}
+	return redis.NewIntResult(int64(len(entry.set)), nil)
+}
+
+func (m *Memory) ZRemRangeByScore(_ context.Context, key, min, max string) *redis.IntCmd {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	low, err := parseScore(min)
+	if err != nil {
+		return redis.NewIntResult(0, err)
+	}
+	high, err := parseScore(max)
+	if err != nil {
+		return redis.NewIntResult(0, err)
+	}
+	entry := m.entry(key)
+	if entry == nil {
+		return redis.NewIntResult(0, nil)
+	}
+	var removed int64
+	for member, score := range entry.set {
+		if score >= low && score <= high {
+			delete(entry.set, member)
+			removed++
+		}
+	}
+	return redis.NewIntResult(removed, nil)
+}
+
+func parseScore(score string) (float64, error) {
+	switch score {
+	case "-inf":
+		return math.Inf(-1), nil
+	case "+inf", "inf":
+		return math.Inf(1), nil
+	}
+	return strconv.ParseFloat(score, 64)
+}
+
+// formatValue stores a value the way the Redis client sends it
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case encoding.BinaryMarshaler:
+		if b, err := v.MarshalBinary(); err == nil {
+			return string(b)
+		}
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,54 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells the time. Services read it instead of calling time.Now so that
+// tests can move time forward to expire tokens, reservations and lockouts.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// System returns the clock of the machine
+func System() Clock {
+	return systemClock{}
+}
+
+// Fake is a clock that only moves when told to
+type Fake struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewFake returns a fake clock stopped at now
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Set moves the clock to now
+func (f *Fake) Set(now time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = now
+}
+
+// Advance moves the clock forward by d
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+}
@@ -1,62 +1,160 @@
 package config
 
 import (
-	"os"
+	"time"
+)
 
-	"github.com/alibaba0010/postgres-api/internal/logger"
-	"github.com/joho/godotenv"
-	"go.uber.org/zap"
+// The secrets' development defaults; the server refuses to start with them
+// outside development and test
+const (
+	defaultRefreshTokenSecret = "default_refresh_secret"
+	defaultInviteTokenSecret  = "default_invite_secret"
 )
 
+// The profiles APP_ENV can name. Each starts from its own defaults and may
+// have its own config.<profile>.yaml.
+const (
+	ProfileDevelopment = "development"
+	ProfileTest        = "test"
+	ProfileStaging     = "staging"
+	ProfileProduction  = "production"
+)
 
+// The formats LOG_FORMAT can name
+const (
+	LogFormatConsole = "console"
+	LogFormatJSON    = "json"
+)
+
+// Config is the server configuration. Every field is read, in increasing
+// priority, from the profile defaults, config.yaml, config.<profile>.yaml,
+// the environment variable named like the field and the flag of the same name
+// in lower case with dashes (DB_PORT, db_port in YAML, --db-port). Fields
+// tagged secret are redacted by Redacted.
 type Config struct {
-	Port string
-	DB_HOST string
-	DB_PORT string
-	DB_USERNAME string
-	DB_PASSWORD string
-	DB_NAME string
-	REDIS_HOST string
-	REDIS_PORT string
-	REDIS_PASSWORD string
-	EMAIL_PORT string
-	EMAIL_HOST string
-	EMAIL_USER string
-	EMAIL_PASSWORD string
-	FRONTEND_URL string
-	ACCESS_TOKEN_SECRET string
-	REFRESH_TOKEN_SECRET string
+	// APP_ENV is the profile: "development" (the default), "test",
+	// "staging" or "production"
+	APP_ENV string
+	Port    int
+	// HTTP_READ_HEADER_TIMEOUT, HTTP_READ_TIMEOUT, HTTP_WRITE_TIMEOUT and
+	// HTTP_IDLE_TIMEOUT bound how long a connection may take; SHUTDOWN_TIMEOUT
+	// is how long a stopping server waits for requests and background work.
+	HTTP_READ_HEADER_TIMEOUT time.Duration
+	HTTP_READ_TIMEOUT        time.Duration
+	HTTP_WRITE_TIMEOUT       time.Duration
+	HTTP_IDLE_TIMEOUT        time.Duration
+	SHUTDOWN_TIMEOUT         time.Duration
+	DB_HOST                  string
+	DB_PORT                  int
+	DB_USERNAME              string
+	DB_PASSWORD              string `secret:"true"`
+	DB_NAME                  string
+	REDIS_HOST               string
+	REDIS_PORT               int
+	REDIS_PASSWORD           string `secret:"true"`
+	EMAIL_PORT               int
+	EMAIL_HOST               string
+	EMAIL_USER               string
+	EMAIL_PASSWORD           string `secret:"true"`
+	FRONTEND_URL             string
+	REFRESH_TOKEN_SECRET     string `secret:"true"`
+	INVITE_TOKEN_SECRET      string `secret:"true"`
+	// JWT_SIGNING_KEY_FILE is the PEM private key (RSA or Ed25519) access
+	// tokens are signed with. JWT_VERIFICATION_KEY_FILES lists, comma
+	// separated, the PEM keys of earlier or upcoming signing keys that are
+	// still accepted and published in the JWKS.
+	JWT_SIGNING_KEY_FILE       string
+	JWT_VERIFICATION_KEY_FILES []string
+	// OIDC_PROVIDERS lists the social login providers, e.g. "google,microsoft"
+	OIDC_PROVIDERS []OIDCProvider
+	// LOG_LEVEL is debug, info, warn or error. LOG_FORMAT is "console"
+	// (colored, for people) or "json" (for log collectors). LOG_SAMPLING
+	// keeps the first 100 entries with the same message every second and
+	// every 100th after that, so a flood of errors cannot swamp the logs.
+	LOG_LEVEL    string
+	LOG_FORMAT   string
+	LOG_SAMPLING bool
+	// RATE_LIMIT_API, RATE_LIMIT_AUTH and RATE_LIMIT_CREDENTIALS are how many
+	// requests a client may make per window: across the API (per API key or
+	// user, in bursts), on /auth (per IP) and on the credential endpoints of
+	// /user (per user)
+	RATE_LIMIT_API                int
+	RATE_LIMIT_API_WINDOW         time.Duration
+	RATE_LIMIT_AUTH               int
+	RATE_LIMIT_AUTH_WINDOW        time.Duration
+	RATE_LIMIT_CREDENTIALS        int
+	RATE_LIMIT_CREDENTIALS_WINDOW time.Duration
+	// TRUSTED_PROXIES lists, comma separated, the IP addresses and CIDR
+	// ranges of the load balancers in front of the server. Only requests
+	// from them may name the client in X-Forwarded-For or X-Real-Ip.
+	TRUSTED_PROXIES []string
 }
 
-func LoadConfig() Config {
-		err := godotenv.Load()
-	if err != nil {
-		// log.Println("No .env file found, using system environment variables...")
-		logger.Log.Warn("No .env file found", zap.Error(err))
+// OIDCProvider is an OpenID Connect identity provider users can sign in with.
+// Each name in OIDC_PROVIDERS reads OIDC_<NAME>_ISSUER, OIDC_<NAME>_CLIENT_ID,
+// OIDC_<NAME>_CLIENT_SECRET and optionally OIDC_<NAME>_REDIRECT_URL, which
+// override the provider of the same name in the YAML files.
+type OIDCProvider struct {
+	Name         string `yaml:"name"`
+	Issuer       string `yaml:"issuer"`
+	ClientID     string `yaml:"client_id"`
+	ClientSecret string `yaml:"client_secret" secret:"true"`
+	RedirectURL  string `yaml:"redirect_url"`
+}
+
+// defaults returns the configuration a profile starts from
+func defaults(profile string) Config {
+	cfg := Config{
+		APP_ENV:                       profile,
+		Port:                          2000,
+		HTTP_READ_HEADER_TIMEOUT:      5 * time.Second,
+		HTTP_READ_TIMEOUT:             15 * time.Second,
+		HTTP_WRITE_TIMEOUT:            30 * time.Second,
+		HTTP_IDLE_TIMEOUT:             60 * time.Second,
+		SHUTDOWN_TIMEOUT:              30 * time.Second,
+		DB_HOST:                       "localhost",
+		DB_PORT:                       5432,
+		DB_USERNAME:                   "postgres",
+		DB_PASSWORD:                   "password",
+		DB_NAME:                       "postgres",
+		REDIS_HOST:                    "localhost",
+		REDIS_PORT:                    6379,
+		EMAIL_PORT:                    587,
+		EMAIL_HOST:                    "smtp.gmail.com",
+		FRONTEND_URL:                  "http://localhost:3000",
+		REFRESH_TOKEN_SECRET:          defaultRefreshTokenSecret,
+		INVITE_TOKEN_SECRET:           defaultInviteTokenSecret,
+		LOG_LEVEL:                     "info",
+		LOG_FORMAT:                    LogFormatConsole,
+		RATE_LIMIT_API:                300,
+		RATE_LIMIT_API_WINDOW:         time.Minute,
+		RATE_LIMIT_AUTH:               20,
+		RATE_LIMIT_AUTH_WINDOW:        time.Minute,
+		RATE_LIMIT_CREDENTIALS:        5,
+		RATE_LIMIT_CREDENTIALS_WINDOW: 15 * time.Minute,
 	}
-	return Config{
-		Port: getEnv("PORT", "2000"),
-		DB_HOST:     getEnv("DB_HOST", "localhost"),
-		DB_PORT:     "5432",
-		DB_USERNAME: getEnv("DB_USERNAME", "postgres"),
-		DB_PASSWORD: getEnv("DB_PASSWORD", "password"),
-		DB_NAME:     getEnv("DB_NAME", "postgres"),
-		REDIS_HOST:     getEnv("REDIS_HOST", "localhost"),
-		REDIS_PORT:     getEnv("REDIS_PORT", "6379"),
-		REDIS_PASSWORD: getEnv("REDIS_PASSWORD", ""),
-		EMAIL_PORT:     getEnv("EMAIL_PORT", "587"),
-		EMAIL_HOST:     getEnv("EMAIL_HOST", "smtp.gmail.com"),
-		EMAIL_USER:     getEnv("EMAIL_USER", ""),
-		EMAIL_PASSWORD: getEnv("EMAIL_PASSWORD", ""),
-		FRONTEND_URL:  getEnv("FRONTEND_URL", "http://localhost:3000"),
-		ACCESS_TOKEN_SECRET: getEnv("ACCESS_TOKEN_SECRET", "default_access_secret"),
-		REFRESH_TOKEN_SECRET: getEnv("REFRESH_TOKEN_SECRET", "default_refresh_secret"),
+	switch profile {
+	case ProfileTest:
+		cfg.DB_NAME = "postgres_test"
+		cfg.SHUTDOWN_TIMEOUT = 5 * time.Second
+	case ProfileStaging, ProfileProduction:
+		// nothing insecure to fall back to: these must be configured
+		cfg.DB_PASSWORD = ""
+		cfg.REFRESH_TOKEN_SECRET = ""
+		cfg.INVITE_TOKEN_SECRET = ""
+		cfg.LOG_FORMAT = LogFormatJSON
+		cfg.LOG_SAMPLING = true
 	}
+	return cfg
 }
 
-func getEnv(key, fallback string) string {
-	if val, ok := os.LookupEnv(key); ok {
-		return val
-	}
-	return fallback
+// IsDevelopment reports whether the server runs on a developer's machine
+func (c Config) IsDevelopment() bool {
+	return c.APP_ENV == ProfileDevelopment
+}
+
+// AllowsInsecureDefaults reports whether missing secrets and keys may fall
+// back to development defaults, which is only the case in development and test
+func (c Config) AllowsInsecureDefaults() bool {
+	return c.APP_ENV == ProfileDevelopment || c.APP_ENV == ProfileTest
 }
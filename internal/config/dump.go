@@ -0,0 +1,44 @@
+package config
+
+import (
+	"io"
+	"reflect"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// redactedValue replaces a secret that is set; an empty secret stays empty
+// so that a dump still shows it is missing
+const redactedValue = "[REDACTED]"
+
+// Redacted returns a copy of the configuration with its secrets replaced
+func (c Config) Redacted() Config {
+	redacted := c
+	redactSecrets(reflect.ValueOf(&redacted).Elem())
+	redacted.OIDC_PROVIDERS = make([]OIDCProvider, len(c.OIDC_PROVIDERS))
+	for i, provider := range c.OIDC_PROVIDERS {
+		redactSecrets(reflect.ValueOf(&provider).Elem())
+		redacted.OIDC_PROVIDERS[i] = provider
+	}
+	return redacted
+}
+
+// Dump writes the configuration with its secrets redacted, in the YAML the
+// configuration files are read in
+func (c Config) Dump(w io.Writer) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(c.Redacted()); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+func redactSecrets(value reflect.Value) {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Field(i)
+		if value.Type().Field(i).Tag.Get("secret") == "true" && field.String() != "" {
+			field.SetString(redactedValue)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRedactedHidesSetSecrets(t *testing.T) {
+	cfg := productionConfig()
+	cfg.EMAIL_PASSWORD = ""
+	cfg.OIDC_PROVIDERS = []OIDCProvider{{Name: "google", ClientID: "api", ClientSecret: "client-secret"}}
+
+	redacted := cfg.Redacted()
+	for name, value := range map[string]string{
+		"DB_PASSWORD":          redacted.DB_PASSWORD,
+		"REFRESH_TOKEN_SECRET": redacted.REFRESH_TOKEN_SECRET,
+		"INVITE_TOKEN_SECRET":  redacted.INVITE_TOKEN_SECRET,
+		"OIDC client secret":   redacted.OIDC_PROVIDERS[0].ClientSecret,
+	} {
+		if value != redactedValue {
+			t.Errorf("%s = %q, want it redacted", name, value)
+		}
+	}
+	// a missing secret stays visible as missing
+	if redacted.EMAIL_PASSWORD != "" {
+		t.Errorf("EMAIL_PASSWORD = %q, want it empty", redacted.EMAIL_PASSWORD)
+	}
+	if redacted.OIDC_PROVIDERS[0].ClientID != "api" || redacted.DB_HOST != cfg.DB_HOST {
+		t.Error("Redacted changed settings that are not secret")
+	}
+	// the original is left alone
+	if cfg.OIDC_PROVIDERS[0].ClientSecret != "client-secret" || cfg.DB_PASSWORD != "db-password" {
+		t.Error("Redacted changed the configuration it copied")
+	}
+}
+
+func TestDumpLeaksNoSecret(t *testing.T) {
+	cfg := productionConfig()
+	cfg.OIDC_PROVIDERS = []OIDCProvider{{Name: "google", ClientID: "api", ClientSecret: "client-secret"}}
+
+	var out strings.Builder
+	if err := cfg.Dump(&out); err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"db-password", "refresh-secret", "invite-secret", "client-secret"} {
+		if strings.Contains(out.String(), secret) {
+			t.Errorf("dump contains %q:\n%s", secret, out.String())
+		}
+	}
+	if !strings.Contains(out.String(), "restaurant.example") {
+		t.Errorf("dump is missing FRONTEND_URL:\n%s", out.String())
+	}
+}
@@ -0,0 +1,280 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"go.uber.org/zap"
+	"go.yaml.in/yaml/v3"
+
+)
+
+// defaultConfigFile is read when present; CONFIG_FILE or --config name
+// another file, which must then exist
+const defaultConfigFile = "config.yaml"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Load builds the configuration from the profile defaults, the YAML files,
+// the environment (and .env) and the flags in args, and validates it. A
+// configuration that fails validation is returned along with the error.
+// log reports the files read.
+func Load(args []string, log *zap.Logger) (Config, error) {
+	if err := godotenv.Load(); err != nil {
+		log.Debug("No .env file found", zap.Error(err))
+	}
+
+	flags, configFile, err := parseFlags(args)
+	if err != nil {
+		return Config{}, err
+	}
+	explicitFile := configFile != ""
+	if !explicitFile {
+		configFile, explicitFile = os.LookupEnv("CONFIG_FILE")
+	}
+	if !explicitFile {
+		configFile = defaultConfigFile
+	}
+	base, err := readConfigFile(configFile, explicitFile)
+	if err != nil {
+		return Config{}, err
+	}
+
+	profile, err := resolveProfile(flags, base)
+	if err != nil {
+		return Config{}, err
+	}
+	cfg := defaults(profile)
+	if err := decodeConfigFile(configFile, base, &cfg, log); err != nil {
+		return Config{}, err
+	}
+	profileFile := strings.TrimSuffix(configFile, filepath.Ext(configFile)) + "." + profile + filepath.Ext(configFile)
+	overlay, err := readConfigFile(profileFile, false)
+	if err != nil {
+		return Config{}, err
+	}
+	if err := decodeConfigFile(profileFile, overlay, &cfg, log); err != nil {
+		return Config{}, err
+	}
+
+	if err := applyEnv(&cfg); err != nil {
+		return Config{}, err
+	}
+	for key, value := range flags {
+		if err := setField(&cfg, key, value); err != nil {
+			return Config{}, fmt.Errorf("--%s: %w", flagName(key), err)
+		}
+	}
+	// the profile is fixed before any file is read; a file cannot switch it
+	cfg.APP_ENV = profile
+
+	for i := range cfg.OIDC_PROVIDERS {
+		provider := &cfg.OIDC_PROVIDERS[i]
+		provider.Issuer = strings.TrimSuffix(provider.Issuer, "/")
+		if provider.RedirectURL == "" {
+			// like the verification link, the callback is served by the API
+			provider.RedirectURL = cfg.FRONTEND_URL + "/api/v1/auth/oidc/" + provider.Name + "/callback"
+		}
+	}
+	return cfg, cfg.Validate()
+}
+
+// parseFlags returns the values of the flags set in args keyed by the name of
+// their field, and the --config file
+func parseFlags(args []string) (map[string]string, string, error) {
+	values := map[string]string{}
+	set := flag.NewFlagSet("server", flag.ContinueOnError)
+	configFile := set.String("config", "", "YAML configuration file (CONFIG_FILE, default "+defaultConfigFile+")")
+	for _, key := range fieldKeys() {
+		key := key
+		set.Func(flagName(key), "overrides "+key, func(value string) error {
+			values[key] = value
+			return nil
+		})
+	}
+	if err := set.Parse(args); err != nil {
+		return nil, "", err
+	}
+	if set.NArg() > 0 {
+		return nil, "", fmt.Errorf("unexpected argument %q", set.Arg(0))
+	}
+	return values, *configFile, nil
+}
+
+// resolveProfile picks APP_ENV from the flags, the environment or the base
+// file, in that order
+func resolveProfile(flags map[string]string, base []byte) (string, error) {
+	profile, ok := flags["APP_ENV"]
+	if !ok {
+		profile, ok = os.LookupEnv("APP_ENV")
+	}
+	if !ok && base != nil {
+		var peek struct {
+			AppEnv string `yaml:"app_env"`
+		}
+		if err := yaml.Unmarshal(base, &peek); err != nil {
+			return "", fmt.Errorf("reading app_env: %w", err)
+		}
+		profile = peek.AppEnv
+	}
+	profile = strings.ToLower(strings.TrimSpace(profile))
+	if profile == "" {
+		profile = ProfileDevelopment
+	}
+	return profile, nil
+}
+
+// readConfigFile returns the contents of a YAML file, or nil when an optional
+// file does not exist
+func readConfigFile(path string, required bool) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && !required {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading configuration: %w", err)
+	}
+	return data, nil
+}
+
+// decodeConfigFile sets the fields a YAML file names, rejecting unknown keys
+// so that a typo does not go unnoticed
+func decodeConfigFile(path string, data []byte, cfg *Config, log *zap.Logger) error {
+	if data == nil {
+		return nil
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && err != io.EOF {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	log.Info("Configuration file loaded", zap.String("path", path))
+	return nil
+}
+
+// applyEnv sets the fields whose environment variable is set
+func applyEnv(cfg *Config) error {
+	for _, key := range fieldKeys() {
+		value, ok := os.LookupEnv(key)
+		if !ok {
+			continue
+		}
+		if err := setField(cfg, key, value); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	return applyOIDCEnv(cfg)
+}
+
+// applyOIDCEnv replaces the providers with those OIDC_PROVIDERS names, when
+// set, and applies the OIDC_<NAME>_* variables to every provider
+func applyOIDCEnv(cfg *Config) error {
+	if names, ok := os.LookupEnv("OIDC_PROVIDERS"); ok {
+		var providers []OIDCProvider
+		for _, name := range splitList(names) {
+			provider := OIDCProvider{Name: strings.ToLower(name)}
+			for _, configured := range cfg.OIDC_PROVIDERS {
+				if strings.EqualFold(configured.Name, name) {
+					provider = configured
+				}
+			}
+			providers = append(providers, provider)
+		}
+		cfg.OIDC_PROVIDERS = providers
+	}
+
+	for i := range cfg.OIDC_PROVIDERS {
+		provider := &cfg.OIDC_PROVIDERS[i]
+		provider.Name = strings.ToLower(strings.TrimSpace(provider.Name))
+		prefix := "OIDC_" + strings.ToUpper(provider.Name) + "_"
+		for suffix, field := range map[string]*string{
+			"ISSUER":        &provider.Issuer,
+			"CLIENT_ID":     &provider.ClientID,
+			"CLIENT_SECRET": &provider.ClientSecret,
+			"REDIRECT_URL":  &provider.RedirectURL,
+		} {
+			if value, ok := os.LookupEnv(prefix + suffix); ok {
+				*field = value
+			}
+		}
+	}
+	return nil
+}
+
+// fieldKeys lists the fields set from the environment and flags by name;
+// OIDC_PROVIDERS has variables of its own
+func fieldKeys() []string {
+	var keys []string
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		if name := t.Field(i).Name; name != "OIDC_PROVIDERS" {
+			keys = append(keys, strings.ToUpper(name))
+		}
+	}
+	return keys
+}
+
+// setField parses value into the field whose upper-cased name is key
+func setField(cfg *Config, key, value string) error {
+	field := reflect.ValueOf(cfg).Elem().FieldByNameFunc(func(name string) bool {
+		return strings.ToUpper(name) == key
+	})
+	if !field.IsValid() {
+		return fmt.Errorf("unknown setting")
+	}
+	value = strings.TrimSpace(value)
+	switch {
+	case field.Type() == durationType:
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration such as \"30s\"", value)
+		}
+		field.SetInt(int64(duration))
+	case field.Kind() == reflect.Int:
+		number, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", value)
+		}
+		field.SetInt(int64(number))
+	case field.Kind() == reflect.Bool:
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", value)
+		}
+		field.SetBool(enabled)
+	case field.Kind() == reflect.String:
+		field.SetString(value)
+	case field.Type() == reflect.TypeOf([]string{}):
+		field.Set(reflect.ValueOf(splitList(value)))
+	default:
+		return fmt.Errorf("unsupported setting type %s", field.Type())
+	}
+	return nil
+}
+
+// flagName is the flag of a field: DB_PORT is --db-port
+func flagName(key string) string {
+	return strings.ReplaceAll(strings.ToLower(key), "_", "-")
+}
+
+// splitList reads a comma separated list, skipping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
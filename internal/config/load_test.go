@@ -0,0 +1,136 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// inEmptyDir runs the test in a directory without config.yaml or .env
+func inEmptyDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Chdir(dir)
+	return dir
+}
+
+func writeFile(t *testing.T, path, contents string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadLayersSources(t *testing.T) {
+	dir := inEmptyDir(t)
+	writeFile(t, filepath.Join(dir, "config.yaml"), "db_port: 5433\nredis_port: 6380\nemail_port: 2525\nport: 3000\n")
+	writeFile(t, filepath.Join(dir, "config.test.yaml"), "redis_port: 6381\nemail_port: 2526\nport: 3001\n")
+	t.Setenv("EMAIL_PORT", "2527")
+	t.Setenv("PORT", "3002")
+
+	cfg, err := Load([]string{"--app-env=test", "--port=3003"}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// each source overrides the ones before it
+	if cfg.DB_PORT != 5433 || cfg.REDIS_PORT != 6381 || cfg.EMAIL_PORT != 2527 || cfg.Port != 3003 {
+		t.Fatalf("DB_PORT %d, REDIS_PORT %d, EMAIL_PORT %d, PORT %d; want 5433, 6381, 2527, 3003",
+			cfg.DB_PORT, cfg.REDIS_PORT, cfg.EMAIL_PORT, cfg.Port)
+	}
+	// and the rest keeps the profile's defaults
+	if cfg.DB_NAME != "postgres_test" {
+		t.Fatalf("DB_NAME %q, want the test default", cfg.DB_NAME)
+	}
+}
+
+func TestLoadProfileFromFile(t *testing.T) {
+	dir := inEmptyDir(t)
+	writeFile(t, filepath.Join(dir, "config.yaml"), "app_env: test\n")
+	// the profile file cannot switch to another profile
+	writeFile(t, filepath.Join(dir, "config.test.yaml"), "app_env: production\n")
+
+	cfg, err := Load(nil, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.APP_ENV != ProfileTest {
+		t.Fatalf("APP_ENV %q, want test", cfg.APP_ENV)
+	}
+}
+
+func TestLoadParsesTypes(t *testing.T) {
+	dir := inEmptyDir(t)
+	writeFile(t, filepath.Join(dir, "config.yaml"), "http_read_timeout: 20s\n")
+	t.Setenv("SHUTDOWN_TIMEOUT", "45s")
+	t.Setenv("LOG_SAMPLING", "true")
+	t.Setenv("JWT_VERIFICATION_KEY_FILES", "old.pem, ,older.pem")
+
+	cfg, err := Load([]string{"--app-env=test"}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.HTTP_READ_TIMEOUT != 20*time.Second || cfg.SHUTDOWN_TIMEOUT != 45*time.Second {
+		t.Fatalf("HTTP_READ_TIMEOUT %v, SHUTDOWN_TIMEOUT %v; want 20s, 45s", cfg.HTTP_READ_TIMEOUT, cfg.SHUTDOWN_TIMEOUT)
+	}
+	if !cfg.LOG_SAMPLING {
+		t.Fatal("LOG_SAMPLING not set from the environment")
+	}
+	if strings.Join(cfg.JWT_VERIFICATION_KEY_FILES, "|") != "old.pem|older.pem" {
+		t.Fatalf("JWT_VERIFICATION_KEY_FILES %q, want old.pem and older.pem", cfg.JWT_VERIFICATION_KEY_FILES)
+	}
+}
+
+func TestLoadRejectsMalformedSources(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(t *testing.T, dir string)
+		args    []string
+		problem string
+	}{
+		{"bad duration", func(t *testing.T, dir string) { t.Setenv("SHUTDOWN_TIMEOUT", "30") }, nil, `SHUTDOWN_TIMEOUT: "30" is not a duration`},
+		{"bad number", func(t *testing.T, dir string) {}, []string{"--db-port=postgres"}, `--db-port: "postgres" is not a number`},
+		{"unknown flag", func(t *testing.T, dir string) {}, []string{"--db-prot=5432"}, "db-prot"},
+		{"unknown key", func(t *testing.T, dir string) {
+			writeFile(t, filepath.Join(dir, "config.yaml"), "db_prot: 5432\n")
+		}, nil, "db_prot"},
+		{"missing explicit file", func(t *testing.T, dir string) {}, []string{"--config=absent.yaml"}, "reading configuration"},
+		{"invalid value", func(t *testing.T, dir string) {}, []string{"--port=0"}, "PORT 0 is not a port"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := inEmptyDir(t)
+			tt.setup(t, dir)
+			_, err := Load(append([]string{"--app-env=test"}, tt.args...), zap.NewNop())
+			if err == nil || !strings.Contains(err.Error(), tt.problem) {
+				t.Fatalf("Load() = %v, want %q", err, tt.problem)
+			}
+		})
+	}
+}
+
+func TestLoadOIDCProvidersFromEnvironment(t *testing.T) {
+	inEmptyDir(t)
+	t.Setenv("OIDC_PROVIDERS", "Google")
+	t.Setenv("OIDC_GOOGLE_ISSUER", "https://accounts.google.com/")
+	t.Setenv("OIDC_GOOGLE_CLIENT_ID", "restaurant-api")
+	t.Setenv("OIDC_GOOGLE_CLIENT_SECRET", "client-secret")
+
+	cfg, err := Load([]string{"--app-env=test", "--frontend-url=http://localhost:2000"}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := OIDCProvider{
+		Name:         "google",
+		Issuer:       "https://accounts.google.com",
+		ClientID:     "restaurant-api",
+		ClientSecret: "client-secret",
+		RedirectURL:  "http://localhost:2000/api/v1/auth/oidc/google/callback",
+	}
+	if len(cfg.OIDC_PROVIDERS) != 1 || cfg.OIDC_PROVIDERS[0] != want {
+		t.Fatalf("OIDC_PROVIDERS %+v, want %+v", cfg.OIDC_PROVIDERS, want)
+	}
+}
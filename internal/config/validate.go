@@ -0,0 +1,142 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/alibaba0010/postgres-api/internal/utils"
+)
+
+// Validate reports every problem of the configuration at once
+func (c Config) Validate() error {
+	var problems []string
+	fail := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	switch c.APP_ENV {
+	case ProfileDevelopment, ProfileTest, ProfileStaging, ProfileProduction:
+	default:
+		fail("APP_ENV %q is not one of development, test, staging, production", c.APP_ENV)
+	}
+
+	ports := []struct {
+		key  string
+		port int
+	}{{"PORT", c.Port}, {"DB_PORT", c.DB_PORT}, {"REDIS_PORT", c.REDIS_PORT}, {"EMAIL_PORT", c.EMAIL_PORT}}
+	for _, setting := range ports {
+		if setting.port < 1 || setting.port > 65535 {
+			fail("%s %d is not a port between 1 and 65535", setting.key, setting.port)
+		}
+	}
+
+	value := reflect.ValueOf(c)
+	for i := 0; i < value.NumField(); i++ {
+		if value.Field(i).Type() == durationType && value.Field(i).Int() <= 0 {
+			fail("%s must be a positive duration", value.Type().Field(i).Name)
+		}
+	}
+
+	// the RateLimit-Policy header counts windows in whole seconds
+	rateLimits := []struct {
+		key    string
+		limit  int
+		window time.Duration
+	}{
+		{"RATE_LIMIT_API", c.RATE_LIMIT_API, c.RATE_LIMIT_API_WINDOW},
+		{"RATE_LIMIT_AUTH", c.RATE_LIMIT_AUTH, c.RATE_LIMIT_AUTH_WINDOW},
+		{"RATE_LIMIT_CREDENTIALS", c.RATE_LIMIT_CREDENTIALS, c.RATE_LIMIT_CREDENTIALS_WINDOW},
+	}
+	for _, setting := range rateLimits {
+		if setting.limit <= 0 {
+			fail("%s %d must be a positive number of requests", setting.key, setting.limit)
+		}
+		if setting.window > 0 && setting.window < time.Second {
+			fail("%s_WINDOW %v must be at least 1s", setting.key, setting.window)
+		}
+	}
+
+	if _, err := utils.ParseTrustedProxies(c.TRUSTED_PROXIES); err != nil {
+		fail("TRUSTED_PROXIES: %v", err)
+	}
+
+	required := []struct {
+		key   string
+		value string
+	}{{"DB_HOST", c.DB_HOST}, {"DB_USERNAME", c.DB_USERNAME}, {"DB_NAME", c.DB_NAME}, {"REDIS_HOST", c.REDIS_HOST}, {"EMAIL_HOST", c.EMAIL_HOST}}
+	for _, setting := range required {
+		if strings.TrimSpace(setting.value) == "" {
+			fail("%s must be set", setting.key)
+		}
+	}
+
+	if err := checkURL(c.FRONTEND_URL); err != nil {
+		fail("FRONTEND_URL %v", err)
+	}
+
+	switch c.LOG_LEVEL {
+	case "debug", "info", "warn", "error":
+	default:
+		fail("LOG_LEVEL %q is not one of debug, info, warn, error", c.LOG_LEVEL)
+	}
+	switch c.LOG_FORMAT {
+	case LogFormatConsole, LogFormatJSON:
+	default:
+		fail("LOG_FORMAT %q is not one of console, json", c.LOG_FORMAT)
+	}
+
+	// the secrets' defaults are public, so only development and test use them
+	var missing []string
+	if c.REFRESH_TOKEN_SECRET == "" || (c.REFRESH_TOKEN_SECRET == defaultRefreshTokenSecret && !c.AllowsInsecureDefaults()) {
+		missing = append(missing, "REFRESH_TOKEN_SECRET")
+	}
+	if c.INVITE_TOKEN_SECRET == "" || (c.INVITE_TOKEN_SECRET == defaultInviteTokenSecret && !c.AllowsInsecureDefaults()) {
+		missing = append(missing, "INVITE_TOKEN_SECRET")
+	}
+	if c.JWT_SIGNING_KEY_FILE == "" && !c.AllowsInsecureDefaults() {
+		missing = append(missing, "JWT_SIGNING_KEY_FILE")
+	}
+	if len(missing) > 0 {
+		fail("%s must be set when APP_ENV is %q", strings.Join(missing, ", "), c.APP_ENV)
+	}
+
+	seen := map[string]bool{}
+	for _, provider := range c.OIDC_PROVIDERS {
+		if provider.Name == "" {
+			fail("an OIDC provider has no name")
+			continue
+		}
+		if seen[provider.Name] {
+			fail("OIDC provider %q is listed twice", provider.Name)
+		}
+		seen[provider.Name] = true
+		if err := checkURL(provider.Issuer); err != nil {
+			fail("OIDC provider %q: issuer %v", provider.Name, err)
+		} else if !strings.HasPrefix(provider.Issuer, "https://") && !c.AllowsInsecureDefaults() {
+			fail("OIDC provider %q: issuer must use https", provider.Name)
+		}
+		if provider.ClientID == "" {
+			fail("OIDC provider %q: client ID must be set", provider.Name)
+		}
+		if err := checkURL(provider.RedirectURL); err != nil {
+			fail("OIDC provider %q: redirect URL %v", provider.Name, err)
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid configuration: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// checkURL requires an absolute http or https URL
+func checkURL(raw string) error {
+	parsed, err := url.Parse(raw)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("%q is not an absolute http(s) URL", raw)
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateRateLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(*Config)
+		problem string
+	}{
+		{"zero limit", func(c *Config) { c.RATE_LIMIT_API = 0 }, "RATE_LIMIT_API 0 must be a positive number of requests"},
+		{"negative limit", func(c *Config) { c.RATE_LIMIT_AUTH = -5 }, "RATE_LIMIT_AUTH -5 must be a positive number of requests"},
+		{"zero window", func(c *Config) { c.RATE_LIMIT_CREDENTIALS_WINDOW = 0 }, "RATE_LIMIT_CREDENTIALS_WINDOW must be a positive duration"},
+		{"sub-second window", func(c *Config) { c.RATE_LIMIT_AUTH_WINDOW = 500 * time.Millisecond }, "RATE_LIMIT_AUTH_WINDOW 500ms must be at least 1s"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaults(ProfileTest)
+			tt.change(&cfg)
+			err := cfg.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.problem) {
+				t.Fatalf("Validate() = %v, want %q", err, tt.problem)
+			}
+		})
+	}
+
+	if err := defaults(ProfileTest).Validate(); err != nil {
+		t.Fatalf("default rate limits rejected: %v", err)
+	}
+}
+
+func TestValidateDefaultsOfEachProfile(t *testing.T) {
+	for _, profile := range []string{ProfileDevelopment, ProfileTest} {
+		if err := defaults(profile).Validate(); err != nil {
+			t.Errorf("%s defaults rejected: %v", profile, err)
+		}
+	}
+	// staging and production have no secrets to fall back to
+	for _, profile := range []string{ProfileStaging, ProfileProduction} {
+		err := defaults(profile).Validate()
+		want := `REFRESH_TOKEN_SECRET, INVITE_TOKEN_SECRET, JWT_SIGNING_KEY_FILE must be set when APP_ENV is "` + profile + `"`
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s defaults: Validate() = %v, want %q", profile, err, want)
+		}
+	}
+}
+
+func TestValidateRejectsDevelopmentSecretsInProduction(t *testing.T) {
+	cfg := productionConfig()
+	cfg.REFRESH_TOKEN_SECRET = defaultRefreshTokenSecret
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "REFRESH_TOKEN_SECRET must be set") {
+		t.Fatalf("Validate() = %v, want the default refresh secret rejected", err)
+	}
+
+	cfg = productionConfig()
+	cfg.INVITE_TOKEN_SECRET = defaultInviteTokenSecret
+	err = cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "INVITE_TOKEN_SECRET must be set") {
+		t.Fatalf("Validate() = %v, want the default invite secret rejected", err)
+	}
+
+	if err := productionConfig().Validate(); err != nil {
+		t.Fatalf("configured production rejected: %v", err)
+	}
+}
+
+// productionConfig is a valid production configuration
+func productionConfig() Config {
+	cfg := defaults(ProfileProduction)
+	cfg.DB_PASSWORD = "db-password"
+	cfg.REFRESH_TOKEN_SECRET = "refresh-secret"
+	cfg.INVITE_TOKEN_SECRET = "invite-secret"
+	cfg.JWT_SIGNING_KEY_FILE = "/etc/restaurant/signing-key.pem"
+	cfg.FRONTEND_URL = "https://restaurant.example"
+	return cfg
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(*Config)
+		problem string
+	}{
+		{"unknown profile", func(c *Config) { c.APP_ENV = "qa" }, `APP_ENV "qa" is not one of development, test, staging, production`},
+		{"port zero", func(c *Config) { c.Port = 0 }, "PORT 0 is not a port between 1 and 65535"},
+		{"port too high", func(c *Config) { c.DB_PORT = 70000 }, "DB_PORT 70000 is not a port between 1 and 65535"},
+		{"negative timeout", func(c *Config) { c.HTTP_READ_TIMEOUT = -time.Second }, "HTTP_READ_TIMEOUT must be a positive duration"},
+		{"zero timeout", func(c *Config) { c.SHUTDOWN_TIMEOUT = 0 }, "SHUTDOWN_TIMEOUT must be a positive duration"},
+		{"blank host", func(c *Config) { c.DB_HOST = "  " }, "DB_HOST must be set"},
+		{"relative frontend", func(c *Config) { c.FRONTEND_URL = "restaurant.example" }, `FRONTEND_URL "restaurant.example" is not an absolute http(s) URL`},
+		{"frontend scheme", func(c *Config) { c.FRONTEND_URL = "ftp://restaurant.example" }, "FRONTEND_URL"},
+		{"log level", func(c *Config) { c.LOG_LEVEL = "trace" }, `LOG_LEVEL "trace" is not one of debug, info, warn, error`},
+		{"log format", func(c *Config) { c.LOG_FORMAT = "xml" }, `LOG_FORMAT "xml" is not one of console, json`},
+		{"trusted proxy", func(c *Config) { c.TRUSTED_PROXIES = []string{"10.0.0.0/8", "lb.internal"} }, `TRUSTED_PROXIES: "lb.internal" is not an IP address or CIDR range`},
+		{"provider without name", func(c *Config) {
+			c.OIDC_PROVIDERS = []OIDCProvider{{Issuer: "https://id.example", ClientID: "api", RedirectURL: "https://api.example/cb"}}
+		}, "an OIDC provider has no name"},
+		{"provider twice", func(c *Config) {
+			provider := OIDCProvider{Name: "google", Issuer: "https://accounts.google.com", ClientID: "api", RedirectURL: "https://api.example/cb"}
+			c.OIDC_PROVIDERS = []OIDCProvider{provider, provider}
+		}, `OIDC provider "google" is listed twice`},
+		{"provider without client", func(c *Config) {
+			c.OIDC_PROVIDERS = []OIDCProvider{{Name: "google", Issuer: "https://accounts.google.com", RedirectURL: "https://api.example/cb"}}
+		}, `OIDC provider "google": client ID must be set`},
+		{"provider issuer", func(c *Config) {
+			c.OIDC_PROVIDERS = []OIDCProvider{{Name: "google", Issuer: "accounts.google.com", ClientID: "api", RedirectURL: "https://api.example/cb"}}
+		}, `OIDC provider "google": issuer "accounts.google.com" is not an absolute http(s) URL`},
+		{"provider redirect", func(c *Config) {
+			c.OIDC_PROVIDERS = []OIDCProvider{{Name: "google", Issuer: "https://accounts.google.com", ClientID: "api", RedirectURL: "/cb"}}
+		}, `OIDC provider "google": redirect URL "/cb" is not an absolute http(s) URL`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaults(ProfileTest)
+			tt.change(&cfg)
+			err := cfg.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.problem) {
+				t.Fatalf("Validate() = %v, want %q", err, tt.problem)
+			}
+		})
+	}
+}
+
+func TestValidateRequiresHTTPSIssuerOutsideDevelopment(t *testing.T) {
+	provider := OIDCProvider{Name: "local", Issuer: "http://localhost:8080", ClientID: "api", RedirectURL: "http://localhost:2000/cb"}
+
+	cfg := defaults(ProfileDevelopment)
+	cfg.OIDC_PROVIDERS = []OIDCProvider{provider}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("http issuer rejected in development: %v", err)
+	}
+
+	cfg = productionConfig()
+	cfg.OIDC_PROVIDERS = []OIDCProvider{provider}
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), `OIDC provider "local": issuer must use https`) {
+		t.Fatalf("Validate() = %v, want the http issuer rejected in production", err)
+	}
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	cfg := defaults(ProfileTest)
+	cfg.Port = 0
+	cfg.LOG_LEVEL = "loud"
+	cfg.EMAIL_HOST = ""
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("invalid configuration accepted")
+	}
+	for _, problem := range []string{"PORT 0", `LOG_LEVEL "loud"`, "EMAIL_HOST must be set"} {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("Validate() = %v, missing %q", err, problem)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/alibaba0010/postgres-api/internal/auth"
+	"github.com/alibaba0010/postgres-api/internal/dto"
+	"github.com/alibaba0010/postgres-api/internal/errors"
+)
+
+// CreateAPIKeyHandler creates an API key for one of the caller's restaurants.
+// The key is only ever returned here.
+func (h *Handler) CreateAPIKeyHandler(writer http.ResponseWriter, request *http.Request) {
+	principal, ok := sessionUser(writer, request)
+	if !ok {
+		return
+	}
+
+	var input dto.CreateAPIKeyInput
+	if err := json.NewDecoder(request.Body).Decode(&input); err != nil {
+		errors.ErrorResponse(writer, request, errors.ValidationError("Invalid JSON body"))
+		return
+	}
+
+	key, appErr := h.svc.CreateAPIKey(request.Context(), principal.UserID, principal.Role, input)
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(writer).Encode(key)
+}
+
+func (h *Handler) ListAPIKeysHandler(writer http.ResponseWriter, request *http.Request) {
+	principal, ok := sessionUser(writer, request)
+	if !ok {
+		return
+	}
+
+	keys, appErr := h.svc.ListAPIKeys(request.Context(), principal.UserID)
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(keys)
+}
+
+func (h *Handler) RevokeAPIKeyHandler(writer http.ResponseWriter, request *http.Request) {
+	principal, ok := sessionUser(writer, request)
+	if !ok {
+		return
+	}
+
+	if appErr := h.svc.RevokeAPIKey(request.Context(), principal.UserID, mux.Vars(request)["id"]); appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+// sessionUser returns the caller of a request made with a signed-in session;
+// an API key lacks the credentials scope, so it cannot manage API keys
+func sessionUser(writer http.ResponseWriter, request *http.Request) (*auth.Principal, bool) {
+	principal := auth.FromContext(request.Context())
+	if principal == nil {
+		errors.ErrorResponse(writer, request, errors.UnauthorizedError("user not authenticated"))
+		return nil, false
+	}
+	if !principal.HasScope(auth.ScopeCredentials) {
+		errors.ErrorResponse(writer, request, errors.ForbiddenError("API keys cannot manage API keys; sign in instead"))
+		return nil, false
+	}
+	return principal, true
+}
@@ -2,15 +2,12 @@ package controllers
 
 import (
 	"encoding/json"
-	"fmt"
 	"net/http"
 	"strings"
-	"time"
 
 	"github.com/go-playground/validator/v10"
 
-	"github.com/alibaba0010/postgres-api/internal/config"
-	"github.com/alibaba0010/postgres-api/internal/database"
+	"github.com/alibaba0010/postgres-api/internal/auth"
 	"github.com/alibaba0010/postgres-api/internal/dto"
 	"github.com/alibaba0010/postgres-api/internal/errors"
 	"github.com/alibaba0010/postgres-api/internal/models"
@@ -19,7 +16,7 @@ import (
 )
 
 
-func SignupHandler(writer http.ResponseWriter, request *http.Request) {
+func (h *Handler) SignupHandler(writer http.ResponseWriter, request *http.Request) {
 	var input dto.SignupInput
 
 	// Decode JSON
@@ -28,7 +25,7 @@ func SignupHandler(writer http.ResponseWriter, request *http.Request) {
 		return
 	}
 	
-	_, appErr := services.RegisterUser(request.Context(), input)
+	_, appErr := h.svc.RegisterUser(request.Context(), input)
 	if appErr != nil {
 		errors.ErrorResponse(writer, request, appErr)
 		return
@@ -43,25 +40,29 @@ func SignupHandler(writer http.ResponseWriter, request *http.Request) {
 	writer.WriteHeader(http.StatusCreated)
 	_ = json.NewEncoder(writer).Encode(resp)
 }
-func ActivateUserHandler(writer http.ResponseWriter, request *http.Request) {
+func (h *Handler) ActivateUserHandler(writer http.ResponseWriter, request *http.Request) {
 	token := request.URL.Query().Get("token")
 	if token == "" {
 		errors.ErrorResponse(writer, request, errors.ValidationError("token is required"))
 		return
 	}
 
-	user, appErr := services.ActivateUser(request.Context(), token)
+	user, appErr := h.svc.ActivateUser(request.Context(), token)
 	if appErr != nil {
 		errors.ErrorResponse(writer, request, appErr)
 		return
 	}
+	if services.MFARequired(user) {
+		h.writeMFAChallenge(writer, request, user)
+		return
+	}
 
 	// generate token pair and set refresh token cookie
 	// extract IP and user-agent
 	ip:= utils.ExtractClientIP(request)
 	ua := request.Header.Get("User-Agent")
 
-	tokens, appErr := services.GenerateTokenPair(request.Context(), user.ID, user.Role, ip, ua)
+	tokens, appErr := h.svc.GenerateTokenPair(request.Context(), user.ID, user.Role, ip, ua)
 	if appErr != nil {
 		errors.ErrorResponse(writer, request, appErr)
 		return
@@ -69,21 +70,7 @@ func ActivateUserHandler(writer http.ResponseWriter, request *http.Request) {
 	request.Header.Set("Authorization", "Bearer "+tokens.AccessToken)
 
 	// set refresh token cookie
-	cfg := config.LoadConfig()
-	cookie := &http.Cookie{
-		Name:     "refresh_token",
-		Value:    tokens.RefreshToken,
-		HttpOnly: true,
-		Path:     "/",
-		Expires:  time.Now().Add(services.RefreshTokenDuration),
-		Secure:   false,
-		SameSite: http.SameSiteLaxMode,
-	}
-	// If running behind TLS, make cookie secure
-	if strings.HasPrefix(cfg.FRONTEND_URL, "https") {
-		cookie.Secure = true
-	}
-	http.SetCookie(writer, cookie)
+	h.setRefreshTokenCookie(writer, tokens.RefreshToken)
 
 	// Return created user (omit password) + tokens
 	resp := dto.SignUpResponse{
@@ -102,7 +89,7 @@ func ActivateUserHandler(writer http.ResponseWriter, request *http.Request) {
 	_ = json.NewEncoder(writer).Encode(resp)
 }
 
-func SigninHandler(writer http.ResponseWriter, request *http.Request) {
+func (h *Handler) SigninHandler(writer http.ResponseWriter, request *http.Request) {
 	var input dto.SigninInput
 
 	// Decode JSON
@@ -123,130 +110,394 @@ func SigninHandler(writer http.ResponseWriter, request *http.Request) {
 	}
 
 	// Authenticate user
-	user, _, appErr := services.LoginUser(request.Context(), input.Email, input.Password)
+	user, _, appErr := h.svc.LoginUser(request.Context(), input.Email, input.Password, utils.ExtractClientIP(request))
 	if appErr != nil {
 		errors.ErrorResponse(writer, request, appErr)
 		return
 	}
 
-	// Extract client IP and User-Agent
+	// Accounts with two-factor authentication (and admins, who must enroll)
+	// only get an mfa token here; tokens are issued by /auth/mfa/verify
+	if services.MFARequired(user) {
+		h.writeMFAChallenge(writer, request, user)
+		return
+	}
+
+	h.writeSigninResponse(writer, request, user, "Signin successful")
+}
+
+func (h *Handler) ResendVerificationHandler(writer http.ResponseWriter, request *http.Request) {
+	var body struct{
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(request.Body).Decode(&body); err != nil {
+		errors.ErrorResponse(writer, request, errors.ValidationError("Invalid JSON body"))
+		return
+	}
+	email := strings.TrimSpace(body.Email)
+	if email == "" {
+		errors.ErrorResponse(writer, request, errors.ValidationError("email is required"))
+		return
+	}
+
+	if appErr := h.svc.ResendVerification(request.Context(), email); appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(map[string]string{"message": "Verification email resent"})
+}
+
+// ForgotPasswordHandler sends a password reset link. The response is the same
+// whether or not the email is registered.
+func (h *Handler) ForgotPasswordHandler(writer http.ResponseWriter, request *http.Request) {
+	var input dto.ForgotPasswordInput
+	if err := json.NewDecoder(request.Body).Decode(&input); err != nil {
+		errors.ErrorResponse(writer, request, errors.ValidationError("Invalid JSON body"))
+		return
+	}
+
+	if appErr := h.svc.RequestPasswordReset(request.Context(), input); appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(map[string]string{"message": "If an account exists for that email, a password reset link has been sent"})
+}
+
+// ResetPasswordHandler sets a new password using the token from the reset email
+func (h *Handler) ResetPasswordHandler(writer http.ResponseWriter, request *http.Request) {
+	var input dto.ResetPasswordInput
+	if err := json.NewDecoder(request.Body).Decode(&input); err != nil {
+		errors.ErrorResponse(writer, request, errors.ValidationError("Invalid JSON body"))
+		return
+	}
+
+	if appErr := h.svc.ResetPassword(request.Context(), input); appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(map[string]string{"message": "Password has been reset, please sign in again"})
+}
+
+
+// ConfirmEmailChangeHandler switches an account to the new email confirmed by
+// the emailed token
+func (h *Handler) ConfirmEmailChangeHandler(writer http.ResponseWriter, request *http.Request) {
+	token := request.URL.Query().Get("token")
+	if token == "" {
+		errors.ErrorResponse(writer, request, errors.ValidationError("token is required"))
+		return
+	}
+
+	user, appErr := h.svc.ConfirmEmailChange(request.Context(), token)
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(map[string]any{"message": "Email changed successfully", "data": user})
+}
+
+// RefreshTokenHandler exchanges the refresh token (cookie or body) for a new
+// token pair. The presented refresh token stops working.
+func (h *Handler) RefreshTokenHandler(writer http.ResponseWriter, request *http.Request) {
+	refreshToken, appErr := refreshTokenFromRequest(request)
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+	if refreshToken == "" {
+		errors.ErrorResponse(writer, request, errors.UnauthorizedError("refresh token missing; please login again"))
+		return
+	}
+
 	ip := utils.ExtractClientIP(request)
 	userAgent := request.Header.Get("User-Agent")
 
-	// Generate token pair
-	tokens, appErr := services.GenerateTokenPair(request.Context(), user.ID, user.Role, ip, userAgent)
+	tokens, appErr := h.svc.RefreshAccessToken(request.Context(), refreshToken, "", ip, userAgent)
 	if appErr != nil {
+		clearRefreshTokenCookie(writer)
 		errors.ErrorResponse(writer, request, appErr)
 		return
 	}
-	
-	request.Header.Set("Authorization", "Bearer "+tokens.AccessToken)
 
-	// Set refresh token cookie
-	cfg := config.LoadConfig()
+	h.setRefreshTokenCookie(writer, tokens.RefreshToken)
+
+	resp := dto.TokenResponse{
+		Title: "Token refreshed",
+		Data: dto.TokenData{
+			AccessToken:  tokens.AccessToken,
+			RefreshToken: tokens.RefreshToken,
+		},
+	}
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(resp)
+}
+
+// SwitchRestaurantHandler exchanges the refresh token (cookie or body) for a
+// token pair whose active restaurant is the requested one
+func (h *Handler) SwitchRestaurantHandler(writer http.ResponseWriter, request *http.Request) {
+	var input dto.SwitchRestaurantInput
+	if err := json.NewDecoder(request.Body).Decode(&input); err != nil {
+		errors.ErrorResponse(writer, request, errors.ValidationError("Invalid JSON body"))
+		return
+	}
+	refreshToken := strings.TrimSpace(input.RefreshToken)
+	if cookie, err := request.Cookie("refresh_token"); err == nil && cookie.Value != "" {
+		refreshToken = cookie.Value
+	}
+	if refreshToken == "" {
+		errors.ErrorResponse(writer, request, errors.UnauthorizedError("refresh token missing; please login again"))
+		return
+	}
+
+	ip := utils.ExtractClientIP(request)
+	userAgent := request.Header.Get("User-Agent")
+
+	tokens, appErr := h.svc.SwitchActiveRestaurant(request.Context(), refreshToken, strings.TrimSpace(input.RestaurantID), ip, userAgent)
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	h.setRefreshTokenCookie(writer, tokens.RefreshToken)
+
+	resp := dto.TokenResponse{
+		Title: "Active restaurant switched",
+		Data: dto.TokenData{
+			AccessToken:  tokens.AccessToken,
+			RefreshToken: tokens.RefreshToken,
+		},
+	}
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(resp)
+}
+
+// LogoutHandler revokes the current session's refresh token family and clears
+// the cookie. A bearer access token sent along is revoked too.
+func (h *Handler) LogoutHandler(writer http.ResponseWriter, request *http.Request) {
+	refreshToken, appErr := refreshTokenFromRequest(request)
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	if accessToken, ok := strings.CutPrefix(request.Header.Get("Authorization"), "Bearer "); ok {
+		if claims, appErr := h.svc.VerifyAccessToken(accessToken); appErr == nil {
+			if appErr := h.svc.RevokeAccessToken(request.Context(), claims); appErr != nil {
+				errors.ErrorResponse(writer, request, appErr)
+				return
+			}
+		}
+	}
+
+	if refreshToken != "" {
+		if appErr := h.svc.RevokeRefreshToken(request.Context(), refreshToken); appErr != nil {
+			errors.ErrorResponse(writer, request, appErr)
+			return
+		}
+	}
+	clearRefreshTokenCookie(writer)
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(map[string]string{"message": "Logged out"})
+}
+
+// LogoutAllHandler revokes every refresh token of the authenticated user
+func (h *Handler) LogoutAllHandler(writer http.ResponseWriter, request *http.Request) {
+	principal := auth.FromContext(request.Context())
+	if principal == nil {
+		errors.ErrorResponse(writer, request, errors.UnauthorizedError("user not authenticated"))
+		return
+	}
+
+	if appErr := h.svc.RevokeAllRefreshTokens(request.Context(), principal.UserID); appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+	clearRefreshTokenCookie(writer)
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(map[string]string{"message": "Logged out of all sessions"})
+}
+
+// JWKSHandler publishes the public keys access tokens are verified with, for
+// services that check our tokens themselves
+func (h *Handler) JWKSHandler(writer http.ResponseWriter, request *http.Request) {
+	jwks := h.svc.AccessTokenJWKS()
+
+	// verifiers may cache the keys; a new key is listed well before it signs
+	writer.Header().Set("Cache-Control", "public, max-age=300")
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(jwks)
+}
+
+// refreshTokenFromRequest reads the refresh_token cookie, falling back to a
+// {"refresh_token": "..."} body. It returns "" when neither is present.
+func refreshTokenFromRequest(request *http.Request) (string, *errors.AppError) {
+	if cookie, err := request.Cookie("refresh_token"); err == nil && cookie.Value != "" {
+		return cookie.Value, nil
+	}
+	if request.Body == nil || request.ContentLength == 0 {
+		return "", nil
+	}
+	var input dto.RefreshTokenInput
+	if err := json.NewDecoder(request.Body).Decode(&input); err != nil {
+		return "", errors.ValidationError("Invalid JSON body")
+	}
+	return strings.TrimSpace(input.RefreshToken), nil
+}
+
+func (h *Handler) setRefreshTokenCookie(writer http.ResponseWriter, refreshToken string) {
+	cfg := h.cfg
 	cookie := &http.Cookie{
 		Name:     "refresh_token",
-		Value:    tokens.RefreshToken,
+		Value:    refreshToken,
 		HttpOnly: true,
 		Path:     "/",
-		Expires:  time.Now().Add(services.RefreshTokenDuration),
+		Expires:  h.clock.Now().Add(services.RefreshTokenDuration),
 		Secure:   false,
 		SameSite: http.SameSiteLaxMode,
 	}
+	// If running behind TLS, make cookie secure
 	if strings.HasPrefix(cfg.FRONTEND_URL, "https") {
 		cookie.Secure = true
 	}
 	http.SetCookie(writer, cookie)
+}
 
-	// Return response
-	resp := dto.SigninResponse{
-		Title: "Signin successful",
-		Data: dto.SigninData{
-			ID:           user.ID,
-			Name:         user.Name,
-			Email:        user.Email,
-			Role:         user.Role,
-			AccessToken:  tokens.AccessToken,
-			RefreshToken: tokens.RefreshToken,
-		},
+func clearRefreshTokenCookie(writer http.ResponseWriter) {
+	http.SetCookie(writer, &http.Cookie{
+		Name:     "refresh_token",
+		Value:    "",
+		HttpOnly: true,
+		Path:     "/",
+		MaxAge:   -1,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// MFAVerifyHandler completes a two-factor sign-in with a TOTP or recovery code
+func (h *Handler) MFAVerifyHandler(writer http.ResponseWriter, request *http.Request) {
+	var input dto.MFAVerifyInput
+	if err := json.NewDecoder(request.Body).Decode(&input); err != nil {
+		errors.ErrorResponse(writer, request, errors.ValidationError("Invalid JSON body"))
+		return
 	}
 
-	writer.Header().Set("Content-Type", "application/json")
-	writer.WriteHeader(http.StatusOK)
-	_ = json.NewEncoder(writer).Encode(resp)
+	user, appErr := h.svc.CompleteMFAChallenge(request.Context(), input)
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	h.writeSigninResponse(writer, request, user, "Signin successful")
 }
 
-func ResendVerificationHandler(writer http.ResponseWriter, request *http.Request) {
-	var body struct{
-		Email string `json:"email"`
-	}
-	if err := json.NewDecoder(request.Body).Decode(&body); err != nil {
+// PendingMFAEnrollHandler starts enrollment for an account that must set up
+// two-factor authentication before its sign-in can complete
+func (h *Handler) PendingMFAEnrollHandler(writer http.ResponseWriter, request *http.Request) {
+	var input dto.MFATokenInput
+	if err := json.NewDecoder(request.Body).Decode(&input); err != nil {
 		errors.ErrorResponse(writer, request, errors.ValidationError("Invalid JSON body"))
 		return
 	}
-	email := strings.TrimSpace(body.Email)
-	if email == "" {
-		errors.ErrorResponse(writer, request, errors.ValidationError("email is required"))
+
+	enrollment, appErr := h.svc.BeginPendingMFAEnrollment(request.Context(), input)
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
 		return
 	}
 
-	// If a user already exists in DB, they are activated
-	exists, err := database.DB.NewSelect().Model((*models.User)(nil)).Where("email = ?", email).Exists(request.Context())
-	if err != nil {
-		errors.ErrorResponse(writer, request, errors.InternalError(err))
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(enrollment)
+}
+
+// PendingMFAConfirmHandler confirms a sign-in enrollment and signs the user in
+func (h *Handler) PendingMFAConfirmHandler(writer http.ResponseWriter, request *http.Request) {
+	var input dto.MFAConfirmInput
+	if err := json.NewDecoder(request.Body).Decode(&input); err != nil {
+		errors.ErrorResponse(writer, request, errors.ValidationError("Invalid JSON body"))
 		return
 	}
-	if exists {
-		errors.ErrorResponse(writer, request, errors.ValidationError("user already exists"))
+
+	user, appErr := h.svc.ConfirmPendingMFAEnrollment(request.Context(), input)
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
 		return
 	}
 
-	// Scan Redis for verify:* keys and find matching email
-	var cursor uint64
-	found := false
-	for {
-		keys, cur, err := database.RedisClient.Scan(request.Context(), cursor, "verify:*", 100).Result()
-		if err != nil {
-			errors.ErrorResponse(writer, request, errors.InternalError(err))
-			return
-		}
-		for _, k := range keys {
-			b, err := database.RedisClient.Get(request.Context(), k).Bytes()
-			if err != nil {
-				continue
-			}
-			var payload struct{
-				ID string `json:"id"`
-				Name string `json:"name"`
-				Email string `json:"email"`
-			}
-			if err := json.Unmarshal(b, &payload); err != nil {
-				continue
-			}
-			if strings.EqualFold(payload.Email, email) {
-				token := strings.TrimPrefix(k, "verify:")
-				cfg := config.LoadConfig()
-				verifyURL := fmt.Sprintf("%s/api/v1/auth/verify?token=%s", cfg.FRONTEND_URL, token)
-				html := services.VerifyMailHTML(payload.Name, verifyURL)
-				if err := services.SendEmail(email, "Verify your email", html); err != nil {
-					errors.ErrorResponse(writer, request, errors.InternalError(err))
-					return
-				}
-				found = true
-				break
-			}
-		}
-		if cur == 0 || found {
-			break
-		}
-		cursor = cur
+	h.writeSigninResponse(writer, request, user, "Two-factor authentication enabled")
+}
+
+// writeMFAChallenge answers a correct password with an mfa token instead of session tokens
+func (h *Handler) writeMFAChallenge(writer http.ResponseWriter, request *http.Request, user *models.User) {
+	mfaToken, appErr := h.svc.StartMFAChallenge(request.Context(), user.ID)
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	resp := dto.MFAChallengeResponse{
+		Title: "Two-factor authentication required",
+		Data: dto.MFAChallengeData{
+			MFARequired:        true,
+			EnrollmentRequired: !user.MFAEnabled,
+			MFAToken:           mfaToken,
+			ExpiresIn:          int(services.MFAPendingTTL.Seconds()),
+		},
 	}
-	if !found {
-		errors.ErrorResponse(writer, request, errors.ValidationError("verification token not found or expired"))
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(resp)
+}
+
+// writeSigninResponse issues a token pair for user, sets the refresh cookie and
+// writes the sign-in response
+func (h *Handler) writeSigninResponse(writer http.ResponseWriter, request *http.Request, user *models.User, title string) {
+	// Extract client IP and User-Agent
+	ip := utils.ExtractClientIP(request)
+	userAgent := request.Header.Get("User-Agent")
+
+	// Generate token pair
+	tokens, appErr := h.svc.GenerateTokenPair(request.Context(), user.ID, user.Role, ip, userAgent)
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
 		return
 	}
 
+	// Set refresh token cookie
+	h.setRefreshTokenCookie(writer, tokens.RefreshToken)
+
+	resp := dto.SigninResponse{
+		Title: title,
+		Data: dto.SigninData{
+			ID:           user.ID,
+			Name:         user.Name,
+			Email:        user.Email,
+			Role:         user.Role,
+			AccessToken:  tokens.AccessToken,
+			RefreshToken: tokens.RefreshToken,
+		},
+	}
+
 	writer.Header().Set("Content-Type", "application/json")
 	writer.WriteHeader(http.StatusOK)
-	_ = json.NewEncoder(writer).Encode(map[string]string{"message": "Verification email resent"})
-}
\ No newline at end of file
+	_ = json.NewEncoder(writer).Encode(resp)
+}
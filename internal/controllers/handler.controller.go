@@ -0,0 +1,29 @@
+package controllers
+
+import (
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"github.com/alibaba0010/postgres-api/internal/clock"
+	"github.com/alibaba0010/postgres-api/internal/config"
+	"github.com/alibaba0010/postgres-api/internal/logger"
+	"github.com/alibaba0010/postgres-api/internal/services"
+)
+
+// Handler serves the HTTP endpoints of the API on top of the services
+type Handler struct {
+	svc   *services.Service
+	log   *zap.Logger
+	cfg   config.Config
+	clock clock.Clock
+}
+
+// New returns the handlers for svc
+func New(svc *services.Service, cfg config.Config, log *zap.Logger, c clock.Clock) *Handler {
+	return &Handler{svc: svc, log: log, cfg: cfg, clock: c}
+}
+
+func (h *Handler) logger(request *http.Request) *zap.Logger {
+	return logger.FromContextOr(request.Context(), h.log)
+}
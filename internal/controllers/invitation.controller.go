@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/alibaba0010/postgres-api/internal/auth"
+	"github.com/alibaba0010/postgres-api/internal/dto"
+	"github.com/alibaba0010/postgres-api/internal/errors"
+)
+
+// CreateInvitationHandler invites an email to the restaurant's staff
+func (h *Handler) CreateInvitationHandler(writer http.ResponseWriter, request *http.Request) {
+	principal := auth.FromContext(request.Context())
+	if principal == nil {
+		errors.ErrorResponse(writer, request, errors.UnauthorizedError("user not authenticated"))
+		return
+	}
+
+	var input dto.CreateInvitationInput
+	if err := json.NewDecoder(request.Body).Decode(&input); err != nil {
+		errors.ErrorResponse(writer, request, errors.ValidationError("Invalid JSON body"))
+		return
+	}
+
+	invitation, appErr := h.svc.CreateInvitation(request.Context(), mux.Vars(request)["id"], principal.UserID, principal.Role, input)
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(writer).Encode(invitation)
+}
+
+func (h *Handler) ListInvitationsHandler(writer http.ResponseWriter, request *http.Request) {
+	invitations, appErr := h.svc.ListInvitations(request.Context(), mux.Vars(request)["id"])
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(invitations)
+}
+
+func (h *Handler) RevokeInvitationHandler(writer http.ResponseWriter, request *http.Request) {
+	vars := mux.Vars(request)
+	if appErr := h.svc.RevokeInvitation(request.Context(), vars["id"], vars["invitationId"]); appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+// AcceptInvitationHandler joins the invited restaurant's staff. Someone new
+// to the platform gets an account and is signed in; an existing account is
+// given the role and signs in as usual.
+func (h *Handler) AcceptInvitationHandler(writer http.ResponseWriter, request *http.Request) {
+	var input dto.AcceptInvitationInput
+	if err := json.NewDecoder(request.Body).Decode(&input); err != nil {
+		errors.ErrorResponse(writer, request, errors.ValidationError("Invalid JSON body"))
+		return
+	}
+
+	user, created, appErr := h.svc.AcceptInvitation(request.Context(), input)
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	if created {
+		h.writeSigninResponse(writer, request, user, "Invitation accepted")
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(map[string]string{"message": "Invitation accepted, sign in to continue"})
+}
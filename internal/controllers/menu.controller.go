@@ -0,0 +1,181 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/alibaba0010/postgres-api/internal/auth"
+	"github.com/alibaba0010/postgres-api/internal/dto"
+	"github.com/alibaba0010/postgres-api/internal/errors"
+)
+
+// ListMenusHandler returns the active menus of a restaurant
+func (h *Handler) ListMenusHandler(writer http.ResponseWriter, request *http.Request) {
+	menus, appErr := h.svc.ListMenus(request.Context(), mux.Vars(request)["id"])
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(menus)
+}
+
+// GetMenuHandler returns a menu with all of its items
+func (h *Handler) GetMenuHandler(writer http.ResponseWriter, request *http.Request) {
+	menu, appErr := h.svc.GetMenuByID(request.Context(), mux.Vars(request)["id"])
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(menu)
+}
+
+func (h *Handler) CreateMenuHandler(writer http.ResponseWriter, request *http.Request) {
+	principal := auth.FromContext(request.Context())
+	if principal == nil {
+		errors.ErrorResponse(writer, request, errors.UnauthorizedError("user not authenticated"))
+		return
+	}
+
+	var input dto.CreateMenuInput
+	if err := json.NewDecoder(request.Body).Decode(&input); err != nil {
+		errors.ErrorResponse(writer, request, errors.ValidationError("Invalid JSON body"))
+		return
+	}
+
+	menu, appErr := h.svc.CreateMenu(request.Context(), mux.Vars(request)["id"], principal.UserID, principal.Role, input)
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(writer).Encode(menu)
+}
+
+func (h *Handler) UpdateMenuHandler(writer http.ResponseWriter, request *http.Request) {
+	principal := auth.FromContext(request.Context())
+	if principal == nil {
+		errors.ErrorResponse(writer, request, errors.UnauthorizedError("user not authenticated"))
+		return
+	}
+
+	var input dto.UpdateMenuInput
+	if err := json.NewDecoder(request.Body).Decode(&input); err != nil {
+		errors.ErrorResponse(writer, request, errors.ValidationError("Invalid JSON body"))
+		return
+	}
+
+	menu, appErr := h.svc.UpdateMenu(request.Context(), mux.Vars(request)["id"], principal.UserID, principal.Role, input)
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(menu)
+}
+
+func (h *Handler) DeleteMenuHandler(writer http.ResponseWriter, request *http.Request) {
+	principal := auth.FromContext(request.Context())
+	if principal == nil {
+		errors.ErrorResponse(writer, request, errors.UnauthorizedError("user not authenticated"))
+		return
+	}
+
+	if appErr := h.svc.DeleteMenu(request.Context(), mux.Vars(request)["id"], principal.UserID, principal.Role); appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+// ListMenuItemsHandler returns the items of a menu, optionally filtered by ?category=
+func (h *Handler) ListMenuItemsHandler(writer http.ResponseWriter, request *http.Request) {
+	category := strings.TrimSpace(request.URL.Query().Get("category"))
+	items, appErr := h.svc.ListMenuItems(request.Context(), mux.Vars(request)["id"], category)
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(items)
+}
+
+func (h *Handler) CreateMenuItemHandler(writer http.ResponseWriter, request *http.Request) {
+	principal := auth.FromContext(request.Context())
+	if principal == nil {
+		errors.ErrorResponse(writer, request, errors.UnauthorizedError("user not authenticated"))
+		return
+	}
+
+	var input dto.CreateMenuItemInput
+	if err := json.NewDecoder(request.Body).Decode(&input); err != nil {
+		errors.ErrorResponse(writer, request, errors.ValidationError("Invalid JSON body"))
+		return
+	}
+
+	item, appErr := h.svc.CreateMenuItem(request.Context(), mux.Vars(request)["id"], principal.UserID, principal.Role, input)
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(writer).Encode(item)
+}
+
+func (h *Handler) UpdateMenuItemHandler(writer http.ResponseWriter, request *http.Request) {
+	principal := auth.FromContext(request.Context())
+	if principal == nil {
+		errors.ErrorResponse(writer, request, errors.UnauthorizedError("user not authenticated"))
+		return
+	}
+
+	var input dto.UpdateMenuItemInput
+	if err := json.NewDecoder(request.Body).Decode(&input); err != nil {
+		errors.ErrorResponse(writer, request, errors.ValidationError("Invalid JSON body"))
+		return
+	}
+
+	vars := mux.Vars(request)
+	item, appErr := h.svc.UpdateMenuItem(request.Context(), vars["id"], vars["itemId"], principal.UserID, principal.Role, input)
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(item)
+}
+
+func (h *Handler) DeleteMenuItemHandler(writer http.ResponseWriter, request *http.Request) {
+	principal := auth.FromContext(request.Context())
+	if principal == nil {
+		errors.ErrorResponse(writer, request, errors.UnauthorizedError("user not authenticated"))
+		return
+	}
+
+	vars := mux.Vars(request)
+	if appErr := h.svc.DeleteMenuItem(request.Context(), vars["id"], vars["itemId"], principal.UserID, principal.Role); appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,173 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"github.com/alibaba0010/postgres-api/internal/auth"
+	"github.com/alibaba0010/postgres-api/internal/dto"
+	"github.com/alibaba0010/postgres-api/internal/errors"
+	"github.com/alibaba0010/postgres-api/internal/services"
+)
+
+// oidcStateCookie holds the state of the sign-in the browser started; the
+// callback only completes a flow whose state matches it
+const oidcStateCookie = "oidc_state"
+
+// oidcCookiePath limits the state cookie to the callback routes
+const oidcCookiePath = "/api/v1/auth/oidc/"
+
+// oidcProviderErrors words the error codes of RFC 6749 section 4.1.2.1 a
+// provider may send back instead of a code
+var oidcProviderErrors = map[string]string{
+	"access_denied":             "sign-in was cancelled at the provider",
+	"invalid_request":           "the sign-in provider refused the request: invalid_request",
+	"unauthorized_client":       "the sign-in provider refused the request: unauthorized_client",
+	"unsupported_response_type": "the sign-in provider refused the request: unsupported_response_type",
+	"invalid_scope":             "the sign-in provider refused the request: invalid_scope",
+	"server_error":              "the sign-in provider refused the request: server_error",
+	"temporarily_unavailable":   "the sign-in provider is temporarily unavailable; try again later",
+}
+
+// OIDCAuthorizeHandler sends the browser to the identity provider to sign in
+func (h *Handler) OIDCAuthorizeHandler(writer http.ResponseWriter, request *http.Request) {
+	authorizationURL, state, appErr := h.svc.StartOIDCLogin(request.Context(), mux.Vars(request)["provider"], "")
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+	h.setOIDCStateCookie(writer, state)
+	http.Redirect(writer, request, authorizationURL, http.StatusFound)
+}
+
+// OIDCCallbackHandler is where the provider sends the browser back. A sign-in
+// ends like SigninHandler; linking returns the linked identity.
+func (h *Handler) OIDCCallbackHandler(writer http.ResponseWriter, request *http.Request) {
+	browserState := ""
+	if cookie, err := request.Cookie(oidcStateCookie); err == nil {
+		browserState = cookie.Value
+	}
+	// the state is single-use whatever the outcome
+	clearOIDCStateCookie(writer)
+
+	query := request.URL.Query()
+	if providerErr := query.Get("error"); providerErr != "" {
+		// the query string is whatever the browser was sent back with, so
+		// only the codes of RFC 6749 reach the response
+		h.logger(request).Warn("sign-in provider returned an error",
+			zap.String("provider", mux.Vars(request)["provider"]),
+			zap.String("error", providerErr),
+			zap.String("error_description", query.Get("error_description")),
+		)
+		message, known := oidcProviderErrors[providerErr]
+		if !known {
+			message = "the sign-in provider refused the request"
+		}
+		errors.ErrorResponse(writer, request, errors.UnauthorizedError(message))
+		return
+	}
+
+	user, linked, appErr := h.svc.CompleteOIDCLogin(request.Context(), mux.Vars(request)["provider"], query.Get("code"), query.Get("state"), browserState)
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+	if linked != nil {
+		writer.Header().Set("Content-Type", "application/json")
+		writer.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(writer).Encode(linked)
+		return
+	}
+
+	if services.MFARequired(user) {
+		h.writeMFAChallenge(writer, request, user)
+		return
+	}
+	h.writeSigninResponse(writer, request, user, "Signin successful")
+}
+
+// LinkIdentityHandler returns the provider URL that links it to the caller's
+// account; the browser cannot carry the access token through the provider
+func (h *Handler) LinkIdentityHandler(writer http.ResponseWriter, request *http.Request) {
+	principal := auth.FromContext(request.Context())
+	if principal == nil {
+		errors.ErrorResponse(writer, request, errors.UnauthorizedError("user not authenticated"))
+		return
+	}
+
+	authorizationURL, state, appErr := h.svc.StartOIDCLogin(request.Context(), mux.Vars(request)["provider"], principal.UserID)
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+	h.setOIDCStateCookie(writer, state)
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(dto.OIDCAuthorizationResponse{AuthorizationURL: authorizationURL})
+}
+
+func (h *Handler) ListIdentitiesHandler(writer http.ResponseWriter, request *http.Request) {
+	principal := auth.FromContext(request.Context())
+	if principal == nil {
+		errors.ErrorResponse(writer, request, errors.UnauthorizedError("user not authenticated"))
+		return
+	}
+
+	identities, appErr := h.svc.ListIdentities(request.Context(), principal.UserID)
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(identities)
+}
+
+func (h *Handler) UnlinkIdentityHandler(writer http.ResponseWriter, request *http.Request) {
+	principal := auth.FromContext(request.Context())
+	if principal == nil {
+		errors.ErrorResponse(writer, request, errors.UnauthorizedError("user not authenticated"))
+		return
+	}
+
+	if appErr := h.svc.UnlinkIdentity(request.Context(), principal.UserID, mux.Vars(request)["id"]); appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) setOIDCStateCookie(writer http.ResponseWriter, state string) {
+	cookie := &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    state,
+		HttpOnly: true,
+		Path:     oidcCookiePath,
+		MaxAge:   int(services.OIDCStateTTL / time.Second),
+		// Lax still sends it on the provider's redirect back, a top-level GET
+		SameSite: http.SameSiteLaxMode,
+	}
+	if strings.HasPrefix(h.cfg.FRONTEND_URL, "https") {
+		cookie.Secure = true
+	}
+	http.SetCookie(writer, cookie)
+}
+
+func clearOIDCStateCookie(writer http.ResponseWriter) {
+	http.SetCookie(writer, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    "",
+		HttpOnly: true,
+		Path:     oidcCookiePath,
+		MaxAge:   -1,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
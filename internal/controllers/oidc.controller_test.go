@@ -0,0 +1,49 @@
+package controllers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/alibaba0010/postgres-api/internal/apptest"
+)
+
+func callback(env *apptest.Env, query url.Values) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodGet, "/api/v1/auth/oidc/google/callback?"+query.Encode(), nil)
+	request.RemoteAddr = "203.0.113.7:4000"
+	recorder := httptest.NewRecorder()
+	env.HTTPHandler().ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestProviderErrorNotEchoed(t *testing.T) {
+	env := apptest.New(t)
+	const injected = `<script>alert(1)</script> call +1-555-0100 to restore your account`
+
+	response := callback(env, url.Values{"error": {injected}, "state": {"anything"}})
+	if response.Code != http.StatusUnauthorized {
+		t.Fatalf("status %d, want 401", response.Code)
+	}
+	if body := response.Body.String(); strings.Contains(body, "script") || strings.Contains(body, "555-0100") {
+		t.Fatalf("provider error echoed in the response: %s", body)
+	}
+	if entries := env.Logs.FilterField(zap.String("error", injected)).Len(); entries != 1 {
+		t.Fatalf("%d log entries with the provider error, want 1", entries)
+	}
+}
+
+func TestProviderErrorCodeWorded(t *testing.T) {
+	env := apptest.New(t)
+
+	response := callback(env, url.Values{"error": {"access_denied"}, "state": {"anything"}})
+	if response.Code != http.StatusUnauthorized {
+		t.Fatalf("status %d, want 401", response.Code)
+	}
+	if body := response.Body.String(); !strings.Contains(body, "sign-in was cancelled at the provider") {
+		t.Fatalf("access_denied answered with %s", body)
+	}
+}
@@ -0,0 +1,129 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/alibaba0010/postgres-api/internal/auth"
+	"github.com/alibaba0010/postgres-api/internal/dto"
+	"github.com/alibaba0010/postgres-api/internal/errors"
+	"github.com/alibaba0010/postgres-api/internal/utils"
+)
+
+func (h *Handler) CreateOrderHandler(writer http.ResponseWriter, request *http.Request) {
+	principal := auth.FromContext(request.Context())
+	if principal == nil {
+		errors.ErrorResponse(writer, request, errors.UnauthorizedError("user not authenticated"))
+		return
+	}
+
+	var input dto.CreateOrderInput
+	if err := json.NewDecoder(request.Body).Decode(&input); err != nil {
+		errors.ErrorResponse(writer, request, errors.ValidationError("Invalid JSON body"))
+		return
+	}
+
+	order, appErr := h.svc.CreateOrder(request.Context(), principal.UserID, principal.Role, input)
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(writer).Encode(order)
+}
+
+// ListMyOrdersHandler returns the caller's own orders, optionally filtered by ?status=
+func (h *Handler) ListMyOrdersHandler(writer http.ResponseWriter, request *http.Request) {
+	principal := auth.FromContext(request.Context())
+	if principal == nil {
+		errors.ErrorResponse(writer, request, errors.UnauthorizedError("user not authenticated"))
+		return
+	}
+
+	orders, appErr := h.svc.ListCustomerOrders(request.Context(), principal.UserID, orderFilterFromRequest(request))
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(orders)
+}
+
+// ListRestaurantOrdersHandler returns a restaurant's orders for its management staff
+func (h *Handler) ListRestaurantOrdersHandler(writer http.ResponseWriter, request *http.Request) {
+	principal := auth.FromContext(request.Context())
+	if principal == nil {
+		errors.ErrorResponse(writer, request, errors.UnauthorizedError("user not authenticated"))
+		return
+	}
+
+	orders, appErr := h.svc.ListRestaurantOrders(request.Context(), mux.Vars(request)["id"], principal.UserID, principal.Role, orderFilterFromRequest(request))
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(orders)
+}
+
+// GetOrderHandler returns an order with its items and status history
+func (h *Handler) GetOrderHandler(writer http.ResponseWriter, request *http.Request) {
+	principal := auth.FromContext(request.Context())
+	if principal == nil {
+		errors.ErrorResponse(writer, request, errors.UnauthorizedError("user not authenticated"))
+		return
+	}
+
+	order, appErr := h.svc.GetOrder(request.Context(), mux.Vars(request)["id"], principal.UserID, principal.Role)
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(order)
+}
+
+// TransitionOrderHandler moves an order to the requested status
+func (h *Handler) TransitionOrderHandler(writer http.ResponseWriter, request *http.Request) {
+	principal := auth.FromContext(request.Context())
+	if principal == nil {
+		errors.ErrorResponse(writer, request, errors.UnauthorizedError("user not authenticated"))
+		return
+	}
+
+	var input dto.TransitionOrderInput
+	if err := json.NewDecoder(request.Body).Decode(&input); err != nil {
+		errors.ErrorResponse(writer, request, errors.ValidationError("Invalid JSON body"))
+		return
+	}
+
+	order, appErr := h.svc.TransitionOrder(request.Context(), mux.Vars(request)["id"], principal.UserID, principal.Role, input)
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(order)
+}
+
+func orderFilterFromRequest(request *http.Request) dto.OrderFilter {
+	limit, offset := utils.ParsePagination(request, 20, 100)
+	return dto.OrderFilter{
+		Status: strings.TrimSpace(request.URL.Query().Get("status")),
+		Limit:  limit,
+		Offset: offset,
+	}
+}
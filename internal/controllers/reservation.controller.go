@@ -0,0 +1,315 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/alibaba0010/postgres-api/internal/auth"
+	"github.com/alibaba0010/postgres-api/internal/dto"
+	"github.com/alibaba0010/postgres-api/internal/errors"
+	"github.com/alibaba0010/postgres-api/internal/utils"
+)
+
+func (h *Handler) ListTablesHandler(writer http.ResponseWriter, request *http.Request) {
+	principal := auth.FromContext(request.Context())
+	if principal == nil {
+		errors.ErrorResponse(writer, request, errors.UnauthorizedError("user not authenticated"))
+		return
+	}
+
+	tables, appErr := h.svc.ListTables(request.Context(), mux.Vars(request)["id"], principal.UserID, principal.Role)
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(tables)
+}
+
+func (h *Handler) CreateTableHandler(writer http.ResponseWriter, request *http.Request) {
+	principal := auth.FromContext(request.Context())
+	if principal == nil {
+		errors.ErrorResponse(writer, request, errors.UnauthorizedError("user not authenticated"))
+		return
+	}
+
+	var input dto.CreateTableInput
+	if err := json.NewDecoder(request.Body).Decode(&input); err != nil {
+		errors.ErrorResponse(writer, request, errors.ValidationError("Invalid JSON body"))
+		return
+	}
+
+	table, appErr := h.svc.CreateTable(request.Context(), mux.Vars(request)["id"], principal.UserID, principal.Role, input)
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(writer).Encode(table)
+}
+
+func (h *Handler) UpdateTableHandler(writer http.ResponseWriter, request *http.Request) {
+	principal := auth.FromContext(request.Context())
+	if principal == nil {
+		errors.ErrorResponse(writer, request, errors.UnauthorizedError("user not authenticated"))
+		return
+	}
+
+	var input dto.UpdateTableInput
+	if err := json.NewDecoder(request.Body).Decode(&input); err != nil {
+		errors.ErrorResponse(writer, request, errors.ValidationError("Invalid JSON body"))
+		return
+	}
+
+	vars := mux.Vars(request)
+	table, appErr := h.svc.UpdateTable(request.Context(), vars["id"], vars["tableId"], principal.UserID, principal.Role, input)
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(table)
+}
+
+func (h *Handler) DeleteTableHandler(writer http.ResponseWriter, request *http.Request) {
+	principal := auth.FromContext(request.Context())
+	if principal == nil {
+		errors.ErrorResponse(writer, request, errors.UnauthorizedError("user not authenticated"))
+		return
+	}
+
+	vars := mux.Vars(request)
+	if appErr := h.svc.DeleteTable(request.Context(), vars["id"], vars["tableId"], principal.UserID, principal.Role); appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+// GetOpeningHoursHandler returns a restaurant's weekly opening hours
+func (h *Handler) GetOpeningHoursHandler(writer http.ResponseWriter, request *http.Request) {
+	hours, appErr := h.svc.GetOpeningHours(request.Context(), mux.Vars(request)["id"])
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(hours)
+}
+
+// SetOpeningHoursHandler replaces a restaurant's weekly opening hours
+func (h *Handler) SetOpeningHoursHandler(writer http.ResponseWriter, request *http.Request) {
+	principal := auth.FromContext(request.Context())
+	if principal == nil {
+		errors.ErrorResponse(writer, request, errors.UnauthorizedError("user not authenticated"))
+		return
+	}
+
+	var input dto.SetOpeningHoursInput
+	if err := json.NewDecoder(request.Body).Decode(&input); err != nil {
+		errors.ErrorResponse(writer, request, errors.ValidationError("Invalid JSON body"))
+		return
+	}
+
+	hours, appErr := h.svc.SetOpeningHours(request.Context(), mux.Vars(request)["id"], principal.UserID, principal.Role, input)
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(hours)
+}
+
+// GetAvailabilityHandler lists bookable start times for ?date=YYYY-MM-DD&party_size=N
+func (h *Handler) GetAvailabilityHandler(writer http.ResponseWriter, request *http.Request) {
+	query := request.URL.Query()
+	date := strings.TrimSpace(query.Get("date"))
+	if date == "" {
+		errors.ErrorResponse(writer, request, errors.ValidationError("date is required"))
+		return
+	}
+	partySize, err := strconv.Atoi(query.Get("party_size"))
+	if err != nil {
+		errors.ErrorResponse(writer, request, errors.ValidationError("party_size must be a number"))
+		return
+	}
+	duration := 0
+	if raw := query.Get("duration_minutes"); raw != "" {
+		if duration, err = strconv.Atoi(raw); err != nil {
+			errors.ErrorResponse(writer, request, errors.ValidationError("duration_minutes must be a number"))
+			return
+		}
+	}
+
+	slots, appErr := h.svc.GetAvailability(request.Context(), mux.Vars(request)["id"], date, partySize, duration)
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(slots)
+}
+
+func (h *Handler) CreateReservationHandler(writer http.ResponseWriter, request *http.Request) {
+	principal := auth.FromContext(request.Context())
+	if principal == nil {
+		errors.ErrorResponse(writer, request, errors.UnauthorizedError("user not authenticated"))
+		return
+	}
+
+	var input dto.CreateReservationInput
+	if err := json.NewDecoder(request.Body).Decode(&input); err != nil {
+		errors.ErrorResponse(writer, request, errors.ValidationError("Invalid JSON body"))
+		return
+	}
+
+	reservation, appErr := h.svc.CreateReservation(request.Context(), mux.Vars(request)["id"], principal.UserID, input)
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(writer).Encode(reservation)
+}
+
+// ListMyReservationsHandler returns the caller's own reservations
+func (h *Handler) ListMyReservationsHandler(writer http.ResponseWriter, request *http.Request) {
+	principal := auth.FromContext(request.Context())
+	if principal == nil {
+		errors.ErrorResponse(writer, request, errors.UnauthorizedError("user not authenticated"))
+		return
+	}
+
+	filter, appErr := reservationFilterFromRequest(request)
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	reservations, appErr := h.svc.ListCustomerReservations(request.Context(), principal.UserID, filter)
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(reservations)
+}
+
+// ListRestaurantReservationsHandler returns a restaurant's bookings for its management staff
+func (h *Handler) ListRestaurantReservationsHandler(writer http.ResponseWriter, request *http.Request) {
+	principal := auth.FromContext(request.Context())
+	if principal == nil {
+		errors.ErrorResponse(writer, request, errors.UnauthorizedError("user not authenticated"))
+		return
+	}
+
+	filter, appErr := reservationFilterFromRequest(request)
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	reservations, appErr := h.svc.ListRestaurantReservations(request.Context(), mux.Vars(request)["id"], principal.UserID, principal.Role, filter)
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(reservations)
+}
+
+func (h *Handler) GetReservationHandler(writer http.ResponseWriter, request *http.Request) {
+	principal := auth.FromContext(request.Context())
+	if principal == nil {
+		errors.ErrorResponse(writer, request, errors.UnauthorizedError("user not authenticated"))
+		return
+	}
+
+	reservation, appErr := h.svc.GetReservation(request.Context(), mux.Vars(request)["id"], principal.UserID, principal.Role)
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(reservation)
+}
+
+// UpdateReservationStatusHandler cancels a reservation or, for management,
+// marks it completed or no_show
+func (h *Handler) UpdateReservationStatusHandler(writer http.ResponseWriter, request *http.Request) {
+	principal := auth.FromContext(request.Context())
+	if principal == nil {
+		errors.ErrorResponse(writer, request, errors.UnauthorizedError("user not authenticated"))
+		return
+	}
+
+	var body struct {
+		Status string `json:"status"`
+	}
+	if err := json.NewDecoder(request.Body).Decode(&body); err != nil {
+		errors.ErrorResponse(writer, request, errors.ValidationError("Invalid JSON body"))
+		return
+	}
+
+	reservation, appErr := h.svc.UpdateReservationStatus(request.Context(), mux.Vars(request)["id"], principal.UserID, principal.Role, strings.TrimSpace(body.Status))
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(reservation)
+}
+
+// reservationFilterFromRequest reads ?status=&from=&to= (RFC 3339) and pagination
+func reservationFilterFromRequest(request *http.Request) (dto.ReservationFilter, *errors.AppError) {
+	query := request.URL.Query()
+	limit, offset := utils.ParsePagination(request, 20, 100)
+	filter := dto.ReservationFilter{
+		Status: strings.TrimSpace(query.Get("status")),
+		Limit:  limit,
+		Offset: offset,
+	}
+	if raw := query.Get("from"); raw != "" {
+		from, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return filter, errors.ValidationError("from must be an RFC 3339 timestamp")
+		}
+		filter.From = from
+	}
+	if raw := query.Get("to"); raw != "" {
+		to, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return filter, errors.ValidationError("to must be an RFC 3339 timestamp")
+		}
+		filter.To = to
+	}
+	return filter, nil
+}
@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/alibaba0010/postgres-api/internal/auth"
+	"github.com/alibaba0010/postgres-api/internal/dto"
+	"github.com/alibaba0010/postgres-api/internal/errors"
+	"github.com/alibaba0010/postgres-api/internal/utils"
+)
+
+// ListRestaurantsHandler returns restaurants, optionally filtered by cuisine_type or owner_id
+func (h *Handler) ListRestaurantsHandler(writer http.ResponseWriter, request *http.Request) {
+	limit, offset := utils.ParsePagination(request, 20, 100)
+	filter := dto.RestaurantFilter{
+		CuisineType: strings.TrimSpace(request.URL.Query().Get("cuisine_type")),
+		OwnerID:     strings.TrimSpace(request.URL.Query().Get("owner_id")),
+		Limit:       limit,
+		Offset:      offset,
+	}
+
+	restaurants, appErr := h.svc.ListRestaurants(request.Context(), filter)
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(restaurants)
+}
+
+func (h *Handler) GetRestaurantHandler(writer http.ResponseWriter, request *http.Request) {
+	restaurant, appErr := h.svc.GetRestaurantByID(request.Context(), mux.Vars(request)["id"])
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(restaurant)
+}
+
+func (h *Handler) CreateRestaurantHandler(writer http.ResponseWriter, request *http.Request) {
+	principal := auth.FromContext(request.Context())
+	if principal == nil {
+		errors.ErrorResponse(writer, request, errors.UnauthorizedError("user not authenticated"))
+		return
+	}
+
+	var input dto.CreateRestaurantInput
+	if err := json.NewDecoder(request.Body).Decode(&input); err != nil {
+		errors.ErrorResponse(writer, request, errors.ValidationError("Invalid JSON body"))
+		return
+	}
+
+	restaurant, appErr := h.svc.CreateRestaurant(request.Context(), principal.UserID, input)
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(writer).Encode(restaurant)
+}
+
+func (h *Handler) UpdateRestaurantHandler(writer http.ResponseWriter, request *http.Request) {
+	principal := auth.FromContext(request.Context())
+	if principal == nil {
+		errors.ErrorResponse(writer, request, errors.UnauthorizedError("user not authenticated"))
+		return
+	}
+
+	var input dto.UpdateRestaurantInput
+	if err := json.NewDecoder(request.Body).Decode(&input); err != nil {
+		errors.ErrorResponse(writer, request, errors.ValidationError("Invalid JSON body"))
+		return
+	}
+
+	restaurant, appErr := h.svc.UpdateRestaurant(request.Context(), mux.Vars(request)["id"], principal.UserID, principal.Role, input)
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(restaurant)
+}
+
+func (h *Handler) DeleteRestaurantHandler(writer http.ResponseWriter, request *http.Request) {
+	principal := auth.FromContext(request.Context())
+	if principal == nil {
+		errors.ErrorResponse(writer, request, errors.UnauthorizedError("user not authenticated"))
+		return
+	}
+
+	if appErr := h.svc.DeleteRestaurant(request.Context(), mux.Vars(request)["id"], principal.UserID, principal.Role); appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/alibaba0010/postgres-api/internal/auth"
+	"github.com/alibaba0010/postgres-api/internal/dto"
+	"github.com/alibaba0010/postgres-api/internal/errors"
+	"github.com/alibaba0010/postgres-api/internal/services"
+)
+
+// ListPermissionsHandler returns every permission a role can grant
+func (h *Handler) ListPermissionsHandler(writer http.ResponseWriter, request *http.Request) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(services.ListPermissions())
+}
+
+func (h *Handler) ListRolesHandler(writer http.ResponseWriter, request *http.Request) {
+	roles, appErr := h.svc.ListRoles(request.Context())
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(roles)
+}
+
+func (h *Handler) CreateRoleHandler(writer http.ResponseWriter, request *http.Request) {
+	var input dto.CreateRoleInput
+	if err := json.NewDecoder(request.Body).Decode(&input); err != nil {
+		errors.ErrorResponse(writer, request, errors.ValidationError("Invalid JSON body"))
+		return
+	}
+
+	role, appErr := h.svc.CreateRole(request.Context(), input)
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(writer).Encode(role)
+}
+
+func (h *Handler) UpdateRoleHandler(writer http.ResponseWriter, request *http.Request) {
+	var input dto.UpdateRoleInput
+	if err := json.NewDecoder(request.Body).Decode(&input); err != nil {
+		errors.ErrorResponse(writer, request, errors.ValidationError("Invalid JSON body"))
+		return
+	}
+
+	role, appErr := h.svc.UpdateRole(request.Context(), mux.Vars(request)["id"], input)
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(role)
+}
+
+func (h *Handler) DeleteRoleHandler(writer http.ResponseWriter, request *http.Request) {
+	if appErr := h.svc.DeleteRole(request.Context(), mux.Vars(request)["id"]); appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+// ListStaffHandler returns who holds which role in a restaurant
+func (h *Handler) ListStaffHandler(writer http.ResponseWriter, request *http.Request) {
+	staff, appErr := h.svc.ListRestaurantStaff(request.Context(), mux.Vars(request)["id"])
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(staff)
+}
+
+// AssignStaffHandler grants a role in a restaurant to an existing account
+func (h *Handler) AssignStaffHandler(writer http.ResponseWriter, request *http.Request) {
+	principal := auth.FromContext(request.Context())
+	if principal == nil {
+		errors.ErrorResponse(writer, request, errors.UnauthorizedError("user not authenticated"))
+		return
+	}
+
+	var input dto.AssignStaffInput
+	if err := json.NewDecoder(request.Body).Decode(&input); err != nil {
+		errors.ErrorResponse(writer, request, errors.ValidationError("Invalid JSON body"))
+		return
+	}
+
+	member, appErr := h.svc.AssignStaffRole(request.Context(), mux.Vars(request)["id"], principal.UserID, principal.Role, input)
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(writer).Encode(member)
+}
+
+// RemoveStaffHandler revokes a role assignment in a restaurant
+func (h *Handler) RemoveStaffHandler(writer http.ResponseWriter, request *http.Request) {
+	vars := mux.Vars(request)
+	if appErr := h.svc.RemoveStaffRole(request.Context(), vars["id"], vars["assignmentId"]); appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
@@ -3,23 +3,28 @@ package controllers
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/gorilla/mux"
+
+	"github.com/alibaba0010/postgres-api/internal/auth"
+	"github.com/alibaba0010/postgres-api/internal/dto"
 	"github.com/alibaba0010/postgres-api/internal/errors"
-	"github.com/alibaba0010/postgres-api/internal/guards"
-	"github.com/alibaba0010/postgres-api/internal/services"
+	"github.com/alibaba0010/postgres-api/internal/utils"
 )
 
 
-func CurrentUserHandler(writer http.ResponseWriter, request *http.Request) {
+func (h *Handler) CurrentUserHandler(writer http.ResponseWriter, request *http.Request) {
 	// Extract authenticated user from request headers (set by AuthMiddleware)
-	authenticatedUser := guards.ExtractAuthenticatedUser(request)
-	if authenticatedUser == nil {
+	principal := auth.FromContext(request.Context())
+	if principal == nil {
 		errors.ErrorResponse(writer, request, errors.UnauthorizedError("user not authenticated"))
 		return
 	}
 
 	// Fetch user from database
-	user, appErr := services.GetCurrentUserByID(request.Context(), authenticatedUser.UserID)
+	user, appErr := h.svc.GetCurrentUserByID(request.Context(), principal.UserID)
 	if appErr != nil {
 		errors.ErrorResponse(writer, request, appErr)
 		return
@@ -30,3 +35,448 @@ func CurrentUserHandler(writer http.ResponseWriter, request *http.Request) {
 	writer.WriteHeader(http.StatusOK)
 	json.NewEncoder(writer).Encode(user)
 }
+
+
+// UpdateProfileHandler changes the caller's name, address or phone
+func (h *Handler) UpdateProfileHandler(writer http.ResponseWriter, request *http.Request) {
+	principal := auth.FromContext(request.Context())
+	if principal == nil {
+		errors.ErrorResponse(writer, request, errors.UnauthorizedError("user not authenticated"))
+		return
+	}
+
+	var input dto.UpdateProfileInput
+	if err := json.NewDecoder(request.Body).Decode(&input); err != nil {
+		errors.ErrorResponse(writer, request, errors.ValidationError("Invalid JSON body"))
+		return
+	}
+
+	user, appErr := h.svc.UpdateProfile(request.Context(), principal.UserID, input)
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(user)
+}
+
+// ChangePasswordHandler changes the caller's password and signs them out of
+// their other sessions
+func (h *Handler) ChangePasswordHandler(writer http.ResponseWriter, request *http.Request) {
+	principal := auth.FromContext(request.Context())
+	if principal == nil {
+		errors.ErrorResponse(writer, request, errors.UnauthorizedError("user not authenticated"))
+		return
+	}
+
+	var input dto.ChangePasswordInput
+	if err := json.NewDecoder(request.Body).Decode(&input); err != nil {
+		errors.ErrorResponse(writer, request, errors.ValidationError("Invalid JSON body"))
+		return
+	}
+
+	if appErr := h.svc.ChangePassword(request.Context(), principal.UserID, principal.SessionID, input); appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(map[string]string{"message": "Password changed, your other devices have been signed out"})
+}
+
+// ChangeEmailHandler sends a confirmation link to the caller's new email
+func (h *Handler) ChangeEmailHandler(writer http.ResponseWriter, request *http.Request) {
+	principal := auth.FromContext(request.Context())
+	if principal == nil {
+		errors.ErrorResponse(writer, request, errors.UnauthorizedError("user not authenticated"))
+		return
+	}
+
+	var input dto.ChangeEmailInput
+	if err := json.NewDecoder(request.Body).Decode(&input); err != nil {
+		errors.ErrorResponse(writer, request, errors.ValidationError("Invalid JSON body"))
+		return
+	}
+
+	if appErr := h.svc.RequestEmailChange(request.Context(), principal.UserID, input); appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(writer).Encode(map[string]string{"message": "Check your new email for a link to confirm it"})
+}
+
+// ExportAccountHandler downloads everything held about the caller as JSON
+func (h *Handler) ExportAccountHandler(writer http.ResponseWriter, request *http.Request) {
+	principal := auth.FromContext(request.Context())
+	if principal == nil {
+		errors.ErrorResponse(writer, request, errors.UnauthorizedError("user not authenticated"))
+		return
+	}
+
+	export, appErr := h.svc.ExportAccountData(request.Context(), principal.UserID)
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.Header().Set("Content-Disposition", `attachment; filename="account-export-`+export.GeneratedAt.Format("2006-01-02")+`.json"`)
+	writer.WriteHeader(http.StatusOK)
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(export)
+}
+
+// DeleteAccountHandler schedules the deletion of the caller's account and
+// signs them out
+func (h *Handler) DeleteAccountHandler(writer http.ResponseWriter, request *http.Request) {
+	principal := auth.FromContext(request.Context())
+	if principal == nil {
+		errors.ErrorResponse(writer, request, errors.UnauthorizedError("user not authenticated"))
+		return
+	}
+
+	var input dto.DeleteAccountInput
+	if err := json.NewDecoder(request.Body).Decode(&input); err != nil {
+		errors.ErrorResponse(writer, request, errors.ValidationError("Invalid JSON body"))
+		return
+	}
+
+	response, appErr := h.svc.RequestAccountDeletion(request.Context(), principal.UserID, input)
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	clearRefreshTokenCookie(writer)
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(writer).Encode(response)
+}
+
+func (h *Handler) CancelAccountDeletionHandler(writer http.ResponseWriter, request *http.Request) {
+	principal := auth.FromContext(request.Context())
+	if principal == nil {
+		errors.ErrorResponse(writer, request, errors.UnauthorizedError("user not authenticated"))
+		return
+	}
+
+	user, appErr := h.svc.CancelAccountDeletion(request.Context(), principal.UserID)
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(user)
+}
+
+// ListSessionsHandler lists the devices the caller is signed in on
+func (h *Handler) ListSessionsHandler(writer http.ResponseWriter, request *http.Request) {
+	principal := auth.FromContext(request.Context())
+	if principal == nil {
+		errors.ErrorResponse(writer, request, errors.UnauthorizedError("user not authenticated"))
+		return
+	}
+
+	sessions, appErr := h.svc.ListUserSessions(request.Context(), principal.UserID, principal.SessionID)
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(sessions)
+}
+
+// RevokeSessionHandler signs the caller out of one of their sessions
+func (h *Handler) RevokeSessionHandler(writer http.ResponseWriter, request *http.Request) {
+	principal := auth.FromContext(request.Context())
+	if principal == nil {
+		errors.ErrorResponse(writer, request, errors.UnauthorizedError("user not authenticated"))
+		return
+	}
+
+	sessionID := mux.Vars(request)["id"]
+	if appErr := h.svc.RevokeUserSession(request.Context(), principal.UserID, sessionID); appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+	if sessionID == principal.SessionID {
+		clearRefreshTokenCookie(writer)
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+// ListMembershipsHandler lists the restaurants the caller works at
+func (h *Handler) ListMembershipsHandler(writer http.ResponseWriter, request *http.Request) {
+	principal := auth.FromContext(request.Context())
+	if principal == nil {
+		errors.ErrorResponse(writer, request, errors.UnauthorizedError("user not authenticated"))
+		return
+	}
+
+	memberships, appErr := h.svc.ListMemberships(request.Context(), principal.UserID, principal.RestaurantID)
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(memberships)
+}
+
+// ListUsersHandler returns a page of users for admins
+func (h *Handler) ListUsersHandler(writer http.ResponseWriter, request *http.Request) {
+	filter, appErr := userFilterFromRequest(request)
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	users, appErr := h.svc.ListUsers(request.Context(), filter)
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(users)
+}
+
+func (h *Handler) GetUserHandler(writer http.ResponseWriter, request *http.Request) {
+	user, appErr := h.svc.GetUserForAdmin(request.Context(), mux.Vars(request)["id"])
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(user)
+}
+
+// ChangeUserRoleHandler sets a user's account role
+func (h *Handler) ChangeUserRoleHandler(writer http.ResponseWriter, request *http.Request) {
+	principal := auth.FromContext(request.Context())
+	if principal == nil {
+		errors.ErrorResponse(writer, request, errors.UnauthorizedError("user not authenticated"))
+		return
+	}
+
+	var input dto.UpdateUserRoleInput
+	if err := json.NewDecoder(request.Body).Decode(&input); err != nil {
+		errors.ErrorResponse(writer, request, errors.ValidationError("Invalid JSON body"))
+		return
+	}
+
+	user, appErr := h.svc.ChangeUserRole(request.Context(), principal.UserID, mux.Vars(request)["id"], input)
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(user)
+}
+
+// SuspendUserHandler blocks a user and signs them out everywhere
+func (h *Handler) SuspendUserHandler(writer http.ResponseWriter, request *http.Request) {
+	principal := auth.FromContext(request.Context())
+	if principal == nil {
+		errors.ErrorResponse(writer, request, errors.UnauthorizedError("user not authenticated"))
+		return
+	}
+
+	// the reason is optional, so is the body
+	var input dto.SuspendUserInput
+	if request.Body != nil && request.ContentLength != 0 {
+		if err := json.NewDecoder(request.Body).Decode(&input); err != nil {
+			errors.ErrorResponse(writer, request, errors.ValidationError("Invalid JSON body"))
+			return
+		}
+	}
+
+	user, appErr := h.svc.SuspendUser(request.Context(), principal.UserID, mux.Vars(request)["id"], input)
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(user)
+}
+
+func (h *Handler) ReactivateUserHandler(writer http.ResponseWriter, request *http.Request) {
+	principal := auth.FromContext(request.Context())
+	if principal == nil {
+		errors.ErrorResponse(writer, request, errors.UnauthorizedError("user not authenticated"))
+		return
+	}
+
+	user, appErr := h.svc.ReactivateUser(request.Context(), principal.UserID, mux.Vars(request)["id"])
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(user)
+}
+
+func (h *Handler) DeleteUserHandler(writer http.ResponseWriter, request *http.Request) {
+	principal := auth.FromContext(request.Context())
+	if principal == nil {
+		errors.ErrorResponse(writer, request, errors.UnauthorizedError("user not authenticated"))
+		return
+	}
+
+	if appErr := h.svc.DeleteUser(request.Context(), principal.UserID, mux.Vars(request)["id"]); appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+// userFilterFromRequest reads ?role=&email=&status=&created_from=&created_to=
+// (RFC 3339 or YYYY-MM-DD, created_to inclusive), ?sort=&order= and pagination
+func userFilterFromRequest(request *http.Request) (dto.UserFilter, *errors.AppError) {
+	query := request.URL.Query()
+	limit, offset := utils.ParsePagination(request, 20, 100)
+	filter := dto.UserFilter{
+		Role:   strings.TrimSpace(query.Get("role")),
+		Email:  strings.TrimSpace(query.Get("email")),
+		Status: strings.TrimSpace(query.Get("status")),
+		Sort:   strings.TrimSpace(query.Get("sort")),
+		Order:  strings.TrimSpace(query.Get("order")),
+		Limit:  limit,
+		Offset: offset,
+	}
+	if raw := query.Get("created_from"); raw != "" {
+		from, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			if from, err = time.Parse(time.DateOnly, raw); err != nil {
+				return filter, errors.ValidationError("created_from must be an RFC 3339 timestamp or a YYYY-MM-DD date")
+			}
+		}
+		filter.CreatedFrom = from
+	}
+	if raw := query.Get("created_to"); raw != "" {
+		to, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			if to, err = time.Parse(time.DateOnly, raw); err != nil {
+				return filter, errors.ValidationError("created_to must be an RFC 3339 timestamp or a YYYY-MM-DD date")
+			}
+			// a date covers the whole day
+			to = to.AddDate(0, 0, 1)
+		}
+		filter.CreatedTo = to
+	}
+	return filter, nil
+}
+
+// AdminListUserSessionsHandler lists any user's sessions
+func (h *Handler) AdminListUserSessionsHandler(writer http.ResponseWriter, request *http.Request) {
+	sessions, appErr := h.svc.ListUserSessions(request.Context(), mux.Vars(request)["id"], "")
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(sessions)
+}
+
+// AdminRevokeUserSessionHandler signs any user out of one of their sessions
+func (h *Handler) AdminRevokeUserSessionHandler(writer http.ResponseWriter, request *http.Request) {
+	vars := mux.Vars(request)
+	if appErr := h.svc.RevokeUserSession(request.Context(), vars["id"], vars["sessionId"]); appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+// EnrollMFAHandler starts two-factor enrollment for the current user
+func (h *Handler) EnrollMFAHandler(writer http.ResponseWriter, request *http.Request) {
+	principal := auth.FromContext(request.Context())
+	if principal == nil {
+		errors.ErrorResponse(writer, request, errors.UnauthorizedError("user not authenticated"))
+		return
+	}
+
+	enrollment, appErr := h.svc.BeginMFAEnrollment(request.Context(), principal.UserID)
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(enrollment)
+}
+
+// ConfirmMFAHandler enables two-factor authentication with the first code from the authenticator
+func (h *Handler) ConfirmMFAHandler(writer http.ResponseWriter, request *http.Request) {
+	principal := auth.FromContext(request.Context())
+	if principal == nil {
+		errors.ErrorResponse(writer, request, errors.UnauthorizedError("user not authenticated"))
+		return
+	}
+
+	var input dto.MFAConfirmInput
+	if err := json.NewDecoder(request.Body).Decode(&input); err != nil {
+		errors.ErrorResponse(writer, request, errors.ValidationError("Invalid JSON body"))
+		return
+	}
+
+	if appErr := h.svc.ConfirmMFAEnrollment(request.Context(), principal.UserID, input.Code); appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(map[string]string{"message": "Two-factor authentication enabled"})
+}
+
+// DisableMFAHandler turns two-factor authentication off for the current user
+func (h *Handler) DisableMFAHandler(writer http.ResponseWriter, request *http.Request) {
+	principal := auth.FromContext(request.Context())
+	if principal == nil {
+		errors.ErrorResponse(writer, request, errors.UnauthorizedError("user not authenticated"))
+		return
+	}
+
+	var input dto.MFACodeInput
+	if err := json.NewDecoder(request.Body).Decode(&input); err != nil {
+		errors.ErrorResponse(writer, request, errors.ValidationError("Invalid JSON body"))
+		return
+	}
+
+	if appErr := h.svc.DisableMFA(request.Context(), principal.UserID, principal.Role, input); appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(map[string]string{"message": "Two-factor authentication disabled"})
+}
@@ -2,12 +2,10 @@ package database
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 	"time"
 
 	"github.com/alibaba0010/postgres-api/internal/config"
-	"github.com/alibaba0010/postgres-api/internal/logger"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/stdlib"
 	"github.com/uptrace/bun"
@@ -16,31 +14,26 @@ import (
 	"go.uber.org/zap"
 )
 
-var (
-	Pool *sql.DB
-	DB   *bun.DB
-)
-
-func ConnectDB() *bun.DB {
-	cfg := config.LoadConfig()
-	connectionURL := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable", 
+// ConnectDB opens the Postgres database of cfg and checks that it answers
+func ConnectDB(cfg config.Config, log *zap.Logger) (*bun.DB, error) {
+	connectionURL := fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=disable", 
 		cfg.DB_USERNAME, cfg.DB_PASSWORD, cfg.DB_HOST, cfg.DB_PORT, cfg.DB_NAME)
 
 	config, err := pgx.ParseConfig(connectionURL)
 	if err != nil {
-		logger.Log.Fatal("Unable to parse database config", zap.Error(err))
+		return nil, fmt.Errorf("unable to parse database config: %w", err)
 	}
 
-	Pool = stdlib.OpenDB(*config)
-	Pool.SetMaxIdleConns(25)
-	Pool.SetMaxOpenConns(25)
-	Pool.SetConnMaxLifetime(5 * time.Minute)
+	pool := stdlib.OpenDB(*config)
+	pool.SetMaxIdleConns(25)
+	pool.SetMaxOpenConns(25)
+	pool.SetConnMaxLifetime(5 * time.Minute)
 
 	// Create a Bun db instance
-	DB = bun.NewDB(Pool, pgdialect.New())
+	db := bun.NewDB(pool, pgdialect.New())
 
 	// Add query debug hook in development
-	DB.AddQueryHook(bundebug.NewQueryHook(
+	db.AddQueryHook(bundebug.NewQueryHook(
 		bundebug.WithVerbose(true),
 		bundebug.FromEnv(),
 	))
@@ -49,18 +42,19 @@ func ConnectDB() *bun.DB {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	if err := DB.PingContext(ctx); err != nil {
-		logger.Log.Fatal("Database ping failed", zap.Error(err))
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("database ping failed: %w", err)
 	}
 
-	logger.Log.Info("✅ Connected to PostgreSQL database")
-	return DB
+	log.Info("✅ Connected to PostgreSQL database")
+	return db, nil
 }
 
 // Close connection when shutting down
-func CloseDB() {
-	if Pool != nil {
-		Pool.Close()
-		logger.Log.Info("🔌 Database connection closed")
+func CloseDB(db *bun.DB, log *zap.Logger) {
+	if db != nil {
+		db.Close()
+		log.Info("🔌 Database connection closed")
 	}
 }
\ No newline at end of file
@@ -51,8 +51,8 @@ func (h PrettyQueryHook) AfterQuery(ctx context.Context, event *bun.QueryEvent)
     }
     if err != nil {
         fields = append(fields, zap.Error(err))
-        logger.Log.Warn("sql query", fields...)
+        logger.FromContext(ctx).Warn("sql query", fields...)
         return
     }
-    logger.Log.Debug("sql query", fields...)
+    logger.FromContext(ctx).Debug("sql query", fields...)
 }
@@ -2,85 +2,116 @@ package database
 
 import (
 	"context"
+	"fmt"
+	"net"
+	"strconv"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 
 	"github.com/alibaba0010/postgres-api/internal/config"
-	"github.com/alibaba0010/postgres-api/internal/logger"
 )
 
-var RedisClient *redis.Client
-
-func ConnectRedis() *redis.Client {
-	cfg := config.LoadConfig()
+// Redis is the Redis client of the application together with the janitor
+// cleaning it
+type Redis struct {
+	*redis.Client
+	log *zap.Logger
+	// janitorStop asks the janitor to return; janitorDone is closed when it has
+	janitorStop, janitorDone chan struct{}
+}
 
+// ConnectRedis connects to the Redis server of cfg and starts the janitor
+func ConnectRedis(cfg config.Config, log *zap.Logger) (*Redis, error) {
 	// short-lived context for initial ping
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	RedisClient = redis.NewClient(&redis.Options{
-		Addr:     cfg.REDIS_HOST + ":" + cfg.REDIS_PORT,
+	client := redis.NewClient(&redis.Options{
+		Addr:     net.JoinHostPort(cfg.REDIS_HOST, strconv.Itoa(cfg.REDIS_PORT)),
 		Password: cfg.REDIS_PASSWORD, // no password set
 		DB:       0,  // use default DB
 	})
 
 	// Ping to verify connection
-	err := RedisClient.Ping(ctx).Err()
+	err := client.Ping(ctx).Err()
 	if err != nil {
-		logger.Log.Fatal("❌ Redis connection failed", zap.Error(err))
+		client.Close()
+		return nil, fmt.Errorf("redis connection failed: %w", err)
 	}
 
-	logger.Log.Info("✅ Connected to Redis")
+	log.Info("✅ Connected to Redis")
 
 	// Start a background janitor to clean up any verify keys that were stored
 	// without TTL (orphaned). Redis normally expires keys automatically, but
 	// if a key was stored without an expiry due to a bug, this janitor will
 	// remove `verify:*` keys that have no TTL set (TTL == -1).
-	go startRedisJanitor()
-	return RedisClient
+	r := &Redis{
+		Client:      client,
+		log:         log,
+		janitorStop: make(chan struct{}),
+		janitorDone: make(chan struct{}),
+	}
+	go r.startJanitor()
+	return r, nil
 }
 
-// Close connection when shutting down
-func CloseRedis() {
-	if RedisClient != nil {
-		err := RedisClient.Close()
-		if err != nil {
-			logger.Log.Error("Error closing Redis connection", zap.Error(err))
-		}
-		logger.Log.Info("🔌 Redis connection closed")
+// Close connection when shutting down; the janitor is stopped first so it
+// never runs against a closed client
+func (r *Redis) Close() {
+	if r.janitorStop != nil {
+		close(r.janitorStop)
+		<-r.janitorDone
+		r.janitorStop = nil
 	}
+	err := r.Client.Close()
+	if err != nil {
+		r.log.Error("Error closing Redis connection", zap.Error(err))
+	}
+	r.log.Info("🔌 Redis connection closed")
 }
 
-// startRedisJanitor launches a goroutine that periodically scans Redis for
-// verification keys (prefix "verify:") and deletes keys that have no TTL set.
+// startJanitor periodically scans Redis for verification keys (prefix
+// "verify:") and deletes keys that have no TTL set, until Close stops it.
 // This is defensive: Redis normally expires keys, but this removes orphaned
 // verify keys that would otherwise persist indefinitely.
-func startRedisJanitor() {
-	ctx := context.Background()
+func (r *Redis) startJanitor() {
+	defer close(r.janitorDone)
+	// cancelled on stop so a scan in progress does not hold up the shutdown
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stop := r.janitorStop
+	go func() {
+		select {
+		case <-stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
 	ticker := time.NewTicker(5 * time.Minute)
+	defer ticker.Stop()
 	// Run an initial cleanup quickly
 	doCleanup := func() {
 		var cursor uint64
 		for {
-			keys, cur, err := RedisClient.Scan(ctx, cursor, "verify:*", 100).Result()
+			keys, cur, err := r.Client.Scan(ctx, cursor, "verify:*", 100).Result()
 			if err != nil {
-				logger.Log.Error("redis janitor scan failed", zap.Error(err))
+				r.log.Error("redis janitor scan failed", zap.Error(err))
 				break
 			}
 			for _, k := range keys {
-				ttl, err := RedisClient.TTL(ctx, k).Result()
+				ttl, err := r.Client.TTL(ctx, k).Result()
 				if err != nil {
-					logger.Log.Error("redis janitor ttl failed", zap.Error(err), zap.String("key", k))
+					r.log.Error("redis janitor ttl failed", zap.Error(err), zap.String("key", k))
 					continue
 				}
 				// TTL == -1 means key exists but has no expiry; remove it.
 				if ttl == -1 {
-					if err := RedisClient.Del(ctx, k).Err(); err != nil {
-						logger.Log.Error("redis janitor del failed", zap.Error(err), zap.String("key", k))
+					if err := r.Client.Del(ctx, k).Err(); err != nil {
+						r.log.Error("redis janitor del failed", zap.Error(err), zap.String("key", k))
 					} else {
-						logger.Log.Info("redis janitor deleted orphaned key", zap.String("key", k))
+						r.log.Info("redis janitor deleted orphaned key", zap.String("key", k))
 					}
 				}
 			}
@@ -94,9 +125,12 @@ func startRedisJanitor() {
 	// initial run
 	doCleanup()
 
-	go func() {
-		for range ticker.C {
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
 			doCleanup()
 		}
-	}()
+	}
 }
\ No newline at end of file
@@ -2,6 +2,7 @@ package dto
 
 import (
 	"regexp"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 )
@@ -11,7 +12,9 @@ type SignupInput struct {
 	Email           string `json:"email" validate:"required,email"`
 	Password        string `json:"password" validate:"required,min=6,max=18,password_special"`
 	ConfirmPassword string `json:"confirmPassword" validate:"required,eqfield=Password"`
-	Role            string `json:"role" validate:"omitempty,oneof=user admin management"`
+	// Role may only be user; staff join through invitations and elevated
+	// account roles are granted by an admin
+	Role            string `json:"role" validate:"omitempty,oneof=user"`
 }
 
 type SigninInput struct {
@@ -19,6 +22,35 @@ type SigninInput struct {
 	Password string `json:"password" validate:"required,min=6"`
 }
 
+// ForgotPasswordInput is the body accepted by POST /auth/forgot-password
+type ForgotPasswordInput struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// ResetPasswordInput is the body accepted by POST /auth/reset-password
+type ResetPasswordInput struct {
+	Token           string `json:"token" validate:"required"`
+	Password        string `json:"password" validate:"required,min=6,max=18,password_special"`
+	ConfirmPassword string `json:"confirmPassword" validate:"required,eqfield=Password"`
+}
+
+// RefreshTokenInput is the optional body of POST /auth/refresh and /auth/logout
+// for clients that cannot use the refresh_token cookie
+type RefreshTokenInput struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// TokenResponse is returned by POST /auth/refresh
+type TokenResponse struct {
+	Title string    `json:"title"`
+	Data  TokenData `json:"data"`
+}
+
+type TokenData struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
 // SigninResponse contains user info and tokens after successful login
 type SigninResponse struct {
 	Title string     `json:"title"`
@@ -48,9 +80,63 @@ type SignUpData struct {
 	RefreshToken string `json:"refresh_token,omitempty"`
 }
 
+// MFAChallengeResponse is returned by sign-in when a second factor is needed.
+// EnrollmentRequired is set for accounts that must enroll before signing in.
+type MFAChallengeResponse struct {
+	Title string           `json:"title"`
+	Data  MFAChallengeData `json:"data"`
+}
+
+type MFAChallengeData struct {
+	MFARequired        bool   `json:"mfa_required"`
+	EnrollmentRequired bool   `json:"enrollment_required"`
+	MFAToken           string `json:"mfa_token"`
+	ExpiresIn          int    `json:"expires_in"`
+}
+
+// MFATokenInput carries the mfa_token handed out by a pending sign-in
+type MFATokenInput struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+}
+
+// MFAVerifyInput completes a pending sign-in with either a TOTP code or a recovery code
+type MFAVerifyInput struct {
+	MFAToken     string `json:"mfa_token" validate:"required"`
+	Code         string `json:"code" validate:"omitempty,len=6,numeric"`
+	RecoveryCode string `json:"recovery_code" validate:"omitempty,max=32"`
+}
+
+// MFAConfirmInput confirms an enrollment with the first code from the authenticator.
+// MFAToken is only used by accounts enrolling during sign-in.
+type MFAConfirmInput struct {
+	MFAToken string `json:"mfa_token"`
+	Code     string `json:"code" validate:"required,len=6,numeric"`
+}
+
+// MFACodeInput carries a TOTP or recovery code for sensitive changes such as disabling 2FA
+type MFACodeInput struct {
+	Code string `json:"code" validate:"required,max=32"`
+}
+
+// MFAEnrollmentResponse is shown once when enrollment starts; the recovery
+// codes cannot be retrieved again.
+type MFAEnrollmentResponse struct {
+	Secret        string   `json:"secret"`
+	OTPAuthURI    string   `json:"otpauth_uri"`
+	RecoveryCodes []string `json:"recovery_codes"`
+	ExpiresIn     int      `json:"expires_in"`
+}
+
 // RegisterValidators registers custom validators on the provided validator instance.
 func RegisterValidators(v *validator.Validate) {
 	_ = v.RegisterValidation("password_special", validatePasswordSpecial)
+	_ = v.RegisterValidation("time_of_day", validateTimeOfDay)
+}
+
+// validateTimeOfDay ensures a string is a 24-hour "HH:MM" clock time.
+func validateTimeOfDay(fl validator.FieldLevel) bool {
+	_, err := time.Parse("15:04", fl.Field().String())
+	return err == nil
 }
 
 // validatePasswordSpecial ensures password contains at least one uppercase,
@@ -68,4 +154,28 @@ func validatePasswordSpecial(fl validator.FieldLevel) bool {
 	hasSpecial := regexp.MustCompile(`[!@#$%^&*()_+\-=[\]{};':"\\|,.<>\/?]`).MatchString(password)
 
 	return hasUpper && hasLower && hasDigit && hasSpecial
-}
\ No newline at end of file
+}
+// SwitchRestaurantInput is the body accepted by POST /auth/switch-restaurant.
+// An empty RestaurantID clears the active restaurant.
+type SwitchRestaurantInput struct {
+	RestaurantID string `json:"restaurant_id"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// JWKS is the JSON Web Key Set served at /.well-known/jwks.json with the
+// public keys access tokens are verified with
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWK is a public key: N and E for RSA keys, Crv and X for Ed25519 (OKP) keys
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
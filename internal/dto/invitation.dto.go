@@ -0,0 +1,30 @@
+package dto
+
+import "time"
+
+// CreateInvitationInput is the body accepted by POST /restaurants/{id}/invitations
+type CreateInvitationInput struct {
+	Email  string `json:"email" validate:"required,email"`
+	RoleID string `json:"role_id" validate:"required"`
+}
+
+// AcceptInvitationInput is the body accepted by POST /auth/invitations/accept.
+// Name and password are only needed when no account uses the invited email yet.
+type AcceptInvitationInput struct {
+	Token           string `json:"token" validate:"required"`
+	Name            string `json:"name" validate:"omitempty,min=3,max=50"`
+	Password        string `json:"password" validate:"omitempty,min=6,max=18,password_special"`
+	ConfirmPassword string `json:"confirmPassword" validate:"eqfield=Password"`
+}
+
+// InvitationResponse is one staff invitation of a restaurant
+type InvitationResponse struct {
+	ID           string    `json:"id"`
+	RestaurantID string    `json:"restaurant_id"`
+	Email        string    `json:"email"`
+	RoleID       string    `json:"role_id"`
+	RoleName     string    `json:"role_name"`
+	Status       string    `json:"status"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	CreatedAt    time.Time `json:"created_at"`
+}
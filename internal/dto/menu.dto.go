@@ -0,0 +1,45 @@
+package dto
+
+// CreateMenuInput is the body accepted by POST /restaurants/{id}/menus
+type CreateMenuInput struct {
+	Name        string `json:"name" validate:"required,min=2,max=100"`
+	Type        string `json:"type" validate:"required,oneof=breakfast lunch dinner all_day"`
+	Description string `json:"description" validate:"omitempty,max=500"`
+	IsActive    *bool  `json:"is_active"`
+}
+
+// UpdateMenuInput is the body accepted by PATCH /menus/{id}. Nil fields are left untouched.
+type UpdateMenuInput struct {
+	Name        *string `json:"name" validate:"omitempty,min=2,max=100"`
+	Type        *string `json:"type" validate:"omitempty,oneof=breakfast lunch dinner all_day"`
+	Description *string `json:"description" validate:"omitempty,max=500"`
+	IsActive    *bool   `json:"is_active"`
+}
+
+// CreateMenuItemInput is the body accepted by POST /menus/{id}/items
+type CreateMenuItemInput struct {
+	Category       string   `json:"category" validate:"required,min=2,max=50"`
+	Name           string   `json:"name" validate:"required,min=2,max=100"`
+	Description    string   `json:"description" validate:"omitempty,max=500"`
+	PriceCents     int64    `json:"price_cents" validate:"min=0"`
+	Currency       string   `json:"currency" validate:"omitempty,len=3,alpha"`
+	Allergens      []string `json:"allergens" validate:"omitempty,dive,min=2,max=30"`
+	DietaryTags    []string `json:"dietary_tags" validate:"omitempty,dive,min=2,max=30"`
+	IsAvailable    *bool    `json:"is_available"`
+	AvailableFrom  string   `json:"available_from" validate:"omitempty,time_of_day"`
+	AvailableUntil string   `json:"available_until" validate:"omitempty,time_of_day"`
+}
+
+// UpdateMenuItemInput is the body accepted by PATCH /menus/{id}/items/{itemId}
+type UpdateMenuItemInput struct {
+	Category       *string   `json:"category" validate:"omitempty,min=2,max=50"`
+	Name           *string   `json:"name" validate:"omitempty,min=2,max=100"`
+	Description    *string   `json:"description" validate:"omitempty,max=500"`
+	PriceCents     *int64    `json:"price_cents" validate:"omitempty,min=0"`
+	Currency       *string   `json:"currency" validate:"omitempty,len=3,alpha"`
+	Allergens      *[]string `json:"allergens" validate:"omitempty,dive,min=2,max=30"`
+	DietaryTags    *[]string `json:"dietary_tags" validate:"omitempty,dive,min=2,max=30"`
+	IsAvailable    *bool     `json:"is_available"`
+	AvailableFrom  *string   `json:"available_from" validate:"omitempty,time_of_day"`
+	AvailableUntil *string   `json:"available_until" validate:"omitempty,time_of_day"`
+}
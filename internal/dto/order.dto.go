@@ -0,0 +1,29 @@
+package dto
+
+// CreateOrderInput is the body accepted by POST /orders
+type CreateOrderInput struct {
+	RestaurantID string                 `json:"restaurant_id" validate:"required,uuid"`
+	Type         string                 `json:"type" validate:"required,oneof=dine_in takeaway delivery"`
+	TableNumber  string                 `json:"table_number" validate:"omitempty,max=20"`
+	Notes        string                 `json:"notes" validate:"omitempty,max=500"`
+	Items        []CreateOrderItemInput `json:"items" validate:"required,min=1,max=50,dive"`
+}
+
+type CreateOrderItemInput struct {
+	MenuItemID string `json:"menu_item_id" validate:"required,uuid"`
+	Quantity   int    `json:"quantity" validate:"required,min=1,max=100"`
+	Notes      string `json:"notes" validate:"omitempty,max=200"`
+}
+
+// TransitionOrderInput is the body accepted by POST /orders/{id}/status
+type TransitionOrderInput struct {
+	Status string `json:"status" validate:"required,oneof=accepted preparing ready served delivered paid cancelled refunded"`
+	Reason string `json:"reason" validate:"omitempty,max=500"`
+}
+
+// OrderFilter holds the optional query parameters of the order list endpoints
+type OrderFilter struct {
+	Status string
+	Limit  int
+	Offset int
+}
@@ -0,0 +1,59 @@
+package dto
+
+import "time"
+
+// CreateTableInput is the body accepted by POST /restaurants/{id}/tables
+type CreateTableInput struct {
+	Label    string `json:"label" validate:"required,min=1,max=20"`
+	Capacity int    `json:"capacity" validate:"required,min=1,max=100"`
+	Section  string `json:"section" validate:"omitempty,max=50"`
+	IsActive *bool  `json:"is_active"`
+}
+
+// UpdateTableInput is the body accepted by PATCH /restaurants/{id}/tables/{tableId}
+type UpdateTableInput struct {
+	Label    *string `json:"label" validate:"omitempty,min=1,max=20"`
+	Capacity *int    `json:"capacity" validate:"omitempty,min=1,max=100"`
+	Section  *string `json:"section" validate:"omitempty,max=50"`
+	IsActive *bool   `json:"is_active"`
+}
+
+// OpeningHourInput is one window in PUT /restaurants/{id}/opening-hours
+type OpeningHourInput struct {
+	Weekday  int    `json:"weekday" validate:"min=0,max=6"`
+	OpensAt  string `json:"opens_at" validate:"required,time_of_day"`
+	ClosesAt string `json:"closes_at" validate:"required,time_of_day"`
+}
+
+// SetOpeningHoursInput replaces the whole weekly schedule of a restaurant
+type SetOpeningHoursInput struct {
+	Hours []OpeningHourInput `json:"hours" validate:"max=50,dive"`
+}
+
+// CreateReservationInput is the body accepted by POST /restaurants/{id}/reservations.
+// DurationMinutes defaults to DefaultReservationMinutes.
+type CreateReservationInput struct {
+	PartySize       int       `json:"party_size" validate:"required,min=1,max=100"`
+	StartsAt        time.Time `json:"starts_at" validate:"required"`
+	DurationMinutes int       `json:"duration_minutes" validate:"omitempty,min=30,max=360"`
+	Notes           string    `json:"notes" validate:"omitempty,max=500"`
+}
+
+// DefaultReservationMinutes is how long a table is held when no duration is given
+const DefaultReservationMinutes = 90
+
+// ReservationFilter holds the optional query parameters of the reservation list endpoints
+type ReservationFilter struct {
+	Status string
+	From   time.Time
+	To     time.Time
+	Limit  int
+	Offset int
+}
+
+// AvailabilitySlot is one bookable start time returned by GET /restaurants/{id}/availability
+type AvailabilitySlot struct {
+	StartsAt        time.Time `json:"starts_at"`
+	EndsAt          time.Time `json:"ends_at"`
+	AvailableTables int       `json:"available_tables"`
+}
@@ -0,0 +1,29 @@
+package dto
+
+// CreateRestaurantInput is the body accepted by POST /restaurants
+type CreateRestaurantInput struct {
+	Name        string `json:"name" validate:"required,min=2,max=100"`
+	Description string `json:"description" validate:"omitempty,max=500"`
+	Address     string `json:"address" validate:"required,min=5,max=200"`
+	CuisineType string `json:"cuisine_type" validate:"omitempty,max=50"`
+	Timezone    string `json:"timezone" validate:"omitempty,timezone"`
+}
+
+// UpdateRestaurantInput is the body accepted by PATCH /restaurants/{id}.
+// Nil fields are left untouched.
+type UpdateRestaurantInput struct {
+	Name        *string  `json:"name" validate:"omitempty,min=2,max=100"`
+	Description *string  `json:"description" validate:"omitempty,max=500"`
+	Address     *string  `json:"address" validate:"omitempty,min=5,max=200"`
+	CuisineType *string  `json:"cuisine_type" validate:"omitempty,max=50"`
+	Rating      *float64 `json:"rating" validate:"omitempty,min=0,max=5"`
+	Timezone    *string  `json:"timezone" validate:"omitempty,timezone"`
+}
+
+// RestaurantFilter holds the optional query parameters of GET /restaurants
+type RestaurantFilter struct {
+	CuisineType string
+	OwnerID     string
+	Limit       int
+	Offset      int
+}
@@ -0,0 +1,50 @@
+package dto
+
+import "time"
+
+// CreateRoleInput is the body accepted by POST /roles
+type CreateRoleInput struct {
+	Name        string   `json:"name" validate:"required,min=2,max=50"`
+	Description string   `json:"description" validate:"omitempty,max=255"`
+	Permissions []string `json:"permissions" validate:"required,min=1"`
+}
+
+// UpdateRoleInput is the body accepted by PATCH /roles/{id}.
+// Nil fields are left untouched.
+type UpdateRoleInput struct {
+	Name        *string  `json:"name" validate:"omitempty,min=2,max=50"`
+	Description *string  `json:"description" validate:"omitempty,max=255"`
+	Permissions []string `json:"permissions" validate:"omitempty,min=1"`
+}
+
+// PermissionResponse describes one permission for GET /roles/permissions
+type PermissionResponse struct {
+	Name             string `json:"name"`
+	RestaurantScoped bool   `json:"restaurant_scoped"`
+}
+
+// AssignStaffInput is the body accepted by POST /restaurants/{id}/staff
+type AssignStaffInput struct {
+	Email  string `json:"email" validate:"required,email"`
+	RoleID string `json:"role_id" validate:"required"`
+}
+
+// StaffMemberResponse is one role assignment in a restaurant
+type StaffMemberResponse struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	RoleID    string    `json:"role_id"`
+	RoleName  string    `json:"role_name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// MembershipResponse is one restaurant the user works at, with the roles they
+// hold there
+type MembershipResponse struct {
+	RestaurantID   string   `json:"restaurant_id"`
+	RestaurantName string   `json:"restaurant_name"`
+	Roles          []string `json:"roles"`
+	Active         bool     `json:"active"`
+}
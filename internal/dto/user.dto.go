@@ -1,12 +1,149 @@
 package dto
 
+import "time"
+
 // CurrentUserResponse represents the response structure for the current user endpoint
 type CurrentUserResponse struct {
-	ID        string `json:"id"`
-	Name      string `json:"name"`
-	Email     string `json:"email"`
-	Address   string `json:"address,omitempty"`
-	Role      string `json:"role"`
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
-}
\ No newline at end of file
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Email      string `json:"email"`
+	Address    string `json:"address,omitempty"`
+	Phone      string `json:"phone,omitempty"`
+	Role       string `json:"role"`
+	MFAEnabled bool   `json:"mfa_enabled"`
+	CreatedAt  string `json:"created_at"`
+	UpdatedAt  string `json:"updated_at"`
+	// DeletionScheduledAt is set while a requested account deletion can still be cancelled
+	DeletionScheduledAt string `json:"deletion_scheduled_at,omitempty"`
+}
+
+// SessionResponse describes one signed-in device returned by GET /user/sessions
+type SessionResponse struct {
+	ID         string    `json:"id"`
+	Device     string    `json:"device"`
+	Browser    string    `json:"browser"`
+	OS         string    `json:"os"`
+	DeviceType string    `json:"device_type"`
+	IPAddress  string    `json:"ip_address,omitempty"`
+	UserAgent  string    `json:"user_agent,omitempty"`
+	SignedInAt time.Time `json:"signed_in_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+}
+
+// UserFilter holds the query parameters of GET /users. Zero values do not filter.
+type UserFilter struct {
+	Role        string
+	Email       string
+	Status      string
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	Sort        string
+	Order       string
+	Limit       int
+	Offset      int
+}
+
+// AdminUserResponse is a user as seen by admins
+type AdminUserResponse struct {
+	ID              string     `json:"id"`
+	Name            string     `json:"name"`
+	Email           string     `json:"email"`
+	Address         string     `json:"address,omitempty"`
+	Phone           string     `json:"phone,omitempty"`
+	Role            string     `json:"role"`
+	Status          string     `json:"status"`
+	MFAEnabled      bool       `json:"mfa_enabled"`
+	SuspendedAt     *time.Time `json:"suspended_at,omitempty"`
+	SuspendedReason string     `json:"suspended_reason,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// UserListResponse is returned by GET /users
+type UserListResponse struct {
+	Title      string              `json:"title"`
+	Data       []AdminUserResponse `json:"data"`
+	Pagination Pagination          `json:"pagination"`
+}
+
+// Pagination describes the page of a list response
+type Pagination struct {
+	Total  int `json:"total"`
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
+
+// UpdateUserRoleInput is the body accepted by PATCH /users/{id}/role
+type UpdateUserRoleInput struct {
+	Role string `json:"role" validate:"required,oneof=user management admin"`
+}
+
+// SuspendUserInput is the body accepted by POST /users/{id}/suspend
+type SuspendUserInput struct {
+	Reason string `json:"reason" validate:"max=255"`
+}
+
+// UpdateProfileInput is the body accepted by PATCH /user. Nil fields are left
+// untouched; an empty address or phone clears it.
+type UpdateProfileInput struct {
+	Name    *string `json:"name" validate:"omitempty,min=3,max=50"`
+	Address *string `json:"address" validate:"omitempty,max=200"`
+	Phone   *string `json:"phone" validate:"omitempty,e164"`
+}
+
+// ChangePasswordInput is the body accepted by POST /user/change-password
+type ChangePasswordInput struct {
+	CurrentPassword string `json:"currentPassword" validate:"required"`
+	Password        string `json:"password" validate:"required,min=6,max=18,password_special"`
+	ConfirmPassword string `json:"confirmPassword" validate:"required,eqfield=Password"`
+}
+
+// ChangeEmailInput is the body accepted by POST /user/change-email
+type ChangeEmailInput struct {
+	Email    string `json:"email" validate:"required,email,max=255"`
+	Password string `json:"password" validate:"required"`
+}
+
+// DeleteAccountInput is the body accepted by DELETE /user
+type DeleteAccountInput struct {
+	Password string `json:"password" validate:"required"`
+}
+
+// AccountDeletionResponse is returned once an account deletion is scheduled
+type AccountDeletionResponse struct {
+	Message             string    `json:"message"`
+	DeletionScheduledAt time.Time `json:"deletion_scheduled_at"`
+}
+
+// OIDCAuthorizationResponse carries the provider URL to send the browser to
+type OIDCAuthorizationResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+}
+
+// CreateAPIKeyInput is the body accepted by POST /user/api-keys. The key acts
+// for the caller in RestaurantID; ExpiresInDays defaults to 90.
+type CreateAPIKeyInput struct {
+	Name          string `json:"name" validate:"required,max=100"`
+	RestaurantID  string `json:"restaurant_id" validate:"required,uuid"`
+	ExpiresInDays int    `json:"expires_in_days" validate:"omitempty,min=1,max=365"`
+}
+
+// APIKeyResponse describes an API key without its secret
+type APIKeyResponse struct {
+	ID           string     `json:"id"`
+	Name         string     `json:"name"`
+	Prefix       string     `json:"prefix"`
+	RestaurantID string     `json:"restaurant_id"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	LastUsedAt   *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// CreatedAPIKeyResponse is returned once, when the key is created; Key cannot
+// be retrieved again
+type CreatedAPIKeyResponse struct {
+	APIKeyResponse
+	Key string `json:"key"`
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 )
@@ -41,6 +42,24 @@ func ForbiddenError(message string) *AppError {
 	return New("Forbidden", message, http.StatusForbidden, nil)
 }
 
+// ConflictError signals a request that clashes with the current state of a resource
+func ConflictError(message string) *AppError {
+	return New("Conflict", message, http.StatusConflict, nil)
+}
+
+// TooManyRequestsError rejects a request until retryAfter has passed
+func TooManyRequestsError(message string, retryAfter time.Duration) *AppError {
+	appErr := New("Too Many Requests", message, http.StatusTooManyRequests, nil)
+	appErr.RetryAfter = retryAfter
+	return appErr
+}
+
+// BadGatewayError reports that a service we depend on, such as an identity
+// provider, failed or answered something unusable
+func BadGatewayError(message string, err error) *AppError {
+	return New("Bad Gateway", message, http.StatusBadGateway, err)
+}
+
 func InternalError(err error) *AppError {
 	if err == nil {
 		return New("Internal Server Error", "Something went wrong, try again later", http.StatusInternalServerError, nil)
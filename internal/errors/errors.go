@@ -3,6 +3,8 @@ package errors
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/alibaba0010/postgres-api/internal/logger"
 	"go.uber.org/zap"
@@ -13,15 +15,20 @@ type ErrorResponseStruct struct {
     Title    string   `json:"title"`
     Message  string   `json:"message,omitempty"`
     Messages []string `json:"messages,omitempty"`
+    // RequestID is the X-Request-ID of the request, to quote when reporting
+    // the error; it finds the matching log entries
+    RequestID string `json:"request_id,omitempty"`
 }
 
 // AppError wraps any error with a title and HTTP status
 type AppError struct {
-    Title     string
-    Message   string
-    Messages  []string
-    Status    int
-    Err       error
+    Title      string
+    Message    string
+    Messages   []string
+    Status     int
+    Err        error
+    // RetryAfter, when set, is sent to the client as the Retry-After header
+    RetryAfter time.Duration
 }
 
 func (err *AppError)Error() string{
@@ -47,25 +54,32 @@ func ErrorResponse(writer http.ResponseWriter, request *http.Request, appErr *Ap
     }
 
     writer.Header().Set("Content-Type", "application/json")
+    if appErr.RetryAfter > 0 {
+        // Retry-After is in whole seconds; round up so clients never retry early
+        seconds := int64((appErr.RetryAfter + time.Second - 1) / time.Second)
+        writer.Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
+    }
     writer.WriteHeader(appErr.Status)
 requestPath := request.URL.Path
+    log := logger.FromContext(request.Context())
     // Log minimal info only. Do NOT print internal error details or stack traces to console.
     // For client-side/non-critical errors (4xx) log as Info; for server errors (5xx) log as Error
     if appErr.Status >= 500 {
         if appErr.Err != nil {
-            logger.Log.Error(appErr.Title, zap.Int("status", appErr.Status), zap.String("path", requestPath), zap.Error(appErr.Err))
+            log.Error(appErr.Title, zap.Int("status", appErr.Status), zap.String("path", requestPath), zap.Error(appErr.Err))
         } else {
-            logger.Log.Error(appErr.Title, zap.Int("status", appErr.Status), zap.String("path", requestPath))
+            log.Error(appErr.Title, zap.Int("status", appErr.Status), zap.String("path", requestPath))
         }
     } else {
-        logger.Log.Info(appErr.Title, zap.Int("status", appErr.Status), zap.String("path", requestPath))
+        log.Info(appErr.Title, zap.Int("status", appErr.Status), zap.String("path", requestPath))
     }
 
     // Respond to client (only public info)
     // If JSON encoding fails, don't attempt to write another body (avoids recursive logging)
     resp := ErrorResponseStruct{
-        Title:   appErr.Title,
-        Message: appErr.Message,
+        Title:     appErr.Title,
+        Message:   appErr.Message,
+        RequestID: logger.RequestIDFromContext(request.Context()),
     }
     if len(appErr.Messages) > 0 {
         resp.Messages = appErr.Messages
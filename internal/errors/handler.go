@@ -85,7 +85,7 @@ func RecoverMiddleware(next http.Handler) http.Handler {
                 // This intentionally logs more detail than the public response. If you
                 // are running in production and want to avoid logging stack traces,
                 // remove the stack logging below.
-                logger.Log.Error("panic recovered in request",
+                logger.FromContext(r.Context()).Error("panic recovered in request",
                     zap.String("path", r.URL.Path),
                     // zap.Any("panic", rec),
                     // zap.String("stack", string(debug.Stack())),
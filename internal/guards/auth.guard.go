@@ -3,8 +3,9 @@ package guards
 import (
 	"net/http"
 	"strings"
-	"time"
 
+	"github.com/alibaba0010/postgres-api/internal/auth"
+	"github.com/alibaba0010/postgres-api/internal/clock"
 	"github.com/alibaba0010/postgres-api/internal/config"
 	"github.com/alibaba0010/postgres-api/internal/errors"
 	"github.com/alibaba0010/postgres-api/internal/logger"
@@ -13,16 +14,31 @@ import (
 	"go.uber.org/zap"
 )
 
-// AuthenticatedUser is stored in request context for downstream handlers
-type AuthenticatedUser struct {
-	UserID string
-	Role   string
+// Guard holds the middleware that authenticates and authorizes requests
+type Guard struct {
+	svc   *services.Service
+	log   *zap.Logger
+	cfg   config.Config
+	clock clock.Clock
+}
+
+// New returns the guards checking requests against svc
+func New(svc *services.Service, cfg config.Config, log *zap.Logger, c clock.Clock) *Guard {
+	return &Guard{svc: svc, log: log, cfg: cfg, clock: c}
+}
+
+// logger returns the logger of the request, which carries its ID and caller
+func (g *Guard) logger(request *http.Request) *zap.Logger {
+	return logger.FromContextOr(request.Context(), g.log)
 }
 
 // AuthMiddleware validates the access token from Authorization header (Bearer scheme).
 // If expired, attempts to refresh using the refresh_token cookie.
-// Sets ctx.Request.Header["X-User-Id"] and ["X-User-Role"] for downstream handlers.
-func AuthMiddleware(next http.Handler) http.Handler {
+// "Authorization: ApiKey <key>" is accepted as well and acts for the key's
+// user in the key's restaurant.
+// Stores the caller as an auth.Principal in the request context for
+// downstream handlers (auth.FromContext).
+func (g *Guard) AuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
 		// Extract access token from Authorization header
 		authHeader := request.Header.Get("Authorization")
@@ -31,8 +47,12 @@ func AuthMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
-		// Expect "Bearer <token>"
+		// Expect "Bearer <token>" or "ApiKey <key>"
 		parts := strings.SplitN(authHeader, " ", 2)
+		if len(parts) == 2 && parts[0] == "ApiKey" {
+			g.serveAPIKey(writer, request, next, parts[1])
+			return
+		}
 		if len(parts) != 2 || parts[0] != "Bearer" {
 			errors.ErrorResponse(writer, request, errors.UnauthorizedError("invalid authorization header format"))
 			return
@@ -41,17 +61,31 @@ func AuthMiddleware(next http.Handler) http.Handler {
 		accessToken := parts[1]
 
 		// Try to verify access token
-		claims, appErr := services.VerifyAccessToken(accessToken)
+		claims, appErr := g.svc.VerifyAccessToken(accessToken)
 		if appErr == nil {
-			// Access token is valid, proceed
-			request.Header.Set("X-User-Id", claims.UserID)
-			request.Header.Set("X-User-Role", claims.Role)
-			next.ServeHTTP(writer, request)
+			// Access token is valid; it may have been revoked by a logout, and
+			// its user may have been suspended or deleted since
+			if appErr := g.svc.CheckAccessTokenRevoked(request.Context(), claims); appErr != nil {
+				errors.ErrorResponse(writer, request, appErr)
+				return
+			}
+			if appErr := g.svc.CheckUserActive(request.Context(), claims.UserID); appErr != nil {
+				errors.ErrorResponse(writer, request, appErr)
+				return
+			}
+			next.ServeHTTP(writer, withPrincipal(request, &auth.Principal{
+				UserID:       claims.UserID,
+				Role:         claims.Role,
+				SessionID:    claims.SessionID,
+				RestaurantID: claims.RestaurantID,
+				Method:       auth.MethodAccessToken,
+				Scopes:       auth.SessionScopes(),
+			}))
 			return
 		}
 
 		// Access token is invalid or expired, try to refresh
-		logger.Log.Debug("access token invalid or expired, attempting refresh")
+		g.logger(request).Debug("access token invalid or expired, attempting refresh")
 
 		// Get refresh token from cookie
 		refreshCookie, err := request.Cookie("refresh_token")
@@ -62,14 +96,13 @@ func AuthMiddleware(next http.Handler) http.Handler {
 		}
 		
 		refreshToken := refreshCookie.Value
-		logger.Log.Info("Refresh token value in cookie ", zap.String("refresh_token",refreshToken))
 
 		// Extract IP and User-Agent for refresh validation
 		ip := utils.ExtractClientIP(request)
 		userAgent := request.Header.Get("User-Agent")
 
 		// Extract userID from access token claims even if expired (to know which user to refresh)
-		expiredClaims, _ := services.VerifyAccessToken(accessToken)
+		expiredClaims, _ := g.svc.VerifyAccessToken(accessToken)
 		userID := ""
 		if expiredClaims != nil {
 			userID = expiredClaims.UserID
@@ -77,7 +110,7 @@ func AuthMiddleware(next http.Handler) http.Handler {
 
 		// If we can't get userID from claims, try parsing the refresh token
 		if userID == "" {
-			refreshClaims, err := services.ValidateRefreshToken(refreshToken)
+			refreshClaims, err := g.svc.ValidateRefreshToken(refreshToken)
 			if err != nil {
 				errors.ErrorResponse(writer, request, errors.UnauthorizedError("invalid refresh token; please login again"))
 				return
@@ -86,7 +119,7 @@ func AuthMiddleware(next http.Handler) http.Handler {
 		}
 
 		// Attempt to refresh the access token
-		newTokenPair, appErr := services.RefreshAccessToken(request.Context(), refreshToken, userID, ip, userAgent)
+		newTokenPair, appErr := g.svc.RefreshAccessToken(request.Context(), refreshToken, userID, ip, userAgent)
 		if appErr != nil {
 			// Refresh failed, user must login again
 			errors.ErrorResponse(writer, request, errors.UnauthorizedError("refresh token invalid or revoked; please login again"))
@@ -103,11 +136,11 @@ func AuthMiddleware(next http.Handler) http.Handler {
 			Value:    newTokenPair.RefreshToken,
 			HttpOnly: true,
 			Path:     "/",
-			Expires:  time.Now().Add(services.RefreshTokenDuration),
+			Expires:  g.clock.Now().Add(services.RefreshTokenDuration),
 			Secure:   false,
 			SameSite: http.SameSiteLaxMode,
 		}
-		if strings.HasPrefix(config.LoadConfig().FRONTEND_URL, "https") {
+		if strings.HasPrefix(g.cfg.FRONTEND_URL, "https") {
 			newCookie.Secure = true
 		}
 		http.SetCookie(writer, newCookie)
@@ -115,46 +148,70 @@ func AuthMiddleware(next http.Handler) http.Handler {
 		// Send new access token in response header for client to update
 		writer.Header().Set("X-New-Access-Token", newTokenPair.AccessToken)
 
-		// Extract user info from refresh token claims
-		refreshClaims, _ := services.ValidateRefreshToken(refreshToken)
+		// Extract user info from the issued refresh token, which carries the
+		// role read at the rotation
+		refreshClaims, _ := g.svc.ValidateRefreshToken(newTokenPair.RefreshToken)
 		if refreshClaims != nil {
-			request.Header.Set("X-User-Id", refreshClaims.UserID)
-			request.Header.Set("X-User-Role", refreshClaims.Role)
-			logger.Log.Info("access token refreshed successfully", zap.String("user_id", refreshClaims.UserID))
+			request = withPrincipal(request, &auth.Principal{
+				UserID:       refreshClaims.UserID,
+				Role:         refreshClaims.Role,
+				SessionID:    refreshClaims.FamilyID,
+				RestaurantID: refreshClaims.RestaurantID,
+				Method:       auth.MethodRefreshToken,
+				Scopes:       auth.SessionScopes(),
+			})
+			g.logger(request).Info("access token refreshed successfully", zap.String("user_id", refreshClaims.UserID))
 		}
 
 		next.ServeHTTP(writer, request)
 	})
 }
 
-// ExtractAuthenticatedUser extracts authenticated user info from request headers
-// (set by AuthMiddleware).
-func ExtractAuthenticatedUser(request *http.Request) *AuthenticatedUser {
-	userID := request.Header.Get("X-User-Id")
-	role := request.Header.Get("X-User-Role")
-	if userID == "" {
-		return nil
+// serveAPIKey authenticates a request made with an API key. The key acts for
+// its user in its restaurant, like an access token with that active restaurant.
+func (g *Guard) serveAPIKey(writer http.ResponseWriter, request *http.Request, next http.Handler, rawKey string) {
+	key, role, appErr := g.svc.AuthenticateAPIKey(request.Context(), rawKey)
+	if appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
 	}
-	return &AuthenticatedUser{
-		UserID: userID,
-		Role:   role,
+	if appErr := g.svc.CheckUserActive(request.Context(), key.UserID); appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
 	}
+
+	next.ServeHTTP(writer, withPrincipal(request, &auth.Principal{
+		UserID:       key.UserID,
+		Role:         role,
+		RestaurantID: key.RestaurantID,
+		Method:       auth.MethodAPIKey,
+		APIKeyID:     key.ID,
+		Scopes:       auth.APIKeyScopes(),
+	}))
+}
+
+// withPrincipal returns request carrying the authenticated caller
+func withPrincipal(request *http.Request, principal *auth.Principal) *http.Request {
+	// Every entry logged for the request from here on, the access log
+	// included, names the user
+	logger.SetUserID(request.Context(), principal.UserID)
+	return request.WithContext(auth.WithPrincipal(request.Context(), principal))
 }
 
 
 
-func RequireRole(allowedRoles ...string) func(http.Handler) http.Handler {
+func (g *Guard) RequireRole(allowedRoles ...string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
-			user := ExtractAuthenticatedUser(request)
+			user := auth.FromContext(request.Context())
 			if user == nil {
 				errors.ErrorResponse(writer, request, errors.UnauthorizedError("user not authenticated"))
 				return
 			}
 
 			// Check if user's role has permission for any of the allowed roles
-			if !services.CheckRolePermission(user.Role, allowedRoles...) {
-				logger.Log.Warn("unauthorized access attempt", 
+			if !g.svc.CheckRolePermission(user.Role, allowedRoles...) {
+				g.logger(request).Warn("unauthorized access attempt", 
 					zap.String("user_id", user.UserID), 
 					zap.String("user_role", user.Role), 
 					zap.Strings("required_roles", allowedRoles))
@@ -162,7 +219,7 @@ func RequireRole(allowedRoles ...string) func(http.Handler) http.Handler {
 				return
 			}
 
-			logger.Log.Debug("user authorized", zap.String("user_id", user.UserID), zap.String("role", user.Role))
+			g.logger(request).Debug("user authorized", zap.String("user_id", user.UserID), zap.String("role", user.Role))
 			next.ServeHTTP(writer, request)
 		})
 	}
@@ -0,0 +1,51 @@
+package guards_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/alibaba0010/postgres-api/internal/app"
+	"github.com/alibaba0010/postgres-api/internal/clock"
+	"github.com/alibaba0010/postgres-api/internal/config"
+)
+
+func TestRefreshCookieNeverLogged(t *testing.T) {
+	cfg, err := config.Load([]string{"--app-env=test"}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	core, logs := observer.New(zapcore.DebugLevel)
+	application, err := app.NewInMemory(cfg, zap.New(core), nil, clock.NewFake(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = application.Services.StopBackgroundWork(context.Background()) })
+
+	const cookie = "refresh-cookie-secret"
+	request := httptest.NewRequest(http.MethodGet, "/api/v1/user", nil)
+	request.Header.Set("Authorization", "Bearer expired-or-garbage")
+	request.AddCookie(&http.Cookie{Name: "refresh_token", Value: cookie})
+	recorder := httptest.NewRecorder()
+	application.HTTPHandler().ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("status %d, want 401", recorder.Code)
+	}
+
+	if logs.Len() == 0 {
+		t.Fatal("no entries observed; the request logger is not the observed one")
+	}
+	for _, entry := range logs.All() {
+		if strings.Contains(entry.Message, cookie) || strings.Contains(fmt.Sprint(entry.ContextMap()), cookie) {
+			t.Fatalf("refresh token logged: %s %v", entry.Message, entry.ContextMap())
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package guards
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"github.com/alibaba0010/postgres-api/internal/auth"
+	"github.com/alibaba0010/postgres-api/internal/errors"
+	"github.com/alibaba0010/postgres-api/internal/utils"
+)
+
+// TenantMiddleware scopes the request to the restaurant named by the {id} path
+// variable, as in /restaurants/{id}/... The restaurant must exist and the
+// caller must be a member of it; an access token whose active restaurant is a
+// different one is rejected. Restaurant-owned models then filter every query
+// by that restaurant. Use it after AuthMiddleware.
+func (g *Guard) TenantMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		user := auth.FromContext(request.Context())
+		if user == nil {
+			errors.ErrorResponse(writer, request, errors.UnauthorizedError("user not authenticated"))
+			return
+		}
+
+		restaurantID := mux.Vars(request)["id"]
+		if _, err := uuid.Parse(restaurantID); err != nil {
+			errors.ErrorResponse(writer, request, errors.NotFoundError("restaurant not found"))
+			return
+		}
+		if user.RestaurantID != "" && user.RestaurantID != restaurantID {
+			g.logger(request).Warn("access token scoped to another restaurant",
+				zap.String("user_id", user.UserID),
+				zap.String("active_restaurant_id", user.RestaurantID),
+				zap.String("restaurant_id", restaurantID))
+			errors.ErrorResponse(writer, request, errors.ForbiddenError("your session is active in another restaurant; switch restaurants first"))
+			return
+		}
+
+		g.serveTenant(writer, request, next, user, restaurantID)
+	})
+}
+
+// ActiveTenantMiddleware scopes the request to the active restaurant of the
+// access token, for routes that do not name the restaurant (e.g.
+// /menus/{id}). A request without an active restaurant would reach every
+// restaurant's rows, so it is rejected; the caller switches restaurants first.
+func (g *Guard) ActiveTenantMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		user := auth.FromContext(request.Context())
+		if user == nil {
+			errors.ErrorResponse(writer, request, errors.UnauthorizedError("user not authenticated"))
+			return
+		}
+		if user.RestaurantID == "" {
+			g.logger(request).Warn("restaurant route without an active restaurant", zap.String("user_id", user.UserID))
+			errors.ErrorResponse(writer, request, errors.ForbiddenError("choose the restaurant to act in first with /auth/switch-restaurant"))
+			return
+		}
+
+		g.serveTenant(writer, request, next, user, user.RestaurantID)
+	})
+}
+
+// serveTenant checks the caller may act in the restaurant, which may have
+// changed since the token was issued, and runs next scoped to it
+func (g *Guard) serveTenant(writer http.ResponseWriter, request *http.Request, next http.Handler, user *auth.Principal, restaurantID string) {
+	if appErr := g.svc.AuthorizeTenant(request.Context(), user.UserID, user.Role, restaurantID); appErr != nil {
+		errors.ErrorResponse(writer, request, appErr)
+		return
+	}
+	next.ServeHTTP(writer, request.WithContext(utils.WithTenant(request.Context(), restaurantID)))
+}
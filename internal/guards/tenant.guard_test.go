@@ -0,0 +1,80 @@
+package guards_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alibaba0010/postgres-api/internal/apptest"
+	"github.com/alibaba0010/postgres-api/internal/dto"
+	"github.com/alibaba0010/postgres-api/internal/models"
+)
+
+func patchMenu(env *apptest.Env, accessToken, menuID string) int {
+	request := httptest.NewRequest(http.MethodPatch, "/api/v1/menus/"+menuID, strings.NewReader(`{"name":"Renamed"}`))
+	request.Header.Set("Authorization", "Bearer "+accessToken)
+	request.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	env.HTTPHandler().ServeHTTP(recorder, request)
+	return recorder.Code
+}
+
+func TestActiveTenantRequiresActiveRestaurant(t *testing.T) {
+	env := apptest.New(t)
+	ctx := context.Background()
+	const userID = "0191f000-0000-7000-8000-000000000001"
+	pair, appErr := env.Services.GenerateTokenPair(ctx, userID, "management", "203.0.113.7", "test")
+	if appErr != nil {
+		t.Fatal(appErr)
+	}
+	// the user was recently found active, so AuthMiddleware does not ask the database
+	if err := env.Cache.Set(ctx, "user_blocked:"+userID, "active", time.Minute).Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	// rejected before any query, so no database is needed
+	if code := patchMenu(env, pair.AccessToken, "0191f000-0000-7000-8000-000000000002"); code != http.StatusForbidden {
+		t.Fatalf("menu edit without an active restaurant: status %d, want 403", code)
+	}
+}
+
+func TestActiveTenantScopesToActiveRestaurant(t *testing.T) {
+	env := apptest.NewWithDB(t)
+	ctx := context.Background()
+	owner := env.CreateUser(t, "management")
+	active := env.CreateRestaurant(t, owner)
+	other := env.CreateRestaurant(t, owner)
+	menu, appErr := env.Services.CreateMenu(ctx, other.ID, owner.ID, owner.Role, dto.CreateMenuInput{Name: "Dinner", Type: "dinner"})
+	if appErr != nil {
+		t.Fatalf("create menu: %v", appErr)
+	}
+
+	session := env.Session(t, owner)
+	switched, appErr := env.Services.SwitchActiveRestaurant(ctx, session.RefreshToken, active.ID, "203.0.113.7", "apptest")
+	if appErr != nil {
+		t.Fatalf("switch restaurant: %v", appErr)
+	}
+	if code := patchMenu(env, switched.AccessToken, menu.ID); code == http.StatusOK {
+		t.Fatal("menu of another restaurant edited in the active one")
+	}
+
+	stored := &models.Menu{}
+	if err := env.DB.NewSelect().Model(stored).Where("id = ?", menu.ID).Scan(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if stored.Name != "Dinner" {
+		t.Fatalf("menu renamed to %q outside the active restaurant", stored.Name)
+	}
+
+	// in the menu's restaurant the edit goes through
+	switched, appErr = env.Services.SwitchActiveRestaurant(ctx, switched.RefreshToken, other.ID, "203.0.113.7", "apptest")
+	if appErr != nil {
+		t.Fatalf("switch restaurant: %v", appErr)
+	}
+	if code := patchMenu(env, switched.AccessToken, menu.ID); code != http.StatusOK {
+		t.Fatalf("menu edit in its restaurant: status %d, want 200", code)
+	}
+}
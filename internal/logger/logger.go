@@ -1,59 +1,142 @@
 package logger
 
 import (
+	"context"
+	"fmt"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
-var Log *zap.Logger
-
 // customTimeEncoder serializes a time.Time to a human-friendly format
 func customTimeEncoder(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
 	enc.AppendString(t.Format("2006-01-02 15:04:05"))
 }
 
-func InitLogger() {
-	// Configure console output with colors
-	config := zap.NewDevelopmentEncoderConfig()
-	config.EncodeLevel = zapcore.CapitalColorLevelEncoder // Enable colors
-	config.EncodeTime = customTimeEncoder                 // Human-friendly timestamp
-	config.EncodeCaller = nil                            // Disable caller
-	config.EncodeLevel = zapcore.CapitalColorLevelEncoder
-	config.ConsoleSeparator = " "                        // Clean spacing between fields
-	
-	// Create console encoder
-	consoleEncoder := zapcore.NewConsoleEncoder(config)
-	
-	// Write to stderr for console output
-	// Allow LOG_LEVEL env var to control log level (debug|info|warn|error)
-	lvl := zapcore.InfoLevel
-	switch strings.ToLower(strings.TrimSpace(os.Getenv("LOG_LEVEL"))) {
+// The formats Options.Format can name
+const (
+	FormatConsole = "console"
+	FormatJSON    = "json"
+)
+
+// Options configure the logger built by New
+type Options struct {
+	// Level is debug, info, warn or error; empty means info
+	Level string
+	// Format is FormatConsole, colored for people, or FormatJSON, one object
+	// per line for log collectors; empty means FormatConsole
+	Format string
+	// Sampling keeps the first 100 entries with the same level and message
+	// every second and every 100th after that
+	Sampling bool
+}
+
+// New returns the logger of the application, writing to stderr
+func New(opts Options) (*zap.Logger, error) {
+	var lvl zapcore.Level
+	switch strings.ToLower(strings.TrimSpace(opts.Level)) {
 	case "debug":
 		lvl = zapcore.DebugLevel
-	case "info":
+	case "", "info":
 		lvl = zapcore.InfoLevel
 	case "warn", "warning":
 		lvl = zapcore.WarnLevel
 	case "error":
 		lvl = zapcore.ErrorLevel
+	default:
+		return nil, fmt.Errorf("unknown log level %q", opts.Level)
+	}
+
+	var encoder zapcore.Encoder
+	switch opts.Format {
+	case "", FormatConsole:
+		// Configure console output with colors
+		config := zap.NewDevelopmentEncoderConfig()
+		config.EncodeLevel = zapcore.CapitalColorLevelEncoder // Enable colors
+		config.EncodeTime = customTimeEncoder                 // Human-friendly timestamp
+		config.EncodeCaller = nil                             // Disable caller
+		config.ConsoleSeparator = " "                         // Clean spacing between fields
+		encoder = zapcore.NewConsoleEncoder(config)
+	case FormatJSON:
+		// ts, level, msg and the fields, with RFC 3339 timestamps
+		config := zap.NewProductionEncoderConfig()
+		config.EncodeTime = zapcore.RFC3339NanoTimeEncoder
+		config.EncodeCaller = nil
+		encoder = zapcore.NewJSONEncoder(config)
+	default:
+		return nil, fmt.Errorf("unknown log format %q", opts.Format)
 	}
 
-	core := zapcore.NewCore(
-		consoleEncoder,
-		zapcore.AddSync(os.Stderr),
-		lvl,
-	)
+	core := zapcore.NewCore(encoder, zapcore.Lock(os.Stderr), lvl)
+	if opts.Sampling {
+		core = zapcore.NewSamplerWithOptions(core, time.Second, 100, 100)
+	}
 
 	// Create logger without caller or stacktrace
-	Log = zap.New(core)
+	return zap.New(core), nil
 }
 
-func Sync(){
-	if Log != nil {
-		_ = Log.Sync()
+// Bootstrap returns the console logger used until the configuration is
+// loaded, at the level named by the LOG_LEVEL environment variable
+func Bootstrap() *zap.Logger {
+	log, err := New(Options{Level: os.Getenv("LOG_LEVEL")})
+	if err != nil {
+		log, _ = New(Options{})
 	}
-}
\ No newline at end of file
+	return log
+}
+
+type contextKey struct{}
+
+// loggerState is the logger of a request. The middleware stores it in the
+// context before routing; the guards, deeper down, add the user once they know
+// it, and everything that shares the context sees the change.
+type loggerState struct {
+	mu     sync.Mutex
+	log    *zap.Logger
+	userID string
+}
+
+// WithContext returns a copy of ctx carrying log
+func WithContext(ctx context.Context, log *zap.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, &loggerState{log: log})
+}
+
+// FromContext returns the logger stored by WithContext, or one that discards
+// everything when there is none
+func FromContext(ctx context.Context) *zap.Logger {
+	return FromContextOr(ctx, zap.NewNop())
+}
+
+// FromContextOr returns the logger stored by WithContext, or fallback when
+// there is none, as for background work started outside a request
+func FromContextOr(ctx context.Context, fallback *zap.Logger) *zap.Logger {
+	state, ok := ctx.Value(contextKey{}).(*loggerState)
+	if !ok {
+		return fallback
+	}
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	return state.log
+}
+
+// SetUserID adds user_id to every entry logged through ctx from now on,
+// including the access log of the request. Nothing happens when ctx carries
+// no logger or already names that user.
+func SetUserID(ctx context.Context, userID string) {
+	state, ok := ctx.Value(contextKey{}).(*loggerState)
+	if !ok || userID == "" {
+		return
+	}
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	if state.userID == userID {
+		return
+	}
+	state.userID = userID
+	state.log = state.log.With(zap.String("user_id", userID))
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	// "sync"
+	"github.com/gorilla/mux"
 	"go.uber.org/zap"
 )
 type loggingResponseWriter struct {
@@ -17,40 +18,54 @@ func (lrw *loggingResponseWriter) WriteHeader(status int) {
     lrw.status = status
     lrw.ResponseWriter.WriteHeader(status)
 }
-func Logger(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
-		start := time.Now()
-		// Wrap the original ResponseWriter so we can capture the status code
-		lrw := &loggingResponseWriter{ResponseWriter: writer, status: http.StatusOK}
-		// Pass the wrapped writer into the next handler so WriteHeader calls
-		// set lrw.status and write to the original underlying writer.
-		next.ServeHTTP(lrw, request)
-		duration := time.Since(start)
+// Logger logs every request with log, which handlers find in the request
+// context (FromContext). Every entry logged through the context carries the
+// request ID (see RequestID, which must run first), the route template and,
+// once a guard has authenticated the caller, the user ID.
+func Logger(log *zap.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			fields := []zap.Field{zap.String("request_id", RequestIDFromContext(request.Context()))}
+			// The template, not the path, so entries group by endpoint
+			if route := mux.CurrentRoute(request); route != nil {
+				if template, err := route.GetPathTemplate(); err == nil {
+					fields = append(fields, zap.String("route", template))
+				}
+			}
+			request = request.WithContext(WithContext(request.Context(), log.With(fields...)))
+			start := time.Now()
+			// Wrap the original ResponseWriter so we can capture the status code
+			lrw := &loggingResponseWriter{ResponseWriter: writer, status: http.StatusOK}
+			// Pass the wrapped writer into the next handler so WriteHeader calls
+			// set lrw.status and write to the original underlying writer.
+			next.ServeHTTP(lrw, request)
+			duration := time.Since(start)
 
-		// extract client IP (respect X-Forwarded-For, X-Real-Ip) similar to other handlers
-		var ip string
-		if xf := request.Header.Get("X-Forwarded-For"); xf != "" {
-			parts := strings.Split(xf, ",")
-			ip = strings.TrimSpace(parts[0])
-		} else if xr := request.Header.Get("X-Real-Ip"); xr != "" {
-			ip = xr
-		} else {
-			remote := request.RemoteAddr
-			if i := strings.LastIndex(remote, ":"); i != -1 {
-				ip = remote[:i]
+			// extract client IP (respect X-Forwarded-For, X-Real-Ip) similar to other handlers
+			var ip string
+			if xf := request.Header.Get("X-Forwarded-For"); xf != "" {
+				parts := strings.Split(xf, ",")
+				ip = strings.TrimSpace(parts[0])
+			} else if xr := request.Header.Get("X-Real-Ip"); xr != "" {
+				ip = xr
 			} else {
-				ip = remote
+				remote := request.RemoteAddr
+				if i := strings.LastIndex(remote, ":"); i != -1 {
+					ip = remote[:i]
+				} else {
+					ip = remote
+				}
 			}
-		}
 
-		// use the package-level Log variable directly (same package)
-		Log.Info("Incoming request",
-			zap.String("method", request.Method),
-			zap.String("path", request.URL.Path),
-			zap.Int("status", lrw.status),
-			zap.Duration("duration", duration),
-			zap.String("ip", ip),
-			zap.String("user-agent", request.UserAgent()),
-		)
-	})
-}
\ No newline at end of file
+			// Through the context, to pick up the user ID set by the guards
+			FromContext(request.Context()).Info("Incoming request",
+				zap.String("method", request.Method),
+				zap.String("path", request.URL.Path),
+				zap.Int("status", lrw.status),
+				zap.Duration("duration", duration),
+				zap.String("ip", ip),
+				zap.String("user-agent", request.UserAgent()),
+			)
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package logger
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries the ID of a request, in both directions
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds the IDs taken from clients, which end up in
+// every log entry of the request
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// RequestID gives every request an ID: the X-Request-ID sent by the client or
+// a proxy in front of the API when it is a sensible one, a new UUID otherwise.
+// The ID is echoed in the X-Request-ID response header and stored in the
+// request context (RequestIDFromContext), where the logger and error
+// responses pick it up.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		id := request.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		writer.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(writer, request.WithContext(context.WithValue(request.Context(), requestIDKey{}, id)))
+	})
+}
+
+// RequestIDFromContext returns the ID stored by RequestID, or "" outside a
+// request
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// validRequestID accepts the IDs proxies and tracing systems generate, such
+// as UUIDs and hex trace IDs, and rejects anything that could forge or break
+// log lines
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID returns a UUIDv7, so IDs sort by the time of the request
+func newRequestID() string {
+	if id, err := uuid.NewV7(); err == nil {
+		return id.String()
+	}
+	return uuid.NewString()
+}
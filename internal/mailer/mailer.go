@@ -0,0 +1,106 @@
+package mailer
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/wneessen/go-mail"
+
+	"github.com/alibaba0010/postgres-api/internal/config"
+)
+
+// Mailer sends the HTML emails of the services
+type Mailer interface {
+	Send(to, subject, htmlBody string) error
+}
+
+// SMTP sends emails through the EMAIL_* server
+type SMTP struct {
+	host     string
+	port     int
+	user     string
+	password string
+}
+
+// NewSMTP returns a mailer for the configured server
+func NewSMTP(cfg config.Config) *SMTP {
+	return &SMTP{
+		host:     cfg.EMAIL_HOST,
+		port:     cfg.EMAIL_PORT,
+		user:     cfg.EMAIL_USER,
+		password: cfg.EMAIL_PASSWORD,
+	}
+}
+
+// Send sends an HTML email to the specified recipient
+func (m *SMTP) Send(to, subject, htmlBody string) error {
+	client, err := mail.NewClient(m.host,
+		mail.WithPort(m.port),
+		mail.WithSMTPAuth(mail.SMTPAuthPlain),
+		mail.WithUsername(m.user),
+		mail.WithPassword(m.password),
+		mail.WithTLSPolicy(mail.TLSMandatory),
+		mail.WithTimeout(10*time.Second),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create mail client: %w", err)
+	}
+
+	msg := mail.NewMsg()
+	if m.user != "" {
+		msg.From(m.user)
+	}
+	msg.To(to)
+	msg.Subject(subject)
+	msg.SetBodyString(mail.TypeTextHTML, htmlBody)
+
+	if err := client.DialAndSend(msg); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// Message is an email kept by the memory mailer
+type Message struct {
+	To      string
+	Subject string
+	HTML    string
+}
+
+// Memory keeps the emails it is asked to send, for tests to read
+type Memory struct {
+	mu   sync.Mutex
+	sent []Message
+	err  error
+}
+
+// NewMemory returns a mailer that sends nothing
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+func (m *Memory) Send(to, subject, htmlBody string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return m.err
+	}
+	m.sent = append(m.sent, Message{To: to, Subject: subject, HTML: htmlBody})
+	return nil
+}
+
+// Fail makes the following sends return err without keeping the email, like
+// an unreachable server, until Fail(nil) is called
+func (m *Memory) Fail(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.err = err
+}
+
+// Sent returns the emails sent so far, oldest first
+func (m *Memory) Sent() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Message(nil), m.sent...)
+}
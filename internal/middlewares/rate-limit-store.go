@@ -0,0 +1,235 @@
+package middlewares
+
+import (
+	"context"
+	stdErrors "errors"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	redisPkg "github.com/redis/go-redis/v9"
+
+	"github.com/alibaba0010/postgres-api/internal/clock"
+)
+
+var errRedisUnavailable = stdErrors.New("redis client not connected")
+
+// tokenBucketScript refills the bucket for the time elapsed since the last
+// request, spends one token if there is one, and returns
+// {allowed, remaining, ms until full, ms until the next token}.
+var tokenBucketScript = redisPkg.NewScript(`
+local capacity = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local rate = capacity / window
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1]) or capacity
+local ts = tonumber(state[2]) or now
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * rate)
+
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], window)
+
+local retry = 0
+if allowed == 0 then
+	retry = math.ceil((1 - tokens) / rate)
+end
+return {allowed, math.floor(tokens), math.ceil((capacity - tokens) / rate), retry}
+`)
+
+// slidingWindowScript keeps one sorted set member per allowed request, scored
+// by time, and returns {allowed, remaining, ms until the oldest one leaves the window}.
+var slidingWindowScript = redisPkg.NewScript(`
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+local count = redis.call('ZCARD', KEYS[1])
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', KEYS[1], now, ARGV[4])
+	count = count + 1
+	allowed = 1
+end
+redis.call('PEXPIRE', KEYS[1], window)
+
+local reset = 0
+local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+if oldest[2] then
+	reset = tonumber(oldest[2]) + window - now
+end
+return {allowed, limit - count, reset}
+`)
+
+// RedisRateLimitStore keeps counters in Redis so limits hold across instances.
+// The time of a request is read from Clock, which all instances must agree on.
+type RedisRateLimitStore struct {
+	Client *redisPkg.Client
+	Clock  clock.Clock
+}
+
+func (s *RedisRateLimitStore) Allow(ctx context.Context, key string, policy RateLimitPolicy) (RateLimitResult, error) {
+	if err := policy.validate(); err != nil {
+		return RateLimitResult{}, err
+	}
+	client := s.Client
+	if client == nil {
+		return RateLimitResult{}, errRedisUnavailable
+	}
+
+	now := s.Clock.Now()
+	window := policy.Window.Milliseconds()
+	result := RateLimitResult{Limit: policy.Limit}
+
+	switch policy.Algorithm {
+	case TokenBucket:
+		values, err := tokenBucketScript.Run(ctx, client, []string{key}, policy.Limit, window, now.UnixMilli()).Int64Slice()
+		if err != nil {
+			return RateLimitResult{}, err
+		}
+		result.Allowed = values[0] == 1
+		result.Remaining = int(values[1])
+		result.Reset = time.Duration(values[2]) * time.Millisecond
+		result.RetryAfter = time.Duration(values[3]) * time.Millisecond
+	case SlidingWindow:
+		member := strconv.FormatInt(now.UnixNano(), 10)
+		values, err := slidingWindowScript.Run(ctx, client, []string{key}, policy.Limit, window, now.UnixMilli(), member).Int64Slice()
+		if err != nil {
+			return RateLimitResult{}, err
+		}
+		result.Allowed = values[0] == 1
+		result.Remaining = int(values[1])
+		result.Reset = time.Duration(values[2]) * time.Millisecond
+		if !result.Allowed {
+			result.RetryAfter = result.Reset
+		}
+	default:
+		return RateLimitResult{}, fmt.Errorf("unknown rate limit algorithm %q", policy.Algorithm)
+	}
+	return result, nil
+}
+
+// MemoryRateLimitStore keeps counters in process memory. It implements the
+// same algorithms as the Redis store, for tests and as a fallback while Redis
+// is unavailable; limits are then enforced per instance.
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	clock     clock.Clock
+	buckets   map[string]*memoryBucket
+	windows   map[string]*memoryWindow
+	lastSweep time.Time
+}
+
+type memoryWindow struct {
+	hits   []time.Time
+	length time.Duration
+}
+
+type memoryBucket struct {
+	tokens   float64
+	updated  time.Time
+	lifetime time.Duration
+}
+
+// NewMemoryRateLimitStore returns an empty store reading the time from c
+func NewMemoryRateLimitStore(c clock.Clock) *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		clock:     c,
+		buckets:   make(map[string]*memoryBucket),
+		windows:   make(map[string]*memoryWindow),
+		lastSweep: c.Now(),
+	}
+}
+
+func (s *MemoryRateLimitStore) Allow(ctx context.Context, key string, policy RateLimitPolicy) (RateLimitResult, error) {
+	if err := policy.validate(); err != nil {
+		return RateLimitResult{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+	s.sweep(now)
+	result := RateLimitResult{Limit: policy.Limit}
+
+	switch policy.Algorithm {
+	case TokenBucket:
+		capacity := float64(policy.Limit)
+		// in float, so a window shorter than Limit nanoseconds does not round to 0
+		perToken := float64(policy.Window) / float64(policy.Limit)
+		bucket, ok := s.buckets[key]
+		if !ok {
+			bucket = &memoryBucket{tokens: capacity, updated: now}
+			s.buckets[key] = bucket
+		}
+		bucket.tokens = math.Min(capacity, bucket.tokens+float64(now.Sub(bucket.updated))/perToken)
+		bucket.updated = now
+		bucket.lifetime = policy.Window
+
+		if bucket.tokens >= 1 {
+			bucket.tokens--
+			result.Allowed = true
+		} else {
+			result.RetryAfter = time.Duration((1 - bucket.tokens) * perToken)
+		}
+		result.Remaining = int(bucket.tokens)
+		result.Reset = time.Duration((capacity - bucket.tokens) * perToken)
+	case SlidingWindow:
+		window, ok := s.windows[key]
+		if !ok {
+			window = &memoryWindow{}
+			s.windows[key] = window
+		}
+		window.length = policy.Window
+		cutoff := now.Add(-policy.Window)
+		kept := window.hits[:0]
+		for _, hit := range window.hits {
+			if hit.After(cutoff) {
+				kept = append(kept, hit)
+			}
+		}
+		if len(kept) < policy.Limit {
+			kept = append(kept, now)
+			result.Allowed = true
+		}
+		window.hits = kept
+
+		result.Remaining = policy.Limit - len(kept)
+		if len(kept) > 0 {
+			result.Reset = kept[0].Add(policy.Window).Sub(now)
+		}
+		if !result.Allowed {
+			result.RetryAfter = result.Reset
+		}
+	default:
+		return RateLimitResult{}, fmt.Errorf("unknown rate limit algorithm %q", policy.Algorithm)
+	}
+	return result, nil
+}
+
+// sweep drops idle counters once a minute so the maps do not grow without bound
+func (s *MemoryRateLimitStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+	for key, bucket := range s.buckets {
+		if now.Sub(bucket.updated) > bucket.lifetime {
+			delete(s.buckets, key)
+		}
+	}
+	for key, window := range s.windows {
+		if len(window.hits) == 0 || now.Sub(window.hits[len(window.hits)-1]) > window.length {
+			delete(s.windows, key)
+		}
+	}
+}
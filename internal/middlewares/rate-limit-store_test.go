@@ -0,0 +1,124 @@
+package middlewares
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alibaba0010/postgres-api/internal/clock"
+)
+
+func TestMemoryStoreSlidingWindowFollowsClock(t *testing.T) {
+	fake := clock.NewFake(time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC))
+	store := NewMemoryRateLimitStore(fake)
+	policy := RateLimitPolicy{Name: "test", Limit: 3, Window: time.Minute, Algorithm: SlidingWindow}
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		result, err := store.Allow(ctx, "k", policy)
+		if err != nil || !result.Allowed {
+			t.Fatalf("request %d: allowed=%v err=%v", i+1, result.Allowed, err)
+		}
+		fake.Advance(10 * time.Second)
+	}
+	result, err := store.Allow(ctx, "k", policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Allowed {
+		t.Fatal("fourth request within the window was allowed")
+	}
+	// the first request left the window 60s after it was made, 30s from now
+	if result.RetryAfter != 30*time.Second {
+		t.Fatalf("RetryAfter = %v, want 30s", result.RetryAfter)
+	}
+
+	fake.Advance(30 * time.Second)
+	if result, _ := store.Allow(ctx, "k", policy); !result.Allowed {
+		t.Fatal("request after the oldest one left the window was denied")
+	}
+}
+
+func TestMemoryStoreTokenBucketRefillsByClock(t *testing.T) {
+	fake := clock.NewFake(time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC))
+	store := NewMemoryRateLimitStore(fake)
+	// one token every 10 seconds
+	policy := RateLimitPolicy{Name: "test", Limit: 6, Window: time.Minute, Algorithm: TokenBucket}
+	ctx := context.Background()
+
+	for i := 0; i < 6; i++ {
+		if result, _ := store.Allow(ctx, "k", policy); !result.Allowed {
+			t.Fatalf("burst request %d was denied", i+1)
+		}
+	}
+	result, _ := store.Allow(ctx, "k", policy)
+	if result.Allowed {
+		t.Fatal("request over the burst was allowed")
+	}
+	if result.RetryAfter != 10*time.Second {
+		t.Fatalf("RetryAfter = %v, want 10s", result.RetryAfter)
+	}
+
+	fake.Advance(9 * time.Second)
+	if result, _ := store.Allow(ctx, "k", policy); result.Allowed {
+		t.Fatal("allowed before a token was refilled")
+	}
+	fake.Advance(time.Second)
+	if result, _ := store.Allow(ctx, "k", policy); !result.Allowed {
+		t.Fatal("denied after a token was refilled")
+	}
+}
+
+func TestMemoryStoreSweepsIdleCounters(t *testing.T) {
+	fake := clock.NewFake(time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC))
+	store := NewMemoryRateLimitStore(fake)
+	policy := RateLimitPolicy{Name: "test", Limit: 1, Window: time.Second, Algorithm: SlidingWindow}
+	ctx := context.Background()
+
+	if _, err := store.Allow(ctx, "idle", policy); err != nil {
+		t.Fatal(err)
+	}
+	fake.Advance(2 * time.Minute)
+	if _, err := store.Allow(ctx, "other", policy); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.windows["idle"]; ok {
+		t.Fatal("idle counter was not swept")
+	}
+}
+
+func TestStoresRejectEmptyBudget(t *testing.T) {
+	fake := clock.NewFake(time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC))
+	stores := map[string]RateLimitStore{
+		"memory": NewMemoryRateLimitStore(fake),
+		"redis":  &RedisRateLimitStore{Clock: fake},
+	}
+	policies := []RateLimitPolicy{
+		{Name: "no requests", Limit: 0, Window: time.Minute, Algorithm: TokenBucket},
+		{Name: "negative", Limit: -1, Window: time.Minute, Algorithm: SlidingWindow},
+		{Name: "no window", Limit: 10, Window: 0, Algorithm: TokenBucket},
+		{Name: "sub-millisecond window", Limit: 10, Window: time.Microsecond, Algorithm: SlidingWindow},
+	}
+	for name, store := range stores {
+		for _, policy := range policies {
+			if _, err := store.Allow(context.Background(), "k", policy); err == nil {
+				t.Errorf("%s store accepted policy %q", name, policy.Name)
+			}
+		}
+	}
+}
+
+func TestMemoryStoreTokenBucketWithManyTokensPerNanosecond(t *testing.T) {
+	fake := clock.NewFake(time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC))
+	store := NewMemoryRateLimitStore(fake)
+	// Window / Limit is below a nanosecond
+	policy := RateLimitPolicy{Name: "test", Limit: 10_000_000, Window: time.Millisecond, Algorithm: TokenBucket}
+
+	result, err := store.Allow(context.Background(), "k", policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Allowed || result.Remaining != policy.Limit-1 {
+		t.Fatalf("result %+v, want allowed with %d remaining", result, policy.Limit-1)
+	}
+}
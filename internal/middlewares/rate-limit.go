@@ -0,0 +1,225 @@
+package middlewares
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	redisPkg "github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"github.com/alibaba0010/postgres-api/internal/auth"
+	"github.com/alibaba0010/postgres-api/internal/clock"
+	"github.com/alibaba0010/postgres-api/internal/errors"
+	"github.com/alibaba0010/postgres-api/internal/logger"
+	"github.com/alibaba0010/postgres-api/internal/services"
+	"github.com/alibaba0010/postgres-api/internal/utils"
+)
+
+// RateLimitAlgorithm selects how a policy spends its budget
+type RateLimitAlgorithm string
+
+const (
+	// TokenBucket refills Limit tokens evenly over Window and allows bursts up to Limit
+	TokenBucket RateLimitAlgorithm = "token_bucket"
+	// SlidingWindow allows at most Limit requests in any period of length Window
+	SlidingWindow RateLimitAlgorithm = "sliding_window"
+)
+
+// RateLimitKeyFunc identifies the client a request is counted against
+type RateLimitKeyFunc func(request *http.Request) string
+
+// RateLimitPolicy describes one limit. Name namespaces the counters so the
+// same client has separate budgets under different policies.
+type RateLimitPolicy struct {
+	Name      string
+	Limit     int
+	Window    time.Duration
+	Algorithm RateLimitAlgorithm
+	Key       RateLimitKeyFunc
+	// Store overrides the store of the RateLimiter for this policy
+	Store RateLimitStore
+}
+
+// validate rejects a policy the stores cannot count: the budget needs at
+// least one request, and Redis counts windows in milliseconds
+func (p RateLimitPolicy) validate() error {
+	if p.Limit <= 0 {
+		return fmt.Errorf("rate limit policy %q: limit %d is not positive", p.Name, p.Limit)
+	}
+	if p.Window < time.Millisecond {
+		return fmt.Errorf("rate limit policy %q: window %v is shorter than a millisecond", p.Name, p.Window)
+	}
+	return nil
+}
+
+// RateLimitResult is the outcome of spending one request from a budget
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration // until the budget is full again
+	RetryAfter time.Duration // until the next request is allowed, when denied
+}
+
+// RateLimitStore keeps the counters behind rate limit policies
+type RateLimitStore interface {
+	Allow(ctx context.Context, key string, policy RateLimitPolicy) (RateLimitResult, error)
+}
+
+// RateLimiter builds the rate limit middleware. Its store is used by policies
+// that do not set their own.
+type RateLimiter struct {
+	store RateLimitStore
+	svc   *services.Service
+	log   *zap.Logger
+}
+
+// NewRateLimiter returns a rate limiter counting in store. svc verifies the
+// bearer tokens KeyByUser reads.
+func NewRateLimiter(store RateLimitStore, svc *services.Service, log *zap.Logger) *RateLimiter {
+	return &RateLimiter{store: store, svc: svc, log: log}
+}
+
+// logger returns the logger of the request, which carries its ID
+func (l *RateLimiter) logger(request *http.Request) *zap.Logger {
+	return logger.FromContextOr(request.Context(), l.log)
+}
+
+// NewFallbackRateLimitStore counts in Redis and falls back to process memory
+// while Redis is unavailable, both reading the time from c
+func NewFallbackRateLimitStore(client *redisPkg.Client, log *zap.Logger, c clock.Clock) RateLimitStore {
+	return &FallbackRateLimitStore{
+		Primary:  &RedisRateLimitStore{Client: client, Clock: c},
+		Fallback: NewMemoryRateLimitStore(c),
+		Log:      log,
+	}
+}
+
+// RateLimit returns middleware that enforces policy. Every response carries
+// the RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset and
+// RateLimit-Policy headers; requests over the limit get a 429 with Retry-After.
+// It panics on a policy without a positive limit and window, which
+// config.Validate rules out for the configured ones.
+func (l *RateLimiter) RateLimit(policy RateLimitPolicy) func(http.Handler) http.Handler {
+	if err := policy.validate(); err != nil {
+		panic(err)
+	}
+	if policy.Algorithm == "" {
+		policy.Algorithm = SlidingWindow
+	}
+	if policy.Key == nil {
+		policy.Key = KeyByIP
+	}
+	if policy.Store == nil {
+		policy.Store = l.store
+	}
+	policyHeader := strconv.Itoa(policy.Limit) + ";w=" + strconv.Itoa(int(policy.Window/time.Second))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			key := "ratelimit:" + policy.Name + ":" + policy.Key(request)
+			result, err := policy.Store.Allow(request.Context(), key, policy)
+			if err != nil {
+				// Fail open: losing the counters must not take the API down
+				l.logger(request).Error("rate limit check failed", zap.Error(err), zap.String("policy", policy.Name))
+				next.ServeHTTP(writer, request)
+				return
+			}
+
+			writer.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+			writer.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			writer.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+			writer.Header().Set("RateLimit-Policy", policyHeader)
+
+			if !result.Allowed {
+				l.logger(request).Warn("rate limit exceeded", zap.String("policy", policy.Name), zap.String("key", key))
+				errors.ErrorResponse(writer, request, errors.TooManyRequestsError("rate limit exceeded, try again later", result.RetryAfter))
+				return
+			}
+			next.ServeHTTP(writer, request)
+		})
+	}
+}
+
+// KeyByIP counts requests per client IP. Forwarded headers only name the
+// client when a trusted proxy sent them, so forging one does not open a
+// fresh bucket.
+func KeyByIP(request *http.Request) string {
+	return "ip:" + utils.ExtractClientIP(request)
+}
+
+// KeyByUser counts requests per authenticated user, falling back to the client
+// IP for anonymous requests. It also works in front of AuthMiddleware by
+// reading the bearer token itself.
+func (l *RateLimiter) KeyByUser(request *http.Request) string {
+	if user := auth.FromContext(request.Context()); user != nil {
+		return "user:" + user.UserID
+	}
+	if token, ok := authorizationCredential(request, "Bearer"); ok {
+		if claims, appErr := l.svc.VerifyAccessToken(token); appErr == nil {
+			return "user:" + claims.UserID
+		}
+	}
+	return KeyByIP(request)
+}
+
+// KeyByAPIKey counts requests per API key, sent as "Authorization: ApiKey
+// <key>", and falls back to KeyByUser. Only a live key gets a budget of its
+// own, under its ID; made-up keys count against the client like any other
+// anonymous request.
+func (l *RateLimiter) KeyByAPIKey(request *http.Request) string {
+	apiKey, ok := authorizationCredential(request, "ApiKey")
+	if !ok {
+		return l.KeyByUser(request)
+	}
+	keyID, ok := l.svc.APIKeyID(request.Context(), apiKey)
+	if !ok {
+		return l.KeyByUser(request)
+	}
+	return "apikey:" + keyID
+}
+
+func authorizationCredential(request *http.Request, scheme string) (string, bool) {
+	parts := strings.SplitN(request.Header.Get("Authorization"), " ", 2)
+	if len(parts) != 2 || parts[0] != scheme || parts[1] == "" {
+		return "", false
+	}
+	return parts[1], true
+}
+
+// FallbackRateLimitStore uses Primary and switches to Fallback for as long as
+// Primary returns errors.
+type FallbackRateLimitStore struct {
+	Primary  RateLimitStore
+	Fallback RateLimitStore
+	// Log reports the switches between the stores
+	Log *zap.Logger
+
+	degraded atomic.Bool
+}
+
+func (s *FallbackRateLimitStore) Allow(ctx context.Context, key string, policy RateLimitPolicy) (RateLimitResult, error) {
+	result, err := s.Primary.Allow(ctx, key, policy)
+	if err == nil {
+		if s.degraded.CompareAndSwap(true, false) {
+			s.Log.Info("rate limiter using primary store again")
+		}
+		return result, nil
+	}
+	if s.degraded.CompareAndSwap(false, true) {
+		s.Log.Warn("rate limiter falling back to in-memory store", zap.Error(err))
+	}
+	return s.Fallback.Allow(ctx, key, policy)
+}
+
+func ceilSeconds(d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+	return int((d + time.Second - 1) / time.Second)
+}
@@ -0,0 +1,20 @@
+package middlewares
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/alibaba0010/postgres-api/internal/clock"
+)
+
+func TestRateLimitPanicsOnEmptyBudget(t *testing.T) {
+	limiter := NewRateLimiter(NewMemoryRateLimitStore(clock.NewFake(time.Now())), nil, zap.NewNop())
+	defer func() {
+		if recover() == nil {
+			t.Fatal("RateLimit accepted a policy with no requests")
+		}
+	}()
+	limiter.RateLimit(RateLimitPolicy{Name: "test", Limit: 0, Window: time.Minute})
+}
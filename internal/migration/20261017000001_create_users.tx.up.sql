@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS users (
+    id          UUID PRIMARY KEY,
+    name        VARCHAR(50)  NOT NULL,
+    email       VARCHAR(255) NOT NULL UNIQUE,
+    password    TEXT         NOT NULL,
+    address     TEXT,
+    role        VARCHAR(20)  NOT NULL DEFAULT 'user',
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT current_timestamp,
+    updated_at  TIMESTAMPTZ  NOT NULL DEFAULT current_timestamp
+);
+
+--bun:split
+
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id          UUID PRIMARY KEY,
+    user_id     UUID        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    token       TEXT        NOT NULL UNIQUE,
+    ip_address  VARCHAR(64),
+    user_agent  TEXT,
+    expires_at  TIMESTAMPTZ NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT current_timestamp
+);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id ON refresh_tokens (user_id);
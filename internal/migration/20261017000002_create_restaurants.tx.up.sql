@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS restaurants (
+    id            UUID PRIMARY KEY,
+    owner_id      UUID          NOT NULL REFERENCES users (id),
+    name          VARCHAR(100)  NOT NULL,
+    description   VARCHAR(500),
+    address       VARCHAR(200)  NOT NULL,
+    cuisine_type  VARCHAR(50),
+    rating        NUMERIC(2, 1) NOT NULL DEFAULT 0 CHECK (rating >= 0 AND rating <= 5),
+    created_at    TIMESTAMPTZ   NOT NULL DEFAULT current_timestamp,
+    updated_at    TIMESTAMPTZ   NOT NULL DEFAULT current_timestamp,
+    deleted_at    TIMESTAMPTZ
+);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS idx_restaurants_owner_id ON restaurants (owner_id);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS menu_items;
+
+--bun:split
+
+DROP TABLE IF EXISTS menus;
@@ -0,0 +1,38 @@
+CREATE TABLE IF NOT EXISTS menus (
+    id             UUID PRIMARY KEY,
+    restaurant_id  UUID         NOT NULL REFERENCES restaurants (id) ON DELETE CASCADE,
+    name           VARCHAR(100) NOT NULL,
+    type           VARCHAR(20)  NOT NULL,
+    description    VARCHAR(500),
+    is_active      BOOLEAN      NOT NULL DEFAULT TRUE,
+    created_at     TIMESTAMPTZ  NOT NULL DEFAULT current_timestamp,
+    updated_at     TIMESTAMPTZ  NOT NULL DEFAULT current_timestamp
+);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS idx_menus_restaurant_id ON menus (restaurant_id);
+
+--bun:split
+
+CREATE TABLE IF NOT EXISTS menu_items (
+    id               UUID PRIMARY KEY,
+    menu_id          UUID         NOT NULL REFERENCES menus (id) ON DELETE CASCADE,
+    restaurant_id    UUID         NOT NULL REFERENCES restaurants (id) ON DELETE CASCADE,
+    category         VARCHAR(50)  NOT NULL,
+    name             VARCHAR(100) NOT NULL,
+    description      VARCHAR(500),
+    price_cents      BIGINT       NOT NULL CHECK (price_cents >= 0),
+    currency         CHAR(3)      NOT NULL DEFAULT 'USD',
+    allergens        TEXT[]       NOT NULL DEFAULT '{}',
+    dietary_tags     TEXT[]       NOT NULL DEFAULT '{}',
+    is_available     BOOLEAN      NOT NULL DEFAULT TRUE,
+    available_from   VARCHAR(5),
+    available_until  VARCHAR(5),
+    created_at       TIMESTAMPTZ  NOT NULL DEFAULT current_timestamp,
+    updated_at       TIMESTAMPTZ  NOT NULL DEFAULT current_timestamp
+);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS idx_menu_items_menu_id ON menu_items (menu_id);
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS order_transitions;
+
+--bun:split
+
+DROP TABLE IF EXISTS order_items;
+
+--bun:split
+
+DROP TABLE IF EXISTS orders;
@@ -0,0 +1,55 @@
+CREATE TABLE IF NOT EXISTS orders (
+    id              UUID PRIMARY KEY,
+    restaurant_id   UUID         NOT NULL REFERENCES restaurants (id),
+    customer_id     UUID         NOT NULL REFERENCES users (id),
+    type            VARCHAR(20)  NOT NULL,
+    status          VARCHAR(20)  NOT NULL,
+    table_number    VARCHAR(20),
+    notes           VARCHAR(500),
+    subtotal_cents  BIGINT       NOT NULL CHECK (subtotal_cents >= 0),
+    currency        CHAR(3)      NOT NULL,
+    created_at      TIMESTAMPTZ  NOT NULL DEFAULT current_timestamp,
+    updated_at      TIMESTAMPTZ  NOT NULL DEFAULT current_timestamp
+);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS idx_orders_customer_id ON orders (customer_id, created_at DESC);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS idx_orders_restaurant_status ON orders (restaurant_id, status);
+
+--bun:split
+
+CREATE TABLE IF NOT EXISTS order_items (
+    id                UUID PRIMARY KEY,
+    order_id          UUID         NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    menu_item_id      UUID         REFERENCES menu_items (id) ON DELETE SET NULL,
+    name              VARCHAR(100) NOT NULL,
+    unit_price_cents  BIGINT       NOT NULL CHECK (unit_price_cents >= 0),
+    quantity          INTEGER      NOT NULL CHECK (quantity > 0),
+    total_cents       BIGINT       NOT NULL CHECK (total_cents >= 0),
+    notes             VARCHAR(200)
+);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS idx_order_items_order_id ON order_items (order_id);
+
+--bun:split
+
+CREATE TABLE IF NOT EXISTS order_transitions (
+    id           UUID PRIMARY KEY,
+    order_id     UUID         NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    from_status  VARCHAR(20),
+    to_status    VARCHAR(20)  NOT NULL,
+    actor_id     UUID         NOT NULL REFERENCES users (id),
+    actor_role   VARCHAR(20)  NOT NULL,
+    reason       VARCHAR(500),
+    created_at   TIMESTAMPTZ  NOT NULL DEFAULT current_timestamp
+);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS idx_order_transitions_order_id ON order_transitions (order_id, created_at);
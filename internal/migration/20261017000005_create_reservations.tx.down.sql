@@ -0,0 +1,13 @@
+DROP TABLE IF EXISTS reservations;
+
+--bun:split
+
+DROP TABLE IF EXISTS opening_hours;
+
+--bun:split
+
+DROP TABLE IF EXISTS restaurant_tables;
+
+--bun:split
+
+ALTER TABLE restaurants DROP COLUMN IF EXISTS timezone;
@@ -0,0 +1,65 @@
+ALTER TABLE restaurants ADD COLUMN IF NOT EXISTS timezone VARCHAR(64) NOT NULL DEFAULT 'UTC';
+
+--bun:split
+
+-- btree_gist lets the exclusion constraint below compare UUIDs with "="
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+--bun:split
+
+CREATE TABLE IF NOT EXISTS restaurant_tables (
+    id             UUID PRIMARY KEY,
+    restaurant_id  UUID        NOT NULL REFERENCES restaurants (id) ON DELETE CASCADE,
+    label          VARCHAR(20) NOT NULL,
+    capacity       INTEGER     NOT NULL CHECK (capacity > 0),
+    section        VARCHAR(50),
+    is_active      BOOLEAN     NOT NULL DEFAULT TRUE,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    updated_at     TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    UNIQUE (restaurant_id, label)
+);
+
+--bun:split
+
+CREATE TABLE IF NOT EXISTS opening_hours (
+    id             UUID PRIMARY KEY,
+    restaurant_id  UUID       NOT NULL REFERENCES restaurants (id) ON DELETE CASCADE,
+    weekday        SMALLINT   NOT NULL CHECK (weekday BETWEEN 0 AND 6),
+    opens_at       VARCHAR(5) NOT NULL,
+    closes_at      VARCHAR(5) NOT NULL
+);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS idx_opening_hours_restaurant_id ON opening_hours (restaurant_id, weekday);
+
+--bun:split
+
+CREATE TABLE IF NOT EXISTS reservations (
+    id                UUID PRIMARY KEY,
+    restaurant_id     UUID         NOT NULL REFERENCES restaurants (id),
+    table_id          UUID         NOT NULL REFERENCES restaurant_tables (id),
+    customer_id       UUID         NOT NULL REFERENCES users (id),
+    party_size        INTEGER      NOT NULL CHECK (party_size > 0),
+    starts_at         TIMESTAMPTZ  NOT NULL,
+    ends_at           TIMESTAMPTZ  NOT NULL,
+    status            VARCHAR(20)  NOT NULL,
+    notes             VARCHAR(500),
+    reminder_sent_at  TIMESTAMPTZ,
+    created_at        TIMESTAMPTZ  NOT NULL DEFAULT current_timestamp,
+    updated_at        TIMESTAMPTZ  NOT NULL DEFAULT current_timestamp,
+    CHECK (ends_at > starts_at),
+    -- A table can never hold two confirmed bookings whose time ranges overlap.
+    CONSTRAINT reservations_no_overlap EXCLUDE USING gist (
+        table_id WITH =,
+        tstzrange(starts_at, ends_at) WITH &&
+    ) WHERE (status = 'confirmed')
+);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS idx_reservations_restaurant_starts ON reservations (restaurant_id, starts_at);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS idx_reservations_customer_id ON reservations (customer_id, starts_at DESC);
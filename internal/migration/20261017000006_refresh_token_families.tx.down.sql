@@ -0,0 +1,13 @@
+DROP INDEX IF EXISTS idx_refresh_tokens_expires_at;
+
+--bun:split
+
+DROP INDEX IF EXISTS idx_refresh_tokens_family_id;
+
+--bun:split
+
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS rotated_at;
+
+--bun:split
+
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS family_id;
@@ -0,0 +1,24 @@
+-- Every refresh token belongs to a family: the chain of tokens issued from a
+-- single sign-in. Rotated tokens are kept (rotated_at set) until they expire
+-- so that replaying one can be detected and the whole family revoked.
+ALTER TABLE refresh_tokens ADD COLUMN IF NOT EXISTS family_id UUID;
+
+--bun:split
+
+UPDATE refresh_tokens SET family_id = id WHERE family_id IS NULL;
+
+--bun:split
+
+ALTER TABLE refresh_tokens ALTER COLUMN family_id SET NOT NULL;
+
+--bun:split
+
+ALTER TABLE refresh_tokens ADD COLUMN IF NOT EXISTS rotated_at TIMESTAMPTZ;
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON refresh_tokens (family_id);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_expires_at ON refresh_tokens (expires_at);
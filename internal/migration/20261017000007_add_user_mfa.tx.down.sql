@@ -0,0 +1,13 @@
+DROP TABLE IF EXISTS mfa_recovery_codes;
+
+--bun:split
+
+ALTER TABLE users DROP COLUMN IF EXISTS mfa_last_step;
+
+--bun:split
+
+ALTER TABLE users DROP COLUMN IF EXISTS mfa_secret;
+
+--bun:split
+
+ALTER TABLE users DROP COLUMN IF EXISTS mfa_enabled;
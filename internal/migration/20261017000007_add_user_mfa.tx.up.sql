@@ -0,0 +1,27 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS mfa_enabled BOOLEAN NOT NULL DEFAULT false;
+
+--bun:split
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS mfa_secret TEXT;
+
+--bun:split
+
+-- last accepted TOTP time step; a code is only accepted for a later step
+ALTER TABLE users ADD COLUMN IF NOT EXISTS mfa_last_step BIGINT;
+
+--bun:split
+
+CREATE TABLE IF NOT EXISTS mfa_recovery_codes (
+    id          UUID PRIMARY KEY,
+    user_id     UUID        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    code_hash   VARCHAR(64) NOT NULL,
+    used_at     TIMESTAMPTZ,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    UNIQUE (user_id, code_hash)
+);
+
+--bun:split
+
+-- Admins must now sign in with two-factor authentication: end their existing
+-- sessions so the next sign-in walks them through enrollment.
+DELETE FROM refresh_tokens WHERE user_id IN (SELECT id FROM users WHERE role = 'admin');
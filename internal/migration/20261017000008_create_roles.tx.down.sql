@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS role_assignments;
+
+--bun:split
+
+DROP TABLE IF EXISTS roles;
@@ -0,0 +1,40 @@
+CREATE TABLE IF NOT EXISTS roles (
+    id           UUID PRIMARY KEY,
+    name         VARCHAR(50)  NOT NULL UNIQUE,
+    description  VARCHAR(255),
+    permissions  TEXT[]       NOT NULL DEFAULT '{}',
+    built_in     BOOLEAN      NOT NULL DEFAULT false,
+    created_at   TIMESTAMPTZ  NOT NULL DEFAULT current_timestamp,
+    updated_at   TIMESTAMPTZ  NOT NULL DEFAULT current_timestamp
+);
+
+--bun:split
+
+-- A role granted to a user within one restaurant
+CREATE TABLE IF NOT EXISTS role_assignments (
+    id             UUID PRIMARY KEY,
+    user_id        UUID        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    role_id        UUID        NOT NULL REFERENCES roles (id) ON DELETE CASCADE,
+    restaurant_id  UUID        NOT NULL REFERENCES restaurants (id) ON DELETE CASCADE,
+    granted_by     UUID        REFERENCES users (id) ON DELETE SET NULL,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    UNIQUE (user_id, role_id, restaurant_id)
+);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS idx_role_assignments_restaurant_id ON role_assignments (restaurant_id);
+
+--bun:split
+
+-- Built-in roles. user, management and admin match users.role and apply
+-- everywhere; owner applies on every restaurant a user owns.
+INSERT INTO roles (id, name, description, permissions, built_in) VALUES
+    ('01920000-0000-7000-8000-000000000001', 'user', 'Customer account', '{}', true),
+    ('01920000-0000-7000-8000-000000000002', 'management', 'Restaurant operator; runs the restaurants they own',
+        '{restaurant:create}', true),
+    ('01920000-0000-7000-8000-000000000003', 'owner', 'Full control of one restaurant',
+        '{restaurant:write,restaurant:delete,menu:write,order:read,order:advance,reservation:manage,table:manage,staff:manage,report:read}', true),
+    ('01920000-0000-7000-8000-000000000004', 'admin', 'Full access to the platform',
+        '{restaurant:create,user:manage,role:manage,restaurant:write,restaurant:delete,menu:write,order:read,order:advance,reservation:manage,table:manage,staff:manage,report:read}', true)
+ON CONFLICT (name) DO NOTHING;
@@ -0,0 +1,9 @@
+DELETE FROM roles WHERE id IN (
+    '01920000-0000-7000-8000-000000000005',
+    '01920000-0000-7000-8000-000000000006',
+    '01920000-0000-7000-8000-000000000007'
+);
+
+--bun:split
+
+DROP TABLE IF EXISTS staff_invitations;
@@ -0,0 +1,31 @@
+-- An invitation to join a restaurant's staff with a role. The emailed token
+-- is a signed JWT naming the invitation; the row makes it single-use and
+-- revocable.
+CREATE TABLE IF NOT EXISTS staff_invitations (
+    id             UUID PRIMARY KEY,
+    restaurant_id  UUID         NOT NULL REFERENCES restaurants (id) ON DELETE CASCADE,
+    role_id        UUID         NOT NULL REFERENCES roles (id) ON DELETE CASCADE,
+    email          VARCHAR(255) NOT NULL,
+    invited_by     UUID         REFERENCES users (id) ON DELETE SET NULL,
+    expires_at     TIMESTAMPTZ  NOT NULL,
+    accepted_at    TIMESTAMPTZ,
+    accepted_by    UUID         REFERENCES users (id) ON DELETE SET NULL,
+    revoked_at     TIMESTAMPTZ,
+    created_at     TIMESTAMPTZ  NOT NULL DEFAULT current_timestamp
+);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS idx_staff_invitations_restaurant_id ON staff_invitations (restaurant_id, created_at DESC);
+
+--bun:split
+
+-- Built-in staff roles managers can invite people with
+INSERT INTO roles (id, name, description, permissions, built_in) VALUES
+    ('01920000-0000-7000-8000-000000000005', 'cook', 'Kitchen staff; works the order queue',
+        '{order:read,order:advance}', true),
+    ('01920000-0000-7000-8000-000000000006', 'waiter', 'Front of house; serves orders and seats reservations',
+        '{order:read,order:advance,reservation:manage}', true),
+    ('01920000-0000-7000-8000-000000000007', 'cashier', 'Takes payments and reads sales reports',
+        '{order:read,order:advance,report:read}', true)
+ON CONFLICT (name) DO NOTHING;
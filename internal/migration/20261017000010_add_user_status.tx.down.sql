@@ -0,0 +1,39 @@
+DROP INDEX IF EXISTS idx_users_created_at;
+
+--bun:split
+
+-- No rows are deleted to make the rollback fit: a soft-deleted account may
+-- still own restaurants, orders and reservations. While an address is used by
+-- a soft-deleted account and by a live one, the plain unique constraint cannot
+-- come back and the rollback stops here; resolve the duplicates by hand first.
+DO $$
+DECLARE
+    duplicates TEXT;
+BEGIN
+    SELECT string_agg(email, ', ') INTO duplicates
+    FROM (SELECT email FROM users GROUP BY email HAVING count(*) > 1 LIMIT 10) AS d;
+    IF duplicates IS NOT NULL THEN
+        RAISE EXCEPTION 'cannot restore UNIQUE (email) on users: addresses held by more than one account, including deleted ones: %', duplicates;
+    END IF;
+END
+$$;
+
+--bun:split
+
+DROP INDEX IF EXISTS users_email_key;
+
+--bun:split
+
+ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE (email);
+
+--bun:split
+
+ALTER TABLE users DROP COLUMN IF EXISTS deleted_at;
+
+--bun:split
+
+ALTER TABLE users DROP COLUMN IF EXISTS suspended_reason;
+
+--bun:split
+
+ALTER TABLE users DROP COLUMN IF EXISTS suspended_at;
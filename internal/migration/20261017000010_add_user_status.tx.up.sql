@@ -0,0 +1,23 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS suspended_at TIMESTAMPTZ;
+
+--bun:split
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS suspended_reason VARCHAR(255);
+
+--bun:split
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+--bun:split
+
+-- Soft-deleted accounts keep their row; only live accounts need a unique
+-- email so the address can sign up again.
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_key;
+
+--bun:split
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_key ON users (email) WHERE deleted_at IS NULL;
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS idx_users_created_at ON users (created_at);
@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS phone VARCHAR(20);
@@ -0,0 +1,12 @@
+-- Accounts at external OpenID Connect providers that can sign in as a user
+CREATE TABLE IF NOT EXISTS user_identities (
+    id            UUID PRIMARY KEY,
+    user_id       UUID         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    provider      VARCHAR(50)  NOT NULL,
+    subject       VARCHAR(255) NOT NULL,
+    email         VARCHAR(255),
+    created_at    TIMESTAMPTZ  NOT NULL DEFAULT current_timestamp,
+    last_used_at  TIMESTAMPTZ  NOT NULL DEFAULT current_timestamp,
+    UNIQUE (provider, subject),
+    UNIQUE (user_id, provider)
+);
@@ -0,0 +1,19 @@
+-- Keys that let devices such as POS terminals act for a user in one
+-- restaurant. Only the SHA-256 of the secret part is stored; the prefix
+-- identifies the key.
+CREATE TABLE IF NOT EXISTS api_keys (
+    id             UUID PRIMARY KEY,
+    user_id        UUID         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    restaurant_id  UUID         NOT NULL REFERENCES restaurants (id) ON DELETE CASCADE,
+    name           VARCHAR(100) NOT NULL,
+    prefix         VARCHAR(16)  NOT NULL UNIQUE,
+    key_hash       VARCHAR(64)  NOT NULL,
+    expires_at     TIMESTAMPTZ  NOT NULL,
+    last_used_at   TIMESTAMPTZ,
+    revoked_at     TIMESTAMPTZ,
+    created_at     TIMESTAMPTZ  NOT NULL DEFAULT current_timestamp
+);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys (user_id);
@@ -0,0 +1,28 @@
+package migration
+
+import (
+	"embed"
+
+	"github.com/uptrace/bun/migrate"
+)
+
+// sqlMigrations embeds every *.up.sql / *.down.sql file in this directory so
+// the migrate binary does not depend on the working directory it runs from.
+//
+//go:embed *.sql
+var sqlMigrations embed.FS
+
+// Migrations is the registry shared by the migrate command. New SQL files
+// created with `./migrate.sh create <name>` land next to this file.
+var Migrations = migrate.NewMigrations()
+
+func init() {
+	if err := Migrations.Discover(sqlMigrations); err != nil {
+		panic(err)
+	}
+}
+
+// New returns the project's migration registry.
+func New() *migrate.Migrations {
+	return Migrations
+}
@@ -0,0 +1,75 @@
+package migration_test
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/alibaba0010/postgres-api/internal/apptest"
+	"github.com/alibaba0010/postgres-api/internal/models"
+)
+
+const userStatusDown = "20261017000010_add_user_status.tx.down.sql"
+
+// runDown runs the statements of a down migration in the test's transaction,
+// which is rolled back when the test ends
+func runDown(t *testing.T, env *apptest.Env, file string) error {
+	t.Helper()
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, statement := range strings.Split(string(data), "--bun:split") {
+		if _, err := env.DB.ExecContext(context.Background(), statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func softDelete(t *testing.T, env *apptest.Env, user *models.User) {
+	t.Helper()
+	if _, err := env.DB.NewUpdate().Model((*models.User)(nil)).
+		Set("deleted_at = ?", env.Clock.Now()).
+		Where("id = ?", user.ID).
+		Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUserStatusDownKeepsDeletedUsers(t *testing.T) {
+	env := apptest.NewWithDB(t)
+	owner := env.CreateUser(t, "management")
+	env.CreateRestaurant(t, owner)
+	softDelete(t, env, owner)
+
+	if err := runDown(t, env, userStatusDown); err != nil {
+		t.Fatalf("down migration: %v", err)
+	}
+	var count int
+	if err := env.DB.NewRaw("SELECT count(*) FROM users WHERE id = ?", owner.ID).Scan(context.Background(), &count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Fatal("down migration deleted a soft-deleted user")
+	}
+}
+
+func TestUserStatusDownRefusesDuplicateEmails(t *testing.T) {
+	env := apptest.NewWithDB(t)
+	deleted := env.CreateUser(t, "user")
+	softDelete(t, env, deleted)
+	// the address signed up again after the deletion
+	again := &models.User{ID: uuid.Must(uuid.NewV7()).String(), Name: "Again", Email: deleted.Email, Password: "!", Role: "user"}
+	if _, err := env.DB.NewInsert().Model(again).Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	err := runDown(t, env, userStatusDown)
+	if err == nil || !strings.Contains(err.Error(), "cannot restore UNIQUE (email)") || !strings.Contains(err.Error(), deleted.Email) {
+		t.Fatalf("down migration: %v, want it refused naming %s", err, deleted.Email)
+	}
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// APIKey lets a device act for UserID within RestaurantID without signing in.
+// The key is shown once at creation; only the hash of its secret is kept and
+// Prefix, which is part of the key, finds the row.
+type APIKey struct {
+	bun.BaseModel `bun:"table:api_keys"`
+
+	ID           string    `bun:",pk" json:"id"`
+	UserID       string    `bun:",notnull" json:"user_id"`
+	RestaurantID string    `bun:",notnull" json:"restaurant_id"`
+	Name         string    `bun:",notnull" json:"name"`
+	Prefix       string    `bun:",unique,notnull" json:"prefix"`
+	KeyHash      string    `bun:",notnull" json:"-"`
+	ExpiresAt    time.Time `bun:",notnull" json:"expires_at"`
+	LastUsedAt   time.Time `bun:",nullzero" json:"last_used_at"`
+	RevokedAt    time.Time `bun:",nullzero" json:"-"`
+	CreatedAt    time.Time `bun:",nullzero,notnull,default:current_timestamp" json:"created_at"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// UserIdentity links a user to their account at an OpenID Connect provider.
+// Subject is the provider's stable user ID; a user has at most one identity
+// per provider.
+type UserIdentity struct {
+	bun.BaseModel `bun:"table:user_identities"`
+
+	ID         string    `bun:",pk" json:"id"`
+	UserID     string    `bun:",notnull" json:"user_id"`
+	Provider   string    `bun:",notnull" json:"provider"`
+	Subject    string    `bun:",notnull" json:"-"`
+	Email      string    `bun:",nullzero" json:"email,omitempty"`
+	CreatedAt  time.Time `bun:",nullzero,notnull,default:current_timestamp" json:"created_at"`
+	LastUsedAt time.Time `bun:",nullzero,notnull,default:current_timestamp" json:"last_used_at"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// Invitation statuses, derived from the timestamps of a StaffInvitation
+const (
+	InvitationStatusPending  = "pending"
+	InvitationStatusAccepted = "accepted"
+	InvitationStatusRevoked  = "revoked"
+	InvitationStatusExpired  = "expired"
+)
+
+// StaffInvitation invites an email to join a restaurant's staff with a role.
+// Accepting it creates the account if needed and assigns the role.
+type StaffInvitation struct {
+	bun.BaseModel `bun:"table:staff_invitations"`
+
+	ID           string      `bun:",pk" json:"id"`
+	RestaurantID string      `bun:",notnull" json:"restaurant_id"`
+	RoleID       string      `bun:",notnull" json:"role_id"`
+	Email        string      `bun:",notnull" json:"email"`
+	InvitedBy    string      `bun:",nullzero" json:"invited_by,omitempty"`
+	ExpiresAt    time.Time   `bun:",notnull" json:"expires_at"`
+	AcceptedAt   time.Time   `bun:",nullzero" json:"accepted_at,omitempty"`
+	AcceptedBy   string      `bun:",nullzero" json:"accepted_by,omitempty"`
+	RevokedAt    time.Time   `bun:",nullzero" json:"revoked_at,omitempty"`
+	Role         *Role       `bun:"rel:belongs-to,join:role_id=id" json:"role,omitempty"`
+	Restaurant   *Restaurant `bun:"rel:belongs-to,join:restaurant_id=id" json:"-"`
+	CreatedAt    time.Time   `bun:",nullzero,notnull,default:current_timestamp" json:"created_at"`
+}
+
+// Status reports where the invitation stands at now
+func (i *StaffInvitation) Status(now time.Time) string {
+	switch {
+	case !i.AcceptedAt.IsZero():
+		return InvitationStatusAccepted
+	case !i.RevokedAt.IsZero():
+		return InvitationStatusRevoked
+	case now.After(i.ExpiresAt):
+		return InvitationStatusExpired
+	default:
+		return InvitationStatusPending
+	}
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// MenuType groups menus by the part of the day they are served
+const (
+	MenuTypeBreakfast = "breakfast"
+	MenuTypeLunch     = "lunch"
+	MenuTypeDinner    = "dinner"
+	MenuTypeAllDay    = "all_day"
+)
+
+type Menu struct {
+	bun.BaseModel `bun:"table:menus"`
+
+	ID           string     `bun:",pk" json:"id"`
+	RestaurantID string     `bun:",notnull" json:"restaurant_id"`
+	Name         string     `bun:",notnull" json:"name"`
+	Type         string     `bun:",notnull" json:"type"`
+	Description  string     `bun:",nullzero" json:"description,omitempty"`
+	IsActive     bool       `bun:",notnull,default:true" json:"is_active"`
+	Items        []MenuItem `bun:"rel:has-many,join:id=menu_id" json:"items,omitempty"`
+	CreatedAt    time.Time  `bun:",nullzero,notnull,default:current_timestamp" json:"created_at"`
+	UpdatedAt    time.Time  `bun:",nullzero,notnull,default:current_timestamp" json:"updated_at"`
+}
+
+// MenuItem is a dish or drink on a menu. Prices are stored in minor units
+// (e.g. cents) to avoid floating point rounding. AvailableFrom/AvailableUntil
+// are optional "HH:MM" windows during which the item can be ordered.
+type MenuItem struct {
+	bun.BaseModel `bun:"table:menu_items"`
+
+	ID             string    `bun:",pk" json:"id"`
+	MenuID         string    `bun:",notnull" json:"menu_id"`
+	RestaurantID   string    `bun:",notnull" json:"restaurant_id"`
+	Category       string    `bun:",notnull" json:"category"`
+	Name           string    `bun:",notnull" json:"name"`
+	Description    string    `bun:",nullzero" json:"description,omitempty"`
+	PriceCents     int64     `bun:",notnull" json:"price_cents"`
+	Currency       string    `bun:",notnull,default:'USD'" json:"currency"`
+	Allergens      []string  `bun:",array" json:"allergens"`
+	DietaryTags    []string  `bun:",array" json:"dietary_tags"`
+	IsAvailable    bool      `bun:",notnull,default:true" json:"is_available"`
+	AvailableFrom  string    `bun:",nullzero" json:"available_from,omitempty"`
+	AvailableUntil string    `bun:",nullzero" json:"available_until,omitempty"`
+	CreatedAt      time.Time `bun:",nullzero,notnull,default:current_timestamp" json:"created_at"`
+	UpdatedAt      time.Time `bun:",nullzero,notnull,default:current_timestamp" json:"updated_at"`
+}
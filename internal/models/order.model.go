@@ -0,0 +1,77 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// Order statuses. The allowed transitions between them are enforced in
+// services.TransitionOrder.
+const (
+	OrderStatusPending   = "pending"
+	OrderStatusAccepted  = "accepted"
+	OrderStatusPreparing = "preparing"
+	OrderStatusReady     = "ready"
+	OrderStatusServed    = "served"
+	OrderStatusDelivered = "delivered"
+	OrderStatusPaid      = "paid"
+	OrderStatusCancelled = "cancelled"
+	OrderStatusRefunded  = "refunded"
+)
+
+// Order types decide whether a ready order is served at a table or handed over
+const (
+	OrderTypeDineIn   = "dine_in"
+	OrderTypeTakeaway = "takeaway"
+	OrderTypeDelivery = "delivery"
+)
+
+type Order struct {
+	bun.BaseModel `bun:"table:orders"`
+
+	ID            string            `bun:",pk" json:"id"`
+	RestaurantID  string            `bun:",notnull" json:"restaurant_id"`
+	CustomerID    string            `bun:",notnull" json:"customer_id"`
+	Type          string            `bun:",notnull" json:"type"`
+	Status        string            `bun:",notnull" json:"status"`
+	TableNumber   string            `bun:",nullzero" json:"table_number,omitempty"`
+	Notes         string            `bun:",nullzero" json:"notes,omitempty"`
+	SubtotalCents int64             `bun:",notnull" json:"subtotal_cents"`
+	Currency      string            `bun:",notnull" json:"currency"`
+	Items         []OrderItem       `bun:"rel:has-many,join:id=order_id" json:"items,omitempty"`
+	Transitions   []OrderTransition `bun:"rel:has-many,join:id=order_id" json:"transitions,omitempty"`
+	CreatedAt     time.Time         `bun:",nullzero,notnull,default:current_timestamp" json:"created_at"`
+	UpdatedAt     time.Time         `bun:",nullzero,notnull,default:current_timestamp" json:"updated_at"`
+}
+
+// OrderItem is a line of an order. Name and unit price are copied from the
+// menu item at order time so later menu edits do not rewrite history; the
+// menu item reference is cleared if that item is deleted.
+type OrderItem struct {
+	bun.BaseModel `bun:"table:order_items"`
+
+	ID             string `bun:",pk" json:"id"`
+	OrderID        string `bun:",notnull" json:"order_id"`
+	MenuItemID     string `bun:",nullzero" json:"menu_item_id,omitempty"`
+	Name           string `bun:",notnull" json:"name"`
+	UnitPriceCents int64  `bun:",notnull" json:"unit_price_cents"`
+	Quantity       int    `bun:",notnull" json:"quantity"`
+	TotalCents     int64  `bun:",notnull" json:"total_cents"`
+	Notes          string `bun:",nullzero" json:"notes,omitempty"`
+}
+
+// OrderTransition is the audit record written for every status change.
+// FromStatus is empty for the initial "pending" entry.
+type OrderTransition struct {
+	bun.BaseModel `bun:"table:order_transitions"`
+
+	ID         string    `bun:",pk" json:"id"`
+	OrderID    string    `bun:",notnull" json:"order_id"`
+	FromStatus string    `bun:",nullzero" json:"from_status,omitempty"`
+	ToStatus   string    `bun:",notnull" json:"to_status"`
+	ActorID    string    `bun:",notnull" json:"actor_id"`
+	ActorRole  string    `bun:",notnull" json:"actor_role"`
+	Reason     string    `bun:",nullzero" json:"reason,omitempty"`
+	CreatedAt  time.Time `bun:",nullzero,notnull,default:current_timestamp" json:"created_at"`
+}
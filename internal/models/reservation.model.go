@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// Reservation statuses. Only confirmed reservations hold a table; the
+// reservations_no_overlap exclusion constraint enforces that in Postgres.
+const (
+	ReservationStatusConfirmed = "confirmed"
+	ReservationStatusCancelled = "cancelled"
+	ReservationStatusCompleted = "completed"
+	ReservationStatusNoShow    = "no_show"
+)
+
+// RestaurantTable is a bookable table of a restaurant
+type RestaurantTable struct {
+	bun.BaseModel `bun:"table:restaurant_tables"`
+
+	ID           string    `bun:",pk" json:"id"`
+	RestaurantID string    `bun:",notnull" json:"restaurant_id"`
+	Label        string    `bun:",notnull" json:"label"`
+	Capacity     int       `bun:",notnull" json:"capacity"`
+	Section      string    `bun:",nullzero" json:"section,omitempty"`
+	IsActive     bool      `bun:",notnull,default:true" json:"is_active"`
+	CreatedAt    time.Time `bun:",nullzero,notnull,default:current_timestamp" json:"created_at"`
+	UpdatedAt    time.Time `bun:",nullzero,notnull,default:current_timestamp" json:"updated_at"`
+}
+
+// OpeningHour is one opening window of a restaurant on a weekday
+// (0 = Sunday, matching time.Weekday). Times are "HH:MM" in the restaurant's
+// timezone; a day may have several windows (e.g. lunch and dinner).
+type OpeningHour struct {
+	bun.BaseModel `bun:"table:opening_hours"`
+
+	ID           string `bun:",pk" json:"id"`
+	RestaurantID string `bun:",notnull" json:"restaurant_id"`
+	Weekday      int    `bun:",notnull" json:"weekday"`
+	OpensAt      string `bun:",notnull" json:"opens_at"`
+	ClosesAt     string `bun:",notnull" json:"closes_at"`
+}
+
+type Reservation struct {
+	bun.BaseModel `bun:"table:reservations"`
+
+	ID             string           `bun:",pk" json:"id"`
+	RestaurantID   string           `bun:",notnull" json:"restaurant_id"`
+	TableID        string           `bun:",notnull" json:"table_id"`
+	Table          *RestaurantTable `bun:"rel:belongs-to,join:table_id=id" json:"table,omitempty"`
+	CustomerID     string           `bun:",notnull" json:"customer_id"`
+	PartySize      int              `bun:",notnull" json:"party_size"`
+	StartsAt       time.Time        `bun:",notnull" json:"starts_at"`
+	EndsAt         time.Time        `bun:",notnull" json:"ends_at"`
+	Status         string           `bun:",notnull" json:"status"`
+	Notes          string           `bun:",nullzero" json:"notes,omitempty"`
+	ReminderSentAt time.Time        `bun:",nullzero" json:"-"`
+	CreatedAt      time.Time        `bun:",nullzero,notnull,default:current_timestamp" json:"created_at"`
+	UpdatedAt      time.Time        `bun:",nullzero,notnull,default:current_timestamp" json:"updated_at"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// Restaurant is a venue owned by a management user. Timezone is an IANA name
+// used to interpret its opening hours and reservation times.
+type Restaurant struct {
+	bun.BaseModel `bun:"table:restaurants"`
+
+	ID          string    `bun:",pk" json:"id"`
+	OwnerID     string    `bun:",notnull" json:"owner_id"`
+	Name        string    `bun:",notnull" json:"name"`
+	Description string    `bun:",nullzero" json:"description,omitempty"`
+	Address     string    `bun:",notnull" json:"address"`
+	CuisineType string    `bun:",nullzero" json:"cuisine_type,omitempty"`
+	Rating      float64   `bun:",notnull,default:0" json:"rating"`
+	Timezone    string    `bun:",nullzero,notnull,default:'UTC'" json:"timezone"`
+	CreatedAt   time.Time `bun:",nullzero,notnull,default:current_timestamp" json:"created_at"`
+	UpdatedAt   time.Time `bun:",nullzero,notnull,default:current_timestamp" json:"updated_at"`
+	// DeletedAt enables bun's soft delete: deleted rows are hidden from
+	// selects automatically but kept for anything that still references them.
+	DeletedAt time.Time `bun:",soft_delete,nullzero" json:"-"`
+}
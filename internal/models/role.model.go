@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// Role is a named set of permissions. Built-in roles are seeded by the
+// migrations and cannot be edited or deleted.
+type Role struct {
+	bun.BaseModel `bun:"table:roles"`
+
+	ID          string    `bun:",pk" json:"id"`
+	Name        string    `bun:",unique,notnull" json:"name"`
+	Description string    `bun:",nullzero" json:"description,omitempty"`
+	Permissions []string  `bun:",array,notnull" json:"permissions"`
+	BuiltIn     bool      `bun:",notnull,default:false" json:"built_in"`
+	CreatedAt   time.Time `bun:",nullzero,notnull,default:current_timestamp" json:"created_at"`
+	UpdatedAt   time.Time `bun:",nullzero,notnull,default:current_timestamp" json:"updated_at"`
+}
+
+// RoleAssignment grants a role to a user within one restaurant
+type RoleAssignment struct {
+	bun.BaseModel `bun:"table:role_assignments"`
+
+	ID           string    `bun:",pk" json:"id"`
+	UserID       string    `bun:",notnull" json:"user_id"`
+	RoleID       string    `bun:",notnull" json:"role_id"`
+	RestaurantID string    `bun:",notnull" json:"restaurant_id"`
+	GrantedBy    string    `bun:",nullzero" json:"granted_by,omitempty"`
+	User         *User     `bun:"rel:belongs-to,join:user_id=id" json:"-"`
+	Role         *Role     `bun:"rel:belongs-to,join:role_id=id" json:"role,omitempty"`
+	CreatedAt    time.Time `bun:",nullzero,notnull,default:current_timestamp" json:"created_at"`
+}
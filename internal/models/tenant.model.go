@@ -0,0 +1,218 @@
+package models
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/schema"
+
+	"github.com/alibaba0010/postgres-api/internal/utils"
+)
+
+// Restaurant-owned models filter every select, update and delete by the
+// tenant of the context (see utils.WithTenant) and refuse to insert rows for
+// another restaurant, so a tenant-scoped request cannot reach other
+// restaurants' data even when a query forgets the restaurant_id condition.
+// bun does not run select hooks for Count and Exists; run those through
+// models.Count and models.Exists, which do.
+
+var (
+	_ bun.BeforeSelectHook = (*Restaurant)(nil)
+	_ bun.BeforeSelectHook = (*Menu)(nil)
+	_ bun.BeforeSelectHook = (*MenuItem)(nil)
+	_ bun.BeforeSelectHook = (*Order)(nil)
+	_ bun.BeforeSelectHook = (*RestaurantTable)(nil)
+	_ bun.BeforeSelectHook = (*OpeningHour)(nil)
+	_ bun.BeforeSelectHook = (*Reservation)(nil)
+	_ bun.BeforeSelectHook = (*RoleAssignment)(nil)
+	_ bun.BeforeSelectHook = (*StaffInvitation)(nil)
+)
+
+// scopeToTenant limits the query to rows whose column holds the context's tenant
+func scopeToTenant(ctx context.Context, query bun.QueryBuilder, column string) error {
+	if restaurantID, ok := utils.TenantFromContext(ctx); ok {
+		query.Where("?TableAlias.? = ?", bun.Ident(column), restaurantID)
+	}
+	return nil
+}
+
+// Count runs query.Count after the select hook of its model, which bun skips
+// for Count
+func Count(ctx context.Context, query *bun.SelectQuery) (int, error) {
+	if err := beforeSelect(ctx, query); err != nil {
+		return 0, err
+	}
+	return query.Count(ctx)
+}
+
+// Exists runs query.Exists after the select hook of its model, which bun
+// skips for Exists
+func Exists(ctx context.Context, query *bun.SelectQuery) (bool, error) {
+	if err := beforeSelect(ctx, query); err != nil {
+		return false, err
+	}
+	return query.Exists(ctx)
+}
+
+func beforeSelect(ctx context.Context, query *bun.SelectQuery) error {
+	model, ok := query.GetModel().(interface{ Table() *schema.Table })
+	if !ok {
+		return nil
+	}
+	if hook, ok := model.Table().ZeroIface.(bun.BeforeSelectHook); ok {
+		return hook.BeforeSelect(ctx, query)
+	}
+	return nil
+}
+
+// checkTenantInsert rejects inserting a row that belongs to another restaurant
+func checkTenantInsert(ctx context.Context, query bun.Query, restaurantID string) error {
+	if _, ok := query.(*bun.InsertQuery); !ok {
+		return nil
+	}
+	if tenant, ok := utils.TenantFromContext(ctx); ok && restaurantID != tenant {
+		return fmt.Errorf("models: insert for restaurant %q outside tenant %q", restaurantID, tenant)
+	}
+	return nil
+}
+
+func (*Restaurant) BeforeSelect(ctx context.Context, query *bun.SelectQuery) error {
+	return scopeToTenant(ctx, query.QueryBuilder(), "id")
+}
+
+func (*Restaurant) BeforeUpdate(ctx context.Context, query *bun.UpdateQuery) error {
+	return scopeToTenant(ctx, query.QueryBuilder(), "id")
+}
+
+func (*Restaurant) BeforeDelete(ctx context.Context, query *bun.DeleteQuery) error {
+	return scopeToTenant(ctx, query.QueryBuilder(), "id")
+}
+
+func (*Menu) BeforeSelect(ctx context.Context, query *bun.SelectQuery) error {
+	return scopeToTenant(ctx, query.QueryBuilder(), "restaurant_id")
+}
+
+func (*Menu) BeforeUpdate(ctx context.Context, query *bun.UpdateQuery) error {
+	return scopeToTenant(ctx, query.QueryBuilder(), "restaurant_id")
+}
+
+func (*Menu) BeforeDelete(ctx context.Context, query *bun.DeleteQuery) error {
+	return scopeToTenant(ctx, query.QueryBuilder(), "restaurant_id")
+}
+
+func (m *Menu) BeforeAppendModel(ctx context.Context, query bun.Query) error {
+	return checkTenantInsert(ctx, query, m.RestaurantID)
+}
+
+func (*MenuItem) BeforeSelect(ctx context.Context, query *bun.SelectQuery) error {
+	return scopeToTenant(ctx, query.QueryBuilder(), "restaurant_id")
+}
+
+func (*MenuItem) BeforeUpdate(ctx context.Context, query *bun.UpdateQuery) error {
+	return scopeToTenant(ctx, query.QueryBuilder(), "restaurant_id")
+}
+
+func (*MenuItem) BeforeDelete(ctx context.Context, query *bun.DeleteQuery) error {
+	return scopeToTenant(ctx, query.QueryBuilder(), "restaurant_id")
+}
+
+func (m *MenuItem) BeforeAppendModel(ctx context.Context, query bun.Query) error {
+	return checkTenantInsert(ctx, query, m.RestaurantID)
+}
+
+func (*Order) BeforeSelect(ctx context.Context, query *bun.SelectQuery) error {
+	return scopeToTenant(ctx, query.QueryBuilder(), "restaurant_id")
+}
+
+func (*Order) BeforeUpdate(ctx context.Context, query *bun.UpdateQuery) error {
+	return scopeToTenant(ctx, query.QueryBuilder(), "restaurant_id")
+}
+
+func (*Order) BeforeDelete(ctx context.Context, query *bun.DeleteQuery) error {
+	return scopeToTenant(ctx, query.QueryBuilder(), "restaurant_id")
+}
+
+func (m *Order) BeforeAppendModel(ctx context.Context, query bun.Query) error {
+	return checkTenantInsert(ctx, query, m.RestaurantID)
+}
+
+func (*RestaurantTable) BeforeSelect(ctx context.Context, query *bun.SelectQuery) error {
+	return scopeToTenant(ctx, query.QueryBuilder(), "restaurant_id")
+}
+
+func (*RestaurantTable) BeforeUpdate(ctx context.Context, query *bun.UpdateQuery) error {
+	return scopeToTenant(ctx, query.QueryBuilder(), "restaurant_id")
+}
+
+func (*RestaurantTable) BeforeDelete(ctx context.Context, query *bun.DeleteQuery) error {
+	return scopeToTenant(ctx, query.QueryBuilder(), "restaurant_id")
+}
+
+func (m *RestaurantTable) BeforeAppendModel(ctx context.Context, query bun.Query) error {
+	return checkTenantInsert(ctx, query, m.RestaurantID)
+}
+
+func (*OpeningHour) BeforeSelect(ctx context.Context, query *bun.SelectQuery) error {
+	return scopeToTenant(ctx, query.QueryBuilder(), "restaurant_id")
+}
+
+func (*OpeningHour) BeforeUpdate(ctx context.Context, query *bun.UpdateQuery) error {
+	return scopeToTenant(ctx, query.QueryBuilder(), "restaurant_id")
+}
+
+func (*OpeningHour) BeforeDelete(ctx context.Context, query *bun.DeleteQuery) error {
+	return scopeToTenant(ctx, query.QueryBuilder(), "restaurant_id")
+}
+
+func (m *OpeningHour) BeforeAppendModel(ctx context.Context, query bun.Query) error {
+	return checkTenantInsert(ctx, query, m.RestaurantID)
+}
+
+func (*Reservation) BeforeSelect(ctx context.Context, query *bun.SelectQuery) error {
+	return scopeToTenant(ctx, query.QueryBuilder(), "restaurant_id")
+}
+
+func (*Reservation) BeforeUpdate(ctx context.Context, query *bun.UpdateQuery) error {
+	return scopeToTenant(ctx, query.QueryBuilder(), "restaurant_id")
+}
+
+func (*Reservation) BeforeDelete(ctx context.Context, query *bun.DeleteQuery) error {
+	return scopeToTenant(ctx, query.QueryBuilder(), "restaurant_id")
+}
+
+func (m *Reservation) BeforeAppendModel(ctx context.Context, query bun.Query) error {
+	return checkTenantInsert(ctx, query, m.RestaurantID)
+}
+
+func (*RoleAssignment) BeforeSelect(ctx context.Context, query *bun.SelectQuery) error {
+	return scopeToTenant(ctx, query.QueryBuilder(), "restaurant_id")
+}
+
+func (*RoleAssignment) BeforeUpdate(ctx context.Context, query *bun.UpdateQuery) error {
+	return scopeToTenant(ctx, query.QueryBuilder(), "restaurant_id")
+}
+
+func (*RoleAssignment) BeforeDelete(ctx context.Context, query *bun.DeleteQuery) error {
+	return scopeToTenant(ctx, query.QueryBuilder(), "restaurant_id")
+}
+
+func (m *RoleAssignment) BeforeAppendModel(ctx context.Context, query bun.Query) error {
+	return checkTenantInsert(ctx, query, m.RestaurantID)
+}
+
+func (*StaffInvitation) BeforeSelect(ctx context.Context, query *bun.SelectQuery) error {
+	return scopeToTenant(ctx, query.QueryBuilder(), "restaurant_id")
+}
+
+func (*StaffInvitation) BeforeUpdate(ctx context.Context, query *bun.UpdateQuery) error {
+	return scopeToTenant(ctx, query.QueryBuilder(), "restaurant_id")
+}
+
+func (*StaffInvitation) BeforeDelete(ctx context.Context, query *bun.DeleteQuery) error {
+	return scopeToTenant(ctx, query.QueryBuilder(), "restaurant_id")
+}
+
+func (m *StaffInvitation) BeforeAppendModel(ctx context.Context, query bun.Query) error {
+	return checkTenantInsert(ctx, query, m.RestaurantID)
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+
+	"github.com/alibaba0010/postgres-api/internal/utils"
+)
+
+// queryDB builds queries without connecting anywhere
+func queryDB(t *testing.T) *bun.DB {
+	t.Helper()
+	sqlDB, err := sql.Open("pgx", "postgres://localhost:1/unused")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := bun.NewDB(sqlDB, pgdialect.New())
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestBeforeSelectScopesToTenant(t *testing.T) {
+	db := queryDB(t)
+	ctx := utils.WithTenant(context.Background(), "restaurant-a")
+
+	query := db.NewSelect().Model((*Menu)(nil)).Where("name = ?", "Lunch")
+	if err := beforeSelect(ctx, query); err != nil {
+		t.Fatal(err)
+	}
+	if got := query.String(); !strings.Contains(got, `"menu"."restaurant_id" = 'restaurant-a'`) {
+		t.Fatalf("query %s is not scoped to the tenant", got)
+	}
+}
+
+func TestBeforeSelectWithoutTenant(t *testing.T) {
+	db := queryDB(t)
+
+	query := db.NewSelect().Model((*Menu)(nil)).Where("name = ?", "Lunch")
+	if err := beforeSelect(context.Background(), query); err != nil {
+		t.Fatal(err)
+	}
+	if got := query.String(); strings.Contains(got, `"restaurant_id" =`) {
+		t.Fatalf("query %s scoped without a tenant", got)
+	}
+}
+
+func TestBeforeSelectOfUntenantedModel(t *testing.T) {
+	db := queryDB(t)
+	ctx := utils.WithTenant(context.Background(), "restaurant-a")
+
+	query := db.NewSelect().Model((*User)(nil)).Where("email = ?", "a@example.com")
+	if err := beforeSelect(ctx, query); err != nil {
+		t.Fatal(err)
+	}
+	if got := query.String(); strings.Contains(got, "restaurant-a") {
+		t.Fatalf("query %s of a model without a tenant was scoped", got)
+	}
+}
@@ -8,15 +8,19 @@ import (
 	"github.com/uptrace/bun"
 )
 
+// RefreshToken is one issued refresh token. Tokens rotated from the same
+// sign-in share a FamilyID; RotatedAt is set once the token has been exchanged.
 type RefreshToken struct {
 	bun.BaseModel `bun:"table:refresh_tokens"`
 
 	ID        string    `bun:",pk" json:"id"`
 	UserID    string    `bun:",notnull" json:"user_id"`
+	FamilyID  string    `bun:",notnull" json:"family_id"`
 	Token     string    `bun:",unique,notnull" json:"token"`
 	IPAddress string    `bun:",nullzero" json:"ip_address"`
 	UserAgent string    `bun:",nullzero" json:"user_agent"`
 	ExpiresAt time.Time `bun:",notnull" json:"expires_at"`
+	RotatedAt time.Time `bun:",nullzero" json:"rotated_at,omitempty"`
 	CreatedAt time.Time `bun:",nullzero,notnull,default:current_timestamp" json:"created_at"`
 }
 
@@ -13,14 +13,32 @@ type User struct {
 	bun.BaseModel `bun:"table:users"`
 	// ID is stored as a UUID in the database. Use string here so Bun
 	// doesn't try to scan it into an integer.
-	ID        string    `bun:",pk" json:"id"`
-	Name      string    `bun:",notnull" json:"name"`
-	Email     string    `bun:",unique,notnull" json:"email"`
-	Password  string    `bun:",notnull" json:"-"`
-	Address   string    `bun:",nullzero" json:"address,omitempty"`
-	Role      string    `bun:",notnull,default:'user'" json:"role"`
-	CreatedAt time.Time `bun:",nullzero,notnull,default:current_timestamp" json:"created_at"`
-	UpdatedAt time.Time `bun:",nullzero,notnull,default:current_timestamp" json:"updated_at"`
+	ID          string    `bun:",pk" json:"id"`
+	Name        string    `bun:",notnull" json:"name"`
+	Email       string    `bun:",unique,notnull" json:"email"`
+	Password    string    `bun:",notnull" json:"-"`
+	Address     string    `bun:",nullzero" json:"address,omitempty"`
+	Phone       string    `bun:",nullzero" json:"phone,omitempty"`
+	Role        string    `bun:",notnull,default:'user'" json:"role"`
+	MFAEnabled  bool      `bun:"mfa_enabled,notnull,default:false" json:"mfa_enabled"`
+	MFASecret   string    `bun:"mfa_secret,nullzero" json:"-"`
+	MFALastStep int64     `bun:"mfa_last_step,nullzero" json:"-"`
+	// SuspendedAt is set while an admin has suspended the account; suspended
+	// users cannot sign in or use tokens issued before the suspension.
+	SuspendedAt     time.Time `bun:",nullzero" json:"suspended_at,omitempty"`
+	SuspendedReason string    `bun:",nullzero" json:"-"`
+	// DeletionScheduledAt is when a deletion the user asked for becomes final;
+	// until then they can sign in and cancel it.
+	DeletionScheduledAt time.Time `bun:",nullzero" json:"-"`
+	CreatedAt   time.Time `bun:",nullzero,notnull,default:current_timestamp" json:"created_at"`
+	UpdatedAt   time.Time `bun:",nullzero,notnull,default:current_timestamp" json:"updated_at"`
+	// DeletedAt enables bun's soft delete, like on Restaurant
+	DeletedAt time.Time `bun:",soft_delete,nullzero" json:"-"`
+}
+
+// IsSuspended reports whether an admin has suspended the account
+func (u *User) IsSuspended() bool {
+	return !u.SuspendedAt.IsZero()
 }
 
 	// // BeforeInsert hook to generate UUIDv7 for ID if not set
@@ -34,3 +52,15 @@ type User struct {
 	//        }
 	//        return nil
 	// }
+
+// MFARecoveryCode is a single-use code that replaces a TOTP code when the
+// authenticator is lost. Only a SHA-256 hash of the code is stored.
+type MFARecoveryCode struct {
+	bun.BaseModel `bun:"table:mfa_recovery_codes"`
+
+	ID        string    `bun:",pk" json:"id"`
+	UserID    string    `bun:",notnull" json:"user_id"`
+	CodeHash  string    `bun:",notnull" json:"-"`
+	UsedAt    time.Time `bun:",nullzero" json:"used_at,omitempty"`
+	CreatedAt time.Time `bun:",nullzero,notnull,default:current_timestamp" json:"created_at"`
+}
@@ -0,0 +1,248 @@
+// Package oidctest is a minimal OpenID Connect provider for tests and local
+// development. It implements discovery, the authorization code flow with PKCE
+// (S256), RS256 ID tokens and a JWKS endpoint. There is no login page: every
+// authorization signs in as the user set with SignInAs.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// keyID names the provider's only signing key in the JWKS
+const keyID = "oidctest-1"
+
+// Client is the relying party allowed to use the provider
+type Client struct {
+	ID     string
+	Secret string
+}
+
+// User is the account the provider vouches for
+type User struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// Provider serves the OpenID Connect endpoints under its issuer URL
+type Provider struct {
+	issuer string
+	client Client
+	key    *rsa.PrivateKey
+	mux    *http.ServeMux
+	server *httptest.Server
+
+	mu    sync.Mutex
+	user  User
+	codes map[string]grant
+}
+
+// grant is an issued authorization code waiting to be exchanged
+type grant struct {
+	user          User
+	redirectURI   string
+	codeChallenge string
+	nonce         string
+	expiresAt     time.Time
+}
+
+// NewProvider returns a provider for issuer, e.g. "http://localhost:9000",
+// to be served with http.ListenAndServe
+func NewProvider(issuer string, client Client) (*Provider, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	p := &Provider{
+		issuer: issuer,
+		client: client,
+		key:    key,
+		mux:    http.NewServeMux(),
+		user:   User{Subject: "oidctest-user", Email: "diner@example.com", EmailVerified: true, Name: "Test Diner"},
+		codes:  make(map[string]grant),
+	}
+	p.mux.HandleFunc("/.well-known/openid-configuration", p.discovery)
+	p.mux.HandleFunc("/authorize", p.authorize)
+	p.mux.HandleFunc("/token", p.token)
+	p.mux.HandleFunc("/jwks", p.jwks)
+	return p, nil
+}
+
+// StartProvider runs a provider on a random local port, as tests need. Call
+// Close when done.
+func StartProvider(client Client) (*Provider, error) {
+	server := httptest.NewUnstartedServer(nil)
+	p, err := NewProvider("http://"+server.Listener.Addr().String(), client)
+	if err != nil {
+		server.Close()
+		return nil, err
+	}
+	server.Config.Handler = p
+	server.Start()
+	p.server = server
+	return p, nil
+}
+
+// Issuer is the issuer URL, which is also the base URL of the endpoints
+func (p *Provider) Issuer() string {
+	return p.issuer
+}
+
+// SignInAs sets the user the following authorizations sign in as
+func (p *Provider) SignInAs(user User) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.user = user
+}
+
+// Close stops a provider started with StartProvider
+func (p *Provider) Close() {
+	if p.server != nil {
+		p.server.Close()
+	}
+}
+
+func (p *Provider) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	p.mux.ServeHTTP(writer, request)
+}
+
+func (p *Provider) discovery(writer http.ResponseWriter, request *http.Request) {
+	writeJSON(writer, http.StatusOK, map[string]any{
+		"issuer":                                p.issuer,
+		"authorization_endpoint":                p.issuer + "/authorize",
+		"token_endpoint":                        p.issuer + "/token",
+		"jwks_uri":                              p.issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+// authorize approves at once and sends the browser back with a code
+func (p *Provider) authorize(writer http.ResponseWriter, request *http.Request) {
+	query := request.URL.Query()
+	redirectURI, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil || redirectURI.Scheme == "" {
+		http.Error(writer, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+	if query.Get("client_id") != p.client.ID || query.Get("response_type") != "code" ||
+		query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		http.Error(writer, "invalid authorization request", http.StatusBadRequest)
+		return
+	}
+
+	code := randomString()
+	p.mu.Lock()
+	p.codes[code] = grant{
+		user:          p.user,
+		redirectURI:   redirectURI.String(),
+		codeChallenge: query.Get("code_challenge"),
+		nonce:         query.Get("nonce"),
+		expiresAt:     time.Now().Add(time.Minute),
+	}
+	p.mu.Unlock()
+
+	back := redirectURI.Query()
+	back.Set("code", code)
+	back.Set("state", query.Get("state"))
+	redirectURI.RawQuery = back.Encode()
+	http.Redirect(writer, request, redirectURI.String(), http.StatusFound)
+}
+
+// token exchanges a code for an ID token after checking the client and PKCE verifier
+func (p *Provider) token(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodPost || request.ParseForm() != nil {
+		writeJSON(writer, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+	form := request.PostForm
+	if form.Get("client_id") != p.client.ID || form.Get("client_secret") != p.client.Secret {
+		writeJSON(writer, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+	if form.Get("grant_type") != "authorization_code" {
+		writeJSON(writer, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+		return
+	}
+
+	// codes are single-use, like at a real provider
+	p.mu.Lock()
+	issued, ok := p.codes[form.Get("code")]
+	delete(p.codes, form.Get("code"))
+	p.mu.Unlock()
+
+	challenge := sha256.Sum256([]byte(form.Get("code_verifier")))
+	if !ok || time.Now().After(issued.expiresAt) || issued.redirectURI != form.Get("redirect_uri") ||
+		base64.RawURLEncoding.EncodeToString(challenge[:]) != issued.codeChallenge {
+		writeJSON(writer, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":            p.issuer,
+		"aud":            p.client.ID,
+		"sub":            issued.user.Subject,
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+		"nonce":          issued.nonce,
+		"email":          issued.user.Email,
+		"email_verified": issued.user.EmailVerified,
+		"name":           issued.user.Name,
+	})
+	token.Header["kid"] = keyID
+	idToken, err := token.SignedString(p.key)
+	if err != nil {
+		writeJSON(writer, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	writeJSON(writer, http.StatusOK, map[string]any{
+		"access_token": randomString(),
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     idToken,
+	})
+}
+
+func (p *Provider) jwks(writer http.ResponseWriter, request *http.Request) {
+	public := p.key.PublicKey
+	writeJSON(writer, http.StatusOK, map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": keyID,
+			"n":   base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+		}},
+	})
+}
+
+func writeJSON(writer http.ResponseWriter, status int, body any) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(status)
+	_ = json.NewEncoder(writer).Encode(body)
+}
+
+func randomString() string {
+	bytes := make([]byte, 16)
+	_, _ = rand.Read(bytes)
+	return hex.EncodeToString(bytes)
+}
@@ -1,14 +1,38 @@
 package routes
 
 import (
-	"github.com/alibaba0010/postgres-api/internal/controllers"
 	"github.com/gorilla/mux"
 )
 
 // AuthRoutes registers auth-related handlers onto the provided subrouter.
-func AuthRoutes(route *mux.Router) {
-	route.HandleFunc("/signup", controllers.SignupHandler).Methods("POST")
-	route.HandleFunc("/verify", controllers.ActivateUserHandler).Methods("GET")
-	route.HandleFunc("/resend", controllers.ResendVerificationHandler).Methods("POST")
-	route.HandleFunc("/signin", controllers.SigninHandler).Methods("POST")
+func (r *Router) AuthRoutes(route *mux.Router) {
+	route.HandleFunc("/signup", r.handler.SignupHandler).Methods("POST")
+	route.HandleFunc("/verify", r.handler.ActivateUserHandler).Methods("GET")
+	route.HandleFunc("/resend", r.handler.ResendVerificationHandler).Methods("POST")
+	route.HandleFunc("/signin", r.handler.SigninHandler).Methods("POST")
+	route.HandleFunc("/forgot-password", r.handler.ForgotPasswordHandler).Methods("POST")
+	route.HandleFunc("/reset-password", r.handler.ResetPasswordHandler).Methods("POST")
+	// Confirms the new address of a /user/change-email request
+	route.HandleFunc("/verify-email", r.handler.ConfirmEmailChangeHandler).Methods("GET")
+	route.HandleFunc("/refresh", r.handler.RefreshTokenHandler).Methods("POST")
+	route.HandleFunc("/logout", r.handler.LogoutHandler).Methods("POST")
+	// Picks the restaurant a staff session acts in, like /refresh
+	route.HandleFunc("/switch-restaurant", r.handler.SwitchRestaurantHandler).Methods("POST")
+
+	// Social login with OpenID Connect providers (authorization code + PKCE)
+	route.HandleFunc("/oidc/{provider}/authorize", r.handler.OIDCAuthorizeHandler).Methods("GET")
+	route.HandleFunc("/oidc/{provider}/callback", r.handler.OIDCCallbackHandler).Methods("GET")
+
+	// Joining a restaurant's staff with the emailed invite token
+	route.HandleFunc("/invitations/accept", r.handler.AcceptInvitationHandler).Methods("POST")
+
+	// Second step of a two-factor sign-in, authenticated by the mfa token
+	route.HandleFunc("/mfa/verify", r.handler.MFAVerifyHandler).Methods("POST")
+	route.HandleFunc("/mfa/enroll", r.handler.PendingMFAEnrollHandler).Methods("POST")
+	route.HandleFunc("/mfa/confirm", r.handler.PendingMFAConfirmHandler).Methods("POST")
+
+	// Signing out everywhere needs a valid access token
+	logoutAllRouter := route.PathPrefix("/logout-all").Subrouter()
+	logoutAllRouter.Use(r.guard.AuthMiddleware)
+	logoutAllRouter.HandleFunc("", r.handler.LogoutAllHandler).Methods("POST")
 }
\ No newline at end of file
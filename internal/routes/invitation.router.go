@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"github.com/alibaba0010/postgres-api/internal/types"
+	"github.com/gorilla/mux"
+)
+
+// InvitationRoutes registers the staff invitation handlers of a restaurant.
+// Accepting an invitation is an auth route, see AuthRoutes.
+func (r *Router) InvitationRoutes(route *mux.Router) {
+	invitationRouter := route.PathPrefix("/restaurants/{id}/invitations").Subrouter()
+	invitationRouter.Use(r.guard.AuthMiddleware)
+	invitationRouter.Use(r.guard.TenantMiddleware)
+	invitationRouter.Use(r.guard.RequireRestaurantPermission(types.PermStaffManage))
+	invitationRouter.HandleFunc("", r.handler.ListInvitationsHandler).Methods("GET")
+	invitationRouter.HandleFunc("", r.handler.CreateInvitationHandler).Methods("POST")
+	invitationRouter.HandleFunc("/{invitationId}", r.handler.RevokeInvitationHandler).Methods("DELETE")
+}
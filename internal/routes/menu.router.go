@@ -0,0 +1,34 @@
+package routes
+
+import (
+	"github.com/alibaba0010/postgres-api/internal/types"
+	"github.com/gorilla/mux"
+)
+
+// MenuRoutes registers menu and menu-item handlers. Like /healthcheck, the
+// read endpoints are public; edits require menu:write in the menu's restaurant.
+func (r *Router) MenuRoutes(route *mux.Router) {
+	// Public read endpoints
+	route.HandleFunc("/restaurants/{id}/menus", r.handler.ListMenusHandler).Methods("GET")
+	route.HandleFunc("/menus/{id}", r.handler.GetMenuHandler).Methods("GET")
+	route.HandleFunc("/menus/{id}/items", r.handler.ListMenuItemsHandler).Methods("GET")
+
+	// Write endpoints
+	restaurantMenuRouter := route.PathPrefix("/restaurants/{id}/menus").Subrouter()
+	restaurantMenuRouter.Use(r.guard.AuthMiddleware)
+	restaurantMenuRouter.Use(r.guard.TenantMiddleware)
+	restaurantMenuRouter.Use(r.guard.RequireRestaurantPermission(types.PermMenuWrite))
+	restaurantMenuRouter.HandleFunc("", r.handler.CreateMenuHandler).Methods("POST")
+
+	menuRouter := route.PathPrefix("/menus/{id}").Subrouter()
+	menuRouter.Use(r.guard.AuthMiddleware)
+	menuRouter.Use(r.guard.ActiveTenantMiddleware)
+	// these routes act in the session's active restaurant; menus of other
+	// restaurants are out of scope there
+	menuRouter.Use(r.guard.RequirePermission(types.PermMenuWrite))
+	menuRouter.HandleFunc("", r.handler.UpdateMenuHandler).Methods("PUT", "PATCH")
+	menuRouter.HandleFunc("", r.handler.DeleteMenuHandler).Methods("DELETE")
+	menuRouter.HandleFunc("/items", r.handler.CreateMenuItemHandler).Methods("POST")
+	menuRouter.HandleFunc("/items/{itemId}", r.handler.UpdateMenuItemHandler).Methods("PUT", "PATCH")
+	menuRouter.HandleFunc("/items/{itemId}", r.handler.DeleteMenuItemHandler).Methods("DELETE")
+}
@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"github.com/alibaba0010/postgres-api/internal/types"
+	"github.com/gorilla/mux"
+)
+
+// OrderRoutes registers order handlers. Every order endpoint requires
+// authentication; who may see or advance a given order is decided in the services.
+func (r *Router) OrderRoutes(route *mux.Router) {
+	orderRouter := route.PathPrefix("/orders").Subrouter()
+	orderRouter.Use(r.guard.AuthMiddleware)
+
+	orderRouter.HandleFunc("", r.handler.CreateOrderHandler).Methods("POST")
+	orderRouter.HandleFunc("", r.handler.ListMyOrdersHandler).Methods("GET")
+	orderRouter.HandleFunc("/{id}", r.handler.GetOrderHandler).Methods("GET")
+	orderRouter.HandleFunc("/{id}/status", r.handler.TransitionOrderHandler).Methods("POST")
+
+	// GET /restaurants/{id}/orders - the restaurant's order queue for its staff
+	restaurantOrderRouter := route.PathPrefix("/restaurants/{id}/orders").Subrouter()
+	restaurantOrderRouter.Use(r.guard.AuthMiddleware)
+	restaurantOrderRouter.Use(r.guard.TenantMiddleware)
+	restaurantOrderRouter.Use(r.guard.RequireRestaurantPermission(types.PermOrderRead))
+	restaurantOrderRouter.HandleFunc("", r.handler.ListRestaurantOrdersHandler).Methods("GET")
+}
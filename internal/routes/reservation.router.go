@@ -0,0 +1,47 @@
+package routes
+
+import (
+	"github.com/alibaba0010/postgres-api/internal/types"
+	"github.com/gorilla/mux"
+)
+
+// ReservationRoutes registers tables, opening hours, availability and booking handlers
+func (r *Router) ReservationRoutes(route *mux.Router) {
+	// Public read endpoints
+	route.HandleFunc("/restaurants/{id}/opening-hours", r.handler.GetOpeningHoursHandler).Methods("GET")
+	route.HandleFunc("/restaurants/{id}/availability", r.handler.GetAvailabilityHandler).Methods("GET")
+
+	// Any authenticated user can book a table
+	bookingRouter := route.PathPrefix("/restaurants/{id}/reservations").Subrouter()
+	bookingRouter.Use(r.guard.AuthMiddleware)
+	bookingRouter.HandleFunc("", r.handler.CreateReservationHandler).Methods("POST")
+
+	// Restaurant staff: the reservation book, schedule and tables
+	reservationBookRouter := route.PathPrefix("/restaurants/{id}/reservations").Subrouter()
+	reservationBookRouter.Use(r.guard.AuthMiddleware)
+	reservationBookRouter.Use(r.guard.TenantMiddleware)
+	reservationBookRouter.Use(r.guard.RequireRestaurantPermission(types.PermReservationManage))
+	reservationBookRouter.HandleFunc("", r.handler.ListRestaurantReservationsHandler).Methods("GET")
+
+	scheduleRouter := route.PathPrefix("/restaurants/{id}/opening-hours").Subrouter()
+	scheduleRouter.Use(r.guard.AuthMiddleware)
+	scheduleRouter.Use(r.guard.TenantMiddleware)
+	scheduleRouter.Use(r.guard.RequireRestaurantPermission(types.PermRestaurantWrite))
+	scheduleRouter.HandleFunc("", r.handler.SetOpeningHoursHandler).Methods("PUT")
+
+	tableRouter := route.PathPrefix("/restaurants/{id}/tables").Subrouter()
+	tableRouter.Use(r.guard.AuthMiddleware)
+	tableRouter.Use(r.guard.TenantMiddleware)
+	tableRouter.Use(r.guard.RequireRestaurantPermission(types.PermTableManage))
+	tableRouter.HandleFunc("", r.handler.ListTablesHandler).Methods("GET")
+	tableRouter.HandleFunc("", r.handler.CreateTableHandler).Methods("POST")
+	tableRouter.HandleFunc("/{tableId}", r.handler.UpdateTableHandler).Methods("PUT", "PATCH")
+	tableRouter.HandleFunc("/{tableId}", r.handler.DeleteTableHandler).Methods("DELETE")
+
+	// The caller's own reservations
+	reservationRouter := route.PathPrefix("/reservations").Subrouter()
+	reservationRouter.Use(r.guard.AuthMiddleware)
+	reservationRouter.HandleFunc("", r.handler.ListMyReservationsHandler).Methods("GET")
+	reservationRouter.HandleFunc("/{id}", r.handler.GetReservationHandler).Methods("GET")
+	reservationRouter.HandleFunc("/{id}/status", r.handler.UpdateReservationStatusHandler).Methods("POST")
+}
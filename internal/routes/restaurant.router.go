@@ -0,0 +1,33 @@
+package routes
+
+import (
+	"github.com/alibaba0010/postgres-api/internal/types"
+	"github.com/gorilla/mux"
+)
+
+// RestaurantRoutes registers restaurant handlers. Reads are public; creating and
+// mutating a restaurant requires the matching permission.
+func (r *Router) RestaurantRoutes(route *mux.Router) {
+	// Public read endpoints
+	route.HandleFunc("/restaurants", r.handler.ListRestaurantsHandler).Methods("GET")
+	route.HandleFunc("/restaurants/{id}", r.handler.GetRestaurantHandler).Methods("GET")
+
+	// Opening a restaurant is a platform permission
+	createRouter := route.PathPrefix("/restaurants").Subrouter()
+	createRouter.Use(r.guard.AuthMiddleware)
+	createRouter.Use(r.guard.RequirePermission(types.PermRestaurantCreate))
+	createRouter.HandleFunc("", r.handler.CreateRestaurantHandler).Methods("POST")
+
+	// Editing and deleting need the permission in that restaurant
+	updateRouter := route.PathPrefix("/restaurants/{id}").Subrouter()
+	updateRouter.Use(r.guard.AuthMiddleware)
+	updateRouter.Use(r.guard.TenantMiddleware)
+	updateRouter.Use(r.guard.RequireRestaurantPermission(types.PermRestaurantWrite))
+	updateRouter.HandleFunc("", r.handler.UpdateRestaurantHandler).Methods("PUT", "PATCH")
+
+	deleteRouter := route.PathPrefix("/restaurants/{id}").Subrouter()
+	deleteRouter.Use(r.guard.AuthMiddleware)
+	deleteRouter.Use(r.guard.TenantMiddleware)
+	deleteRouter.Use(r.guard.RequireRestaurantPermission(types.PermRestaurantDelete))
+	deleteRouter.HandleFunc("", r.handler.DeleteRestaurantHandler).Methods("DELETE")
+}
@@ -0,0 +1,33 @@
+package routes
+
+import (
+	"github.com/alibaba0010/postgres-api/internal/types"
+	"github.com/gorilla/mux"
+)
+
+// RoleRoutes registers role and restaurant staff handlers
+func (r *Router) RoleRoutes(route *mux.Router) {
+	// Anyone who manages staff somewhere can see what can be granted
+	roleRouter := route.PathPrefix("/roles").Subrouter()
+	roleRouter.Use(r.guard.AuthMiddleware)
+	roleRouter.Use(r.guard.RequirePermission(types.PermStaffManage))
+	roleRouter.HandleFunc("", r.handler.ListRolesHandler).Methods("GET")
+	roleRouter.HandleFunc("/permissions", r.handler.ListPermissionsHandler).Methods("GET")
+
+	// Defining roles is a platform permission
+	roleAdminRouter := route.PathPrefix("/roles").Subrouter()
+	roleAdminRouter.Use(r.guard.AuthMiddleware)
+	roleAdminRouter.Use(r.guard.RequirePermission(types.PermRoleManage))
+	roleAdminRouter.HandleFunc("", r.handler.CreateRoleHandler).Methods("POST")
+	roleAdminRouter.HandleFunc("/{id}", r.handler.UpdateRoleHandler).Methods("PUT", "PATCH")
+	roleAdminRouter.HandleFunc("/{id}", r.handler.DeleteRoleHandler).Methods("DELETE")
+
+	// Who holds which role in a restaurant
+	staffRouter := route.PathPrefix("/restaurants/{id}/staff").Subrouter()
+	staffRouter.Use(r.guard.AuthMiddleware)
+	staffRouter.Use(r.guard.TenantMiddleware)
+	staffRouter.Use(r.guard.RequireRestaurantPermission(types.PermStaffManage))
+	staffRouter.HandleFunc("", r.handler.ListStaffHandler).Methods("GET")
+	staffRouter.HandleFunc("", r.handler.AssignStaffHandler).Methods("POST")
+	staffRouter.HandleFunc("/{assignmentId}", r.handler.RemoveStaffHandler).Methods("DELETE")
+}
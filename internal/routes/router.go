@@ -4,33 +4,97 @@ import (
 	"encoding/json"
 	"net/http"
 
+	"github.com/alibaba0010/postgres-api/internal/auth"
+	"github.com/alibaba0010/postgres-api/internal/config"
+	"github.com/alibaba0010/postgres-api/internal/controllers"
 	"github.com/alibaba0010/postgres-api/internal/errors"
+	"github.com/alibaba0010/postgres-api/internal/guards"
 	"github.com/alibaba0010/postgres-api/internal/logger"
+	"github.com/alibaba0010/postgres-api/internal/middlewares"
+	"github.com/alibaba0010/postgres-api/internal/utils"
 	"github.com/gorilla/mux"
 	httpSwagger "github.com/swaggo/http-swagger"
+	"go.uber.org/zap"
 )
 
-func ApiRouter() *mux.Router {
+// Router registers the endpoints of the API with the middleware guarding them
+type Router struct {
+	handler *controllers.Handler
+	guard   *guards.Guard
+	limiter *middlewares.RateLimiter
+	cfg     config.Config
+	log     *zap.Logger
+}
+
+// New returns a router serving handler behind guard and limiter, with the
+// rate limits of cfg, logging requests with log
+func New(handler *controllers.Handler, guard *guards.Guard, limiter *middlewares.RateLimiter, cfg config.Config, log *zap.Logger) *Router {
+	return &Router{handler: handler, guard: guard, limiter: limiter, cfg: cfg, log: log}
+}
+
+func (r *Router) ApiRouter() *mux.Router {
 	route := mux.NewRouter()
+	// The caller is only ever taken from the request context; headers that
+	// claim an identity are dropped before anything can read them
+	route.Use(auth.StripIdentityHeaders)
+	// Forwarded client addresses are only believed from the configured
+	// proxies; Validate has already rejected malformed entries
+	proxies, err := utils.ParseTrustedProxies(r.cfg.TRUSTED_PROXIES)
+	if err != nil {
+		panic(err)
+	}
+	route.Use(proxies.Middleware)
+	// The request ID and the logger go first so that the recovery middleware
+	// and every handler find them in the request context
+	route.Use(logger.RequestID)
+	route.Use(logger.Logger(r.log))
 	// Add recovery middleware early so panics are caught and do not print stack traces.	
 	route.Use(errors.RecoverMiddleware)
-	route.Use(logger.Logger)
 	
 	// Serve Swagger UI at /swagger/
 	route.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
+
+	// Public keys for verifying access tokens, at the well-known location
+	// outside the versioned API
+	route.HandleFunc("/.well-known/jwks.json", r.handler.JWKSHandler).Methods("GET")
 	
 	// Create v1 subrouter with /api/v1 prefix
 	v1 := route.PathPrefix("/api/v1").Subrouter()
+	// Every client gets a generous budget across the whole API; bursts are fine
+	v1.Use(r.limiter.RateLimit(middlewares.RateLimitPolicy{
+		Name:      "api",
+		Limit:     r.cfg.RATE_LIMIT_API,
+		Window:    r.cfg.RATE_LIMIT_API_WINDOW,
+		Algorithm: middlewares.TokenBucket,
+		Key:       r.limiter.KeyByAPIKey,
+	}))
+
+	// Credential endpoints are strictly limited per IP on top of that
+	authRouter := v1.PathPrefix("/auth").Subrouter()
+	authRouter.Use(r.limiter.RateLimit(middlewares.RateLimitPolicy{
+		Name:      "auth",
+		Limit:     r.cfg.RATE_LIMIT_AUTH,
+		Window:    r.cfg.RATE_LIMIT_AUTH_WINDOW,
+		Algorithm: middlewares.SlidingWindow,
+		Key:       middlewares.KeyByIP,
+	}))
 	
 // Routes
 v1.HandleFunc("/healthcheck", HealthCheckHandler).Methods("GET")
-	AuthRoutes(v1.PathPrefix("/auth").Subrouter())
-	UserRoutes(v1)
+	r.AuthRoutes(authRouter)
+	r.UserRoutes(v1)
+	r.RestaurantRoutes(v1)
+	r.MenuRoutes(v1)
+	r.OrderRoutes(v1)
+	r.ReservationRoutes(v1)
+	r.RoleRoutes(v1)
+	r.InvitationRoutes(v1)
 
 
-	route.NotFoundHandler = http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+	// mux does not run route.Use middleware for unmatched routes
+	route.NotFoundHandler = logger.RequestID(logger.Logger(r.log)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
 		errors.ErrorResponse(writer, request, errors.RouteNotExist())
-	})
+	})))
 
 	return route
 }
@@ -1,28 +1,73 @@
 package routes
 
 import (
-	"github.com/alibaba0010/postgres-api/internal/controllers"
-	"github.com/alibaba0010/postgres-api/internal/guards"
+	"github.com/alibaba0010/postgres-api/internal/middlewares"
+	"github.com/alibaba0010/postgres-api/internal/types"
 	"github.com/gorilla/mux"
 )
 
 // UserRoutes defines user-related routes with appropriate middleware and role-based access control
-func UserRoutes(route *mux.Router) {
+func (r *Router) UserRoutes(route *mux.Router) {
 	// All user routes require authentication
 	userRouter := route.PathPrefix("/user").Subrouter()
-	userRouter.Use(guards.AuthMiddleware)
+	userRouter.Use(r.guard.AuthMiddleware)
 
 	// GET /user - Get current authenticated user (accessible to all authenticated users)
-	userRouter.HandleFunc("", controllers.CurrentUserHandler).Methods("GET")
-
-	// Additional role-based endpoints can be added here:
-	// Example: GET /user/admin - only for admin users
-	// adminRouter := userRouter.PathPrefix("/admin").Subrouter()
-	// adminRouter.Use(guards.RequireRole("admin"))
-	// adminRouter.HandleFunc("", controllers.ListAllUsersHandler).Methods("GET")
-
-	// Example: GET /user/profile - only for users and admins (can be extended)
-	// profileRouter := userRouter.PathPrefix("/profile").Subrouter()
-	// profileRouter.Use(guards.RequireRole("user", "admin"))
-	// profileRouter.HandleFunc("", controllers.GetProfileHandler).Methods("GET")
+	userRouter.HandleFunc("", r.handler.CurrentUserHandler).Methods("GET")
+	userRouter.HandleFunc("", r.handler.UpdateProfileHandler).Methods("PATCH")
+
+	// Changing credentials checks the current password, so guesses are limited per user
+	credentialRouter := userRouter.NewRoute().Subrouter()
+	credentialRouter.Use(r.limiter.RateLimit(middlewares.RateLimitPolicy{
+		Name:      "credentials",
+		Limit:     r.cfg.RATE_LIMIT_CREDENTIALS,
+		Window:    r.cfg.RATE_LIMIT_CREDENTIALS_WINDOW,
+		Algorithm: middlewares.SlidingWindow,
+		Key:       r.limiter.KeyByUser,
+	}))
+	credentialRouter.HandleFunc("/change-password", r.handler.ChangePasswordHandler).Methods("POST")
+	credentialRouter.HandleFunc("/change-email", r.handler.ChangeEmailHandler).Methods("POST")
+	credentialRouter.HandleFunc("", r.handler.DeleteAccountHandler).Methods("DELETE")
+
+	// Personal data: download it, or take back a pending account deletion
+	userRouter.HandleFunc("/export", r.handler.ExportAccountHandler).Methods("GET")
+	userRouter.HandleFunc("/cancel-deletion", r.handler.CancelAccountDeletionHandler).Methods("POST")
+
+	// Devices the current user is signed in on
+	userRouter.HandleFunc("/sessions", r.handler.ListSessionsHandler).Methods("GET")
+	userRouter.HandleFunc("/sessions/{id}", r.handler.RevokeSessionHandler).Methods("DELETE")
+
+	// Restaurants the current user works at
+	userRouter.HandleFunc("/restaurants", r.handler.ListMembershipsHandler).Methods("GET")
+
+	// Social login providers linked to the account
+	userRouter.HandleFunc("/identities", r.handler.ListIdentitiesHandler).Methods("GET")
+	userRouter.HandleFunc("/identities/{provider}", r.handler.LinkIdentityHandler).Methods("POST")
+	userRouter.HandleFunc("/identities/{id}", r.handler.UnlinkIdentityHandler).Methods("DELETE")
+
+	// API keys for devices that cannot sign in, such as POS terminals
+	apiKeyRouter := userRouter.PathPrefix("/api-keys").Subrouter()
+	apiKeyRouter.Use(r.guard.RequireRole(types.RoleManagement.String()))
+	apiKeyRouter.HandleFunc("", r.handler.ListAPIKeysHandler).Methods("GET")
+	apiKeyRouter.HandleFunc("", r.handler.CreateAPIKeyHandler).Methods("POST")
+	apiKeyRouter.HandleFunc("/{id}", r.handler.RevokeAPIKeyHandler).Methods("DELETE")
+
+	// Two-factor authentication
+	userRouter.HandleFunc("/mfa/enroll", r.handler.EnrollMFAHandler).Methods("POST")
+	userRouter.HandleFunc("/mfa/confirm", r.handler.ConfirmMFAHandler).Methods("POST")
+	userRouter.HandleFunc("/mfa/disable", r.handler.DisableMFAHandler).Methods("POST")
+
+	// Admin-only management of other users
+	adminRouter := route.PathPrefix("/users").Subrouter()
+	adminRouter.Use(r.guard.AuthMiddleware)
+	adminRouter.Use(r.guard.RequirePermission(types.PermUserManage))
+	adminRouter.HandleFunc("", r.handler.ListUsersHandler).Methods("GET")
+	adminRouter.HandleFunc("/{id}", r.handler.GetUserHandler).Methods("GET")
+	adminRouter.HandleFunc("/{id}", r.handler.DeleteUserHandler).Methods("DELETE")
+	adminRouter.HandleFunc("/{id}/role", r.handler.ChangeUserRoleHandler).Methods("PATCH")
+	adminRouter.HandleFunc("/{id}/suspend", r.handler.SuspendUserHandler).Methods("POST")
+	adminRouter.HandleFunc("/{id}/reactivate", r.handler.ReactivateUserHandler).Methods("POST")
+	adminRouter.HandleFunc("/{id}/sessions", r.handler.AdminListUserSessionsHandler).Methods("GET")
+	adminRouter.HandleFunc("/{id}/sessions/{sessionId}", r.handler.AdminRevokeUserSessionHandler).Methods("DELETE")
+
 }
\ No newline at end of file
@@ -0,0 +1,325 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/uptrace/bun"
+	"go.uber.org/zap"
+
+	"github.com/alibaba0010/postgres-api/internal/dto"
+	"github.com/alibaba0010/postgres-api/internal/errors"
+	"github.com/alibaba0010/postgres-api/internal/models"
+	"github.com/alibaba0010/postgres-api/internal/types"
+	"github.com/alibaba0010/postgres-api/internal/utils"
+)
+
+// AccountDeletionGrace is how long a requested account deletion can still be
+// cancelled before personal data is erased
+const AccountDeletionGrace = 30 * 24 * time.Hour
+
+// anonymizedName replaces the name of a deleted account
+const anonymizedName = "Deleted user"
+
+// AccountExport is everything held about a user, returned by GET /user/export
+type AccountExport struct {
+	GeneratedAt  time.Time                `json:"generated_at"`
+	Profile      *dto.CurrentUserResponse `json:"profile"`
+	Sessions     []ExportedSession        `json:"sessions"`
+	Memberships  []dto.MembershipResponse `json:"memberships"`
+	Identities   []models.UserIdentity    `json:"identities"`
+	Orders       []models.Order           `json:"orders"`
+	Reservations []models.Reservation     `json:"reservations"`
+}
+
+// ExportedSession is one refresh token of the user without the token itself
+type ExportedSession struct {
+	SessionID string     `json:"session_id"`
+	IPAddress string     `json:"ip_address,omitempty"`
+	UserAgent string     `json:"user_agent,omitempty"`
+	IssuedAt  time.Time  `json:"issued_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RotatedAt *time.Time `json:"rotated_at,omitempty"`
+}
+
+// ExportAccountData collects the profile, sign-in history, restaurant
+// memberships, linked identities, orders and reservations of a user
+func (s *Service) ExportAccountData(ctx context.Context, userID string) (*AccountExport, *errors.AppError) {
+	user, appErr := s.getUserByID(ctx, userID)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	export := &AccountExport{
+		GeneratedAt:  s.clock.Now().UTC(),
+		Profile:      currentUserResponse(user),
+		Sessions:     make([]ExportedSession, 0),
+		Orders:       make([]models.Order, 0),
+		Reservations: make([]models.Reservation, 0),
+	}
+
+	var tokens []models.RefreshToken
+	if err := s.db.NewSelect().Model(&tokens).
+		Where("user_id = ?", userID).
+		Order("created_at ASC").
+		Scan(ctx); err != nil {
+		s.logger(ctx).Error("failed to export sessions", zap.Error(err), zap.String("user_id", userID))
+		return nil, errors.InternalError(err)
+	}
+	for _, token := range tokens {
+		session := ExportedSession{
+			SessionID: token.FamilyID,
+			IPAddress: token.IPAddress,
+			UserAgent: token.UserAgent,
+			IssuedAt:  token.CreatedAt,
+			ExpiresAt: token.ExpiresAt,
+		}
+		if !token.RotatedAt.IsZero() {
+			rotatedAt := token.RotatedAt
+			session.RotatedAt = &rotatedAt
+		}
+		export.Sessions = append(export.Sessions, session)
+	}
+
+	memberships, appErr := s.ListMemberships(ctx, userID, "")
+	if appErr != nil {
+		return nil, appErr
+	}
+	export.Memberships = memberships
+
+	identities, appErr := s.ListIdentities(ctx, userID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	export.Identities = identities
+
+	if err := s.db.NewSelect().Model(&export.Orders).
+		Relation("Items").
+		Relation("Transitions").
+		Where("customer_id = ?", userID).
+		OrderExpr("created_at ASC").
+		Scan(ctx); err != nil {
+		s.logger(ctx).Error("failed to export orders", zap.Error(err), zap.String("user_id", userID))
+		return nil, errors.InternalError(err)
+	}
+
+	if err := s.db.NewSelect().Model(&export.Reservations).
+		Relation("Table").
+		Where("?TableAlias.customer_id = ?", userID).
+		OrderExpr("?TableAlias.starts_at ASC").
+		Scan(ctx); err != nil {
+		s.logger(ctx).Error("failed to export reservations", zap.Error(err), zap.String("user_id", userID))
+		return nil, errors.InternalError(err)
+	}
+
+	s.logger(ctx).Info("account data exported", zap.String("user_id", userID))
+	return export, nil
+}
+
+// RequestAccountDeletion schedules the deletion of the current user's account
+// after AccountDeletionGrace and signs them out everywhere. Signing in again
+// before then lets them cancel it.
+func (s *Service) RequestAccountDeletion(ctx context.Context, userID string, input dto.DeleteAccountInput) (*dto.AccountDeletionResponse, *errors.AppError) {
+	if appErr := validateInput(input); appErr != nil {
+		return nil, appErr
+	}
+
+	user, appErr := s.getUserByID(ctx, userID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if !verifyPassword(input.Password, user.Password) {
+		s.logger(ctx).Warn("account deletion with a wrong password", zap.String("user_id", userID))
+		return nil, errors.ValidationError("password is incorrect")
+	}
+	if !user.DeletionScheduledAt.IsZero() {
+		return nil, errors.ConflictError("the account is already scheduled for deletion")
+	}
+	if user.Role == types.RoleAdmin.String() {
+		return nil, errors.ForbiddenError("admins cannot delete their own account")
+	}
+	ownsRestaurants, err := models.Exists(ctx, s.db.NewSelect().Model((*models.Restaurant)(nil)).
+		Where("owner_id = ?", userID))
+	if err != nil {
+		return nil, errors.InternalError(err)
+	}
+	if ownsRestaurants {
+		return nil, errors.ConflictError("delete your restaurants before deleting your account")
+	}
+
+	user.DeletionScheduledAt = s.clock.Now().Add(AccountDeletionGrace)
+	user.UpdatedAt = s.clock.Now()
+	if _, err := s.db.NewUpdate().Model(user).
+		Column("deletion_scheduled_at", "updated_at").
+		WherePK().
+		Exec(ctx); err != nil {
+		s.logger(ctx).Error("failed to schedule account deletion", zap.Error(err), zap.String("user_id", userID))
+		return nil, errors.InternalError(err)
+	}
+	if appErr := s.RevokeAllRefreshTokens(ctx, userID); appErr != nil {
+		return nil, appErr
+	}
+
+	html := s.AccountDeletionHTML(user.Name, user.DeletionScheduledAt.UTC().Format("Monday, 2 January 2006"))
+	s.runInBackground(func() {
+		if err := s.mailer.Send(user.Email, "Your account will be deleted", html); err != nil {
+			s.logger(ctx).Error("failed to send account deletion email", zap.Error(err), zap.String("email", user.Email))
+		}
+	})
+
+	s.logger(ctx).Info("account deletion scheduled",
+		zap.String("user_id", userID),
+		zap.Time("deletion_scheduled_at", user.DeletionScheduledAt))
+	return &dto.AccountDeletionResponse{
+		Message:             "Your account will be deleted; sign in before then to cancel",
+		DeletionScheduledAt: user.DeletionScheduledAt,
+	}, nil
+}
+
+// CancelAccountDeletion keeps an account whose deletion was requested
+func (s *Service) CancelAccountDeletion(ctx context.Context, userID string) (*dto.CurrentUserResponse, *errors.AppError) {
+	user, appErr := s.getUserByID(ctx, userID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if user.DeletionScheduledAt.IsZero() {
+		return nil, errors.ConflictError("the account is not scheduled for deletion")
+	}
+
+	user.DeletionScheduledAt = time.Time{}
+	user.UpdatedAt = s.clock.Now()
+	if _, err := s.db.NewUpdate().Model(user).
+		Column("deletion_scheduled_at", "updated_at").
+		WherePK().
+		Exec(ctx); err != nil {
+		s.logger(ctx).Error("failed to cancel account deletion", zap.Error(err), zap.String("user_id", userID))
+		return nil, errors.InternalError(err)
+	}
+
+	s.logger(ctx).Info("account deletion cancelled", zap.String("user_id", userID))
+	return currentUserResponse(user), nil
+}
+
+// CompleteAccountDeletions erases the personal data of every account whose
+// deletion grace period is over. The user row is kept, anonymized and
+// soft-deleted, so orders stay attached to it for accounting.
+func (s *Service) CompleteAccountDeletions(ctx context.Context) error {
+	var due []models.User
+	if err := s.db.NewSelect().Model(&due).
+		Column("id").
+		Where("deletion_scheduled_at <= ?", s.clock.Now()).
+		Scan(ctx); err != nil {
+		return err
+	}
+
+	for _, user := range due {
+		if err := s.anonymizeUser(ctx, user.ID); err != nil {
+			s.logger(ctx).Error("failed to complete account deletion", zap.Error(err), zap.String("user_id", user.ID))
+			continue
+		}
+		if appErr := s.blockUser(ctx, user.ID, userBlockedDeleted); appErr != nil {
+			s.logger(ctx).Warn("failed to block deleted user", zap.Error(appErr), zap.String("user_id", user.ID))
+		}
+		s.logger(ctx).Info("account deleted", zap.String("user_id", user.ID))
+	}
+	return nil
+}
+
+// StartAccountDeletionJob runs CompleteAccountDeletions every hour in the
+// background, like the refresh token janitor.
+func (s *Service) StartAccountDeletionJob() {
+	s.startJob("account deletion", time.Hour, func(ctx context.Context) {
+		if err := s.CompleteAccountDeletions(ctx); err != nil {
+			s.log.Error("account deletion run failed", zap.Error(err))
+		}
+	})
+}
+
+// anonymizeUser clears the personal fields of a user and of the records that
+// outlive them, removes their credentials and memberships, cancels their
+// upcoming reservations and soft-deletes the account
+func (s *Service) anonymizeUser(ctx context.Context, userID string) error {
+	return s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		user := &models.User{}
+		// lock the row so a cancellation racing the job either wins or waits
+		err := tx.NewSelect().Model(user).
+			Where("id = ?", userID).
+			Where("deletion_scheduled_at <= ?", s.clock.Now()).
+			For("UPDATE").
+			Scan(ctx)
+		if err != nil {
+			return err
+		}
+
+		// nobody knows this password; the column stays set
+		secret, err := utils.GenerateToken()
+		if err != nil {
+			return err
+		}
+		unusable, err := hashPassword(secret)
+		if err != nil {
+			return err
+		}
+		previousEmail := user.Email
+		user.Name = anonymizedName
+		user.Email = "deleted-" + user.ID + "@deleted.invalid"
+		user.Password = unusable
+		user.Address = ""
+		user.Phone = ""
+		user.MFAEnabled = false
+		user.MFASecret = ""
+		user.MFALastStep = 0
+		user.SuspendedReason = ""
+		user.UpdatedAt = s.clock.Now()
+		if _, err := tx.NewUpdate().Model(user).
+			Column("name", "email", "password", "address", "phone", "mfa_enabled", "mfa_secret", "mfa_last_step", "suspended_reason", "updated_at").
+			WherePK().
+			Exec(ctx); err != nil {
+			return err
+		}
+
+		for _, model := range []any{
+			(*models.RefreshToken)(nil),
+			(*models.MFARecoveryCode)(nil),
+			(*models.RoleAssignment)(nil),
+			(*models.UserIdentity)(nil),
+			(*models.APIKey)(nil),
+		} {
+			if _, err := tx.NewDelete().Model(model).Where("user_id = ?", userID).Exec(ctx); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.NewUpdate().Model((*models.StaffInvitation)(nil)).
+			Set("email = ?", user.Email).
+			Where("lower(email) = lower(?)", previousEmail).
+			Exec(ctx); err != nil {
+			return err
+		}
+
+		// free-text notes may hold personal details; amounts and items stay
+		if _, err := tx.NewUpdate().Model((*models.Order)(nil)).
+			Set("notes = NULL").
+			Where("customer_id = ?", userID).
+			Exec(ctx); err != nil {
+			return err
+		}
+		if _, err := tx.NewUpdate().Model((*models.OrderItem)(nil)).
+			Set("notes = NULL").
+			Where("order_id IN (?)", tx.NewSelect().Model((*models.Order)(nil)).Column("id").Where("customer_id = ?", userID)).
+			Exec(ctx); err != nil {
+			return err
+		}
+		if _, err := tx.NewUpdate().Model((*models.Reservation)(nil)).
+			Set("notes = NULL").
+			Set("status = CASE WHEN status = ? AND starts_at > current_timestamp THEN ? ELSE status END",
+				models.ReservationStatusConfirmed, models.ReservationStatusCancelled).
+			Set("updated_at = current_timestamp").
+			Where("customer_id = ?", userID).
+			Exec(ctx); err != nil {
+			return err
+		}
+
+		_, err = tx.NewDelete().Model(user).WherePK().Exec(ctx)
+		return err
+	})
+}
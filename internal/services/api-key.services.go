@@ -0,0 +1,259 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/alibaba0010/postgres-api/internal/dto"
+	"github.com/alibaba0010/postgres-api/internal/errors"
+	"github.com/alibaba0010/postgres-api/internal/models"
+	"github.com/alibaba0010/postgres-api/internal/types"
+	"github.com/alibaba0010/postgres-api/internal/utils"
+)
+
+// An API key reads "rmk_<12 hex>_<64 hex>". The first 16 characters are the
+// prefix stored in clear to find the key and to tell keys apart in listings
+// and secret scanners; the rest is the secret, of which only the SHA-256 is
+// kept. The secret is random enough that a slow hash buys nothing.
+const (
+	apiKeyScheme       = "rmk_"
+	apiKeyPrefixLength = len(apiKeyScheme) + 12
+)
+
+// APIKeyDefaultLifetime is how long a key works when no expiry is asked for
+const APIKeyDefaultLifetime = 90 * 24 * time.Hour
+
+// apiKeyTouchInterval limits how often a busy key's last_used_at is written
+const apiKeyTouchInterval = time.Minute
+
+// CreateAPIKey creates a key acting for the user in one of their restaurants.
+// The returned response is the only place the key can be read.
+func (s *Service) CreateAPIKey(ctx context.Context, userID, role string, input dto.CreateAPIKeyInput) (*dto.CreatedAPIKeyResponse, *errors.AppError) {
+	input.Name = strings.TrimSpace(input.Name)
+	if appErr := validateInput(input); appErr != nil {
+		return nil, appErr
+	}
+	if appErr := s.AuthorizeTenant(ctx, userID, role, input.RestaurantID); appErr != nil {
+		return nil, appErr
+	}
+
+	newUUID, err := utils.GenerateUUIDv7()
+	if err != nil {
+		return nil, errors.InternalError(err)
+	}
+	prefixBytes := make([]byte, 6)
+	if _, err := rand.Read(prefixBytes); err != nil {
+		return nil, errors.InternalError(err)
+	}
+	secret, err := utils.GenerateToken()
+	if err != nil {
+		return nil, errors.InternalError(err)
+	}
+	prefix := apiKeyScheme + hex.EncodeToString(prefixBytes)
+
+	lifetime := APIKeyDefaultLifetime
+	if input.ExpiresInDays > 0 {
+		lifetime = time.Duration(input.ExpiresInDays) * 24 * time.Hour
+	}
+	now := s.clock.Now()
+	key := &models.APIKey{
+		ID:           newUUID.String(),
+		UserID:       userID,
+		RestaurantID: input.RestaurantID,
+		Name:         input.Name,
+		Prefix:       prefix,
+		KeyHash:      hashAPIKeySecret(secret),
+		ExpiresAt:    now.Add(lifetime),
+		CreatedAt:    now,
+	}
+	if _, err := s.db.NewInsert().Model(key).Exec(ctx); err != nil {
+		s.logger(ctx).Error("failed to create api key", zap.Error(err), zap.String("user_id", userID))
+		return nil, errors.InternalError(err)
+	}
+
+	s.logger(ctx).Info("api key created",
+		zap.String("user_id", userID),
+		zap.String("api_key_id", key.ID),
+		zap.String("restaurant_id", key.RestaurantID))
+	return &dto.CreatedAPIKeyResponse{
+		APIKeyResponse: apiKeyResponse(key),
+		Key:            prefix + "_" + secret,
+	}, nil
+}
+
+// ListAPIKeys returns the user's keys that have not been revoked, newest first
+func (s *Service) ListAPIKeys(ctx context.Context, userID string) ([]dto.APIKeyResponse, *errors.AppError) {
+	var keys []models.APIKey
+	if err := s.db.NewSelect().Model(&keys).
+		Where("user_id = ?", userID).
+		Where("revoked_at IS NULL").
+		OrderExpr("created_at DESC").
+		Scan(ctx); err != nil {
+		s.logger(ctx).Error("failed to list api keys", zap.Error(err), zap.String("user_id", userID))
+		return nil, errors.InternalError(err)
+	}
+
+	response := make([]dto.APIKeyResponse, 0, len(keys))
+	for i := range keys {
+		response = append(response, apiKeyResponse(&keys[i]))
+	}
+	return response, nil
+}
+
+// RevokeAPIKey stops one of the user's keys from working, at once
+func (s *Service) RevokeAPIKey(ctx context.Context, userID, keyID string) *errors.AppError {
+	if _, err := uuid.Parse(keyID); err != nil {
+		return errors.NotFoundError("API key not found")
+	}
+	result, err := s.db.NewUpdate().Model((*models.APIKey)(nil)).
+		Set("revoked_at = current_timestamp").
+		Where("id = ?", keyID).
+		Where("user_id = ?", userID).
+		Where("revoked_at IS NULL").
+		Exec(ctx)
+	if err != nil {
+		s.logger(ctx).Error("failed to revoke api key", zap.Error(err), zap.String("api_key_id", keyID))
+		return errors.InternalError(err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return errors.NotFoundError("API key not found")
+	}
+	s.logger(ctx).Info("api key revoked", zap.String("user_id", userID), zap.String("api_key_id", keyID))
+	return nil
+}
+
+// AuthenticateAPIKey returns the key presented in an "Authorization: ApiKey"
+// header together with the role of the user it acts for
+func (s *Service) AuthenticateAPIKey(ctx context.Context, rawKey string) (*models.APIKey, string, *errors.AppError) {
+	key, appErr := s.findAPIKey(ctx, rawKey)
+	if appErr != nil {
+		return nil, "", appErr
+	}
+
+	// the key acts with the user's current role, and only management may hold keys
+	user, appErr := s.getUserByID(ctx, key.UserID)
+	if appErr != nil {
+		return nil, "", errors.UnauthorizedError("invalid API key")
+	}
+	// SuspendUser revokes the keys, but a suspension must hold even for a key
+	// that escaped it; the blocked user marker only lasts AccessTokenDuration
+	if user.IsSuspended() {
+		s.logger(ctx).Warn("api key of a suspended user presented",
+			zap.String("api_key_id", key.ID),
+			zap.String("user_id", user.ID))
+		return nil, "", blockedUserError(userBlockedSuspended)
+	}
+	if !s.CheckRolePermission(user.Role, types.RoleManagement.String()) {
+		s.logger(ctx).Warn("api key of a user without management role presented",
+			zap.String("api_key_id", key.ID),
+			zap.String("user_id", user.ID))
+		return nil, "", errors.ForbiddenError("the owner of this API key may no longer use API keys")
+	}
+
+	s.touchAPIKey(ctx, key)
+	return key, user.Role, nil
+}
+
+// APIKeyID returns the ID of the live key rawKey, so its requests can be
+// counted before AuthMiddleware has run. ok is false for a malformed, unknown,
+// revoked or expired key and when the lookup fails.
+func (s *Service) APIKeyID(ctx context.Context, rawKey string) (string, bool) {
+	key, appErr := s.findAPIKey(ctx, rawKey)
+	if appErr != nil {
+		return "", false
+	}
+	return key.ID, true
+}
+
+// findAPIKey returns the live key whose prefix and secret match rawKey
+func (s *Service) findAPIKey(ctx context.Context, rawKey string) (*models.APIKey, *errors.AppError) {
+	rawKey = strings.TrimSpace(rawKey)
+	if len(rawKey) <= apiKeyPrefixLength+1 || !strings.HasPrefix(rawKey, apiKeyScheme) || rawKey[apiKeyPrefixLength] != '_' {
+		return nil, errors.UnauthorizedError("invalid API key")
+	}
+	prefix, secret := rawKey[:apiKeyPrefixLength], rawKey[apiKeyPrefixLength+1:]
+
+	key := &models.APIKey{}
+	err := s.db.NewSelect().Model(key).
+		Where("prefix = ?", prefix).
+		Where("revoked_at IS NULL").
+		Scan(ctx)
+	if err == sql.ErrNoRows {
+		return nil, errors.UnauthorizedError("invalid API key")
+	}
+	if err != nil {
+		s.logger(ctx).Error("failed to fetch api key", zap.Error(err))
+		return nil, errors.InternalError(err)
+	}
+	if subtle.ConstantTimeCompare([]byte(hashAPIKeySecret(secret)), []byte(key.KeyHash)) != 1 {
+		s.logger(ctx).Warn("api key with a wrong secret presented", zap.String("api_key_id", key.ID))
+		return nil, errors.UnauthorizedError("invalid API key")
+	}
+	if s.clock.Now().After(key.ExpiresAt) {
+		return nil, errors.UnauthorizedError("API key expired")
+	}
+	return key, nil
+}
+
+// revokeUserAPIKeys stops every key of the user from working, as when the
+// user is suspended or deleted. Lifting a suspension does not bring them back.
+func (s *Service) revokeUserAPIKeys(ctx context.Context, userID string) *errors.AppError {
+	result, err := s.db.NewUpdate().Model((*models.APIKey)(nil)).
+		Set("revoked_at = current_timestamp").
+		Where("user_id = ?", userID).
+		Where("revoked_at IS NULL").
+		Exec(ctx)
+	if err != nil {
+		s.logger(ctx).Error("failed to revoke api keys", zap.Error(err), zap.String("user_id", userID))
+		return errors.InternalError(err)
+	}
+	if rows, _ := result.RowsAffected(); rows > 0 {
+		s.logger(ctx).Info("api keys revoked", zap.String("user_id", userID), zap.Int64("count", rows))
+	}
+	return nil
+}
+
+// touchAPIKey records that the key was used; failures only cost the timestamp
+func (s *Service) touchAPIKey(ctx context.Context, key *models.APIKey) {
+	now := s.clock.Now()
+	if now.Sub(key.LastUsedAt) < apiKeyTouchInterval {
+		return
+	}
+	key.LastUsedAt = now
+	if _, err := s.db.NewUpdate().Model(key).
+		Column("last_used_at").
+		WherePK().
+		Exec(ctx); err != nil {
+		s.logger(ctx).Warn("failed to update api key", zap.Error(err), zap.String("api_key_id", key.ID))
+	}
+}
+
+func hashAPIKeySecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func apiKeyResponse(key *models.APIKey) dto.APIKeyResponse {
+	response := dto.APIKeyResponse{
+		ID:           key.ID,
+		Name:         key.Name,
+		Prefix:       key.Prefix,
+		RestaurantID: key.RestaurantID,
+		ExpiresAt:    key.ExpiresAt,
+		CreatedAt:    key.CreatedAt,
+	}
+	if !key.LastUsedAt.IsZero() {
+		lastUsedAt := key.LastUsedAt
+		response.LastUsedAt = &lastUsedAt
+	}
+	return response
+}
@@ -0,0 +1,110 @@
+package services_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alibaba0010/postgres-api/internal/apptest"
+	"github.com/alibaba0010/postgres-api/internal/dto"
+	"github.com/alibaba0010/postgres-api/internal/models"
+	"github.com/alibaba0010/postgres-api/internal/services"
+)
+
+// createAPIKey returns a new key of a management user's restaurant
+func createAPIKey(t *testing.T, env *apptest.Env) (*models.User, string) {
+	t.Helper()
+	owner := env.CreateUser(t, "management")
+	restaurant := env.CreateRestaurant(t, owner)
+	created, appErr := env.Services.CreateAPIKey(context.Background(), owner.ID, owner.Role, dto.CreateAPIKeyInput{
+		Name:         "POS",
+		RestaurantID: restaurant.ID,
+	})
+	if appErr != nil {
+		t.Fatalf("create api key: %v", appErr)
+	}
+	return owner, created.Key
+}
+
+func getCurrentUser(env *apptest.Env, authorization string) int {
+	request := httptest.NewRequest(http.MethodGet, "/api/v1/user", nil)
+	request.Header.Set("Authorization", authorization)
+	recorder := httptest.NewRecorder()
+	env.HTTPHandler().ServeHTTP(recorder, request)
+	return recorder.Code
+}
+
+func TestAPIKeyWorksForActiveOwner(t *testing.T) {
+	env := apptest.NewWithDB(t)
+	owner, key := createAPIKey(t, env)
+
+	authenticated, role, appErr := env.Services.AuthenticateAPIKey(context.Background(), key)
+	if appErr != nil {
+		t.Fatalf("authenticate: %v", appErr)
+	}
+	if authenticated.UserID != owner.ID || role != "management" {
+		t.Fatalf("key acts for %s as %q, want %s as management", authenticated.UserID, role, owner.ID)
+	}
+	if code := getCurrentUser(env, "ApiKey "+key); code != http.StatusOK {
+		t.Fatalf("GET /user with the key: status %d, want 200", code)
+	}
+}
+
+func TestSuspensionRevokesAPIKeysForGood(t *testing.T) {
+	env := apptest.NewWithDB(t)
+	ctx := context.Background()
+	owner, key := createAPIKey(t, env)
+	admin := env.CreateUser(t, "admin")
+
+	if _, appErr := env.Services.SuspendUser(ctx, admin.ID, owner.ID, dto.SuspendUserInput{Reason: "chargebacks"}); appErr != nil {
+		t.Fatalf("suspend: %v", appErr)
+	}
+	if code := getCurrentUser(env, "ApiKey "+key); code == http.StatusOK {
+		t.Fatal("key of a suspended owner accepted right after the suspension")
+	}
+
+	// the blocked user marker is gone once every access token has expired
+	env.Clock.Advance(services.AccessTokenDuration + time.Minute)
+	if code := getCurrentUser(env, "ApiKey "+key); code == http.StatusOK {
+		t.Fatal("key of a suspended owner accepted after the blocked user marker expired")
+	}
+	if _, _, appErr := env.Services.AuthenticateAPIKey(ctx, key); appErr == nil {
+		t.Fatal("AuthenticateAPIKey accepted a key revoked by the suspension")
+	}
+
+	// lifting the suspension does not bring the keys back
+	if _, appErr := env.Services.ReactivateUser(ctx, admin.ID, owner.ID); appErr != nil {
+		t.Fatalf("reactivate: %v", appErr)
+	}
+	if _, _, appErr := env.Services.AuthenticateAPIKey(ctx, key); appErr == nil {
+		t.Fatal("key revoked by a suspension works again after reactivation")
+	}
+}
+
+func TestAPIKeyOfSuspendedOwnerRejectedWithoutMarker(t *testing.T) {
+	env := apptest.NewWithDB(t)
+	ctx := context.Background()
+	owner, key := createAPIKey(t, env)
+
+	// a suspension whose key revocation and marker are gone, as after the
+	// marker expired for a key created around the suspension
+	if _, err := env.DB.NewUpdate().Model((*models.User)(nil)).
+		Set("suspended_at = ?", env.Clock.Now()).
+		Where("id = ?", owner.ID).
+		Exec(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, appErr := env.Services.AuthenticateAPIKey(ctx, key)
+	if appErr == nil {
+		t.Fatal("key of a suspended owner accepted")
+	}
+	if appErr.Status != http.StatusForbidden {
+		t.Fatalf("status %d, want 403", appErr.Status)
+	}
+	if code := getCurrentUser(env, "ApiKey "+key); code != http.StatusForbidden {
+		t.Fatalf("GET /user with the key: status %d, want 403", code)
+	}
+}
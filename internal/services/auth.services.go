@@ -14,19 +14,17 @@ import (
 	"go.uber.org/zap"
 	"golang.org/x/crypto/argon2"
 
-	"github.com/alibaba0010/postgres-api/internal/config"
-	"github.com/alibaba0010/postgres-api/internal/database"
 	"github.com/alibaba0010/postgres-api/internal/dto"
 	"github.com/alibaba0010/postgres-api/internal/errors"
-	"github.com/alibaba0010/postgres-api/internal/logger"
 	"github.com/alibaba0010/postgres-api/internal/models"
+	"github.com/alibaba0010/postgres-api/internal/types"
 	"github.com/alibaba0010/postgres-api/internal/utils"
 )
 
 // RegisterUser handles the DB work for signing up a new user.
 // It checks for an existing email, hashes the password and inserts the user.
 // Returns the created user (with ID populated) or an AppError for controller to return.
-func RegisterUser(ctx context.Context, input dto.SignupInput) (*models.User, *errors.AppError) {
+func (s *Service) RegisterUser(ctx context.Context, input dto.SignupInput) (*models.User, *errors.AppError) {
 	// Validate input using same validation rules as controllers previously used
 	validate := validator.New()
 	dto.RegisterValidators(validate)
@@ -40,7 +38,7 @@ func RegisterUser(ctx context.Context, input dto.SignupInput) (*models.User, *er
 				field := fe.Field()
 				switch fe.Tag() {
 				case "oneof":
-					msg = fmt.Sprintf("%s can only either be user, admin or management", field)
+					msg = fmt.Sprintf("%s can only be user; staff accounts are created from invitations", field)
 				case "required":
 					msg = fmt.Sprintf("%s is required", field)
 				case "min":
@@ -65,14 +63,11 @@ func RegisterUser(ctx context.Context, input dto.SignupInput) (*models.User, *er
 		return nil, errors.ValidationError(err.Error())
 	}
 
-	// Set default role if not provided
-	role := input.Role
-	if role == "" {
-		role = "user"
-	}
+	// Self-signup only ever creates customer accounts
+	role := types.RoleUser.String()
 
 	// Check if user already exists
-	exists, err := database.DB.NewSelect().Model((*models.User)(nil)).
+	exists, err := s.db.NewSelect().Model((*models.User)(nil)).
 		Where("email = ?", input.Email).
 		Exists(ctx)
 	if err != nil {
@@ -128,31 +123,30 @@ func RegisterUser(ctx context.Context, input dto.SignupInput) (*models.User, *er
 
 	key := "verify:" + token
 	ttl := 15 * time.Minute
-	if err := database.RedisClient.Set(ctx, key, b, ttl).Err(); err != nil {
+	if err := s.cache.Set(ctx, key, b, ttl).Err(); err != nil {
 		return nil, errors.InternalError(err)
 	}
 
 	// Build verification URL
-	cfg := config.LoadConfig()
+	cfg := s.cfg
 	verifyURL := fmt.Sprintf("%s/api/v1/auth/verify?token=%s", cfg.FRONTEND_URL, token)
-	html := VerifyMailHTML(user.Name, verifyURL)
-		go func() {
-		if err := SendEmail(user.Email, "Verify your email", html); err != nil {
-			logger.Log.Error("failed to send verification email", 
+	html := s.VerifyMailHTML(user.Name, verifyURL)
+	s.runInBackground(func() {
+		if err := s.mailer.Send(user.Email, "Verify your email", html); err != nil {
+			s.logger(ctx).Error("failed to send verification email", 
 				zap.Error(err),
 				zap.String("email", user.Email),
-				zap.String("token", token),
 			)
 			// Optionally: Add to a retry queue here  future enhancement
 		}
-	}()
+	})
 
 	// Per new flow, registration doesn't persist the user yet — activation will.
 	return nil, nil
 }
-func ActivateUser(ctx context.Context, token string) (*models.User, *errors.AppError) {
+func (s *Service) ActivateUser(ctx context.Context, token string) (*models.User, *errors.AppError) {
 	key := "verify:" + token
-	data, err := database.RedisClient.Get(ctx, key).Bytes()
+	data, err := s.cache.Get(ctx, key).Bytes()
 	if err == redisPkg.Nil {
 		return nil, errors.ValidationError("invalid or expired token")
 	}
@@ -168,7 +162,7 @@ func ActivateUser(ctx context.Context, token string) (*models.User, *errors.AppE
 		Role     string `json:"role"`
 	}
 	if err := json.Unmarshal(data, &payload); err != nil {
-		_ = database.RedisClient.Del(ctx, key).Err()
+		_ = s.cache.Del(ctx, key).Err()
 		return nil, errors.InternalError(err)
 	}
 
@@ -182,7 +176,7 @@ func ActivateUser(ctx context.Context, token string) (*models.User, *errors.AppE
 	}
 
 	// Insert into DB
-	_, err = database.DB.NewInsert().Model(user).
+	_, err = s.db.NewInsert().Model(user).
 		Returning("id").
 		Exec(ctx)
 	if err != nil {
@@ -190,38 +184,106 @@ func ActivateUser(ctx context.Context, token string) (*models.User, *errors.AppE
 	}
 
 	// Token used within TTL -> remove it
-	if err := database.RedisClient.Del(ctx, key).Err(); err != nil {
-		logger.Log.Error("failed to delete verification token", zap.Error(err))
+	if err := s.cache.Del(ctx, key).Err(); err != nil {
+		s.logger(ctx).Error("failed to delete verification token", zap.Error(err))
 	}
 
 	return user, nil
 }
 
+// ResendVerification emails the verification link of a pending signup again.
+// The pending signup is looked up by email among the verify:* keys.
+func (s *Service) ResendVerification(ctx context.Context, email string) *errors.AppError {
+	// If a user already exists in DB, they are activated
+	exists, err := s.db.NewSelect().Model((*models.User)(nil)).Where("email = ?", email).Exists(ctx)
+	if err != nil {
+		return errors.InternalError(err)
+	}
+	if exists {
+		return errors.ValidationError("user already exists")
+	}
+
+	// Scan Redis for verify:* keys and find matching email
+	var cursor uint64
+	for {
+		keys, cur, err := s.cache.Scan(ctx, cursor, "verify:*", 100).Result()
+		if err != nil {
+			return errors.InternalError(err)
+		}
+		for _, k := range keys {
+			b, err := s.cache.Get(ctx, k).Bytes()
+			if err != nil {
+				continue
+			}
+			var payload struct{
+				ID string `json:"id"`
+				Name string `json:"name"`
+				Email string `json:"email"`
+			}
+			if err := json.Unmarshal(b, &payload); err != nil {
+				continue
+			}
+			if strings.EqualFold(payload.Email, email) {
+				token := strings.TrimPrefix(k, "verify:")
+				verifyURL := fmt.Sprintf("%s/api/v1/auth/verify?token=%s", s.cfg.FRONTEND_URL, token)
+				html := s.VerifyMailHTML(payload.Name, verifyURL)
+				if err := s.mailer.Send(email, "Verify your email", html); err != nil {
+					return errors.InternalError(err)
+				}
+				return nil
+			}
+		}
+		if cur == 0 {
+			break
+		}
+		cursor = cur
+	}
+	return errors.ValidationError("verification token not found or expired")
+}
+
 
 // LoginUser authenticates a user by email and password
 // Returns the user and generated token pair if successful
-func LoginUser(ctx context.Context, email, password string) (*models.User, *TokenPair, *errors.AppError) {
+// Failed attempts are counted per email and client IP; see CheckLoginAllowed
+func (s *Service) LoginUser(ctx context.Context, email, password, ip string) (*models.User, *TokenPair, *errors.AppError) {
 	if email == "" || password == "" {
 		return nil, nil, errors.ValidationError("email and password are required")
 	}
 
+	// Refuse locked out emails and IPs before spending time on the password hash
+	if appErr := s.CheckLoginAllowed(ctx, email, ip); appErr != nil {
+		s.logger(ctx).Warn("sign-in rejected by lockout", zap.String("email", email), zap.String("ip", ip))
+		return nil, nil, appErr
+	}
+
 	// Fetch user by email
 	user := &models.User{}
-	err := database.DB.NewSelect().Model(user).
+	err := s.db.NewSelect().Model(user).
 		Where("email = ?", email).
 		Scan(ctx)
 	if err != nil {
-		logger.Log.Debug("user not found for login", zap.String("email", email))
+		s.logger(ctx).Debug("user not found for login", zap.String("email", email))
+		if appErr := s.RecordLoginFailure(ctx, email, ip, nil); appErr != nil {
+			return nil, nil, appErr
+		}
 		return nil, nil, errors.UnauthorizedError("invalid email or password")
 	}
 
 	// Verify password
 	if !verifyPassword(password, user.Password) {
-		logger.Log.Warn("invalid password for login", zap.String("email", email))
+		s.logger(ctx).Warn("invalid password for login", zap.String("email", email), zap.String("ip", ip))
+		if appErr := s.RecordLoginFailure(ctx, email, ip, user); appErr != nil {
+			return nil, nil, appErr
+		}
 		return nil, nil, errors.UnauthorizedError("invalid email or password")
 	}
 
-	logger.Log.Debug("user authenticated successfully", zap.String("user_id", user.ID), zap.String("email", email))
+	s.ResetLoginFailures(ctx, email, ip)
+	if user.IsSuspended() {
+		s.logger(ctx).Warn("sign-in rejected for suspended user", zap.String("user_id", user.ID), zap.String("ip", ip))
+		return nil, nil, errors.ForbiddenError("this account is suspended")
+	}
+	s.logger(ctx).Debug("user authenticated successfully", zap.String("user_id", user.ID), zap.String("email", email))
 	return user, nil, nil
 }
 
@@ -0,0 +1,37 @@
+package services_test
+
+import (
+	"context"
+	stdErrors "errors"
+	"testing"
+
+	"github.com/alibaba0010/postgres-api/internal/apptest"
+	"github.com/alibaba0010/postgres-api/internal/dto"
+)
+
+func TestFailedVerificationEmailDoesNotLogToken(t *testing.T) {
+	env := apptest.NewWithDB(t)
+	ctx := context.Background()
+	env.Mailer.Fail(stdErrors.New("smtp: connection refused"))
+
+	if _, appErr := env.Services.RegisterUser(ctx, dto.SignupInput{
+		Name:            "New Diner",
+		Email:           apptest.Email("diner"),
+		Password:        "Secret#123",
+		ConfirmPassword: "Secret#123",
+	}); appErr != nil {
+		t.Fatalf("register: %v", appErr)
+	}
+	// the email is sent in the background
+	if err := env.Services.StopBackgroundWork(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	failures := env.Logs.FilterMessage("failed to send verification email").All()
+	if len(failures) != 1 {
+		t.Fatalf("%d failure entries, want 1", len(failures))
+	}
+	if _, ok := failures[0].ContextMap()["token"]; ok {
+		t.Fatal("verification token logged with the failure")
+	}
+}
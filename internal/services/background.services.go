@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+)
+
+// backgroundWork tracks the work the services start outside a request
+// (emails sent after the response, periodic jobs) so that a shutdown can wait
+// for it instead of killing it halfway through.
+type backgroundWork struct {
+	// mu orders wg.Add against the Wait of the shutdown: once stopped is set
+	// no goroutine is added
+	mu       sync.Mutex
+	stopped  bool
+	wg       sync.WaitGroup
+	stop     chan struct{}
+	stopOnce sync.Once
+	// ctx is handed to jobs and cancelled when the shutdown deadline passes
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func newBackgroundWork() *backgroundWork {
+	work := &backgroundWork{stop: make(chan struct{})}
+	work.ctx, work.cancel = context.WithCancel(context.Background())
+	return work
+}
+
+// runInBackground runs fn in a goroutine StopBackgroundWork waits for. Once
+// the shutdown has begun fn runs in the caller instead, e.g. a request still
+// being drained, so the work is neither lost nor left unwaited for.
+func (s *Service) runInBackground(fn func()) {
+	s.background.mu.Lock()
+	if s.background.stopped {
+		s.background.mu.Unlock()
+		fn()
+		return
+	}
+	s.background.wg.Add(1)
+	s.background.mu.Unlock()
+	go func() {
+		defer s.background.wg.Done()
+		fn()
+	}()
+}
+
+// startJob runs fn every interval until StopBackgroundWork is called. A run
+// in progress at that point is waited for; a job started after it never runs.
+func (s *Service) startJob(name string, interval time.Duration, fn func(ctx context.Context)) {
+	s.background.mu.Lock()
+	stopped := s.background.stopped
+	s.background.mu.Unlock()
+	if stopped {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	s.runInBackground(func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.background.stop:
+				s.log.Debug("background job stopped", zap.String("job", name))
+				return
+			case <-ticker.C:
+				fn(s.background.ctx)
+			}
+		}
+	})
+}
+
+// StopBackgroundWork stops the periodic jobs and waits for them and for the
+// emails still being sent. When ctx is done first, running jobs are
+// cancelled and its error is returned.
+func (s *Service) StopBackgroundWork(ctx context.Context) error {
+	s.background.stopOnce.Do(func() {
+		s.background.mu.Lock()
+		s.background.stopped = true
+		s.background.mu.Unlock()
+		close(s.background.stop)
+	})
+
+	done := make(chan struct{})
+	go func() {
+		s.background.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		s.background.cancel()
+		return nil
+	case <-ctx.Done():
+		s.background.cancel()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func newBackgroundService() *Service {
+	return &Service{background: newBackgroundWork(), log: zap.NewNop()}
+}
+
+func TestStopWaitsForBackgroundWork(t *testing.T) {
+	s := newBackgroundService()
+	release := make(chan struct{})
+	var finished atomic.Bool
+	s.runInBackground(func() {
+		<-release
+		finished.Store(true)
+	})
+
+	stopped := make(chan error)
+	go func() { stopped <- s.StopBackgroundWork(context.Background()) }()
+	select {
+	case <-stopped:
+		t.Fatal("StopBackgroundWork returned while work was running")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	if err := <-stopped; err != nil {
+		t.Fatal(err)
+	}
+	if !finished.Load() {
+		t.Fatal("StopBackgroundWork returned before the work finished")
+	}
+}
+
+func TestWorkAfterStopRunsInline(t *testing.T) {
+	s := newBackgroundService()
+	if err := s.StopBackgroundWork(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	ran := false
+	s.runInBackground(func() { ran = true })
+	if !ran {
+		t.Fatal("work submitted after the stop did not run before runInBackground returned")
+	}
+}
+
+func TestJobStartedAfterStopNeverRuns(t *testing.T) {
+	s := newBackgroundService()
+	if err := s.StopBackgroundWork(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var runs atomic.Int32
+	s.startJob("test", time.Millisecond, func(context.Context) { runs.Add(1) })
+	time.Sleep(20 * time.Millisecond)
+	if runs.Load() != 0 {
+		t.Fatalf("job started after the stop ran %d times", runs.Load())
+	}
+}
+
+func TestWorkSubmittedDuringStopIsNotLost(t *testing.T) {
+	s := newBackgroundService()
+	const submitters, perSubmitter = 8, 200
+	var done atomic.Int32
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < submitters; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			for j := 0; j < perSubmitter; j++ {
+				s.runInBackground(func() { done.Add(1) })
+			}
+		}()
+	}
+	close(start)
+	if err := s.StopBackgroundWork(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// what was queued before the stop has finished; what came after ran in
+	// its submitter
+	wg.Wait()
+	if got := done.Load(); got != submitters*perSubmitter {
+		t.Fatalf("%d of %d submissions ran", got, submitters*perSubmitter)
+	}
+}
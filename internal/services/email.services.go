@@ -2,71 +2,15 @@ package services
 
 import (
 	"fmt"
-	"strconv"
+	"html"
 	"time"
 
-	"github.com/wneessen/go-mail"
-	"go.uber.org/zap"
 
-	"github.com/alibaba0010/postgres-api/internal/config"
-	"github.com/alibaba0010/postgres-api/internal/logger"
 )
 
-// SendHTMLEmail sends an HTML email to the specified recipient using SMTP
-func SendEmail(to, subject, htmlBody string) error {
-	cfg := config.LoadConfig()
-
-	host := cfg.EMAIL_HOST
-
-	portStr := cfg.EMAIL_PORT
-	user := cfg.EMAIL_USER
-	password := cfg.EMAIL_PASSWORD
-
-	// default port if not provided
-	port, err := strconv.Atoi(cfg.EMAIL_PORT)
-	if err != nil {
-		// don't fatally exit on misconfigured port; fall back to 587
-		logger.Log.Error("Invalid EMAIL_PORT, using default 587", zap.Error(err))
-		port = 587
-	}
-	if portStr != "" {
-		p, err := strconv.Atoi(portStr)
-		if err != nil {
-			return fmt.Errorf("invalid EMAIL_PORT %q: %w", portStr, err)
-		}
-		port = p
-	}
-
-	client, err := mail.NewClient(host,
-		mail.WithPort(port),
-		mail.WithSMTPAuth(mail.SMTPAuthPlain),
-		mail.WithUsername(user),
-		mail.WithPassword(password),
-		mail.WithTLSPolicy(mail.TLSMandatory),
-		mail.WithTLSPolicy(mail.TLSMandatory),
-		mail.WithTimeout(10*time.Second),
-	)
-	if err != nil {
-		return fmt.Errorf("failed to create mail client: %w", err)
-	}
-
-	msg := mail.NewMsg()
-	if user != "" {
-		msg.From(user)
-	}
-	msg.To(to)
-	msg.Subject(subject)
-	msg.SetBodyString(mail.TypeTextHTML, htmlBody)
-
-	if err := client.DialAndSend(msg); err != nil {
-		return fmt.Errorf("failed to send email: %w", err)
-	}
-	return nil
-}
-
 // BuildWelcomeHTML returns a simple welcome HTML body. You can expand this
 // to include verification links, tokens, etc.
-func VerifyMailHTML(name, verifyURL string) string {
+func (s *Service) VerifyMailHTML(name, verifyURL string) string {
 		return fmt.Sprintf(`
 		<!doctype html>
 		<html lang="en">
@@ -107,5 +51,180 @@ func VerifyMailHTML(name, verifyURL string) string {
 			</div>
 		</body>
 		</html>
-		`, name, verifyURL, verifyURL, verifyURL, time.Now().Year())
-}
\ No newline at end of file
+		`, name, verifyURL, verifyURL, verifyURL, s.clock.Now().Year())
+}
+// PasswordResetHTML returns the body of the email carrying a password reset link.
+func (s *Service) PasswordResetHTML(name, resetURL string) string {
+	link := html.EscapeString(resetURL)
+	body := fmt.Sprintf(`
+					<h1>Reset your password, %s</h1>
+					<p class="muted">We received a request to reset the password of your <strong>Restaurant Management Platform</strong> account. Click the button below to choose a new one.</p>
+					<p style="font-weight:700; color:#dc2626;">This link can be used once and expires in <strong>15 minutes</strong>.</p>
+					<p style="text-align:center; margin:24px 0;"><a class="button" href="%s">Reset password</a></p>
+					<p class="muted">If the button doesn't work, copy and paste the following link into your browser:</p>
+					<p class="muted"><a href="%s">%s</a></p>
+					<p class="muted">If you didn't ask to reset your password, you can safely ignore this email; your password will not change.</p>`,
+		html.EscapeString(name), link, link, link)
+	return s.mailLayout("Reset your password", body)
+}
+
+// EmailChangeHTML returns the body of the email sent to a new address to
+// confirm it before it replaces the account's email.
+func (s *Service) EmailChangeHTML(name, newEmail, confirmURL string) string {
+	link := html.EscapeString(confirmURL)
+	body := fmt.Sprintf(`
+					<h1>Confirm your new email, %s</h1>
+					<p class="muted">You asked to use <strong>%s</strong> for your <strong>Restaurant Management Platform</strong> account. Click the button below to confirm it.</p>
+					<p style="font-weight:700; color:#dc2626;">This link can be used once and expires in <strong>15 minutes</strong>.</p>
+					<p style="text-align:center; margin:24px 0;"><a class="button" href="%s">Confirm email</a></p>
+					<p class="muted">If the button doesn't work, copy and paste the following link into your browser:</p>
+					<p class="muted"><a href="%s">%s</a></p>
+					<p class="muted">If you didn't ask for this, you can safely ignore this email; nothing will change.</p>`,
+		html.EscapeString(name), html.EscapeString(newEmail), link, link, link)
+	return s.mailLayout("Confirm your new email", body)
+}
+
+// EmailChangedHTML returns the body of the notice sent to the old address once
+// an account's email has changed.
+func (s *Service) EmailChangedHTML(name, newEmail string) string {
+	body := fmt.Sprintf(`
+					<h1>Your email was changed, %s</h1>
+					<p class="muted">Your <strong>Restaurant Management Platform</strong> account now uses <strong>%s</strong>. Future emails will go to that address.</p>
+					<p class="muted">If you didn't make this change, contact our support team at <a href="mailto:support@example.com">support@example.com</a> right away.</p>`,
+		html.EscapeString(name), html.EscapeString(newEmail))
+	return s.mailLayout("Your email was changed", body)
+}
+
+// PasswordChangedHTML returns the body of the notice sent after the password
+// of an account was changed.
+func (s *Service) PasswordChangedHTML(name string) string {
+	body := fmt.Sprintf(`
+					<h1>Your password was changed, %s</h1>
+					<p class="muted">The password of your <strong>Restaurant Management Platform</strong> account was just changed and your other devices were signed out.</p>
+					<p class="muted">If you didn't make this change, reset your password and contact our support team at <a href="mailto:support@example.com">support@example.com</a> right away.</p>`,
+		html.EscapeString(name))
+	return s.mailLayout("Your password was changed", body)
+}
+
+// AccountDeletionHTML returns the body of the email confirming that an
+// account will be deleted once the grace period ends.
+func (s *Service) AccountDeletionHTML(name, deletesOn string) string {
+	body := fmt.Sprintf(`
+					<h1>Your account will be deleted, %s</h1>
+					<p class="muted">We received your request to delete your <strong>Restaurant Management Platform</strong> account. You have been signed out everywhere.</p>
+					<table class="details">
+						<tr><td>Deleted on</td><td><strong>%s</strong></td></tr>
+					</table>
+					<p class="muted">Until then you can sign in and cancel the deletion from your account settings. Afterwards your personal details are erased; past orders are kept without them for our accounting records.</p>
+					<p class="muted">If you didn't ask for this, sign in and cancel the deletion, then change your password.</p>`,
+		html.EscapeString(name), html.EscapeString(deletesOn))
+	return s.mailLayout("Your account will be deleted", body)
+}
+
+// AccountLockedHTML returns the body of the email sent when repeated failed
+// sign-ins temporarily lock an account.
+func (s *Service) AccountLockedHTML(name, ip, lockedFor, resetURL string) string {
+	link := html.EscapeString(resetURL)
+	body := fmt.Sprintf(`
+					<h1>Sign-in temporarily blocked, %s</h1>
+					<p class="muted">There were too many failed attempts to sign in to your <strong>Restaurant Management Platform</strong> account, so we have paused sign-ins for <strong>%s</strong>.</p>
+					<table class="details">
+						<tr><td>Last attempt from</td><td><strong>%s</strong></td></tr>
+					</table>
+					<p class="muted">If this was you, wait a little and try again. If it wasn't, someone may be guessing your password: we recommend choosing a new one.</p>
+					<p style="text-align:center; margin:24px 0;"><a class="button" href="%s">Reset password</a></p>`,
+		html.EscapeString(name), lockedFor, html.EscapeString(ip), link)
+	return s.mailLayout("Sign-in temporarily blocked", body)
+}
+
+// StaffInvitationHTML returns the body of the email inviting someone to join a
+// restaurant's staff.
+func (s *Service) StaffInvitationHTML(inviterName, restaurantName, roleName, acceptURL string, expiresIn time.Duration) string {
+	link := html.EscapeString(acceptURL)
+	body := fmt.Sprintf(`
+					<h1>You're invited to join %s</h1>
+					<p class="muted"><strong>%s</strong> has invited you to join the team at <strong>%s</strong> on the <strong>Restaurant Management Platform</strong>.</p>
+					<table class="details">
+						<tr><td>Role</td><td><strong>%s</strong></td></tr>
+					</table>
+					<p style="font-weight:700; color:#dc2626;">This invitation can be used once and expires in <strong>%d days</strong>.</p>
+					<p style="text-align:center; margin:24px 0;"><a class="button" href="%s">Accept invitation</a></p>
+					<p class="muted">If the button doesn't work, copy and paste the following link into your browser:</p>
+					<p class="muted"><a href="%s">%s</a></p>
+					<p class="muted">If you weren't expecting this invitation, you can safely ignore this email.</p>`,
+		html.EscapeString(restaurantName), html.EscapeString(inviterName), html.EscapeString(restaurantName),
+		html.EscapeString(roleName), int(expiresIn.Hours()/24), link, link, link)
+	return s.mailLayout("Staff invitation", body)
+}
+
+// ReservationConfirmedHTML returns the body of the email sent once a table is booked.
+func (s *Service) ReservationConfirmedHTML(name, restaurantName, address, when string, partySize int, tableLabel string) string {
+	body := fmt.Sprintf(`
+					<h1>You're booked, %s 🎉</h1>
+					<p class="muted">Your reservation at <strong>%s</strong> is confirmed.</p>
+					<table class="details">
+						<tr><td>When</td><td><strong>%s</strong></td></tr>
+						<tr><td>Party size</td><td><strong>%d</strong></td></tr>
+						<tr><td>Table</td><td><strong>%s</strong></td></tr>
+						<tr><td>Address</td><td><strong>%s</strong></td></tr>
+					</table>
+					<p class="muted">Need to change plans? Please cancel from your account so the table can be offered to other guests.</p>`,
+		html.EscapeString(name), html.EscapeString(restaurantName), when, partySize, html.EscapeString(tableLabel), html.EscapeString(address))
+	return s.mailLayout("Reservation confirmed", body)
+}
+
+// ReservationReminderHTML returns the body of the reminder sent shortly before a booking.
+func (s *Service) ReservationReminderHTML(name, restaurantName, address, when string, partySize int) string {
+	body := fmt.Sprintf(`
+					<h1>See you soon, %s 👋</h1>
+					<p class="muted">This is a friendly reminder of your upcoming reservation at <strong>%s</strong>.</p>
+					<table class="details">
+						<tr><td>When</td><td><strong>%s</strong></td></tr>
+						<tr><td>Party size</td><td><strong>%d</strong></td></tr>
+						<tr><td>Address</td><td><strong>%s</strong></td></tr>
+					</table>
+					<p class="muted">If you can no longer make it, please cancel from your account.</p>`,
+		html.EscapeString(name), html.EscapeString(restaurantName), when, partySize, html.EscapeString(address))
+	return s.mailLayout("Reservation reminder", body)
+}
+
+// mailLayout wraps email content in the same frame as VerifyMailHTML.
+func (s *Service) mailLayout(title, content string) string {
+	return fmt.Sprintf(`
+		<!doctype html>
+		<html lang="en">
+		<head>
+			<meta charset="utf-8">
+			<meta name="viewport" content="width=device-width,initial-scale=1">
+			<title>%s</title>
+			<style>
+				body { background:#f4f6f8; font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, 'Helvetica Neue', Arial; margin:0; padding:0; }
+				.container { max-width:600px; margin:36px auto; background:#ffffff; border-radius:8px; overflow:hidden; box-shadow:0 4px 18px rgba(0,0,0,0.06); }
+				.header { background:linear-gradient(90deg,#3b82f6,#06b6d4); padding:24px; color:#fff; text-align:center; }
+				.logo { font-weight:700; font-size:20px; }
+				.content { padding:28px; color:#333; }
+				h1 { margin:0 0 8px 0; font-size:22px; }
+				p { margin:8px 0 16px 0; line-height:1.5; }
+				.button { display:inline-block; background:#10b981; color:#fff; padding:12px 20px; border-radius:6px; text-decoration:none; font-weight:600; }
+				.details { width:100%%; border-collapse:collapse; margin:16px 0; }
+				.details td { padding:8px 0; border-bottom:1px solid #eef2f7; }
+				.muted { color:#667085; font-size:13px; }
+				.footer { background:#f8fafc; padding:16px 24px; text-align:center; font-size:13px; color:#94a3b8; }
+				@media (max-width:420px) { .container { margin:12px; } .content { padding:18px; } }
+			</style>
+		</head>
+		<body>
+			<div class="container">
+				<div class="header">
+					<div class="logo">Restaurant Management Platform</div>
+				</div>
+				<div class="content">%s
+					<hr style="border:none;border-top:1px solid #eef2f7;margin:20px 0;" />
+					<p class="muted">Need help? Reply to this email or contact our support team at <a href="mailto:support@example.com">support@example.com</a>.</p>
+				</div>
+				<div class="footer">© %d Restaurant Management Platform — Manage reservations, menus and staff with ease.</div>
+			</div>
+		</body>
+		</html>
+		`, title, content, s.clock.Now().Year())
+}
@@ -0,0 +1,425 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/uptrace/bun"
+	"go.uber.org/zap"
+
+	"github.com/alibaba0010/postgres-api/internal/dto"
+	"github.com/alibaba0010/postgres-api/internal/errors"
+	"github.com/alibaba0010/postgres-api/internal/models"
+	"github.com/alibaba0010/postgres-api/internal/types"
+	"github.com/alibaba0010/postgres-api/internal/utils"
+)
+
+// InvitationTTL is how long a staff invitation can be accepted
+const InvitationTTL = 7 * 24 * time.Hour
+
+// InvitationClaims are the claims of the signed token emailed with a staff
+// invitation. The token ID is the invitation ID.
+type InvitationClaims struct {
+	RestaurantID string `json:"rid"`
+	RoleID       string `json:"role_id"`
+	Email        string `json:"email"`
+	jwt.RegisteredClaims
+}
+
+// CreateInvitation invites an email to the restaurant's staff with a role and
+// emails the invite link. A pending invitation for the same email and role is
+// replaced. The inviter can only hand out permissions they hold there
+// themselves (see assignableRole).
+func (s *Service) CreateInvitation(ctx context.Context, restaurantID, invitedBy, inviterRole string, input dto.CreateInvitationInput) (*dto.InvitationResponse, *errors.AppError) {
+	input.Email = strings.ToLower(strings.TrimSpace(input.Email))
+	input.RoleID = strings.TrimSpace(input.RoleID)
+	if appErr := validateInput(input); appErr != nil {
+		return nil, appErr
+	}
+
+	restaurant, appErr := s.GetRestaurantByID(ctx, restaurantID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	role, appErr := s.assignableRole(ctx, restaurant, invitedBy, inviterRole, input.RoleID)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	hasRole, err := models.Exists(ctx, s.db.NewSelect().Model((*models.RoleAssignment)(nil)).
+		Join("JOIN users AS u ON u.id = ?TableAlias.user_id AND u.deleted_at IS NULL").
+		Where("?TableAlias.restaurant_id = ?", restaurantID).
+		Where("?TableAlias.role_id = ?", role.ID).
+		Where("lower(u.email) = ?", input.Email))
+	if err != nil {
+		return nil, errors.InternalError(err)
+	}
+	if hasRole {
+		return nil, errors.ConflictError("the user already has this role here")
+	}
+
+	inviter := &models.User{}
+	if err := s.db.NewSelect().Model(inviter).Where("id = ?", invitedBy).Scan(ctx); err != nil {
+		return nil, errors.InternalError(err)
+	}
+
+	newUUID, err := utils.GenerateUUIDv7()
+	if err != nil {
+		return nil, errors.InternalError(err)
+	}
+	now := s.clock.Now()
+	invitation := &models.StaffInvitation{
+		ID:           newUUID.String(),
+		RestaurantID: restaurantID,
+		RoleID:       role.ID,
+		Email:        input.Email,
+		InvitedBy:    invitedBy,
+		ExpiresAt:    now.Add(InvitationTTL),
+		CreatedAt:    now,
+	}
+
+	token, appErr := s.signInvitationToken(invitation)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	err = s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.NewUpdate().Model((*models.StaffInvitation)(nil)).
+			Set("revoked_at = ?", now).
+			Where("restaurant_id = ?", restaurantID).
+			Where("role_id = ?", role.ID).
+			Where("email = ?", input.Email).
+			Where("accepted_at IS NULL AND revoked_at IS NULL").
+			Exec(ctx); err != nil {
+			return err
+		}
+		_, err := tx.NewInsert().Model(invitation).Exec(ctx)
+		return err
+	})
+	if err != nil {
+		s.logger(ctx).Error("failed to create invitation", zap.Error(err), zap.String("restaurant_id", restaurantID))
+		return nil, errors.InternalError(err)
+	}
+
+	cfg := s.cfg
+	acceptURL := fmt.Sprintf("%s/invitations/accept?token=%s", cfg.FRONTEND_URL, url.QueryEscape(token))
+	html := s.StaffInvitationHTML(inviter.Name, restaurant.Name, role.Name, acceptURL, InvitationTTL)
+	s.runInBackground(func() {
+		if err := s.mailer.Send(invitation.Email, "You're invited to join "+restaurant.Name, html); err != nil {
+			s.logger(ctx).Error("failed to send invitation email",
+				zap.Error(err),
+				zap.String("invitation_id", invitation.ID),
+				zap.String("email", invitation.Email),
+			)
+		}
+	})
+
+	s.logger(ctx).Info("staff invitation created",
+		zap.String("invitation_id", invitation.ID),
+		zap.String("restaurant_id", restaurantID),
+		zap.String("role", role.Name),
+		zap.String("invited_by", invitedBy))
+	invitation.Role = role
+	response := invitationResponse(invitation, now)
+	return &response, nil
+}
+
+// ListInvitations returns the invitations of a restaurant, newest first
+func (s *Service) ListInvitations(ctx context.Context, restaurantID string) ([]dto.InvitationResponse, *errors.AppError) {
+	var invitations []models.StaffInvitation
+	err := s.db.NewSelect().Model(&invitations).
+		Relation("Role").
+		Where("?TableAlias.restaurant_id = ?", restaurantID).
+		OrderExpr("?TableAlias.created_at DESC").
+		Scan(ctx)
+	if err != nil {
+		s.logger(ctx).Error("failed to list invitations", zap.Error(err), zap.String("restaurant_id", restaurantID))
+		return nil, errors.InternalError(err)
+	}
+
+	now := s.clock.Now()
+	responses := make([]dto.InvitationResponse, 0, len(invitations))
+	for i := range invitations {
+		responses = append(responses, invitationResponse(&invitations[i], now))
+	}
+	return responses, nil
+}
+
+// RevokeInvitation withdraws a pending invitation so its link stops working
+func (s *Service) RevokeInvitation(ctx context.Context, restaurantID, invitationID string) *errors.AppError {
+	result, err := s.db.NewUpdate().Model((*models.StaffInvitation)(nil)).
+		Set("revoked_at = ?", s.clock.Now()).
+		Where("id = ?", invitationID).
+		Where("restaurant_id = ?", restaurantID).
+		Where("accepted_at IS NULL AND revoked_at IS NULL").
+		Exec(ctx)
+	if err != nil {
+		s.logger(ctx).Error("failed to revoke invitation", zap.Error(err), zap.String("invitation_id", invitationID))
+		return errors.InternalError(err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return errors.NotFoundError("pending invitation not found")
+	}
+	s.logger(ctx).Info("staff invitation revoked", zap.String("invitation_id", invitationID), zap.String("restaurant_id", restaurantID))
+	return nil
+}
+
+// AcceptInvitation consumes an invite token and assigns the invited role. The
+// account of the invited email is used if there is one; otherwise one is
+// created with the given name and password, and created is true. New accounts
+// always get the user account role: staff rights come from the assignment.
+func (s *Service) AcceptInvitation(ctx context.Context, input dto.AcceptInvitationInput) (user *models.User, created bool, appErr *errors.AppError) {
+	input.Token = strings.TrimSpace(input.Token)
+	input.Name = strings.TrimSpace(input.Name)
+	if appErr := validateInput(input); appErr != nil {
+		return nil, false, appErr
+	}
+
+	claims, appErr := s.parseInvitationToken(input.Token)
+	if appErr != nil {
+		return nil, false, appErr
+	}
+
+	err := s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		invitation := &models.StaffInvitation{}
+		err := tx.NewSelect().Model(invitation).
+			Where("id = ?", claims.ID).
+			For("UPDATE").
+			Scan(ctx)
+		if err == sql.ErrNoRows {
+			return errors.ValidationError("invalid or expired invitation")
+		}
+		if err != nil {
+			return err
+		}
+		if invitation.Email != claims.Email || invitation.RestaurantID != claims.RestaurantID || invitation.RoleID != claims.RoleID {
+			return errors.ValidationError("invalid or expired invitation")
+		}
+		switch invitation.Status(s.clock.Now()) {
+		case models.InvitationStatusAccepted:
+			return errors.ConflictError("this invitation has already been accepted")
+		case models.InvitationStatusRevoked:
+			return errors.ValidationError("this invitation has been revoked")
+		case models.InvitationStatusExpired:
+			return errors.ValidationError("this invitation has expired")
+		}
+
+		exists, err := models.Exists(ctx, tx.NewSelect().Model((*models.Restaurant)(nil)).
+			Where("id = ?", invitation.RestaurantID))
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return errors.NotFoundError("restaurant not found")
+		}
+
+		user = &models.User{}
+		err = tx.NewSelect().Model(user).
+			Where("lower(email) = ?", invitation.Email).
+			Scan(ctx)
+		if err == sql.ErrNoRows {
+			if user, appErr = newInvitedUser(invitation.Email, input); appErr != nil {
+				return appErr
+			}
+			if _, err := tx.NewInsert().Model(user).Exec(ctx); err != nil {
+				return err
+			}
+			created = true
+		} else if err != nil {
+			return err
+		}
+
+		assignmentID, err := utils.GenerateUUIDv7()
+		if err != nil {
+			return err
+		}
+		assignment := &models.RoleAssignment{
+			ID:           assignmentID.String(),
+			UserID:       user.ID,
+			RoleID:       invitation.RoleID,
+			RestaurantID: invitation.RestaurantID,
+			GrantedBy:    invitation.InvitedBy,
+			CreatedAt:    s.clock.Now(),
+		}
+		if _, err := tx.NewInsert().Model(assignment).
+			On("CONFLICT (user_id, role_id, restaurant_id) DO NOTHING").
+			Exec(ctx); err != nil {
+			return err
+		}
+
+		invitation.AcceptedAt = s.clock.Now()
+		invitation.AcceptedBy = user.ID
+		_, err = tx.NewUpdate().Model(invitation).
+			Column("accepted_at", "accepted_by").
+			WherePK().
+			Exec(ctx)
+		return err
+	})
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			return nil, false, appErr
+		}
+		if pgErrorCode(err) == pgUniqueViolation {
+			return nil, false, errors.DuplicateError("email")
+		}
+		s.logger(ctx).Error("failed to accept invitation", zap.Error(err), zap.String("invitation_id", claims.ID))
+		return nil, false, errors.InternalError(err)
+	}
+
+	s.logger(ctx).Info("staff invitation accepted",
+		zap.String("invitation_id", claims.ID),
+		zap.String("restaurant_id", claims.RestaurantID),
+		zap.String("user_id", user.ID),
+		zap.Bool("account_created", created))
+	return user, created, nil
+}
+
+// assignableRole loads a role the grantor may grant within the restaurant.
+// Granting a role cannot widen what the grantor can do there: they must hold
+// every permission of the role in that restaurant, and only an owner of the
+// restaurant may make others owners.
+func (s *Service) assignableRole(ctx context.Context, restaurant *models.Restaurant, grantorID, grantorRole, roleID string) (*models.Role, *errors.AppError) {
+	role, appErr := s.GetRoleByID(ctx, roleID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if _, isAccountRole := types.ToUserRole(role.Name); isAccountRole {
+		return nil, errors.ValidationError("account roles cannot be assigned per restaurant")
+	}
+
+	if role.Name == ownerRoleName {
+		isOwner, appErr := s.isRestaurantOwner(ctx, restaurant, grantorID)
+		if appErr != nil {
+			return nil, appErr
+		}
+		if !isOwner {
+			s.logger(ctx).Warn("owner role grant refused",
+				zap.String("restaurant_id", restaurant.ID),
+				zap.String("user_id", grantorID))
+			return nil, errors.ForbiddenError("only an owner of the restaurant can grant the owner role")
+		}
+	}
+
+	held, appErr := s.UserPermissions(ctx, grantorID, grantorRole, restaurant.ID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if missing := missingPermissions(held, role); len(missing) > 0 {
+		s.logger(ctx).Warn("role grant beyond the grantor's permissions refused",
+			zap.String("restaurant_id", restaurant.ID),
+			zap.String("user_id", grantorID),
+			zap.String("role", role.Name),
+			zap.Strings("missing", missing))
+		return nil, errors.ForbiddenError("you cannot grant permissions you do not hold here: " + strings.Join(missing, ", "))
+	}
+	return role, nil
+}
+
+// missingPermissions returns the permissions of role that are not in held
+func missingPermissions(held PermissionSet, role *models.Role) []string {
+	var missing []string
+	for _, permission := range role.Permissions {
+		if !held.Has(types.Permission(permission)) {
+			missing = append(missing, permission)
+		}
+	}
+	return missing
+}
+
+// isRestaurantOwner reports whether the user owns the restaurant or holds the
+// owner role there
+func (s *Service) isRestaurantOwner(ctx context.Context, restaurant *models.Restaurant, userID string) (bool, *errors.AppError) {
+	if restaurant.OwnerID == userID {
+		return true, nil
+	}
+	isOwner, err := models.Exists(ctx, s.db.NewSelect().Model((*models.RoleAssignment)(nil)).
+		Join("JOIN roles AS r ON r.id = ?TableAlias.role_id").
+		Where("?TableAlias.user_id = ?", userID).
+		Where("?TableAlias.restaurant_id = ?", restaurant.ID).
+		Where("r.name = ?", ownerRoleName))
+	if err != nil {
+		s.logger(ctx).Error("failed to check restaurant ownership", zap.Error(err), zap.String("restaurant_id", restaurant.ID))
+		return false, errors.InternalError(err)
+	}
+	return isOwner, nil
+}
+
+// newInvitedUser builds the account of an invitee who has none yet. The
+// invite link proved they own the email, so it needs no verification.
+func newInvitedUser(email string, input dto.AcceptInvitationInput) (*models.User, *errors.AppError) {
+	if input.Name == "" || input.Password == "" {
+		return nil, errors.ValidationError("name and password are required to create your account")
+	}
+	newUUID, err := utils.GenerateUUIDv7()
+	if err != nil {
+		return nil, errors.InternalError(err)
+	}
+	hashedPwd, err := hashPassword(input.Password)
+	if err != nil {
+		return nil, errors.InternalError(err)
+	}
+	return &models.User{
+		ID:       newUUID.String(),
+		Name:     input.Name,
+		Email:    email,
+		Password: hashedPwd,
+		Role:     types.RoleUser.String(),
+	}, nil
+}
+
+// signInvitationToken signs the token emailed with an invitation
+func (s *Service) signInvitationToken(invitation *models.StaffInvitation) (string, *errors.AppError) {
+	claims := &InvitationClaims{
+		RestaurantID: invitation.RestaurantID,
+		RoleID:       invitation.RoleID,
+		Email:        invitation.Email,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        invitation.ID,
+			ExpiresAt: jwt.NewNumericDate(invitation.ExpiresAt),
+			IssuedAt:  jwt.NewNumericDate(invitation.CreatedAt),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(s.cfg.INVITE_TOKEN_SECRET))
+	if err != nil {
+		s.log.Error("failed to sign invitation token", zap.Error(err))
+		return "", errors.InternalError(err)
+	}
+	return token, nil
+}
+
+// parseInvitationToken verifies an invite token's signature and expiry
+func (s *Service) parseInvitationToken(tokenString string) (*InvitationClaims, *errors.AppError) {
+	claims := &InvitationClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (any, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.UnauthorizedError("invalid token signing method")
+		}
+		return []byte(s.cfg.INVITE_TOKEN_SECRET), nil
+	}, jwt.WithTimeFunc(s.clock.Now))
+	if err != nil || !token.Valid || claims.ID == "" {
+		s.log.Debug("invitation token verification failed", zap.Error(err))
+		return nil, errors.ValidationError("invalid or expired invitation")
+	}
+	return claims, nil
+}
+
+func invitationResponse(invitation *models.StaffInvitation, now time.Time) dto.InvitationResponse {
+	response := dto.InvitationResponse{
+		ID:           invitation.ID,
+		RestaurantID: invitation.RestaurantID,
+		Email:        invitation.Email,
+		RoleID:       invitation.RoleID,
+		Status:       invitation.Status(now),
+		ExpiresAt:    invitation.ExpiresAt,
+		CreatedAt:    invitation.CreatedAt,
+	}
+	if invitation.Role != nil {
+		response.RoleName = invitation.Role.Name
+	}
+	return response
+}
@@ -0,0 +1,110 @@
+package services_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/alibaba0010/postgres-api/internal/apptest"
+	"github.com/alibaba0010/postgres-api/internal/dto"
+	"github.com/alibaba0010/postgres-api/internal/errors"
+	"github.com/alibaba0010/postgres-api/internal/models"
+)
+
+// staffManager sets up a restaurant and a staff member who may manage its
+// staff and do nothing else there
+func staffManager(t *testing.T, env *apptest.Env) (owner, manager *models.User, restaurant *models.Restaurant) {
+	t.Helper()
+	owner = env.CreateUser(t, "management")
+	restaurant = env.CreateRestaurant(t, owner)
+	manager = env.CreateUser(t, "user")
+	env.Assign(t, manager, env.CreateRole(t, "staff:manage"), restaurant)
+	return owner, manager, restaurant
+}
+
+func invite(env *apptest.Env, restaurant *models.Restaurant, inviter *models.User, role *models.Role) (*dto.InvitationResponse, error) {
+	invitation, appErr := env.Services.CreateInvitation(context.Background(), restaurant.ID, inviter.ID, inviter.Role, dto.CreateInvitationInput{
+		Email:  apptest.Email("invitee"),
+		RoleID: role.ID,
+	})
+	if appErr != nil {
+		return nil, appErr
+	}
+	return invitation, nil
+}
+
+func assign(env *apptest.Env, restaurant *models.Restaurant, grantor, user *models.User, role *models.Role) (*dto.StaffMemberResponse, error) {
+	member, appErr := env.Services.AssignStaffRole(context.Background(), restaurant.ID, grantor.ID, grantor.Role, dto.AssignStaffInput{
+		Email:  user.Email,
+		RoleID: role.ID,
+	})
+	if appErr != nil {
+		return nil, appErr
+	}
+	return member, nil
+}
+
+func forbidden(err error) bool {
+	appErr, ok := err.(*errors.AppError)
+	return ok && appErr.Status == http.StatusForbidden
+}
+
+func TestInvitationCannotGrantOwner(t *testing.T) {
+	env := apptest.NewWithDB(t)
+	owner, manager, restaurant := staffManager(t, env)
+	ownerRole := env.Role(t, "owner")
+
+	if _, err := invite(env, restaurant, manager, ownerRole); !forbidden(err) {
+		t.Fatalf("staff manager invited an owner: %v, want 403", err)
+	}
+	if _, err := invite(env, restaurant, owner, ownerRole); err != nil {
+		t.Fatalf("owner invited an owner: %v", err)
+	}
+}
+
+func TestInvitationCannotGrantMorePermissions(t *testing.T) {
+	env := apptest.NewWithDB(t)
+	_, manager, restaurant := staffManager(t, env)
+
+	if _, err := invite(env, restaurant, manager, env.Role(t, "cook")); !forbidden(err) {
+		t.Fatalf("staff manager invited a cook without order permissions: %v, want 403", err)
+	}
+	if _, err := invite(env, restaurant, manager, env.CreateRole(t, "staff:manage", "menu:write")); !forbidden(err) {
+		t.Fatalf("staff manager granted menu:write: %v, want 403", err)
+	}
+	if _, err := invite(env, restaurant, manager, env.CreateRole(t, "staff:manage")); err != nil {
+		t.Fatalf("staff manager invited with their own permissions: %v", err)
+	}
+}
+
+func TestInvitationPermissionsAreOfTheRestaurant(t *testing.T) {
+	env := apptest.NewWithDB(t)
+	_, manager, restaurant := staffManager(t, env)
+
+	// a cook elsewhere holds the order permissions there, not here
+	elsewhere := env.CreateRestaurant(t, env.CreateUser(t, "management"))
+	env.Assign(t, manager, env.Role(t, "cook"), elsewhere)
+
+	if _, err := invite(env, restaurant, manager, env.Role(t, "cook")); !forbidden(err) {
+		t.Fatalf("permissions held in another restaurant allowed the grant: %v, want 403", err)
+	}
+}
+
+func TestAssignStaffRoleFollowsGrantRules(t *testing.T) {
+	env := apptest.NewWithDB(t)
+	owner, manager, restaurant := staffManager(t, env)
+	staff := env.CreateUser(t, "user")
+
+	if _, err := assign(env, restaurant, manager, staff, env.Role(t, "owner")); !forbidden(err) {
+		t.Fatalf("staff manager assigned the owner role: %v, want 403", err)
+	}
+	if _, err := assign(env, restaurant, manager, staff, env.Role(t, "waiter")); !forbidden(err) {
+		t.Fatalf("staff manager assigned a waiter without its permissions: %v, want 403", err)
+	}
+	if _, err := assign(env, restaurant, owner, staff, env.Role(t, "waiter")); err != nil {
+		t.Fatalf("owner assigned a waiter: %v", err)
+	}
+	if _, err := assign(env, restaurant, owner, manager, env.Role(t, "owner")); err != nil {
+		t.Fatalf("owner assigned the owner role: %v", err)
+	}
+}
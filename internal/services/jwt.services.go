@@ -2,14 +2,13 @@ package services
 
 import (
 	"context"
+	"net/http"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 
-	"github.com/alibaba0010/postgres-api/internal/config"
-	"github.com/alibaba0010/postgres-api/internal/database"
 	"github.com/alibaba0010/postgres-api/internal/errors"
-	"github.com/alibaba0010/postgres-api/internal/logger"
 	"github.com/alibaba0010/postgres-api/internal/models"
 	"github.com/alibaba0010/postgres-api/internal/utils"
 	"go.uber.org/zap"
@@ -17,6 +16,11 @@ import (
 const (
 	AccessTokenDuration  = 15 * time.Minute
 	RefreshTokenDuration = 7 * 24 * time.Hour // 7 days
+	// RefreshTokenReuseGrace is how long after its rotation a refresh token
+	// may be presented again without counting as stolen. Browser tabs and
+	// parallel requests sharing one cookie rotate it concurrently; the late
+	// ones get the session's current token instead of a revoked session.
+	RefreshTokenReuseGrace = 30 * time.Second
 )
 
 
@@ -25,58 +29,101 @@ type TokenPair struct {
 	AccessToken  string    `json:"access_token"`
 	RefreshToken string    `json:"refresh_token"`
 }
-// AccessClaims are the JWT claims stored in access tokens.
+// AccessClaims are the JWT claims stored in access tokens. RestaurantID is
+// the active restaurant of a staff session; tenant routes reject any other.
 type AccessTokenClaims struct {
-	UserID string `json:"user_id"`
-	Role   string `json:"role"`
+	UserID       string `json:"user_id"`
+	Role         string `json:"role"`
+	SessionID    string `json:"sid,omitempty"`
+	RestaurantID string `json:"rid,omitempty"`
 	jwt.RegisteredClaims
 }
 
 type RefreshTokenClaims struct {
 	UserID    string    `json:"user_id"`
 	Role   	  string `json:"role"`
+	FamilyID  string    `json:"fid"`
+	RestaurantID string `json:"rid,omitempty"`
 	Token     string    `json:"token"`
 	IPAddress string    `json:"ip_address,omitempty"`
 	UserAgent string    `json:"user_agent,omitempty"`
 	jwt.RegisteredClaims
 }
+
+// RefreshTokenStorage persists issued refresh tokens. A token can be rotated
+// exactly once; deleting a token revokes its whole family (one sign-in session).
 type RefreshTokenStorage interface {
 	StoreRefreshToken(ctx context.Context, token string, data RefreshTokenClaims) error
-	GetRefreshToken(ctx context.Context, token string) (*RefreshTokenClaims, error)
+	GetRefreshToken(ctx context.Context, token string) (*models.RefreshToken, error)
+	RotateRefreshToken(ctx context.Context, oldToken, newToken string, data RefreshTokenClaims) error
+	// GetLiveRefreshToken returns the token of the family that has not been
+	// rotated yet, or ErrRefreshTokenNotFound
+	GetLiveRefreshToken(ctx context.Context, familyID string) (*models.RefreshToken, error)
 	DeleteRefreshToken(ctx context.Context, token string) error
+	DeleteTokenFamily(ctx context.Context, familyID string) error
 	DeleteUserRefreshTokens(ctx context.Context, userID string) error
+	DeleteExpiredRefreshTokens(ctx context.Context) (int64, error)
+	ListUserSessions(ctx context.Context, userID string) ([]Session, error)
 }
 
-func GenerateTokenPair(ctx context.Context, userID, role, ip, userAgent string) (*TokenPair, *errors.AppError) {
-	cfg := config.LoadConfig()
+// Session is one signed-in device: the live token of a refresh token family
+// together with the time the family was started by a sign-in.
+type Session struct {
+	Token      models.RefreshToken
+	SignedInAt time.Time
+}
 
-	now := time.Now()
+// GenerateTokenPair signs a new access/refresh token pair for a fresh sign-in,
+// starting a new refresh token family.
+func (s *Service) GenerateTokenPair(ctx context.Context, userID, role, ip, userAgent string) (*TokenPair, *errors.AppError) {
+	familyID, err := utils.GenerateUUIDv7()
+	if err != nil {
+		s.logger(ctx).Error("failed to generate UUID for token family", zap.Error(err))
+		return nil, errors.InternalError(err)
+	}
 
-	// Access token
-	accessClaims := &AccessTokenClaims{
-		UserID: userID,
-		Role:   role,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenDuration)),
-			IssuedAt:  jwt.NewNumericDate(now),
-			Subject:   userID,
-		},
+	pair, refreshClaims, appErr := s.signTokenPair(userID, role, familyID.String(), "", ip, userAgent)
+	if appErr != nil {
+		return nil, appErr
 	}
 
-	accessTok := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims)
-	accessStr, err := accessTok.SignedString([]byte(cfg.ACCESS_TOKEN_SECRET))
-	if err != nil {
-		logger.Log.Error("failed to sign access token", zap.Error(err))
+	if err := s.refreshTokens.StoreRefreshToken(ctx, pair.RefreshToken, *refreshClaims); err != nil {
+		s.logger(ctx).Error("failed to store refresh token", zap.Error(err))
 		return nil, errors.InternalError(err)
 	}
 
-	// Refresh token
+	return pair, nil
+}
+
+// signTokenPair signs an access token and a refresh token belonging to
+// familyID, both carrying restaurantID as the active restaurant
+func (s *Service) signTokenPair(userID, role, familyID, restaurantID, ip, userAgent string) (*TokenPair, *RefreshTokenClaims, *errors.AppError) {
+	cfg := s.cfg
+
+	now := s.clock.Now()
+
+	accessStr, appErr := s.signAccessToken(userID, role, familyID, restaurantID, now)
+	if appErr != nil {
+		return nil, nil, appErr
+	}
+
+	// Refresh token; its ID doubles as the refresh_tokens row ID and keeps
+	// tokens issued within the same second unique
+	tokenID, err := utils.GenerateUUIDv7()
+	if err != nil {
+		s.log.Error("failed to generate UUID for refresh token", zap.Error(err))
+		return nil, nil, errors.InternalError(err)
+	}
+
 	refreshClaims := &RefreshTokenClaims{
-		UserID: userID,
-		Role:   role,
-		IPAddress:     ip,
-		UserAgent:     userAgent,
+		UserID:    userID,
+		Role:      role,
+		FamilyID:  familyID,
+		RestaurantID: restaurantID,
+		IPAddress: ip,
+		UserAgent: userAgent,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID.String(),
 			ExpiresAt: jwt.NewNumericDate(now.Add(RefreshTokenDuration)),
 			IssuedAt:  jwt.NewNumericDate(now),
 			Subject:   userID,
@@ -86,46 +133,55 @@ func GenerateTokenPair(ctx context.Context, userID, role, ip, userAgent string)
 	refreshTok := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims)
 	refreshStr, err := refreshTok.SignedString([]byte(cfg.REFRESH_TOKEN_SECRET))
 	if err != nil {
-		logger.Log.Error("failed to sign refresh token", zap.Error(err))
-		return nil, errors.InternalError(err)
+		s.log.Error("failed to sign refresh token", zap.Error(err))
+		return nil, nil, errors.InternalError(err)
 	}
 
-	// Persist refresh token in DB
-	newUUID, err := utils.GenerateUUIDv7()
+	return &TokenPair{AccessToken: accessStr, RefreshToken: refreshStr}, refreshClaims, nil
+}
+
+// signAccessToken signs an access token of the session familyID
+func (s *Service) signAccessToken(userID, role, familyID, restaurantID string, now time.Time) (string, *errors.AppError) {
+	// Access token; its ID is what a logout denylists
+	accessTokenID, err := utils.GenerateUUIDv7()
 	if err != nil {
-		logger.Log.Error("failed to generate UUID for refresh token", zap.Error(err))
-		return nil, errors.InternalError(err)
+		s.log.Error("failed to generate UUID for access token", zap.Error(err))
+		return "", errors.InternalError(err)
 	}
-
-	rt := &models.RefreshToken{
-		ID:    newUUID.String(),
+	accessClaims := &AccessTokenClaims{
 		UserID:    userID,
-		Token:     refreshStr,
-		IPAddress: ip,
-		UserAgent: userAgent,
-		ExpiresAt: now.Add(RefreshTokenDuration),
-	}
-	if _, err := database.DB.NewInsert().Model(rt).Exec(ctx); err != nil {
-		logger.Log.Error("failed to store refresh token", zap.Error(err))
-		return nil, errors.InternalError(err)
+		Role:      role,
+		SessionID: familyID,
+		RestaurantID: restaurantID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        accessTokenID.String(),
+			ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenDuration)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			Subject:   userID,
+		},
 	}
 
-	return &TokenPair{AccessToken: accessStr, RefreshToken: refreshStr}, nil
+	// Access tokens are verified by other services too, so they are signed
+	// with the private key of the published key set
+	accessStr, err := s.signer.Sign(accessClaims)
+	if err != nil {
+		s.log.Error("failed to sign access token", zap.Error(err))
+		return "", errors.InternalError(err)
+	}
+	return accessStr, nil
 }
 
-func VerifyAccessToken(tokenString string) (*AccessTokenClaims, *errors.AppError) {
-	cfg := config.LoadConfig()
-
+// VerifyAccessToken checks an access token against the key its kid header
+// names, which may be a retired signing key still in the key set
+func (s *Service) VerifyAccessToken(tokenString string) (*AccessTokenClaims, *errors.AppError) {
 	claims := &AccessTokenClaims{}
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (any, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.UnauthorizedError("invalid token signing method")
-		}
-		return []byte(cfg.ACCESS_TOKEN_SECRET), nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, claims, s.signer.VerificationKey,
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithTimeFunc(s.clock.Now),
+	)
 
 	if err != nil || !token.Valid {
-		logger.Log.Debug("access token verification failed", zap.Error(err))
+		s.log.Debug("access token verification failed", zap.Error(err))
 		return nil, errors.UnauthorizedError("invalid or expired access token")
 	}
 
@@ -133,8 +189,8 @@ func VerifyAccessToken(tokenString string) (*AccessTokenClaims, *errors.AppError
 }
 
 // ValidateRefreshToken verifies the refresh token JWT and returns claims if valid.
-func ValidateRefreshToken(tokenString string) (*RefreshTokenClaims, *errors.AppError) {
-	cfg := config.LoadConfig()
+func (s *Service) ValidateRefreshToken(tokenString string) (*RefreshTokenClaims, *errors.AppError) {
+	cfg := s.cfg
 
 	claims := &RefreshTokenClaims{}
 	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (any, error) {
@@ -142,45 +198,211 @@ func ValidateRefreshToken(tokenString string) (*RefreshTokenClaims, *errors.AppE
 			return nil, errors.UnauthorizedError("invalid token signing method")
 		}
 		return []byte(cfg.REFRESH_TOKEN_SECRET), nil
-	})
+	}, jwt.WithTimeFunc(s.clock.Now))
 
 	if err != nil || !token.Valid {
-		logger.Log.Debug("refresh token verification failed", zap.Error(err))
+		s.log.Debug("refresh token verification failed", zap.Error(err))
 		return nil, errors.UnauthorizedError("invalid or expired refresh token")
 	}
 
 	return claims, nil
 }
 
-func RefreshAccessToken(ctx context.Context, refreshTokenString string, userID string, ip string, userAgent string) (*TokenPair, *errors.AppError) {
+// RefreshAccessToken exchanges a refresh token for a new pair carrying the
+// user's current role. The presented token is consumed; presenting it again
+// after RefreshTokenReuseGrace revokes every token of its family, since that
+// means the token was copied. userID may be empty when the caller only has
+// the refresh token.
+func (s *Service) RefreshAccessToken(ctx context.Context, refreshTokenString string, userID string, ip string, userAgent string) (*TokenPair, *errors.AppError) {
+	return s.rotateTokenPair(ctx, refreshTokenString, userID, ip, userAgent, func(claims *RefreshTokenClaims) (string, *errors.AppError) {
+		return claims.RestaurantID, nil
+	})
+}
+
+// SwitchActiveRestaurant exchanges a refresh token for a pair whose active
+// restaurant is restaurantID, or no restaurant when it is empty. The session
+// stays the same; only restaurants the user may act in can be chosen.
+func (s *Service) SwitchActiveRestaurant(ctx context.Context, refreshTokenString, restaurantID, ip, userAgent string) (*TokenPair, *errors.AppError) {
+	return s.rotateTokenPair(ctx, refreshTokenString, "", ip, userAgent, func(claims *RefreshTokenClaims) (string, *errors.AppError) {
+		if restaurantID == "" {
+			return "", nil
+		}
+		if _, err := uuid.Parse(restaurantID); err != nil {
+			return "", errors.NotFoundError("restaurant not found")
+		}
+		if appErr := s.AuthorizeTenant(ctx, claims.UserID, claims.Role, restaurantID); appErr != nil {
+			return "", appErr
+		}
+		return restaurantID, nil
+	})
+}
+
+// rotateTokenPair consumes a refresh token and signs the next pair of its
+// family, with the active restaurant chosen by restaurant
+func (s *Service) rotateTokenPair(ctx context.Context, refreshTokenString, userID, ip, userAgent string, restaurant func(*RefreshTokenClaims) (string, *errors.AppError)) (*TokenPair, *errors.AppError) {
 	// Verify the refresh token JWT signature and expiration
-	claims, appErr := ValidateRefreshToken(refreshTokenString)
+	claims, appErr := s.ValidateRefreshToken(refreshTokenString)
 	if appErr != nil {
 		return nil, appErr
 	}
 
-	// Check if refresh token exists in database and matches
-	rt := &models.RefreshToken{}
-	exists, err := database.DB.NewSelect().Model(rt).
-		Where("user_id = ? AND token = ?", userID, refreshTokenString).
-		Limit(1).
-		Exists(ctx)
-
+	stored, err := s.refreshTokens.GetRefreshToken(ctx, refreshTokenString)
+	if err == ErrRefreshTokenNotFound {
+		s.logger(ctx).Warn("refresh token not found in database", zap.String("user_id", claims.UserID))
+		return nil, errors.UnauthorizedError("refresh token invalid or revoked")
+	}
 	if err != nil {
-		logger.Log.Error("failed to query refresh token from DB", zap.Error(err))
+		s.logger(ctx).Error("failed to query refresh token from DB", zap.Error(err))
 		return nil, errors.InternalError(err)
 	}
 
-	if !exists {
-		logger.Log.Warn("refresh token not found in database", zap.String("user_id", userID))
+	if userID != "" && stored.UserID != userID {
+		s.logger(ctx).Warn("refresh token presented for another user", zap.String("user_id", userID), zap.String("token_user_id", stored.UserID))
 		return nil, errors.UnauthorizedError("refresh token invalid or revoked")
 	}
 
-	// Token is valid and exists in DB, generate new token pair
-	newTokenPair, appErr := GenerateTokenPair(ctx, claims.UserID, claims.Role, ip, userAgent)
+	if !stored.RotatedAt.IsZero() {
+		return s.reissueRotatedToken(ctx, stored, restaurant)
+	}
+
+	// The role is read again rather than copied from the token, so a
+	// demotion takes effect at the next rotation
+	user, appErr := s.getUserByID(ctx, stored.UserID)
+	if appErr != nil {
+		if appErr.Status == http.StatusNotFound {
+			return nil, errors.UnauthorizedError("refresh token invalid or revoked")
+		}
+		return nil, appErr
+	}
+	claims.Role = user.Role
+
+	restaurantID, appErr := restaurant(claims)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	newTokenPair, newClaims, appErr := s.signTokenPair(stored.UserID, user.Role, stored.FamilyID, restaurantID, ip, userAgent)
 	if appErr != nil {
 		return nil, appErr
 	}
 
+	err = s.refreshTokens.RotateRefreshToken(ctx, refreshTokenString, newTokenPair.RefreshToken, *newClaims)
+	if err == ErrRefreshTokenReused {
+		// Lost a race against another exchange of the same token, which has
+		// just stored the successor
+		stored, err = s.refreshTokens.GetRefreshToken(ctx, refreshTokenString)
+		if err == ErrRefreshTokenNotFound {
+			return nil, errors.UnauthorizedError("refresh token invalid or revoked")
+		}
+		if err != nil {
+			s.logger(ctx).Error("failed to query refresh token from DB", zap.Error(err))
+			return nil, errors.InternalError(err)
+		}
+		return s.reissueRotatedToken(ctx, stored, restaurant)
+	}
+	if err != nil {
+		s.logger(ctx).Error("failed to rotate refresh token", zap.Error(err))
+		return nil, errors.InternalError(err)
+	}
+
 	return newTokenPair, nil
-}
\ No newline at end of file
+}
+
+// reissueRotatedToken answers a refresh token presented again after its
+// rotation. Within RefreshTokenReuseGrace it is a concurrent exchange by the
+// same client, which gets the session's current refresh token and a new
+// access token for it; later it is a replay and the session is revoked. The
+// current token keeps its restaurant, so an exchange asking restaurant for a
+// different one is refused rather than answered for the wrong restaurant.
+func (s *Service) reissueRotatedToken(ctx context.Context, stored *models.RefreshToken, restaurant func(*RefreshTokenClaims) (string, *errors.AppError)) (*TokenPair, *errors.AppError) {
+	if s.clock.Now().Sub(stored.RotatedAt) > RefreshTokenReuseGrace {
+		return nil, s.revokeReusedFamily(ctx, stored)
+	}
+
+	live, err := s.refreshTokens.GetLiveRefreshToken(ctx, stored.FamilyID)
+	if err == ErrRefreshTokenNotFound {
+		return nil, errors.UnauthorizedError("refresh token invalid or revoked")
+	}
+	if err != nil {
+		s.logger(ctx).Error("failed to query refresh token from DB", zap.Error(err))
+		return nil, errors.InternalError(err)
+	}
+	liveClaims, appErr := s.ValidateRefreshToken(live.Token)
+	if appErr != nil {
+		return nil, appErr
+	}
+	restaurantID, appErr := restaurant(liveClaims)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if restaurantID != liveClaims.RestaurantID {
+		s.logger(ctx).Info("restaurant switch raced another exchange of the same refresh token",
+			zap.String("user_id", stored.UserID),
+			zap.String("family_id", stored.FamilyID),
+		)
+		return nil, errors.ConflictError("the session was refreshed at the same time; switch the restaurant again")
+	}
+
+	accessToken, appErr := s.signAccessToken(liveClaims.UserID, liveClaims.Role, liveClaims.FamilyID, liveClaims.RestaurantID, s.clock.Now())
+	if appErr != nil {
+		return nil, appErr
+	}
+	s.logger(ctx).Info("refresh token presented again within the reuse grace period",
+		zap.String("user_id", stored.UserID),
+		zap.String("family_id", stored.FamilyID),
+	)
+	return &TokenPair{AccessToken: accessToken, RefreshToken: live.Token}, nil
+}
+
+// revokeReusedFamily deletes every token of the family a replayed token belongs to
+func (s *Service) revokeReusedFamily(ctx context.Context, stored *models.RefreshToken) *errors.AppError {
+	s.logger(ctx).Warn("refresh token reuse detected, revoking token family",
+		zap.String("user_id", stored.UserID),
+		zap.String("family_id", stored.FamilyID),
+	)
+	if err := s.refreshTokens.DeleteTokenFamily(ctx, stored.FamilyID); err != nil {
+		s.logger(ctx).Error("failed to revoke refresh token family", zap.Error(err))
+		return errors.InternalError(err)
+	}
+	s.revokeSessionAccessTokens(ctx, stored.FamilyID)
+	return errors.UnauthorizedError("refresh token reuse detected; please login again")
+}
+
+// RevokeRefreshToken signs out the session the refresh token belongs to,
+// including the access tokens already issued to it
+func (s *Service) RevokeRefreshToken(ctx context.Context, refreshTokenString string) *errors.AppError {
+	if err := s.refreshTokens.DeleteRefreshToken(ctx, refreshTokenString); err != nil {
+		s.logger(ctx).Error("failed to revoke refresh token", zap.Error(err))
+		return errors.InternalError(err)
+	}
+	if claims, appErr := s.ValidateRefreshToken(refreshTokenString); appErr == nil {
+		s.revokeSessionAccessTokens(ctx, claims.FamilyID)
+	}
+	return nil
+}
+
+// RevokeAllRefreshTokens signs the user out of every session; access tokens
+// issued until now stop working as well
+func (s *Service) RevokeAllRefreshTokens(ctx context.Context, userID string) *errors.AppError {
+	if err := s.refreshTokens.DeleteUserRefreshTokens(ctx, userID); err != nil {
+		s.logger(ctx).Error("failed to revoke refresh tokens", zap.Error(err), zap.String("user_id", userID))
+		return errors.InternalError(err)
+	}
+	s.revokeUserAccessTokens(ctx, userID)
+	return nil
+}
+
+// StartRefreshTokenJanitor periodically deletes expired refresh tokens, which
+// are otherwise kept around to detect reuse of rotated tokens.
+func (s *Service) StartRefreshTokenJanitor() {
+	s.startJob("refresh token janitor", time.Hour, func(ctx context.Context) {
+		deleted, err := s.refreshTokens.DeleteExpiredRefreshTokens(ctx)
+		if err != nil {
+			s.log.Error("refresh token janitor failed", zap.Error(err))
+			return
+		}
+		if deleted > 0 {
+			s.log.Debug("refresh token janitor removed expired tokens", zap.Int64("count", deleted))
+		}
+	})
+}
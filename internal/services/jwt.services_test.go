@@ -0,0 +1,171 @@
+package services_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/alibaba0010/postgres-api/internal/apptest"
+	"github.com/alibaba0010/postgres-api/internal/models"
+	"github.com/alibaba0010/postgres-api/internal/services"
+)
+
+func refresh(env *apptest.Env, refreshToken string) (*services.TokenPair, error) {
+	pair, appErr := env.Services.RefreshAccessToken(context.Background(), refreshToken, "", "203.0.113.7", "apptest")
+	if appErr != nil {
+		return nil, appErr
+	}
+	return pair, nil
+}
+
+func TestRefreshRotatesToken(t *testing.T) {
+	env := apptest.NewWithDB(t)
+	user := env.CreateUser(t, "user")
+	session := env.Session(t, user)
+
+	next, err := refresh(env, session.RefreshToken)
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if next.RefreshToken == session.RefreshToken {
+		t.Fatal("refresh returned the presented token")
+	}
+	if _, err := refresh(env, next.RefreshToken); err != nil {
+		t.Fatalf("refresh with the successor: %v", err)
+	}
+}
+
+func TestConcurrentRefreshGetsCurrentToken(t *testing.T) {
+	env := apptest.NewWithDB(t)
+	user := env.CreateUser(t, "user")
+	session := env.Session(t, user)
+
+	winner, err := refresh(env, session.RefreshToken)
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+
+	// a second tab sends the same cookie a moment later
+	env.Clock.Advance(services.RefreshTokenReuseGrace / 2)
+	loser, err := refresh(env, session.RefreshToken)
+	if err != nil {
+		t.Fatalf("refresh within the grace period: %v", err)
+	}
+	if loser.RefreshToken != winner.RefreshToken {
+		t.Fatal("refresh within the grace period did not return the current token")
+	}
+	claims, appErr := env.Services.VerifyAccessToken(loser.AccessToken)
+	if appErr != nil {
+		t.Fatalf("access token of the grace refresh: %v", appErr)
+	}
+	if claims.UserID != user.ID {
+		t.Fatalf("access token for %s, want %s", claims.UserID, user.ID)
+	}
+
+	// the session survived
+	if _, err := refresh(env, winner.RefreshToken); err != nil {
+		t.Fatalf("refresh with the current token: %v", err)
+	}
+}
+
+func TestRefreshReplayRevokesSession(t *testing.T) {
+	env := apptest.NewWithDB(t)
+	user := env.CreateUser(t, "user")
+	session := env.Session(t, user)
+
+	next, err := refresh(env, session.RefreshToken)
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+
+	env.Clock.Advance(services.RefreshTokenReuseGrace + time.Second)
+	if _, err := refresh(env, session.RefreshToken); err == nil {
+		t.Fatal("replay after the grace period accepted")
+	}
+	if _, err := refresh(env, next.RefreshToken); err == nil {
+		t.Fatal("session of a replayed token still works")
+	}
+}
+
+func TestRefreshReadsCurrentRole(t *testing.T) {
+	env := apptest.NewWithDB(t)
+	user := env.CreateUser(t, "management")
+	session := env.Session(t, user)
+
+	if _, err := env.DB.NewUpdate().Model((*models.User)(nil)).
+		Set("role = ?", "user").
+		Where("id = ?", user.ID).
+		Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	next, err := refresh(env, session.RefreshToken)
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	claims, appErr := env.Services.VerifyAccessToken(next.AccessToken)
+	if appErr != nil {
+		t.Fatal(appErr)
+	}
+	if claims.Role != "user" {
+		t.Fatalf("role %q after a demotion, want user", claims.Role)
+	}
+	refreshClaims, appErr := env.Services.ValidateRefreshToken(next.RefreshToken)
+	if appErr != nil {
+		t.Fatal(appErr)
+	}
+	if refreshClaims.Role != "user" {
+		t.Fatalf("refresh token role %q after a demotion, want user", refreshClaims.Role)
+	}
+}
+
+func TestRefreshOfDeletedUserRejected(t *testing.T) {
+	env := apptest.NewWithDB(t)
+	user := env.CreateUser(t, "user")
+	session := env.Session(t, user)
+
+	if _, err := env.DB.NewDelete().Model(user).WherePK().Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := refresh(env, session.RefreshToken); err == nil {
+		t.Fatal("refresh of a deleted user accepted")
+	}
+}
+
+func TestSwitchWithinGracePeriodKeepsRestaurant(t *testing.T) {
+	env := apptest.NewWithDB(t)
+	ctx := context.Background()
+	owner := env.CreateUser(t, "management")
+	first := env.CreateRestaurant(t, owner)
+	second := env.CreateRestaurant(t, owner)
+	session := env.Session(t, owner)
+
+	switched, appErr := env.Services.SwitchActiveRestaurant(ctx, session.RefreshToken, first.ID, "203.0.113.7", "apptest")
+	if appErr != nil {
+		t.Fatalf("switch restaurant: %v", appErr)
+	}
+
+	// a second tab switches elsewhere with the same cookie a moment later
+	env.Clock.Advance(services.RefreshTokenReuseGrace / 2)
+	_, appErr = env.Services.SwitchActiveRestaurant(ctx, session.RefreshToken, second.ID, "203.0.113.7", "apptest")
+	if appErr == nil || appErr.Status != http.StatusConflict {
+		t.Fatalf("switch to another restaurant within the grace period: %v, want 409", appErr)
+	}
+
+	// repeating the switch that won gets the current token, for its restaurant
+	repeated, appErr := env.Services.SwitchActiveRestaurant(ctx, session.RefreshToken, first.ID, "203.0.113.7", "apptest")
+	if appErr != nil {
+		t.Fatalf("repeated switch within the grace period: %v", appErr)
+	}
+	if repeated.RefreshToken != switched.RefreshToken {
+		t.Fatal("repeated switch did not return the current token")
+	}
+	claims, appErr := env.Services.VerifyAccessToken(repeated.AccessToken)
+	if appErr != nil {
+		t.Fatalf("access token of the repeated switch: %v", appErr)
+	}
+	if claims.RestaurantID != first.ID {
+		t.Fatalf("access token for restaurant %q, want %q", claims.RestaurantID, first.ID)
+	}
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	redisPkg "github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"github.com/alibaba0010/postgres-api/internal/errors"
+	"github.com/alibaba0010/postgres-api/internal/models"
+)
+
+const (
+	// LoginFailureWindow is the sliding window failed sign-ins are counted over
+	LoginFailureWindow = 15 * time.Minute
+	// LoginLockoutDuration is how long an account or client IP stays locked
+	LoginLockoutDuration = 15 * time.Minute
+
+	// failures per email before each further attempt has to wait, doubling from one second
+	loginDelayThreshold = 3
+	loginMaxDelay       = 30 * time.Second
+	// failures within the window that lock the email or the client IP
+	loginEmailLockoutThreshold = 10
+	loginIPLockoutThreshold    = 50
+)
+
+// CheckLoginAllowed rejects a sign-in with 429 while the email or client IP is
+// locked out, or while the email is waiting out its progressive delay.
+// Redis errors are logged and let the attempt through: an outage must not
+// lock everybody out.
+func (s *Service) CheckLoginAllowed(ctx context.Context, email, ip string) *errors.AppError {
+	email = loginEmailKey(email)
+
+	// email lock, IP lock and progressive delay
+	var waits []time.Duration
+	for _, key := range []string{"login_lock:email:" + email, "login_lock:ip:" + ip, "login_delay:" + email} {
+		wait, err := s.cache.PTTL(ctx, key).Result()
+		if err != nil {
+			s.logger(ctx).Error("failed to read sign-in lockout state", zap.Error(err))
+			return nil
+		}
+		waits = append(waits, wait)
+	}
+
+	if wait := maxDuration(waits[0], waits[1]); wait > 0 {
+		return errors.TooManyRequestsError("too many failed sign-in attempts, try again later", wait)
+	}
+	if wait := waits[2]; wait > 0 {
+		return errors.TooManyRequestsError("please wait before trying to sign in again", wait)
+	}
+	return nil
+}
+
+// RecordLoginFailure counts a failed sign-in against the email and the client
+// IP. It sets the progressive delay for the email and, once a threshold is
+// crossed, locks the email or IP and returns the 429 to answer with. user is
+// nil when the email does not belong to an account; it is counted all the
+// same so lockouts do not reveal which addresses are registered.
+func (s *Service) RecordLoginFailure(ctx context.Context, email, ip string, user *models.User) *errors.AppError {
+	email = loginEmailKey(email)
+	now := s.clock.Now()
+
+	emailFailures, err := s.countLoginFailure(ctx, "login_failures:email:"+email, now)
+	if err != nil {
+		s.logger(ctx).Error("failed to record failed sign-in", zap.Error(err))
+		return nil
+	}
+	ipFailures, err := s.countLoginFailure(ctx, "login_failures:ip:"+ip, now)
+	if err != nil {
+		s.logger(ctx).Error("failed to record failed sign-in", zap.Error(err))
+		return nil
+	}
+
+	var locked bool
+	if ipFailures >= loginIPLockoutThreshold && s.lockLogin(ctx, "ip:"+ip) {
+		s.logger(ctx).Warn("client IP locked out after failed sign-ins", zap.String("ip", ip), zap.Int64("failures", ipFailures))
+		locked = true
+	}
+	if emailFailures >= loginEmailLockoutThreshold {
+		if s.lockLogin(ctx, "email:"+email) {
+			s.logger(ctx).Warn("account locked out after failed sign-ins", zap.String("email", email), zap.String("ip", ip), zap.Int64("failures", emailFailures))
+			if user != nil {
+				s.sendAccountLockedEmail(user, ip)
+			}
+		}
+		locked = true
+	} else if emailFailures >= loginDelayThreshold {
+		delay := time.Second << (emailFailures - loginDelayThreshold)
+		if delay > loginMaxDelay {
+			delay = loginMaxDelay
+		}
+		_ = s.cache.Set(ctx, "login_delay:"+email, "1", delay).Err()
+	}
+
+	if locked {
+		return errors.TooManyRequestsError("too many failed sign-in attempts, try again later", LoginLockoutDuration)
+	}
+	return nil
+}
+
+// ResetLoginFailures clears the failure counters after a successful sign-in
+func (s *Service) ResetLoginFailures(ctx context.Context, email, ip string) {
+	email = loginEmailKey(email)
+	err := s.cache.Del(ctx,
+		"login_failures:email:"+email,
+		"login_failures:ip:"+ip,
+		"login_delay:"+email,
+	).Err()
+	if err != nil {
+		s.logger(ctx).Error("failed to reset sign-in failures", zap.Error(err))
+	}
+}
+
+// countLoginFailure adds a failure to the sorted set at key, scored by time,
+// drops the ones older than the window and returns how many are left.
+func (s *Service) countLoginFailure(ctx context.Context, key string, now time.Time) (int64, error) {
+	windowStart := now.Add(-LoginFailureWindow).UnixMilli()
+	// each failure is its own member, so concurrent failures (or failures at
+	// the same instant of a stopped test clock) are all counted even without
+	// a transaction
+	if err := s.cache.ZRemRangeByScore(ctx, key, "-inf", strconv.FormatInt(windowStart, 10)).Err(); err != nil {
+		return 0, err
+	}
+	if err := s.cache.ZAdd(ctx, key, redisPkg.Z{Score: float64(now.UnixMilli()), Member: uuid.NewString()}).Err(); err != nil {
+		return 0, err
+	}
+	if err := s.cache.Expire(ctx, key, LoginFailureWindow).Err(); err != nil {
+		return 0, err
+	}
+	return s.cache.ZCard(ctx, key).Result()
+}
+
+// lockLogin starts a lockout for subject and reports whether it was not
+// already locked. The failures that led to it are forgotten so counting
+// starts afresh once the lockout ends.
+func (s *Service) lockLogin(ctx context.Context, subject string) bool {
+	started, err := s.cache.SetNX(ctx, "login_lock:"+subject, "1", LoginLockoutDuration).Result()
+	if err != nil {
+		s.logger(ctx).Error("failed to lock sign-in", zap.Error(err), zap.String("subject", subject))
+		return false
+	}
+	if started {
+		_ = s.cache.Del(ctx, "login_failures:"+subject).Err()
+	}
+	return started
+}
+
+func (s *Service) sendAccountLockedEmail(user *models.User, ip string) {
+	cfg := s.cfg
+	resetURL := fmt.Sprintf("%s/forgot-password", cfg.FRONTEND_URL)
+	html := s.AccountLockedHTML(user.Name, ip, fmt.Sprintf("%d minutes", int(LoginLockoutDuration.Minutes())), resetURL)
+	s.runInBackground(func() {
+		if err := s.mailer.Send(user.Email, "Sign-in to your account was blocked", html); err != nil {
+			s.log.Error("failed to send account locked email",
+				zap.Error(err),
+				zap.String("email", user.Email),
+			)
+		}
+	})
+}
+
+func loginEmailKey(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func maxDuration(a, b time.Duration) time.Duration {
+	if a > b {
+		return a
+	}
+	return b
+}
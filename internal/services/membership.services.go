@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"github.com/alibaba0010/postgres-api/internal/dto"
+	"github.com/alibaba0010/postgres-api/internal/errors"
+	"github.com/alibaba0010/postgres-api/internal/types"
+)
+
+// A user is a member of a restaurant when they own it or hold a role there
+// through a role assignment.
+
+// ListMemberships returns the restaurants the user is a member of, marking
+// activeRestaurantID as the active one
+func (s *Service) ListMemberships(ctx context.Context, userID, activeRestaurantID string) ([]dto.MembershipResponse, *errors.AppError) {
+	var rows []struct {
+		RestaurantID   string   `bun:"restaurant_id"`
+		RestaurantName string   `bun:"restaurant_name"`
+		Roles          []string `bun:"roles,array"`
+	}
+	err := s.db.NewRaw(`
+		SELECT rs.id AS restaurant_id, rs.name AS restaurant_name, array_agg(DISTINCT m.role ORDER BY m.role) AS roles
+		FROM restaurants AS rs
+		JOIN (
+			SELECT id AS restaurant_id, ? AS role FROM restaurants WHERE owner_id = ?
+			UNION ALL
+			SELECT a.restaurant_id, r.name FROM role_assignments AS a
+				JOIN roles AS r ON r.id = a.role_id
+				WHERE a.user_id = ?
+		) AS m ON m.restaurant_id = rs.id
+		WHERE rs.deleted_at IS NULL
+		GROUP BY rs.id, rs.name
+		ORDER BY rs.name ASC`, ownerRoleName, userID, userID).
+		Scan(ctx, &rows)
+	if err != nil {
+		s.logger(ctx).Error("failed to list memberships", zap.Error(err), zap.String("user_id", userID))
+		return nil, errors.InternalError(err)
+	}
+
+	memberships := make([]dto.MembershipResponse, 0, len(rows))
+	for _, row := range rows {
+		memberships = append(memberships, dto.MembershipResponse{
+			RestaurantID:   row.RestaurantID,
+			RestaurantName: row.RestaurantName,
+			Roles:          row.Roles,
+			Active:         row.RestaurantID == activeRestaurantID,
+		})
+	}
+	return memberships, nil
+}
+
+// IsRestaurantMember reports whether the user owns the restaurant or holds a
+// role in it
+func (s *Service) IsRestaurantMember(ctx context.Context, userID, restaurantID string) (bool, *errors.AppError) {
+	var member bool
+	err := s.db.NewRaw(`
+		SELECT EXISTS (
+			SELECT 1 FROM restaurants AS rs
+			WHERE rs.id = ? AND rs.deleted_at IS NULL AND (
+				rs.owner_id = ?
+				OR EXISTS (SELECT 1 FROM role_assignments AS a WHERE a.restaurant_id = rs.id AND a.user_id = ?)
+			)
+		)`, restaurantID, userID, userID).
+		Scan(ctx, &member)
+	if err != nil {
+		s.logger(ctx).Error("failed to check membership", zap.Error(err), zap.String("user_id", userID), zap.String("restaurant_id", restaurantID))
+		return false, errors.InternalError(err)
+	}
+	return member, nil
+}
+
+// AuthorizeTenant decides whether the user may act within the restaurant:
+// it must exist, and the user must be a member of it or an admin.
+func (s *Service) AuthorizeTenant(ctx context.Context, userID, role, restaurantID string) *errors.AppError {
+	if _, appErr := s.GetRestaurantByID(ctx, restaurantID); appErr != nil {
+		return appErr
+	}
+	if role == types.RoleAdmin.String() {
+		return nil
+	}
+
+	member, appErr := s.IsRestaurantMember(ctx, userID, restaurantID)
+	if appErr != nil {
+		return appErr
+	}
+	if !member {
+		s.logger(ctx).Warn("cross-tenant access rejected",
+			zap.String("user_id", userID),
+			zap.String("restaurant_id", restaurantID))
+		return errors.ForbiddenError("you are not a member of this restaurant")
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alibaba0010/postgres-api/internal/apptest"
+	"github.com/alibaba0010/postgres-api/internal/types"
+)
+
+func TestPermissionsOfRestaurantsDoNotAddUp(t *testing.T) {
+	env := apptest.NewWithDB(t)
+	ctx := context.Background()
+	owner := env.CreateUser(t, "management")
+	first := env.CreateRestaurant(t, owner)
+	second := env.CreateRestaurant(t, owner)
+	staff := env.CreateUser(t, "user")
+	env.Assign(t, staff, env.CreateRole(t, "menu:write"), first)
+	env.Assign(t, staff, env.CreateRole(t, "order:read"), second)
+
+	held, appErr := env.Services.HasPermission(ctx, staff.ID, staff.Role, "", types.PermMenuWrite, types.PermOrderRead)
+	if appErr != nil {
+		t.Fatal(appErr)
+	}
+	if held {
+		t.Fatal("menu:write of one restaurant and order:read of another granted together")
+	}
+
+	held, appErr = env.Services.HasPermission(ctx, staff.ID, staff.Role, "", types.PermMenuWrite)
+	if appErr != nil {
+		t.Fatal(appErr)
+	}
+	if !held {
+		t.Fatal("menu:write held in a restaurant not found without one")
+	}
+
+	held, appErr = env.Services.HasPermission(ctx, staff.ID, staff.Role, second.ID, types.PermMenuWrite)
+	if appErr != nil {
+		t.Fatal(appErr)
+	}
+	if held {
+		t.Fatal("menu:write of the first restaurant granted in the second")
+	}
+}
+
+func TestPermissionsOfOwnedRestaurant(t *testing.T) {
+	env := apptest.NewWithDB(t)
+	ctx := context.Background()
+	owner := env.CreateUser(t, "management")
+	owned := env.CreateRestaurant(t, owner)
+	other := env.CreateRestaurant(t, env.CreateUser(t, "management"))
+
+	permissions, appErr := env.Services.UserPermissions(ctx, owner.ID, owner.Role, owned.ID)
+	if appErr != nil {
+		t.Fatal(appErr)
+	}
+	if !permissions.Has(types.PermMenuWrite, types.PermStaffManage) {
+		t.Fatalf("owner permissions %v, want the owner role's", permissions)
+	}
+	permissions, appErr = env.Services.UserPermissions(ctx, owner.ID, owner.Role, other.ID)
+	if appErr != nil {
+		t.Fatal(appErr)
+	}
+	if permissions.Has(types.PermMenuWrite) {
+		t.Fatal("owner role of one restaurant granted in another")
+	}
+}
@@ -0,0 +1,401 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/uptrace/bun"
+	"go.uber.org/zap"
+
+	"github.com/alibaba0010/postgres-api/internal/dto"
+	"github.com/alibaba0010/postgres-api/internal/errors"
+	"github.com/alibaba0010/postgres-api/internal/models"
+	"github.com/alibaba0010/postgres-api/internal/types"
+	"github.com/alibaba0010/postgres-api/internal/utils"
+)
+
+const defaultCurrency = "USD"
+
+// ListMenus returns the active menus of a restaurant
+func (s *Service) ListMenus(ctx context.Context, restaurantID string) ([]models.Menu, *errors.AppError) {
+	if _, appErr := s.GetRestaurantByID(ctx, restaurantID); appErr != nil {
+		return nil, appErr
+	}
+
+	menus := make([]models.Menu, 0)
+	err := s.db.NewSelect().Model(&menus).
+		Where("restaurant_id = ?", restaurantID).
+		Where("is_active = TRUE").
+		OrderExpr("created_at ASC").
+		Scan(ctx)
+	if err != nil {
+		s.logger(ctx).Error("failed to list menus", zap.Error(err), zap.String("restaurant_id", restaurantID))
+		return nil, errors.InternalError(err)
+	}
+	return menus, nil
+}
+
+// GetMenuByID fetches a menu together with its items, ordered by category
+func (s *Service) GetMenuByID(ctx context.Context, id string) (*models.Menu, *errors.AppError) {
+	menu := &models.Menu{}
+	err := s.db.NewSelect().Model(menu).
+		Relation("Items", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.OrderExpr("category ASC, name ASC")
+		}).
+		Where("menu.id = ?", id).
+		Scan(ctx)
+	if err == sql.ErrNoRows {
+		return nil, errors.NotFoundError("menu not found")
+	}
+	if err != nil {
+		s.logger(ctx).Error("failed to fetch menu", zap.Error(err), zap.String("menu_id", id))
+		return nil, errors.InternalError(err)
+	}
+	return menu, nil
+}
+
+// CreateMenu adds a menu to a restaurant managed by the caller
+func (s *Service) CreateMenu(ctx context.Context, restaurantID, userID, role string, input dto.CreateMenuInput) (*models.Menu, *errors.AppError) {
+	if appErr := validateInput(input); appErr != nil {
+		return nil, appErr
+	}
+
+	restaurant, appErr := s.authorizeRestaurant(ctx, restaurantID, userID, role, types.PermMenuWrite)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	newUUID, err := utils.GenerateUUIDv7()
+	if err != nil {
+		return nil, errors.InternalError(err)
+	}
+
+	menu := &models.Menu{
+		ID:           newUUID.String(),
+		RestaurantID: restaurant.ID,
+		Name:         strings.TrimSpace(input.Name),
+		Type:         input.Type,
+		Description:  strings.TrimSpace(input.Description),
+		IsActive:     true,
+	}
+	if input.IsActive != nil {
+		menu.IsActive = *input.IsActive
+	}
+
+	if _, err := s.db.NewInsert().Model(menu).
+		Returning("*").
+		Exec(ctx); err != nil {
+		s.logger(ctx).Error("failed to create menu", zap.Error(err), zap.String("restaurant_id", restaurantID))
+		return nil, errors.InternalError(err)
+	}
+	return menu, nil
+}
+
+// UpdateMenu applies a partial update to a menu managed by the caller
+func (s *Service) UpdateMenu(ctx context.Context, id, userID, role string, input dto.UpdateMenuInput) (*models.Menu, *errors.AppError) {
+	if appErr := validateInput(input); appErr != nil {
+		return nil, appErr
+	}
+
+	menu, appErr := s.authorizeMenu(ctx, id, userID, role)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	columns := []string{"updated_at"}
+	if input.Name != nil {
+		menu.Name = strings.TrimSpace(*input.Name)
+		columns = append(columns, "name")
+	}
+	if input.Type != nil {
+		menu.Type = *input.Type
+		columns = append(columns, "type")
+	}
+	if input.Description != nil {
+		menu.Description = strings.TrimSpace(*input.Description)
+		columns = append(columns, "description")
+	}
+	if input.IsActive != nil {
+		menu.IsActive = *input.IsActive
+		columns = append(columns, "is_active")
+	}
+	menu.UpdatedAt = s.clock.Now()
+
+	if _, err := s.db.NewUpdate().Model(menu).
+		Column(columns...).
+		WherePK().
+		Exec(ctx); err != nil {
+		s.logger(ctx).Error("failed to update menu", zap.Error(err), zap.String("menu_id", id))
+		return nil, errors.InternalError(err)
+	}
+	return menu, nil
+}
+
+// DeleteMenu removes a menu and, through the foreign key, all of its items
+func (s *Service) DeleteMenu(ctx context.Context, id, userID, role string) *errors.AppError {
+	menu, appErr := s.authorizeMenu(ctx, id, userID, role)
+	if appErr != nil {
+		return appErr
+	}
+
+	if _, err := s.db.NewDelete().Model(menu).
+		WherePK().
+		Exec(ctx); err != nil {
+		s.logger(ctx).Error("failed to delete menu", zap.Error(err), zap.String("menu_id", id))
+		return errors.InternalError(err)
+	}
+	return nil
+}
+
+// ListMenuItems returns the items of a menu, optionally filtered by category
+func (s *Service) ListMenuItems(ctx context.Context, menuID, category string) ([]models.MenuItem, *errors.AppError) {
+	exists, err := models.Exists(ctx, s.db.NewSelect().Model((*models.Menu)(nil)).
+		Where("id = ?", menuID))
+	if err != nil {
+		return nil, errors.InternalError(err)
+	}
+	if !exists {
+		return nil, errors.NotFoundError("menu not found")
+	}
+
+	items := make([]models.MenuItem, 0)
+	query := s.db.NewSelect().Model(&items).
+		Where("menu_id = ?", menuID).
+		OrderExpr("category ASC, name ASC")
+	if category != "" {
+		query = query.Where("category ILIKE ?", category)
+	}
+	if err := query.Scan(ctx); err != nil {
+		s.logger(ctx).Error("failed to list menu items", zap.Error(err), zap.String("menu_id", menuID))
+		return nil, errors.InternalError(err)
+	}
+	return items, nil
+}
+
+// GetMenuItemByID fetches a single menu item
+func (s *Service) GetMenuItemByID(ctx context.Context, id string) (*models.MenuItem, *errors.AppError) {
+	item := &models.MenuItem{}
+	err := s.db.NewSelect().Model(item).
+		Where("id = ?", id).
+		Scan(ctx)
+	if err == sql.ErrNoRows {
+		return nil, errors.NotFoundError("menu item not found")
+	}
+	if err != nil {
+		s.logger(ctx).Error("failed to fetch menu item", zap.Error(err), zap.String("item_id", id))
+		return nil, errors.InternalError(err)
+	}
+	return item, nil
+}
+
+// CreateMenuItem adds an item to a menu managed by the caller
+func (s *Service) CreateMenuItem(ctx context.Context, menuID, userID, role string, input dto.CreateMenuItemInput) (*models.MenuItem, *errors.AppError) {
+	if appErr := validateInput(input); appErr != nil {
+		return nil, appErr
+	}
+	if appErr := validateAvailabilityWindow(input.AvailableFrom, input.AvailableUntil); appErr != nil {
+		return nil, appErr
+	}
+
+	menu, appErr := s.authorizeMenu(ctx, menuID, userID, role)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	newUUID, err := utils.GenerateUUIDv7()
+	if err != nil {
+		return nil, errors.InternalError(err)
+	}
+
+	item := &models.MenuItem{
+		ID:             newUUID.String(),
+		MenuID:         menu.ID,
+		RestaurantID:   menu.RestaurantID,
+		Category:       strings.TrimSpace(input.Category),
+		Name:           strings.TrimSpace(input.Name),
+		Description:    strings.TrimSpace(input.Description),
+		PriceCents:     input.PriceCents,
+		Currency:       normalizeCurrency(input.Currency),
+		Allergens:      normalizeTags(input.Allergens),
+		DietaryTags:    normalizeTags(input.DietaryTags),
+		IsAvailable:    true,
+		AvailableFrom:  input.AvailableFrom,
+		AvailableUntil: input.AvailableUntil,
+	}
+	if input.IsAvailable != nil {
+		item.IsAvailable = *input.IsAvailable
+	}
+
+	if _, err := s.db.NewInsert().Model(item).
+		Returning("*").
+		Exec(ctx); err != nil {
+		s.logger(ctx).Error("failed to create menu item", zap.Error(err), zap.String("menu_id", menuID))
+		return nil, errors.InternalError(err)
+	}
+	return item, nil
+}
+
+// UpdateMenuItem applies a partial update to an item of a menu managed by the caller
+func (s *Service) UpdateMenuItem(ctx context.Context, menuID, itemID, userID, role string, input dto.UpdateMenuItemInput) (*models.MenuItem, *errors.AppError) {
+	if appErr := validateInput(input); appErr != nil {
+		return nil, appErr
+	}
+
+	if _, appErr := s.authorizeMenu(ctx, menuID, userID, role); appErr != nil {
+		return nil, appErr
+	}
+	item, appErr := s.GetMenuItemByID(ctx, itemID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if item.MenuID != menuID {
+		return nil, errors.NotFoundError("menu item not found")
+	}
+
+	columns := []string{"updated_at"}
+	if input.Category != nil {
+		item.Category = strings.TrimSpace(*input.Category)
+		columns = append(columns, "category")
+	}
+	if input.Name != nil {
+		item.Name = strings.TrimSpace(*input.Name)
+		columns = append(columns, "name")
+	}
+	if input.Description != nil {
+		item.Description = strings.TrimSpace(*input.Description)
+		columns = append(columns, "description")
+	}
+	if input.PriceCents != nil {
+		item.PriceCents = *input.PriceCents
+		columns = append(columns, "price_cents")
+	}
+	if input.Currency != nil {
+		item.Currency = normalizeCurrency(*input.Currency)
+		columns = append(columns, "currency")
+	}
+	if input.Allergens != nil {
+		item.Allergens = normalizeTags(*input.Allergens)
+		columns = append(columns, "allergens")
+	}
+	if input.DietaryTags != nil {
+		item.DietaryTags = normalizeTags(*input.DietaryTags)
+		columns = append(columns, "dietary_tags")
+	}
+	if input.IsAvailable != nil {
+		item.IsAvailable = *input.IsAvailable
+		columns = append(columns, "is_available")
+	}
+	if input.AvailableFrom != nil {
+		item.AvailableFrom = *input.AvailableFrom
+		columns = append(columns, "available_from")
+	}
+	if input.AvailableUntil != nil {
+		item.AvailableUntil = *input.AvailableUntil
+		columns = append(columns, "available_until")
+	}
+	if appErr := validateAvailabilityWindow(item.AvailableFrom, item.AvailableUntil); appErr != nil {
+		return nil, appErr
+	}
+	item.UpdatedAt = s.clock.Now()
+
+	if _, err := s.db.NewUpdate().Model(item).
+		Column(columns...).
+		WherePK().
+		Exec(ctx); err != nil {
+		s.logger(ctx).Error("failed to update menu item", zap.Error(err), zap.String("item_id", itemID))
+		return nil, errors.InternalError(err)
+	}
+	return item, nil
+}
+
+// DeleteMenuItem removes an item from a menu managed by the caller
+func (s *Service) DeleteMenuItem(ctx context.Context, menuID, itemID, userID, role string) *errors.AppError {
+	if _, appErr := s.authorizeMenu(ctx, menuID, userID, role); appErr != nil {
+		return appErr
+	}
+
+	res, err := s.db.NewDelete().Model((*models.MenuItem)(nil)).
+		Where("id = ? AND menu_id = ?", itemID, menuID).
+		Exec(ctx)
+	if err != nil {
+		s.logger(ctx).Error("failed to delete menu item", zap.Error(err), zap.String("item_id", itemID))
+		return errors.InternalError(err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return errors.NotFoundError("menu item not found")
+	}
+	return nil
+}
+
+// MenuItemAvailableAt reports whether an item can be ordered at the given time,
+// honouring both the availability flag and the optional daily window. Windows
+// that wrap past midnight (e.g. 22:00-02:00) are supported.
+func MenuItemAvailableAt(item *models.MenuItem, at time.Time) bool {
+	if !item.IsAvailable {
+		return false
+	}
+	if item.AvailableFrom == "" || item.AvailableUntil == "" {
+		return true
+	}
+	now := at.Format("15:04")
+	if item.AvailableFrom <= item.AvailableUntil {
+		return now >= item.AvailableFrom && now < item.AvailableUntil
+	}
+	return now >= item.AvailableFrom || now < item.AvailableUntil
+}
+
+// authorizeMenu loads a menu and checks the caller may edit its restaurant's menus
+func (s *Service) authorizeMenu(ctx context.Context, menuID, userID, role string) (*models.Menu, *errors.AppError) {
+	menu := &models.Menu{}
+	err := s.db.NewSelect().Model(menu).
+		Where("id = ?", menuID).
+		Scan(ctx)
+	if err == sql.ErrNoRows {
+		return nil, errors.NotFoundError("menu not found")
+	}
+	if err != nil {
+		return nil, errors.InternalError(err)
+	}
+
+	if _, appErr := s.authorizeRestaurant(ctx, menu.RestaurantID, userID, role, types.PermMenuWrite); appErr != nil {
+		return nil, appErr
+	}
+	return menu, nil
+}
+
+// validateAvailabilityWindow requires both ends of a window or neither
+func validateAvailabilityWindow(from, until string) *errors.AppError {
+	if (from == "") != (until == "") {
+		return errors.ValidationError("available_from and available_until must be provided together")
+	}
+	if from != "" && from == until {
+		return errors.ValidationError("available_from and available_until cannot be equal")
+	}
+	return nil
+}
+
+func normalizeCurrency(currency string) string {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" {
+		return defaultCurrency
+	}
+	return currency
+}
+
+// normalizeTags lower-cases, trims and de-duplicates tag lists. It never
+// returns nil so the NOT NULL array columns are always satisfied.
+func normalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	out := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		out = append(out, tag)
+	}
+	return out
+}
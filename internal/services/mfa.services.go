@@ -0,0 +1,431 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base32"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	redisPkg "github.com/redis/go-redis/v9"
+	"github.com/uptrace/bun"
+	"go.uber.org/zap"
+
+	"github.com/alibaba0010/postgres-api/internal/dto"
+	"github.com/alibaba0010/postgres-api/internal/errors"
+	"github.com/alibaba0010/postgres-api/internal/models"
+	"github.com/alibaba0010/postgres-api/internal/utils"
+)
+
+const (
+	// MFAPendingTTL is how long a password-verified sign-in waits for its second factor
+	MFAPendingTTL = 5 * time.Minute
+	// MFAEnrollmentTTL is how long a started enrollment waits for confirmation
+	MFAEnrollmentTTL = 10 * time.Minute
+
+	mfaIssuer            = "Restaurant Management Platform"
+	mfaMaxAttempts       = 5
+	mfaRecoveryCodeCount = 10
+	// accept codes from one step either side to absorb clock drift
+	mfaAllowedSkew = 1
+)
+
+type mfaEnrollment struct {
+	Secret        string   `json:"secret"`
+	RecoveryCodes []string `json:"recovery_codes"` // SHA-256 hashes
+}
+
+// MFARequired reports whether signing in as user needs a second factor.
+// Admin accounts always do and have to enroll before they can sign in.
+func MFARequired(user *models.User) bool {
+	return user.MFAEnabled || IsAdminRole(user.Role)
+}
+
+// StartMFAChallenge parks a password-verified sign-in until the second factor
+// is supplied and returns the token that identifies it.
+func (s *Service) StartMFAChallenge(ctx context.Context, userID string) (string, *errors.AppError) {
+	token, err := utils.GenerateToken()
+	if err != nil {
+		return "", errors.InternalError(err)
+	}
+	if err := s.cache.Set(ctx, "mfa_pending:"+token, userID, MFAPendingTTL).Err(); err != nil {
+		return "", errors.InternalError(err)
+	}
+	return token, nil
+}
+
+// CompleteMFAChallenge verifies the second factor of a pending sign-in and
+// returns the user to issue tokens for. The pending token is consumed on
+// success and discarded after too many wrong codes.
+func (s *Service) CompleteMFAChallenge(ctx context.Context, input dto.MFAVerifyInput) (*models.User, *errors.AppError) {
+	input.MFAToken = strings.TrimSpace(input.MFAToken)
+	if appErr := validateInput(input); appErr != nil {
+		return nil, appErr
+	}
+	if input.Code == "" && input.RecoveryCode == "" {
+		return nil, errors.ValidationError("code or recovery_code is required")
+	}
+
+	user, appErr := s.pendingMFAUser(ctx, input.MFAToken)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if !user.MFAEnabled {
+		return nil, errors.ForbiddenError("two-factor authentication must be set up before signing in")
+	}
+
+	var ok bool
+	if input.Code != "" {
+		ok, appErr = s.consumeTOTPCode(ctx, user, input.Code)
+	} else {
+		ok, appErr = s.consumeRecoveryCode(ctx, user.ID, input.RecoveryCode)
+	}
+	if appErr != nil {
+		return nil, appErr
+	}
+	if !ok {
+		return nil, s.failMFAAttempt(ctx, input.MFAToken)
+	}
+
+	if err := s.cache.Del(ctx, "mfa_pending:"+input.MFAToken, "mfa_attempts:"+input.MFAToken).Err(); err != nil {
+		s.logger(ctx).Error("failed to delete mfa pending token", zap.Error(err))
+	}
+	return user, nil
+}
+
+// BeginMFAEnrollment creates a new TOTP secret and recovery codes for the user.
+// Nothing changes until ConfirmMFAEnrollment is called with a valid code.
+func (s *Service) BeginMFAEnrollment(ctx context.Context, userID string) (*dto.MFAEnrollmentResponse, *errors.AppError) {
+	user, appErr := s.getUserByID(ctx, userID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if user.MFAEnabled {
+		return nil, errors.ConflictError("two-factor authentication is already enabled")
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, errors.InternalError(err)
+	}
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, errors.InternalError(err)
+	}
+
+	b, err := json.Marshal(mfaEnrollment{Secret: secret, RecoveryCodes: hashes})
+	if err != nil {
+		return nil, errors.InternalError(err)
+	}
+	if err := s.cache.Set(ctx, "mfa_enroll:"+user.ID, b, MFAEnrollmentTTL).Err(); err != nil {
+		return nil, errors.InternalError(err)
+	}
+
+	return &dto.MFAEnrollmentResponse{
+		Secret:        secret,
+		OTPAuthURI:    utils.TOTPURI(mfaIssuer, user.Email, secret),
+		RecoveryCodes: codes,
+		ExpiresIn:     int(MFAEnrollmentTTL.Seconds()),
+	}, nil
+}
+
+// ConfirmMFAEnrollment turns two-factor authentication on once the user proves
+// their authenticator produces the right codes.
+func (s *Service) ConfirmMFAEnrollment(ctx context.Context, userID, code string) *errors.AppError {
+	ok, appErr := s.confirmMFAEnrollment(ctx, userID, code)
+	if appErr != nil {
+		return appErr
+	}
+	if !ok {
+		return errors.ValidationError("invalid two-factor code")
+	}
+	return nil
+}
+
+// confirmMFAEnrollment reports false without an error when the code is wrong
+func (s *Service) confirmMFAEnrollment(ctx context.Context, userID, code string) (bool, *errors.AppError) {
+	if appErr := validateInput(dto.MFAConfirmInput{Code: code}); appErr != nil {
+		return false, appErr
+	}
+
+	key := "mfa_enroll:" + userID
+	data, err := s.cache.Get(ctx, key).Bytes()
+	if err == redisPkg.Nil {
+		return false, errors.ValidationError("no enrollment in progress or it has expired; start again")
+	}
+	if err != nil {
+		return false, errors.InternalError(err)
+	}
+	var enrollment mfaEnrollment
+	if err := json.Unmarshal(data, &enrollment); err != nil {
+		_ = s.cache.Del(ctx, key).Err()
+		return false, errors.InternalError(err)
+	}
+
+	step, ok := utils.ValidateTOTP(enrollment.Secret, code, s.clock.Now(), mfaAllowedSkew)
+	if !ok {
+		return false, nil
+	}
+
+	err = s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		result, err := tx.NewUpdate().Model((*models.User)(nil)).
+			Set("mfa_enabled = true").
+			Set("mfa_secret = ?", enrollment.Secret).
+			Set("mfa_last_step = ?", step).
+			Set("updated_at = current_timestamp").
+			Where("id = ?", userID).
+			Where("mfa_enabled = false").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		if rows, _ := result.RowsAffected(); rows == 0 {
+			return errors.ConflictError("two-factor authentication is already enabled")
+		}
+		return replaceRecoveryCodes(ctx, tx, userID, enrollment.RecoveryCodes)
+	})
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			return false, appErr
+		}
+		return false, errors.InternalError(err)
+	}
+
+	if err := s.cache.Del(ctx, key).Err(); err != nil {
+		s.logger(ctx).Error("failed to delete mfa enrollment", zap.Error(err))
+	}
+	s.logger(ctx).Info("two-factor authentication enabled", zap.String("user_id", userID))
+	return true, nil
+}
+
+// BeginPendingMFAEnrollment starts enrollment for an account that is forced to
+// enroll while signing in, identified by its pending sign-in token.
+func (s *Service) BeginPendingMFAEnrollment(ctx context.Context, input dto.MFATokenInput) (*dto.MFAEnrollmentResponse, *errors.AppError) {
+	input.MFAToken = strings.TrimSpace(input.MFAToken)
+	if appErr := validateInput(input); appErr != nil {
+		return nil, appErr
+	}
+	user, appErr := s.pendingMFAUser(ctx, input.MFAToken)
+	if appErr != nil {
+		return nil, appErr
+	}
+	return s.BeginMFAEnrollment(ctx, user.ID)
+}
+
+// ConfirmPendingMFAEnrollment finishes enrollment during sign-in and returns
+// the user to issue tokens for.
+func (s *Service) ConfirmPendingMFAEnrollment(ctx context.Context, input dto.MFAConfirmInput) (*models.User, *errors.AppError) {
+	input.MFAToken = strings.TrimSpace(input.MFAToken)
+	if input.MFAToken == "" {
+		return nil, errors.ValidationError("mfa_token is required")
+	}
+	user, appErr := s.pendingMFAUser(ctx, input.MFAToken)
+	if appErr != nil {
+		return nil, appErr
+	}
+	ok, appErr := s.confirmMFAEnrollment(ctx, user.ID, input.Code)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if !ok {
+		return nil, s.failMFAAttempt(ctx, input.MFAToken)
+	}
+
+	if err := s.cache.Del(ctx, "mfa_pending:"+input.MFAToken, "mfa_attempts:"+input.MFAToken).Err(); err != nil {
+		s.logger(ctx).Error("failed to delete mfa pending token", zap.Error(err))
+	}
+	user.MFAEnabled = true
+	return user, nil
+}
+
+// DisableMFA turns two-factor authentication off after checking a current
+// TOTP or recovery code. Admin accounts cannot opt out.
+func (s *Service) DisableMFA(ctx context.Context, userID, role string, input dto.MFACodeInput) *errors.AppError {
+	if appErr := validateInput(input); appErr != nil {
+		return appErr
+	}
+	if IsAdminRole(role) {
+		return errors.ForbiddenError("administrators must keep two-factor authentication enabled")
+	}
+
+	user, appErr := s.getUserByID(ctx, userID)
+	if appErr != nil {
+		return appErr
+	}
+	if !user.MFAEnabled {
+		return errors.ValidationError("two-factor authentication is not enabled")
+	}
+
+	code := strings.TrimSpace(input.Code)
+	var ok bool
+	if len(code) == utils.TOTPDigits {
+		ok, appErr = s.consumeTOTPCode(ctx, user, code)
+	} else {
+		ok, appErr = s.consumeRecoveryCode(ctx, user.ID, code)
+	}
+	if appErr != nil {
+		return appErr
+	}
+	if !ok {
+		return errors.ValidationError("invalid two-factor code")
+	}
+
+	err := s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewUpdate().Model((*models.User)(nil)).
+			Set("mfa_enabled = false").
+			Set("mfa_secret = NULL").
+			Set("mfa_last_step = NULL").
+			Set("updated_at = current_timestamp").
+			Where("id = ?", userID).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		return replaceRecoveryCodes(ctx, tx, userID, nil)
+	})
+	if err != nil {
+		return errors.InternalError(err)
+	}
+
+	s.logger(ctx).Info("two-factor authentication disabled", zap.String("user_id", userID))
+	return nil
+}
+
+// pendingMFAUser resolves a pending sign-in token to its user without consuming it
+func (s *Service) pendingMFAUser(ctx context.Context, mfaToken string) (*models.User, *errors.AppError) {
+	userID, err := s.cache.Get(ctx, "mfa_pending:"+mfaToken).Result()
+	if err == redisPkg.Nil {
+		return nil, errors.UnauthorizedError("invalid or expired mfa token; please sign in again")
+	}
+	if err != nil {
+		return nil, errors.InternalError(err)
+	}
+	user, appErr := s.getUserByID(ctx, userID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	// the account may have been suspended since the password was checked
+	if user.IsSuspended() {
+		return nil, errors.ForbiddenError("this account is suspended")
+	}
+	return user, nil
+}
+
+// failMFAAttempt counts a wrong code against a pending sign-in and drops the
+// sign-in once the limit is reached so codes cannot be brute-forced.
+func (s *Service) failMFAAttempt(ctx context.Context, mfaToken string) *errors.AppError {
+	attemptsKey := "mfa_attempts:" + mfaToken
+	attempts, err := s.cache.Incr(ctx, attemptsKey).Result()
+	if err != nil {
+		return errors.InternalError(err)
+	}
+	if attempts == 1 {
+		_ = s.cache.Expire(ctx, attemptsKey, MFAPendingTTL).Err()
+	}
+	if attempts >= mfaMaxAttempts {
+		_ = s.cache.Del(ctx, "mfa_pending:"+mfaToken, attemptsKey).Err()
+		return errors.UnauthorizedError("too many invalid codes; please sign in again")
+	}
+	return errors.UnauthorizedError("invalid two-factor code")
+}
+
+// consumeTOTPCode checks a TOTP code and records its time step so the same
+// code cannot be replayed.
+func (s *Service) consumeTOTPCode(ctx context.Context, user *models.User, code string) (bool, *errors.AppError) {
+	step, ok := utils.ValidateTOTP(user.MFASecret, code, s.clock.Now(), mfaAllowedSkew)
+	if !ok {
+		return false, nil
+	}
+	result, err := s.db.NewUpdate().Model((*models.User)(nil)).
+		Set("mfa_last_step = ?", step).
+		Where("id = ?", user.ID).
+		Where("mfa_last_step IS NULL OR mfa_last_step < ?", step).
+		Exec(ctx)
+	if err != nil {
+		return false, errors.InternalError(err)
+	}
+	rows, _ := result.RowsAffected()
+	return rows == 1, nil
+}
+
+// consumeRecoveryCode marks a matching unused recovery code as used
+func (s *Service) consumeRecoveryCode(ctx context.Context, userID, code string) (bool, *errors.AppError) {
+	result, err := s.db.NewUpdate().Model((*models.MFARecoveryCode)(nil)).
+		Set("used_at = current_timestamp").
+		Where("user_id = ?", userID).
+		Where("code_hash = ?", hashRecoveryCode(code)).
+		Where("used_at IS NULL").
+		Exec(ctx)
+	if err != nil {
+		return false, errors.InternalError(err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 1 {
+		s.logger(ctx).Info("mfa recovery code used", zap.String("user_id", userID))
+	}
+	return rows == 1, nil
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx bun.Tx, userID string, hashes []string) error {
+	if _, err := tx.NewDelete().Model((*models.MFARecoveryCode)(nil)).Where("user_id = ?", userID).Exec(ctx); err != nil {
+		return err
+	}
+	if len(hashes) == 0 {
+		return nil
+	}
+	codes := make([]models.MFARecoveryCode, 0, len(hashes))
+	for _, hash := range hashes {
+		id, err := utils.GenerateUUIDv7()
+		if err != nil {
+			return err
+		}
+		codes = append(codes, models.MFARecoveryCode{ID: id.String(), UserID: userID, CodeHash: hash})
+	}
+	_, err := tx.NewInsert().Model(&codes).Exec(ctx)
+	return err
+}
+
+// generateRecoveryCodes returns codes formatted like "abcde-fghij" and their hashes
+func generateRecoveryCodes() ([]string, []string, error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+	codes := make([]string, 0, mfaRecoveryCodeCount)
+	hashes := make([]string, 0, mfaRecoveryCodeCount)
+	for i := 0; i < mfaRecoveryCodeCount; i++ {
+		raw := make([]byte, 7)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, err
+		}
+		encoded := strings.ToLower(encoding.EncodeToString(raw))[:10]
+		code := encoded[:5] + "-" + encoded[5:]
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode normalises case and dashes so "ABCDE FGHIJ" matches "abcde-fghij"
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(code)))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *Service) getUserByID(ctx context.Context, userID string) (*models.User, *errors.AppError) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil, errors.NotFoundError("user not found")
+	}
+	user := &models.User{}
+	err := s.db.NewSelect().Model(user).Where("id = ?", userID).Scan(ctx)
+	if err == sql.ErrNoRows {
+		return nil, errors.NotFoundError("user not found")
+	}
+	if err != nil {
+		return nil, errors.InternalError(err)
+	}
+	return user, nil
+}
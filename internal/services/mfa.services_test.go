@@ -0,0 +1,214 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alibaba0010/postgres-api/internal/apptest"
+	"github.com/alibaba0010/postgres-api/internal/dto"
+	"github.com/alibaba0010/postgres-api/internal/models"
+	"github.com/alibaba0010/postgres-api/internal/services"
+	"github.com/alibaba0010/postgres-api/internal/utils"
+)
+
+// currentCode is what the user's authenticator shows now
+func currentCode(t *testing.T, env *apptest.Env, secret string) string {
+	t.Helper()
+	code, err := utils.TOTPCode(secret, utils.TOTPStep(env.Clock.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+// enroll turns two-factor authentication on for user and returns the
+// enrollment, with the secret and recovery codes
+func enroll(t *testing.T, env *apptest.Env, user *models.User) *dto.MFAEnrollmentResponse {
+	t.Helper()
+	ctx := context.Background()
+	enrollment, appErr := env.Services.BeginMFAEnrollment(ctx, user.ID)
+	if appErr != nil {
+		t.Fatalf("begin enrollment: %v", appErr)
+	}
+	if appErr := env.Services.ConfirmMFAEnrollment(ctx, user.ID, currentCode(t, env, enrollment.Secret)); appErr != nil {
+		t.Fatalf("confirm enrollment: %v", appErr)
+	}
+	return enrollment
+}
+
+// challenge completes a new pending sign-in of user with input's code
+func challenge(t *testing.T, env *apptest.Env, user *models.User, input dto.MFAVerifyInput) error {
+	t.Helper()
+	ctx := context.Background()
+	token, appErr := env.Services.StartMFAChallenge(ctx, user.ID)
+	if appErr != nil {
+		t.Fatalf("start challenge: %v", appErr)
+	}
+	input.MFAToken = token
+	if _, appErr := env.Services.CompleteMFAChallenge(ctx, input); appErr != nil {
+		return appErr
+	}
+	return nil
+}
+
+func TestMFAEnrollment(t *testing.T) {
+	env := apptest.NewWithDB(t)
+	ctx := context.Background()
+	user := env.CreateUser(t, "user")
+
+	enrollment, appErr := env.Services.BeginMFAEnrollment(ctx, user.ID)
+	if appErr != nil {
+		t.Fatalf("begin enrollment: %v", appErr)
+	}
+	if len(enrollment.RecoveryCodes) != 10 {
+		t.Fatalf("%d recovery codes, want 10", len(enrollment.RecoveryCodes))
+	}
+	if appErr := env.Services.ConfirmMFAEnrollment(ctx, user.ID, "000000"); appErr == nil {
+		t.Fatal("enrollment confirmed with a wrong code")
+	}
+	if appErr := env.Services.ConfirmMFAEnrollment(ctx, user.ID, currentCode(t, env, enrollment.Secret)); appErr != nil {
+		t.Fatalf("confirm enrollment: %v", appErr)
+	}
+
+	stored := &models.User{}
+	if err := env.DB.NewSelect().Model(stored).Where("id = ?", user.ID).Scan(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !stored.MFAEnabled || stored.MFASecret != enrollment.Secret {
+		t.Fatal("two-factor authentication not enabled with the enrolled secret")
+	}
+	if _, appErr := env.Services.BeginMFAEnrollment(ctx, user.ID); appErr == nil {
+		t.Fatal("second enrollment started while enabled")
+	}
+}
+
+func TestMFAEnrollmentExpires(t *testing.T) {
+	env := apptest.NewWithDB(t)
+	ctx := context.Background()
+	user := env.CreateUser(t, "user")
+
+	enrollment, appErr := env.Services.BeginMFAEnrollment(ctx, user.ID)
+	if appErr != nil {
+		t.Fatalf("begin enrollment: %v", appErr)
+	}
+	env.Clock.Advance(services.MFAEnrollmentTTL + time.Second)
+	if appErr := env.Services.ConfirmMFAEnrollment(ctx, user.ID, currentCode(t, env, enrollment.Secret)); appErr == nil {
+		t.Fatal("expired enrollment confirmed")
+	}
+}
+
+func TestMFACodeCannotBeReplayedInSameStep(t *testing.T) {
+	env := apptest.NewWithDB(t)
+	user := env.CreateUser(t, "user")
+	enrollment := enroll(t, env, user)
+
+	// the confirmation used the code of the current step
+	if err := challenge(t, env, user, dto.MFAVerifyInput{Code: currentCode(t, env, enrollment.Secret)}); err == nil {
+		t.Fatal("enrollment code replayed at sign-in")
+	}
+
+	env.Clock.Advance(utils.TOTPPeriod)
+	code := currentCode(t, env, enrollment.Secret)
+	if err := challenge(t, env, user, dto.MFAVerifyInput{Code: code}); err != nil {
+		t.Fatalf("sign-in with a fresh code: %v", err)
+	}
+	if err := challenge(t, env, user, dto.MFAVerifyInput{Code: code}); err == nil {
+		t.Fatal("code replayed in the same step")
+	}
+
+	// a code of an earlier step, still within the skew, is not accepted either
+	earlier, err := utils.TOTPCode(enrollment.Secret, utils.TOTPStep(env.Clock.Now())-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := challenge(t, env, user, dto.MFAVerifyInput{Code: earlier}); err == nil {
+		t.Fatal("code of a step before the last used one accepted")
+	}
+}
+
+func TestMFAAcceptsClockSkewOfOneStep(t *testing.T) {
+	env := apptest.NewWithDB(t)
+	user := env.CreateUser(t, "user")
+	enrollment := enroll(t, env, user)
+
+	// the authenticator runs one step ahead of the server
+	env.Clock.Advance(2 * utils.TOTPPeriod)
+	ahead, err := utils.TOTPCode(enrollment.Secret, utils.TOTPStep(env.Clock.Now())+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := challenge(t, env, user, dto.MFAVerifyInput{Code: ahead}); err != nil {
+		t.Fatalf("code one step ahead rejected: %v", err)
+	}
+
+	env.Clock.Advance(5 * utils.TOTPPeriod)
+	farAhead, err := utils.TOTPCode(enrollment.Secret, utils.TOTPStep(env.Clock.Now())+2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := challenge(t, env, user, dto.MFAVerifyInput{Code: farAhead}); err == nil {
+		t.Fatal("code two steps ahead accepted")
+	}
+}
+
+func TestMFARecoveryCodeIsSingleUse(t *testing.T) {
+	env := apptest.NewWithDB(t)
+	user := env.CreateUser(t, "user")
+	enrollment := enroll(t, env, user)
+	recoveryCode := enrollment.RecoveryCodes[0]
+
+	if err := challenge(t, env, user, dto.MFAVerifyInput{RecoveryCode: recoveryCode}); err != nil {
+		t.Fatalf("sign-in with a recovery code: %v", err)
+	}
+	if err := challenge(t, env, user, dto.MFAVerifyInput{RecoveryCode: recoveryCode}); err == nil {
+		t.Fatal("recovery code used twice")
+	}
+	// codes are matched regardless of case and dashes
+	other := enrollment.RecoveryCodes[1]
+	if err := challenge(t, env, user, dto.MFAVerifyInput{RecoveryCode: "  " + other[:5] + other[6:] + " "}); err != nil {
+		t.Fatalf("recovery code without its dash: %v", err)
+	}
+}
+
+func TestMFAChallengeDroppedAfterTooManyWrongCodes(t *testing.T) {
+	env := apptest.NewWithDB(t)
+	ctx := context.Background()
+	user := env.CreateUser(t, "user")
+	enrollment := enroll(t, env, user)
+
+	token, appErr := env.Services.StartMFAChallenge(ctx, user.ID)
+	if appErr != nil {
+		t.Fatal(appErr)
+	}
+	for i := 0; i < 5; i++ {
+		if _, appErr := env.Services.CompleteMFAChallenge(ctx, dto.MFAVerifyInput{MFAToken: token, RecoveryCode: "wrong-code"}); appErr == nil {
+			t.Fatal("wrong recovery code accepted")
+		}
+	}
+
+	env.Clock.Advance(utils.TOTPPeriod)
+	if _, appErr := env.Services.CompleteMFAChallenge(ctx, dto.MFAVerifyInput{MFAToken: token, Code: currentCode(t, env, enrollment.Secret)}); appErr == nil {
+		t.Fatal("sign-in completed after too many wrong codes")
+	}
+}
+
+func TestDisableMFA(t *testing.T) {
+	env := apptest.NewWithDB(t)
+	ctx := context.Background()
+	user := env.CreateUser(t, "user")
+	enrollment := enroll(t, env, user)
+
+	if appErr := env.Services.DisableMFA(ctx, user.ID, user.Role, dto.MFACodeInput{Code: "000000"}); appErr == nil {
+		t.Fatal("disabled with a wrong code")
+	}
+	env.Clock.Advance(utils.TOTPPeriod)
+	if appErr := env.Services.DisableMFA(ctx, user.ID, user.Role, dto.MFACodeInput{Code: currentCode(t, env, enrollment.Secret)}); appErr != nil {
+		t.Fatalf("disable: %v", appErr)
+	}
+
+	admin := env.CreateUser(t, "admin")
+	if appErr := env.Services.DisableMFA(ctx, admin.ID, admin.Role, dto.MFACodeInput{Code: "000000"}); appErr == nil {
+		t.Fatal("administrator opted out of two-factor authentication")
+	}
+}
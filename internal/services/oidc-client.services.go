@@ -0,0 +1,308 @@
+package services
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+
+	"github.com/alibaba0010/postgres-api/internal/config"
+	"github.com/alibaba0010/postgres-api/internal/errors"
+)
+
+// oidcHTTPClient talks to identity providers; they must answer quickly since
+// a user is waiting on the callback
+var oidcHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// oidcKeyRefreshInterval limits how often an unknown key ID triggers a JWKS refetch
+const oidcKeyRefreshInterval = time.Minute
+
+// oidcDiscovery is the part of /.well-known/openid-configuration we use
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// oidcProvider is a configured provider with its discovered endpoints and
+// cached signing keys
+type oidcProvider struct {
+	config    config.OIDCProvider
+	discovery oidcDiscovery
+	log       *zap.Logger
+
+	mu            sync.Mutex
+	keys          map[string]*rsa.PublicKey
+	keysFetchedAt time.Time
+}
+
+// OIDCIdentity is what a provider vouches for in a verified ID token
+type OIDCIdentity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// oidcClaims are the ID token claims we read
+type oidcClaims struct {
+	jwt.RegisteredClaims
+	Nonce         string       `json:"nonce"`
+	Email         string       `json:"email"`
+	EmailVerified flexibleBool `json:"email_verified"`
+	Name          string       `json:"name"`
+	GivenName     string       `json:"given_name"`
+}
+
+// flexibleBool accepts both true and "true"; some providers send email_verified as a string
+type flexibleBool bool
+
+func (b *flexibleBool) UnmarshalJSON(data []byte) error {
+	*b = flexibleBool(strings.Trim(string(data), `"`) == "true")
+	return nil
+}
+
+// oidcProviderCache keeps the providers discovered so far, by name
+type oidcProviderCache struct {
+	sync.Mutex
+	byName map[string]*oidcProvider
+}
+
+// getOIDCProvider returns the configured provider called name, discovering its
+// endpoints on first use
+func (s *Service) getOIDCProvider(ctx context.Context, name string) (*oidcProvider, *errors.AppError) {
+	var cfg *config.OIDCProvider
+	for _, provider := range s.cfg.OIDC_PROVIDERS {
+		if provider.Name == name {
+			cfg = &provider
+			break
+		}
+	}
+	if cfg == nil {
+		return nil, errors.NotFoundError("unknown sign-in provider")
+	}
+
+	s.oidcProviders.Lock()
+	defer s.oidcProviders.Unlock()
+	if provider, ok := s.oidcProviders.byName[name]; ok && provider.config == *cfg {
+		return provider, nil
+	}
+
+	var discovery oidcDiscovery
+	if err := oidcGetJSON(ctx, cfg.Issuer+"/.well-known/openid-configuration", &discovery); err != nil {
+		s.logger(ctx).Error("oidc discovery failed", zap.Error(err), zap.String("provider", name))
+		return nil, errors.BadGatewayError("the sign-in provider is unavailable, try again later", err)
+	}
+	if discovery.Issuer != cfg.Issuer || discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		err := fmt.Errorf("invalid discovery document for issuer %s", cfg.Issuer)
+		s.logger(ctx).Error("oidc discovery failed", zap.Error(err), zap.String("provider", name))
+		return nil, errors.BadGatewayError("the sign-in provider is misconfigured", err)
+	}
+
+	provider := &oidcProvider{config: *cfg, discovery: discovery, log: s.log}
+	s.oidcProviders.byName[name] = provider
+	return provider, nil
+}
+
+// authorizationURL builds the URL that starts an authorization code flow with
+// PKCE (S256) at the provider
+func (p *oidcProvider) authorizationURL(state, nonce, codeVerifier string) string {
+	challenge := sha256.Sum256([]byte(codeVerifier))
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.config.ClientID},
+		"redirect_uri":          {p.config.RedirectURL},
+		"scope":                 {"openid email profile"},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	separator := "?"
+	if strings.Contains(p.discovery.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return p.discovery.AuthorizationEndpoint + separator + query.Encode()
+}
+
+// exchange trades an authorization code for tokens and returns the identity
+// from the verified ID token
+func (p *oidcProvider) exchange(ctx context.Context, code, codeVerifier, nonce string) (*OIDCIdentity, *errors.AppError) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.config.RedirectURL},
+		"client_id":     {p.config.ClientID},
+		"code_verifier": {codeVerifier},
+	}
+	if p.config.ClientSecret != "" {
+		form.Set("client_secret", p.config.ClientSecret)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, p.discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, errors.InternalError(err)
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+	response, err := oidcHTTPClient.Do(request)
+	if err != nil {
+		p.log.Error("oidc token request failed", zap.Error(err), zap.String("provider", p.config.Name))
+		return nil, errors.BadGatewayError("the sign-in provider is unavailable, try again later", err)
+	}
+	defer response.Body.Close()
+
+	var tokens struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	body, _ := io.ReadAll(io.LimitReader(response.Body, 1<<20))
+	if err := json.Unmarshal(body, &tokens); err != nil {
+		p.log.Error("oidc token response unreadable", zap.Error(err), zap.String("provider", p.config.Name))
+		return nil, errors.BadGatewayError("the sign-in provider answered unexpectedly", err)
+	}
+	if response.StatusCode != http.StatusOK {
+		// an expired or replayed code is the user's problem, anything else is ours
+		p.log.Warn("oidc code exchange rejected",
+			zap.String("provider", p.config.Name),
+			zap.Int("status", response.StatusCode),
+			zap.String("error", tokens.Error),
+			zap.String("error_description", tokens.ErrorDescription))
+		if tokens.Error == "invalid_grant" {
+			return nil, errors.UnauthorizedError("the sign-in attempt expired, please try again")
+		}
+		return nil, errors.BadGatewayError("the sign-in provider rejected the request", nil)
+	}
+	if tokens.IDToken == "" {
+		return nil, errors.BadGatewayError("the sign-in provider did not return an ID token", nil)
+	}
+
+	return p.verifyIDToken(ctx, tokens.IDToken, nonce)
+}
+
+// verifyIDToken checks the signature, issuer, audience, expiry and nonce of an ID token
+func (p *oidcProvider) verifyIDToken(ctx context.Context, idToken, nonce string) (*OIDCIdentity, *errors.AppError) {
+	claims := &oidcClaims{}
+	_, err := jwt.ParseWithClaims(idToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.signingKey(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256"}),
+		jwt.WithIssuer(p.config.Issuer),
+		jwt.WithAudience(p.config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		p.log.Warn("invalid oidc id token", zap.Error(err), zap.String("provider", p.config.Name))
+		return nil, errors.UnauthorizedError("the sign-in provider's response could not be verified")
+	}
+	if claims.Nonce != nonce {
+		p.log.Warn("oidc id token nonce mismatch", zap.String("provider", p.config.Name))
+		return nil, errors.UnauthorizedError("the sign-in provider's response could not be verified")
+	}
+	if claims.Subject == "" {
+		return nil, errors.UnauthorizedError("the sign-in provider did not identify the user")
+	}
+
+	name := strings.TrimSpace(claims.Name)
+	if name == "" {
+		name = strings.TrimSpace(claims.GivenName)
+	}
+	return &OIDCIdentity{
+		Provider:      p.config.Name,
+		Subject:       claims.Subject,
+		Email:         strings.TrimSpace(claims.Email),
+		EmailVerified: bool(claims.EmailVerified),
+		Name:          name,
+	}, nil
+}
+
+// signingKey returns the provider's RSA key with the given ID, refetching the
+// JWKS when the key is unknown since providers rotate their keys
+func (p *oidcProvider) signingKey(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	if time.Since(p.keysFetchedAt) < oidcKeyRefreshInterval {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	var jwks struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := oidcGetJSON(ctx, p.discovery.JWKSURI, &jwks); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]*rsa.PublicKey, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		if jwk.Kty != "RSA" || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(jwk.N)
+		e, errE := base64.RawURLEncoding.DecodeString(jwk.E)
+		if errN != nil || errE != nil {
+			continue
+		}
+		keys[jwk.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	p.keys = keys
+	p.keysFetchedAt = time.Now()
+
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// lookupKey finds a cached key; a token without a key ID may use the only key
+func (p *oidcProvider) lookupKey(kid string) (*rsa.PublicKey, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	key, ok := p.keys[kid]
+	return key, ok
+}
+
+func oidcGetJSON(ctx context.Context, endpoint string, target any) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Accept", "application/json")
+	response, err := oidcHTTPClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: unexpected status %d", endpoint, response.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(response.Body, 1<<20)).Decode(target)
+}
@@ -0,0 +1,335 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	redisPkg "github.com/redis/go-redis/v9"
+	"github.com/uptrace/bun"
+	"go.uber.org/zap"
+
+	"github.com/alibaba0010/postgres-api/internal/errors"
+	"github.com/alibaba0010/postgres-api/internal/models"
+	"github.com/alibaba0010/postgres-api/internal/types"
+	"github.com/alibaba0010/postgres-api/internal/utils"
+)
+
+// OIDCStateTTL is how long a user has to finish signing in at the provider
+const OIDCStateTTL = 10 * time.Minute
+
+// oidcState is stored under oidc_state:<state> while the user is at the provider
+type oidcState struct {
+	Provider     string `json:"provider"`
+	CodeVerifier string `json:"code_verifier"`
+	Nonce        string `json:"nonce"`
+	// LinkUserID is set when a signed-in user attaches the provider to their account
+	LinkUserID string `json:"link_user_id,omitempty"`
+}
+
+// StartOIDCLogin returns the provider URL that starts a sign-in, or links the
+// provider to linkUserID's account when it is not empty, and the state of the
+// flow. The caller keeps the state in the browser, where CompleteOIDCLogin
+// expects it back.
+func (s *Service) StartOIDCLogin(ctx context.Context, providerName, linkUserID string) (string, string, *errors.AppError) {
+	provider, appErr := s.getOIDCProvider(ctx, providerName)
+	if appErr != nil {
+		return "", "", appErr
+	}
+
+	state, err := utils.GenerateToken()
+	if err != nil {
+		return "", "", errors.InternalError(err)
+	}
+	codeVerifier, err := utils.GenerateToken()
+	if err != nil {
+		return "", "", errors.InternalError(err)
+	}
+	nonce, err := utils.GenerateToken()
+	if err != nil {
+		return "", "", errors.InternalError(err)
+	}
+
+	payload, err := json.Marshal(oidcState{
+		Provider:     providerName,
+		CodeVerifier: codeVerifier,
+		Nonce:        nonce,
+		LinkUserID:   linkUserID,
+	})
+	if err != nil {
+		return "", "", errors.InternalError(err)
+	}
+	if err := s.cache.Set(ctx, "oidc_state:"+state, payload, OIDCStateTTL).Err(); err != nil {
+		return "", "", errors.InternalError(err)
+	}
+
+	return provider.authorizationURL(state, nonce, codeVerifier), state, nil
+}
+
+// CompleteOIDCLogin finishes the flow started by StartOIDCLogin. browserState
+// is the state kept in the browser the callback came from. A sign-in returns
+// the user to issue tokens for; linking returns the new identity.
+func (s *Service) CompleteOIDCLogin(ctx context.Context, providerName, code, state, browserState string) (*models.User, *models.UserIdentity, *errors.AppError) {
+	if code == "" || state == "" {
+		return nil, nil, errors.ValidationError("code and state are required")
+	}
+
+	// The flow must end in the browser that started it. Otherwise an attacker
+	// could start a sign-in, or a link to their own account, and have a
+	// victim's browser finish it.
+	if browserState == "" || subtle.ConstantTimeCompare([]byte(browserState), []byte(state)) != 1 {
+		s.logger(ctx).Warn("oidc callback state does not match the browser", zap.String("provider", providerName))
+		return nil, nil, errors.UnauthorizedError("the sign-in attempt was started in another browser, please try again")
+	}
+
+	// GETDEL makes the state single-use, so a callback cannot be replayed
+	data, err := s.cache.GetDel(ctx, "oidc_state:"+state).Bytes()
+	if err == redisPkg.Nil {
+		return nil, nil, errors.UnauthorizedError("the sign-in attempt expired, please try again")
+	}
+	if err != nil {
+		return nil, nil, errors.InternalError(err)
+	}
+	var pending oidcState
+	if err := json.Unmarshal(data, &pending); err != nil {
+		return nil, nil, errors.InternalError(err)
+	}
+	if pending.Provider != providerName {
+		return nil, nil, errors.UnauthorizedError("the sign-in attempt expired, please try again")
+	}
+
+	provider, appErr := s.getOIDCProvider(ctx, providerName)
+	if appErr != nil {
+		return nil, nil, appErr
+	}
+	identity, appErr := provider.exchange(ctx, code, pending.CodeVerifier, pending.Nonce)
+	if appErr != nil {
+		return nil, nil, appErr
+	}
+
+	if pending.LinkUserID != "" {
+		linked, appErr := s.linkIdentity(ctx, pending.LinkUserID, identity)
+		return nil, linked, appErr
+	}
+
+	user, appErr := s.userForIdentity(ctx, identity)
+	if appErr != nil {
+		return nil, nil, appErr
+	}
+	if user.IsSuspended() {
+		s.logger(ctx).Warn("oidc sign-in rejected for suspended user", zap.String("user_id", user.ID))
+		return nil, nil, errors.ForbiddenError("this account is suspended")
+	}
+	return user, nil, nil
+}
+
+// ListIdentities returns the providers linked to a user's account
+func (s *Service) ListIdentities(ctx context.Context, userID string) ([]models.UserIdentity, *errors.AppError) {
+	identities := make([]models.UserIdentity, 0)
+	if err := s.db.NewSelect().Model(&identities).
+		Where("user_id = ?", userID).
+		OrderExpr("created_at ASC").
+		Scan(ctx); err != nil {
+		s.logger(ctx).Error("failed to list identities", zap.Error(err), zap.String("user_id", userID))
+		return nil, errors.InternalError(err)
+	}
+	return identities, nil
+}
+
+// UnlinkIdentity detaches a provider from the user's account. The password,
+// or a reset link, still signs them in.
+func (s *Service) UnlinkIdentity(ctx context.Context, userID, identityID string) *errors.AppError {
+	if _, err := uuid.Parse(identityID); err != nil {
+		return errors.NotFoundError("identity not found")
+	}
+	result, err := s.db.NewDelete().Model((*models.UserIdentity)(nil)).
+		Where("id = ?", identityID).
+		Where("user_id = ?", userID).
+		Exec(ctx)
+	if err != nil {
+		s.logger(ctx).Error("failed to unlink identity", zap.Error(err), zap.String("identity_id", identityID))
+		return errors.InternalError(err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return errors.NotFoundError("identity not found")
+	}
+	s.logger(ctx).Info("identity unlinked", zap.String("user_id", userID), zap.String("identity_id", identityID))
+	return nil
+}
+
+// userForIdentity finds the user an identity signs in as: the linked user, or
+// the user with the same verified email, who is linked on the way. Without
+// either a customer account is created.
+func (s *Service) userForIdentity(ctx context.Context, identity *OIDCIdentity) (*models.User, *errors.AppError) {
+	linked := &models.UserIdentity{}
+	err := s.db.NewSelect().Model(linked).
+		Where("provider = ?", identity.Provider).
+		Where("subject = ?", identity.Subject).
+		Scan(ctx)
+	if err == nil {
+		user, appErr := s.getUserByID(ctx, linked.UserID)
+		if appErr != nil {
+			return nil, errors.UnauthorizedError("this account no longer exists")
+		}
+		s.touchIdentity(ctx, linked, identity)
+		return user, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, errors.InternalError(err)
+	}
+
+	// Matching by email is only safe when the provider has checked the address
+	if identity.Email == "" || !identity.EmailVerified {
+		return nil, errors.ForbiddenError("the sign-in provider did not confirm your email address")
+	}
+
+	user := &models.User{}
+	err = s.db.NewSelect().Model(user).
+		Where("lower(email) = lower(?)", identity.Email).
+		Scan(ctx)
+	if err == nil {
+		if _, appErr := s.linkIdentity(ctx, user.ID, identity); appErr != nil {
+			return nil, appErr
+		}
+		return user, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, errors.InternalError(err)
+	}
+
+	return s.createUserFromIdentity(ctx, identity)
+}
+
+// linkIdentity attaches identity to userID's account
+func (s *Service) linkIdentity(ctx context.Context, userID string, identity *OIDCIdentity) (*models.UserIdentity, *errors.AppError) {
+	existing := &models.UserIdentity{}
+	err := s.db.NewSelect().Model(existing).
+		Where("provider = ?", identity.Provider).
+		Where("subject = ?", identity.Subject).
+		Scan(ctx)
+	if err == nil {
+		if existing.UserID != userID {
+			return nil, errors.ConflictError("this " + identity.Provider + " account is linked to another user")
+		}
+		s.touchIdentity(ctx, existing, identity)
+		return existing, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, errors.InternalError(err)
+	}
+
+	linked, err := s.newUserIdentity(userID, identity)
+	if err != nil {
+		return nil, errors.InternalError(err)
+	}
+	if _, err := s.db.NewInsert().Model(linked).Exec(ctx); err != nil {
+		if pgErrorCode(err) == pgUniqueViolation {
+			return nil, errors.ConflictError("another " + identity.Provider + " account is already linked; unlink it first")
+		}
+		s.logger(ctx).Error("failed to link identity", zap.Error(err), zap.String("user_id", userID))
+		return nil, errors.InternalError(err)
+	}
+
+	s.logger(ctx).Info("identity linked", zap.String("user_id", userID), zap.String("provider", identity.Provider))
+	return linked, nil
+}
+
+// createUserFromIdentity signs up a customer with the provider's details. The
+// account gets a random password; the reset flow can set a real one.
+func (s *Service) createUserFromIdentity(ctx context.Context, identity *OIDCIdentity) (*models.User, *errors.AppError) {
+	newUUID, err := utils.GenerateUUIDv7()
+	if err != nil {
+		return nil, errors.InternalError(err)
+	}
+	secret, err := utils.GenerateToken()
+	if err != nil {
+		return nil, errors.InternalError(err)
+	}
+	hashedPwd, err := hashPassword(secret)
+	if err != nil {
+		return nil, errors.InternalError(err)
+	}
+
+	user := &models.User{
+		ID:       newUUID.String(),
+		Name:     identityDisplayName(identity),
+		Email:    identity.Email,
+		Password: hashedPwd,
+		Role:     types.RoleUser.String(),
+	}
+	linked, err := s.newUserIdentity(user.ID, identity)
+	if err != nil {
+		return nil, errors.InternalError(err)
+	}
+
+	err = s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.NewInsert().Model(user).Exec(ctx); err != nil {
+			return err
+		}
+		_, err := tx.NewInsert().Model(linked).Exec(ctx)
+		return err
+	})
+	if err != nil {
+		if pgErrorCode(err) == pgUniqueViolation {
+			return nil, errors.DuplicateError("email")
+		}
+		s.logger(ctx).Error("failed to create user from identity", zap.Error(err), zap.String("provider", identity.Provider))
+		return nil, errors.InternalError(err)
+	}
+
+	s.logger(ctx).Info("user signed up with oidc", zap.String("user_id", user.ID), zap.String("provider", identity.Provider))
+	return user, nil
+}
+
+func (s *Service) newUserIdentity(userID string, identity *OIDCIdentity) (*models.UserIdentity, error) {
+	newUUID, err := utils.GenerateUUIDv7()
+	if err != nil {
+		return nil, err
+	}
+	now := s.clock.Now()
+	return &models.UserIdentity{
+		ID:         newUUID.String(),
+		UserID:     userID,
+		Provider:   identity.Provider,
+		Subject:    identity.Subject,
+		Email:      identity.Email,
+		CreatedAt:  now,
+		LastUsedAt: now,
+	}, nil
+}
+
+// touchIdentity records a sign-in with a linked identity; failures only cost
+// the timestamp
+func (s *Service) touchIdentity(ctx context.Context, linked *models.UserIdentity, identity *OIDCIdentity) {
+	linked.LastUsedAt = s.clock.Now()
+	if identity.Email != "" {
+		linked.Email = identity.Email
+	}
+	if _, err := s.db.NewUpdate().Model(linked).
+		Column("last_used_at", "email").
+		WherePK().
+		Exec(ctx); err != nil {
+		s.logger(ctx).Warn("failed to update identity", zap.Error(err), zap.String("identity_id", linked.ID))
+	}
+}
+
+// identityDisplayName picks a user name that passes the signup rules (3 to 50
+// characters), falling back to the email's local part
+func identityDisplayName(identity *OIDCIdentity) string {
+	name := identity.Name
+	if utf8.RuneCountInString(name) < 3 {
+		name, _, _ = strings.Cut(identity.Email, "@")
+	}
+	if utf8.RuneCountInString(name) < 3 {
+		name = "Guest " + name
+	}
+	if runes := []rune(name); len(runes) > 50 {
+		name = strings.TrimSpace(string(runes[:50]))
+	}
+	return name
+}
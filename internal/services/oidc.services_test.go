@@ -0,0 +1,277 @@
+package services_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/alibaba0010/postgres-api/internal/apptest"
+	"github.com/alibaba0010/postgres-api/internal/config"
+	"github.com/alibaba0010/postgres-api/internal/models"
+	"github.com/alibaba0010/postgres-api/internal/oidctest"
+)
+
+const oidcCallbackURL = "http://api.test/api/v1/auth/oidc/test/callback"
+
+// noRedirects stops at the provider's redirect back to the API
+var noRedirects = &http.Client{
+	CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+}
+
+// newOIDCEnv builds the application with the test provider configured as "test"
+func newOIDCEnv(t *testing.T) (*apptest.Env, *oidctest.Provider) {
+	t.Helper()
+	client := oidctest.Client{ID: "restaurant-api", Secret: "client-secret"}
+	var provider *oidctest.Provider
+	env := apptest.NewWithDB(t, func(cfg *config.Config) {
+		var err error
+		provider, err = oidctest.StartProvider(client)
+		if err != nil {
+			t.Fatalf("start provider: %v", err)
+		}
+		cfg.OIDC_PROVIDERS = []config.OIDCProvider{{
+			Name:         "test",
+			Issuer:       provider.Issuer(),
+			ClientID:     client.ID,
+			ClientSecret: client.Secret,
+			RedirectURL:  oidcCallbackURL,
+		}}
+	})
+	t.Cleanup(provider.Close)
+	return env, provider
+}
+
+// authorize starts a sign-in in a browser and signs in at the provider. It
+// returns the state cookie the API set and the callback URL the provider
+// sends the browser back to.
+func authorize(t *testing.T, env *apptest.Env) (*http.Cookie, string) {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	env.HTTPHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/v1/auth/oidc/test/authorize", nil))
+	if recorder.Code != http.StatusFound {
+		t.Fatalf("authorize: status %d, want 302: %s", recorder.Code, recorder.Body)
+	}
+	var stateCookie *http.Cookie
+	for _, cookie := range recorder.Result().Cookies() {
+		if cookie.Name == "oidc_state" {
+			stateCookie = cookie
+		}
+	}
+	if stateCookie == nil || !stateCookie.HttpOnly || stateCookie.SameSite != http.SameSiteLaxMode {
+		t.Fatalf("authorize set state cookie %v, want an HttpOnly SameSite=Lax oidc_state", stateCookie)
+	}
+	return stateCookie, signInAtProvider(t, recorder.Header().Get("Location"))
+}
+
+func signInAtProvider(t *testing.T, authorizationURL string) string {
+	t.Helper()
+	response, err := noRedirects.Get(authorizationURL)
+	if err != nil {
+		t.Fatalf("provider authorize: %v", err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusFound {
+		t.Fatalf("provider authorize: status %d, want 302", response.StatusCode)
+	}
+	return response.Header.Get("Location")
+}
+
+// callback delivers the provider's redirect to the API with the cookies of the browser
+func callback(env *apptest.Env, callbackURL string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodGet, callbackURL, nil)
+	for _, cookie := range cookies {
+		request.AddCookie(&http.Cookie{Name: cookie.Name, Value: cookie.Value})
+	}
+	recorder := httptest.NewRecorder()
+	env.HTTPHandler().ServeHTTP(recorder, request)
+	return recorder
+}
+
+func stateOf(t *testing.T, callbackURL string) string {
+	t.Helper()
+	parsed, err := url.Parse(callbackURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed.Query().Get("state")
+}
+
+// tamperState rewrites one field of the stored state of a pending sign-in
+func tamperState(t *testing.T, env *apptest.Env, state, field, value string) {
+	t.Helper()
+	ctx := context.Background()
+	data, err := env.Cache.Get(ctx, "oidc_state:"+state).Bytes()
+	if err != nil {
+		t.Fatalf("stored state: %v", err)
+	}
+	var pending map[string]any
+	if err := json.Unmarshal(data, &pending); err != nil {
+		t.Fatal(err)
+	}
+	pending[field] = value
+	data, _ = json.Marshal(pending)
+	if err := env.Cache.Set(ctx, "oidc_state:"+state, data, 0).Err(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func identitiesOf(t *testing.T, env *apptest.Env, userID string) []models.UserIdentity {
+	t.Helper()
+	var identities []models.UserIdentity
+	if err := env.DB.NewSelect().Model(&identities).Where("user_id = ?", userID).Scan(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return identities
+}
+
+func TestOIDCSignInCreatesCustomer(t *testing.T) {
+	env, provider := newOIDCEnv(t)
+	email := apptest.Email("diner")
+	provider.SignInAs(oidctest.User{Subject: "new-diner", Email: email, EmailVerified: true, Name: "New Diner"})
+
+	cookie, callbackURL := authorize(t, env)
+	if recorder := callback(env, callbackURL, cookie); recorder.Code != http.StatusOK {
+		t.Fatalf("callback: status %d, want 200: %s", recorder.Code, recorder.Body)
+	}
+
+	user := &models.User{}
+	if err := env.DB.NewSelect().Model(user).Where("email = ?", email).Scan(context.Background()); err != nil {
+		t.Fatalf("customer account: %v", err)
+	}
+	if user.Role != "user" {
+		t.Fatalf("account role %q, want user", user.Role)
+	}
+}
+
+func TestOIDCCallbackRequiresStateCookie(t *testing.T) {
+	env, provider := newOIDCEnv(t)
+	provider.SignInAs(oidctest.User{Subject: "diner", Email: apptest.Email("diner"), EmailVerified: true})
+
+	cookie, callbackURL := authorize(t, env)
+	if recorder := callback(env, callbackURL); recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("callback without the state cookie: status %d, want 401", recorder.Code)
+	}
+
+	// a sign-in started by someone else carries another state
+	otherCookie, _ := authorize(t, env)
+	if recorder := callback(env, callbackURL, otherCookie); recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("callback with another browser's state: status %d, want 401", recorder.Code)
+	}
+
+	// the rejected callbacks did not use the state up
+	if recorder := callback(env, callbackURL, cookie); recorder.Code != http.StatusOK {
+		t.Fatalf("callback with the state cookie: status %d, want 200: %s", recorder.Code, recorder.Body)
+	}
+}
+
+func TestOIDCStateIsSingleUse(t *testing.T) {
+	env, provider := newOIDCEnv(t)
+	provider.SignInAs(oidctest.User{Subject: "diner", Email: apptest.Email("diner"), EmailVerified: true})
+
+	cookie, callbackURL := authorize(t, env)
+	if recorder := callback(env, callbackURL, cookie); recorder.Code != http.StatusOK {
+		t.Fatalf("callback: status %d, want 200: %s", recorder.Code, recorder.Body)
+	}
+	if recorder := callback(env, callbackURL, cookie); recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("replayed callback: status %d, want 401", recorder.Code)
+	}
+}
+
+func TestOIDCLinkCannotBeFinishedByAnotherBrowser(t *testing.T) {
+	env, provider := newOIDCEnv(t)
+	ctx := context.Background()
+	attacker := env.CreateUser(t, "user")
+	victim := oidctest.User{Subject: "victim", Email: apptest.Email("victim"), EmailVerified: true}
+
+	// the attacker starts linking the provider to their account and has the
+	// victim's browser, signed in at the provider, open the URL
+	authorizationURL, _, appErr := env.Services.StartOIDCLogin(ctx, "test", attacker.ID)
+	if appErr != nil {
+		t.Fatalf("start link: %v", appErr)
+	}
+	provider.SignInAs(victim)
+	callbackURL := signInAtProvider(t, authorizationURL)
+
+	if recorder := callback(env, callbackURL); recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("link finished in another browser: status %d, want 401", recorder.Code)
+	}
+	if identities := identitiesOf(t, env, attacker.ID); len(identities) != 0 {
+		t.Fatalf("victim's identity linked to the attacker: %+v", identities)
+	}
+}
+
+func TestOIDCLinkInSameBrowser(t *testing.T) {
+	env, provider := newOIDCEnv(t)
+	ctx := context.Background()
+	user := env.CreateUser(t, "user")
+	provider.SignInAs(oidctest.User{Subject: "linked", Email: apptest.Email("other"), EmailVerified: true})
+
+	authorizationURL, state, appErr := env.Services.StartOIDCLogin(ctx, "test", user.ID)
+	if appErr != nil {
+		t.Fatalf("start link: %v", appErr)
+	}
+	callbackURL := signInAtProvider(t, authorizationURL)
+	if recorder := callback(env, callbackURL, &http.Cookie{Name: "oidc_state", Value: state}); recorder.Code != http.StatusOK {
+		t.Fatalf("link callback: status %d, want 200: %s", recorder.Code, recorder.Body)
+	}
+	identities := identitiesOf(t, env, user.ID)
+	if len(identities) != 1 || identities[0].Subject != "linked" {
+		t.Fatalf("identities %+v, want the linked subject", identities)
+	}
+}
+
+func TestOIDCRejectsWrongNonce(t *testing.T) {
+	env, provider := newOIDCEnv(t)
+	provider.SignInAs(oidctest.User{Subject: "diner", Email: apptest.Email("diner"), EmailVerified: true})
+
+	cookie, callbackURL := authorize(t, env)
+	// the ID token carries the nonce sent at the start, not this one
+	tamperState(t, env, stateOf(t, callbackURL), "nonce", "another-nonce")
+	if recorder := callback(env, callbackURL, cookie); recorder.Code == http.StatusOK {
+		t.Fatal("ID token with a different nonce accepted")
+	}
+}
+
+func TestOIDCRejectsWrongCodeVerifier(t *testing.T) {
+	env, provider := newOIDCEnv(t)
+	provider.SignInAs(oidctest.User{Subject: "diner", Email: apptest.Email("diner"), EmailVerified: true})
+
+	cookie, callbackURL := authorize(t, env)
+	// the provider checks the verifier against the challenge of the authorization
+	tamperState(t, env, stateOf(t, callbackURL), "code_verifier", "another-verifier")
+	if recorder := callback(env, callbackURL, cookie); recorder.Code == http.StatusOK {
+		t.Fatal("code exchanged with a different PKCE verifier")
+	}
+}
+
+func TestOIDCLinksExistingUserByVerifiedEmail(t *testing.T) {
+	env, provider := newOIDCEnv(t)
+	user := env.CreateUser(t, "user")
+	provider.SignInAs(oidctest.User{Subject: "existing", Email: user.Email, EmailVerified: true})
+
+	cookie, callbackURL := authorize(t, env)
+	if recorder := callback(env, callbackURL, cookie); recorder.Code != http.StatusOK {
+		t.Fatalf("callback: status %d, want 200: %s", recorder.Code, recorder.Body)
+	}
+	identities := identitiesOf(t, env, user.ID)
+	if len(identities) != 1 || identities[0].Subject != "existing" {
+		t.Fatalf("identities %+v, want the provider linked to the existing user", identities)
+	}
+}
+
+func TestOIDCRejectsUnverifiedEmailOfExistingUser(t *testing.T) {
+	env, provider := newOIDCEnv(t)
+	user := env.CreateUser(t, "user")
+	provider.SignInAs(oidctest.User{Subject: "claimer", Email: user.Email, EmailVerified: false})
+
+	cookie, callbackURL := authorize(t, env)
+	if recorder := callback(env, callbackURL, cookie); recorder.Code != http.StatusForbidden {
+		t.Fatalf("callback with an unverified email: status %d, want 403", recorder.Code)
+	}
+	if identities := identitiesOf(t, env, user.ID); len(identities) != 0 {
+		t.Fatalf("unverified email linked: %+v", identities)
+	}
+}
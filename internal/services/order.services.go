@@ -0,0 +1,331 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/uptrace/bun"
+	"go.uber.org/zap"
+
+	"github.com/alibaba0010/postgres-api/internal/dto"
+	"github.com/alibaba0010/postgres-api/internal/errors"
+	"github.com/alibaba0010/postgres-api/internal/models"
+	"github.com/alibaba0010/postgres-api/internal/types"
+	"github.com/alibaba0010/postgres-api/internal/utils"
+)
+
+// orderTransitions is the order state machine: for each status, the statuses
+// it may move to next. Anything not listed here is rejected with a 409.
+var orderTransitions = map[string][]string{
+	models.OrderStatusPending:   {models.OrderStatusAccepted, models.OrderStatusCancelled},
+	models.OrderStatusAccepted:  {models.OrderStatusPreparing, models.OrderStatusCancelled},
+	models.OrderStatusPreparing: {models.OrderStatusReady, models.OrderStatusCancelled},
+	models.OrderStatusReady:     {models.OrderStatusServed, models.OrderStatusDelivered},
+	models.OrderStatusServed:    {models.OrderStatusPaid},
+	models.OrderStatusDelivered: {models.OrderStatusPaid},
+	models.OrderStatusPaid:      {models.OrderStatusRefunded},
+	models.OrderStatusCancelled: {},
+	models.OrderStatusRefunded:  {},
+}
+
+// CanTransitionOrder reports whether an order of the given type may move from
+// one status to another. Dine-in orders are served; other types are delivered.
+func CanTransitionOrder(orderType, from, to string) bool {
+	allowed := false
+	for _, next := range orderTransitions[from] {
+		if next == to {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return false
+	}
+	switch to {
+	case models.OrderStatusServed:
+		return orderType == models.OrderTypeDineIn
+	case models.OrderStatusDelivered:
+		return orderType != models.OrderTypeDineIn
+	}
+	return true
+}
+
+// CreateOrder places an order for the customer. Prices and names are taken
+// from the current menu, never from the client.
+func (s *Service) CreateOrder(ctx context.Context, customerID, role string, input dto.CreateOrderInput) (*models.Order, *errors.AppError) {
+	if appErr := validateInput(input); appErr != nil {
+		return nil, appErr
+	}
+
+	restaurant, appErr := s.GetRestaurantByID(ctx, input.RestaurantID)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	// Load every referenced item that is on an active menu of this restaurant
+	ids := make([]string, 0, len(input.Items))
+	for _, line := range input.Items {
+		ids = append(ids, line.MenuItemID)
+	}
+	menuItems := make([]models.MenuItem, 0, len(ids))
+	err := s.db.NewSelect().Model(&menuItems).
+		Join("JOIN menus AS m ON m.id = menu_item.menu_id").
+		Where("menu_item.id IN (?)", bun.In(ids)).
+		Where("menu_item.restaurant_id = ?", restaurant.ID).
+		Where("m.is_active = TRUE").
+		Scan(ctx)
+	if err != nil {
+		s.logger(ctx).Error("failed to load menu items for order", zap.Error(err))
+		return nil, errors.InternalError(err)
+	}
+	byID := make(map[string]*models.MenuItem, len(menuItems))
+	for i := range menuItems {
+		byID[menuItems[i].ID] = &menuItems[i]
+	}
+
+	orderUUID, err := utils.GenerateUUIDv7()
+	if err != nil {
+		return nil, errors.InternalError(err)
+	}
+
+	now := s.clock.Now()
+	order := &models.Order{
+		ID:           orderUUID.String(),
+		RestaurantID: restaurant.ID,
+		CustomerID:   customerID,
+		Type:         input.Type,
+		Status:       models.OrderStatusPending,
+		TableNumber:  strings.TrimSpace(input.TableNumber),
+		Notes:        strings.TrimSpace(input.Notes),
+	}
+
+	for _, line := range input.Items {
+		menuItem, ok := byID[line.MenuItemID]
+		if !ok {
+			return nil, errors.ValidationError(fmt.Sprintf("menu item %s is not on this restaurant's menu", line.MenuItemID))
+		}
+		if !MenuItemAvailableAt(menuItem, now) {
+			return nil, errors.ValidationError(fmt.Sprintf("%s is not available right now", menuItem.Name))
+		}
+		if order.Currency == "" {
+			order.Currency = menuItem.Currency
+		} else if order.Currency != menuItem.Currency {
+			return nil, errors.ValidationError("all items of an order must share the same currency")
+		}
+
+		itemUUID, err := utils.GenerateUUIDv7()
+		if err != nil {
+			return nil, errors.InternalError(err)
+		}
+		total := menuItem.PriceCents * int64(line.Quantity)
+		order.Items = append(order.Items, models.OrderItem{
+			ID:             itemUUID.String(),
+			OrderID:        order.ID,
+			MenuItemID:     menuItem.ID,
+			Name:           menuItem.Name,
+			UnitPriceCents: menuItem.PriceCents,
+			Quantity:       line.Quantity,
+			TotalCents:     total,
+			Notes:          strings.TrimSpace(line.Notes),
+		})
+		order.SubtotalCents += total
+	}
+
+	transition, appErr := newOrderTransition(order.ID, "", models.OrderStatusPending, customerID, role, "")
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	err = s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.NewInsert().Model(order).Returning("created_at, updated_at").Exec(ctx); err != nil {
+			return err
+		}
+		if _, err := tx.NewInsert().Model(&order.Items).Exec(ctx); err != nil {
+			return err
+		}
+		_, err := tx.NewInsert().Model(transition).Returning("created_at").Exec(ctx)
+		return err
+	})
+	if err != nil {
+		s.logger(ctx).Error("failed to create order", zap.Error(err), zap.String("customer_id", customerID))
+		return nil, errors.InternalError(err)
+	}
+	order.Transitions = []models.OrderTransition{*transition}
+
+	s.logger(ctx).Info("order created",
+		zap.String("order_id", order.ID),
+		zap.String("restaurant_id", order.RestaurantID),
+		zap.Int64("subtotal_cents", order.SubtotalCents))
+	return order, nil
+}
+
+// GetOrder returns an order with its items and transition history. Customers
+// may only see their own orders; staff with order:read see their restaurant's.
+func (s *Service) GetOrder(ctx context.Context, id, userID, role string) (*models.Order, *errors.AppError) {
+	order := &models.Order{}
+	err := s.db.NewSelect().Model(order).
+		Relation("Items").
+		Relation("Transitions", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.OrderExpr("created_at ASC")
+		}).
+		Where("?TableAlias.id = ?", id).
+		Scan(ctx)
+	if err == sql.ErrNoRows {
+		return nil, errors.NotFoundError("order not found")
+	}
+	if err != nil {
+		s.logger(ctx).Error("failed to fetch order", zap.Error(err), zap.String("order_id", id))
+		return nil, errors.InternalError(err)
+	}
+
+	if order.CustomerID == userID {
+		return order, nil
+	}
+	allowed, appErr := s.HasPermission(ctx, userID, role, order.RestaurantID, types.PermOrderRead)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if !allowed {
+		// Do not reveal that someone else's order exists
+		return nil, errors.NotFoundError("order not found")
+	}
+	return order, nil
+}
+
+// ListCustomerOrders returns the caller's own orders, newest first
+func (s *Service) ListCustomerOrders(ctx context.Context, customerID string, filter dto.OrderFilter) ([]models.Order, *errors.AppError) {
+	orders := make([]models.Order, 0)
+	query := s.db.NewSelect().Model(&orders).
+		Relation("Items").
+		Where("customer_id = ?", customerID).
+		OrderExpr("created_at DESC").
+		Limit(filter.Limit).
+		Offset(filter.Offset)
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if err := query.Scan(ctx); err != nil {
+		s.logger(ctx).Error("failed to list customer orders", zap.Error(err), zap.String("customer_id", customerID))
+		return nil, errors.InternalError(err)
+	}
+	return orders, nil
+}
+
+// ListRestaurantOrders returns the orders of a restaurant managed by the caller
+func (s *Service) ListRestaurantOrders(ctx context.Context, restaurantID, userID, role string, filter dto.OrderFilter) ([]models.Order, *errors.AppError) {
+	if _, appErr := s.authorizeRestaurant(ctx, restaurantID, userID, role, types.PermOrderRead); appErr != nil {
+		return nil, appErr
+	}
+
+	orders := make([]models.Order, 0)
+	query := s.db.NewSelect().Model(&orders).
+		Relation("Items").
+		Where("restaurant_id = ?", restaurantID).
+		OrderExpr("created_at DESC").
+		Limit(filter.Limit).
+		Offset(filter.Offset)
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if err := query.Scan(ctx); err != nil {
+		s.logger(ctx).Error("failed to list restaurant orders", zap.Error(err), zap.String("restaurant_id", restaurantID))
+		return nil, errors.InternalError(err)
+	}
+	return orders, nil
+}
+
+// TransitionOrder moves an order to a new status and records who did it.
+// Restaurant management may perform any allowed transition; a customer may
+// only cancel their own order while it is still pending. The order row is
+// locked for the duration so concurrent transitions cannot both succeed.
+func (s *Service) TransitionOrder(ctx context.Context, orderID, actorID, actorRole string, input dto.TransitionOrderInput) (*models.Order, *errors.AppError) {
+	if appErr := validateInput(input); appErr != nil {
+		return nil, appErr
+	}
+
+	err := s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		order := &models.Order{}
+		err := tx.NewSelect().Model(order).
+			Where("id = ?", orderID).
+			For("UPDATE").
+			Scan(ctx)
+		if err == sql.ErrNoRows {
+			return errors.NotFoundError("order not found")
+		}
+		if err != nil {
+			return err
+		}
+
+		if appErr := s.authorizeOrderTransition(ctx, order, actorID, actorRole, input.Status); appErr != nil {
+			return appErr
+		}
+		if !CanTransitionOrder(order.Type, order.Status, input.Status) {
+			return errors.ConflictError(fmt.Sprintf("order cannot move from %s to %s", order.Status, input.Status))
+		}
+
+		transition, appErr := newOrderTransition(order.ID, order.Status, input.Status, actorID, actorRole, strings.TrimSpace(input.Reason))
+		if appErr != nil {
+			return appErr
+		}
+
+		order.Status = input.Status
+		order.UpdatedAt = s.clock.Now()
+		if _, err := tx.NewUpdate().Model(order).
+			Column("status", "updated_at").
+			WherePK().
+			Exec(ctx); err != nil {
+			return err
+		}
+		_, err = tx.NewInsert().Model(transition).Exec(ctx)
+		return err
+	})
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			return nil, appErr
+		}
+		s.logger(ctx).Error("failed to transition order", zap.Error(err), zap.String("order_id", orderID))
+		return nil, errors.InternalError(err)
+	}
+
+	s.logger(ctx).Info("order status changed",
+		zap.String("order_id", orderID),
+		zap.String("status", input.Status),
+		zap.String("actor_id", actorID))
+	return s.GetOrder(ctx, orderID, actorID, actorRole)
+}
+
+// authorizeOrderTransition decides whether the actor may move this order at all
+func (s *Service) authorizeOrderTransition(ctx context.Context, order *models.Order, actorID, actorRole, target string) *errors.AppError {
+	if order.CustomerID == actorID && target == models.OrderStatusCancelled && order.Status == models.OrderStatusPending {
+		return nil
+	}
+	allowed, appErr := s.HasPermission(ctx, actorID, actorRole, order.RestaurantID, types.PermOrderAdvance)
+	if appErr != nil {
+		return appErr
+	}
+	if !allowed {
+		if order.CustomerID == actorID {
+			return errors.ForbiddenError("customers can only cancel orders that are still pending")
+		}
+		return errors.NotFoundError("order not found")
+	}
+	return nil
+}
+
+func newOrderTransition(orderID, from, to, actorID, actorRole, reason string) (*models.OrderTransition, *errors.AppError) {
+	newUUID, err := utils.GenerateUUIDv7()
+	if err != nil {
+		return nil, errors.InternalError(err)
+	}
+	return &models.OrderTransition{
+		ID:         newUUID.String(),
+		OrderID:    orderID,
+		FromStatus: from,
+		ToStatus:   to,
+		ActorID:    actorID,
+		ActorRole:  actorRole,
+		Reason:     reason,
+	}, nil
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	redisPkg "github.com/redis/go-redis/v9"
+	"github.com/uptrace/bun"
+	"go.uber.org/zap"
+
+	"github.com/alibaba0010/postgres-api/internal/dto"
+	"github.com/alibaba0010/postgres-api/internal/errors"
+	"github.com/alibaba0010/postgres-api/internal/models"
+	"github.com/alibaba0010/postgres-api/internal/utils"
+)
+
+// PasswordResetTTL is how long a password reset link stays valid
+const PasswordResetTTL = 15 * time.Minute
+
+// RequestPasswordReset emails a single-use reset link to the owner of email.
+// It returns nil whether or not the email belongs to an account so callers
+// cannot use it to discover registered addresses.
+func (s *Service) RequestPasswordReset(ctx context.Context, input dto.ForgotPasswordInput) *errors.AppError {
+	input.Email = strings.TrimSpace(input.Email)
+	if appErr := validateInput(input); appErr != nil {
+		return appErr
+	}
+
+	user := &models.User{}
+	err := s.db.NewSelect().Model(user).
+		Where("email = ?", input.Email).
+		Scan(ctx)
+	if err == sql.ErrNoRows {
+		s.logger(ctx).Debug("password reset requested for unknown email", zap.String("email", input.Email))
+		return nil
+	}
+	if err != nil {
+		return errors.InternalError(err)
+	}
+
+	token, err := utils.GenerateToken()
+	if err != nil {
+		return errors.InternalError(err)
+	}
+
+	// Only the latest link works: drop the token issued by a previous request.
+	userKey := "reset_user:" + user.ID
+	if previous, err := s.cache.Get(ctx, userKey).Result(); err == nil {
+		_ = s.cache.Del(ctx, "reset:"+previous).Err()
+	}
+
+	// the token first: a user key never points at a token that is missing
+	if err := s.cache.Set(ctx, "reset:"+token, user.ID, PasswordResetTTL).Err(); err != nil {
+		return errors.InternalError(err)
+	}
+	if err := s.cache.Set(ctx, userKey, token, PasswordResetTTL).Err(); err != nil {
+		return errors.InternalError(err)
+	}
+
+	cfg := s.cfg
+	resetURL := fmt.Sprintf("%s/reset-password?token=%s", cfg.FRONTEND_URL, token)
+	html := s.PasswordResetHTML(user.Name, resetURL)
+	s.runInBackground(func() {
+		if err := s.mailer.Send(user.Email, "Reset your password", html); err != nil {
+			s.logger(ctx).Error("failed to send password reset email",
+				zap.Error(err),
+				zap.String("email", user.Email),
+			)
+		}
+	})
+
+	return nil
+}
+
+// ResetPassword consumes a reset token, stores the new password and signs the
+// user out everywhere by deleting all of their refresh tokens and revoking
+// their access tokens.
+func (s *Service) ResetPassword(ctx context.Context, input dto.ResetPasswordInput) *errors.AppError {
+	input.Token = strings.TrimSpace(input.Token)
+	if appErr := validateInput(input); appErr != nil {
+		return appErr
+	}
+
+	// GETDEL makes the token single-use even under concurrent requests
+	userID, err := s.cache.GetDel(ctx, "reset:"+input.Token).Result()
+	if err == redisPkg.Nil {
+		return errors.ValidationError("invalid or expired token")
+	}
+	if err != nil {
+		return errors.InternalError(err)
+	}
+	_ = s.cache.Del(ctx, "reset_user:"+userID).Err()
+
+	hashedPwd, err := hashPassword(input.Password)
+	if err != nil {
+		return errors.InternalError(err)
+	}
+
+	err = s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		result, err := tx.NewUpdate().Model((*models.User)(nil)).
+			Set("password = ?", hashedPwd).
+			Set("updated_at = current_timestamp").
+			Where("id = ?", userID).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		if rows, _ := result.RowsAffected(); rows == 0 {
+			return errors.ValidationError("invalid or expired token")
+		}
+
+		_, err = tx.NewDelete().Model((*models.RefreshToken)(nil)).
+			Where("user_id = ?", userID).
+			Exec(ctx)
+		return err
+	})
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			return appErr
+		}
+		return errors.InternalError(err)
+	}
+	s.revokeUserAccessTokens(ctx, userID)
+
+	s.logger(ctx).Info("password reset", zap.String("user_id", userID))
+	return nil
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"github.com/alibaba0010/postgres-api/internal/errors"
+	"github.com/alibaba0010/postgres-api/internal/models"
+	"github.com/alibaba0010/postgres-api/internal/types"
+)
+
+// ownerRoleName is the built-in role every owner holds on their restaurants
+const ownerRoleName = "owner"
+
+// PermissionSet is the set of permissions a user holds in some scope
+type PermissionSet map[types.Permission]bool
+
+// Has reports whether every one of permissions is in the set
+func (s PermissionSet) Has(permissions ...types.Permission) bool {
+	for _, permission := range permissions {
+		if !s[permission] {
+			return false
+		}
+	}
+	return true
+}
+
+// UserPermissions resolves what the user may do in a restaurant: the
+// permissions of their account role, of the owner role if they own the
+// restaurant, and of the roles assigned to them there.
+func (s *Service) UserPermissions(ctx context.Context, userID, accountRole, restaurantID string) (PermissionSet, *errors.AppError) {
+	byRestaurant, appErr := s.restaurantPermissions(ctx, userID, accountRole, restaurantID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	return byRestaurant[restaurantID], nil
+}
+
+// HasPermission reports whether the user holds all of permissions in the
+// restaurant. With an empty restaurantID they must hold them all through
+// their account role or within a single restaurant; permissions held in
+// different restaurants do not add up.
+func (s *Service) HasPermission(ctx context.Context, userID, accountRole, restaurantID string, permissions ...types.Permission) (bool, *errors.AppError) {
+	byRestaurant, appErr := s.restaurantPermissions(ctx, userID, accountRole, restaurantID)
+	if appErr != nil {
+		return false, appErr
+	}
+	if restaurantID != "" {
+		return byRestaurant[restaurantID].Has(permissions...), nil
+	}
+	for _, held := range byRestaurant {
+		if held.Has(permissions...) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// restaurantPermissions returns the user's permissions in each restaurant
+// they own or hold roles in, each including the permissions of the account
+// role. The account role alone is under the empty key. A non-empty
+// restaurantID limits the result to that restaurant, always present.
+func (s *Service) restaurantPermissions(ctx context.Context, userID, accountRole, restaurantID string) (map[string]PermissionSet, *errors.AppError) {
+	var grants []permissionGrant
+
+	assignmentFilter, ownerFilter := "", ""
+	args := []interface{}{accountRole, userID}
+	if restaurantID != "" {
+		assignmentFilter = " AND a.restaurant_id = ?"
+		args = append(args, restaurantID)
+	}
+	args = append(args, userID)
+	if restaurantID != "" {
+		ownerFilter = " AND rs.id = ?"
+		args = append(args, restaurantID)
+	}
+	args = append(args, ownerRoleName)
+
+	err := s.db.NewRaw(`
+		SELECT r.permissions, false AS scoped, '' AS restaurant_id FROM roles AS r WHERE r.name = ?
+		UNION ALL
+		SELECT r.permissions, true AS scoped, a.restaurant_id::text AS restaurant_id FROM role_assignments AS a
+			JOIN roles AS r ON r.id = a.role_id
+			JOIN restaurants AS rs ON rs.id = a.restaurant_id AND rs.deleted_at IS NULL
+			WHERE a.user_id = ?`+assignmentFilter+`
+		UNION ALL
+		SELECT r.permissions, true AS scoped, rs.id::text AS restaurant_id FROM roles AS r
+			JOIN restaurants AS rs ON rs.owner_id = ? AND rs.deleted_at IS NULL`+ownerFilter+`
+			WHERE r.name = ?`, args...).
+		Scan(ctx, &grants)
+	if err != nil {
+		s.logger(ctx).Error("failed to resolve permissions", zap.Error(err), zap.String("user_id", userID))
+		return nil, errors.InternalError(err)
+	}
+
+	return permissionsByRestaurant(grants, restaurantID), nil
+}
+
+// permissionGrant is a role held by a user: the account role, unscoped, or a
+// role in a restaurant
+type permissionGrant struct {
+	Permissions  []string `bun:"permissions,array"`
+	Scoped       bool     `bun:"scoped"`
+	RestaurantID string   `bun:"restaurant_id"`
+}
+
+// permissionsByRestaurant groups grants by restaurant as restaurantPermissions
+// returns them
+func permissionsByRestaurant(grants []permissionGrant, restaurantID string) map[string]PermissionSet {
+	account := PermissionSet{}
+	for _, grant := range grants {
+		if !grant.Scoped {
+			for _, name := range grant.Permissions {
+				account[types.Permission(name)] = true
+			}
+		}
+	}
+	byRestaurant := map[string]PermissionSet{"": account}
+	if restaurantID != "" {
+		byRestaurant[restaurantID] = copyPermissions(account)
+	}
+	for _, grant := range grants {
+		if !grant.Scoped {
+			continue
+		}
+		held, ok := byRestaurant[grant.RestaurantID]
+		if !ok {
+			held = copyPermissions(account)
+			byRestaurant[grant.RestaurantID] = held
+		}
+		for _, name := range grant.Permissions {
+			permission := types.Permission(name)
+			// a role assigned in a restaurant cannot grant platform permissions
+			if !permission.RestaurantScoped() {
+				continue
+			}
+			held[permission] = true
+		}
+	}
+	return byRestaurant
+}
+
+func copyPermissions(permissions PermissionSet) PermissionSet {
+	copied := make(PermissionSet, len(permissions))
+	for permission := range permissions {
+		copied[permission] = true
+	}
+	return copied
+}
+
+// authorizeRestaurantPermission rejects callers that do not hold permission
+// in the restaurant
+func (s *Service) authorizeRestaurantPermission(ctx context.Context, restaurant *models.Restaurant, userID, role string, permission types.Permission) *errors.AppError {
+	allowed, appErr := s.HasPermission(ctx, userID, role, restaurant.ID, permission)
+	if appErr != nil {
+		return appErr
+	}
+	if allowed {
+		return nil
+	}
+	s.logger(ctx).Warn("restaurant permission check failed",
+		zap.String("restaurant_id", restaurant.ID),
+		zap.String("user_id", userID),
+		zap.String("role", role),
+		zap.String("permission", permission.String()))
+	return errors.ForbiddenError("you do not have " + permission.String() + " permission for this restaurant")
+}
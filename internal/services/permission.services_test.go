@@ -0,0 +1,65 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/alibaba0010/postgres-api/internal/models"
+	"github.com/alibaba0010/postgres-api/internal/types"
+)
+
+func TestMissingPermissions(t *testing.T) {
+	held := PermissionSet{types.PermStaffManage: true, types.PermOrderRead: true}
+	tests := []struct {
+		name        string
+		permissions []string
+		want        []string
+	}{
+		{"subset", []string{"staff:manage"}, nil},
+		{"same", []string{"order:read", "staff:manage"}, nil},
+		{"empty role", nil, nil},
+		{"wider", []string{"order:read", "order:advance", "menu:write"}, []string{"order:advance", "menu:write"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := missingPermissions(held, &models.Role{Permissions: tt.permissions})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("missingPermissions = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPermissionsByRestaurant(t *testing.T) {
+	grants := []permissionGrant{
+		{Permissions: []string{"restaurant:create"}},
+		{Permissions: []string{"menu:write", "user:manage"}, Scoped: true, RestaurantID: "a"},
+		{Permissions: []string{"order:read"}, Scoped: true, RestaurantID: "b"},
+	}
+	byRestaurant := permissionsByRestaurant(grants, "")
+
+	if !byRestaurant[""].Has(types.PermRestaurantCreate) || byRestaurant[""].Has(types.PermMenuWrite) {
+		t.Fatalf("account permissions %v, want only the account role's", byRestaurant[""])
+	}
+	if !byRestaurant["a"].Has(types.PermRestaurantCreate, types.PermMenuWrite) {
+		t.Fatalf("restaurant a %v, want the account role's and its own", byRestaurant["a"])
+	}
+	if byRestaurant["a"].Has(types.PermUserManage) {
+		t.Fatal("a role assigned in a restaurant granted a platform permission")
+	}
+	for restaurantID, held := range byRestaurant {
+		if held.Has(types.PermMenuWrite, types.PermOrderRead) {
+			t.Fatalf("permissions of restaurants a and b combined under %q", restaurantID)
+		}
+	}
+}
+
+func TestPermissionsByRestaurantWithoutGrants(t *testing.T) {
+	byRestaurant := permissionsByRestaurant([]permissionGrant{{Permissions: []string{"restaurant:create"}}}, "c")
+	if !byRestaurant["c"].Has(types.PermRestaurantCreate) {
+		t.Fatalf("restaurant without roles %v, want the account role's permissions", byRestaurant["c"])
+	}
+	if byRestaurant["c"].Has(types.PermMenuWrite) {
+		t.Fatal("restaurant without roles granted menu:write")
+	}
+}
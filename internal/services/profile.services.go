@@ -0,0 +1,261 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	redisPkg "github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"github.com/alibaba0010/postgres-api/internal/dto"
+	"github.com/alibaba0010/postgres-api/internal/errors"
+	"github.com/alibaba0010/postgres-api/internal/models"
+	"github.com/alibaba0010/postgres-api/internal/utils"
+)
+
+// EmailChangeTTL is how long the link confirming a new email stays valid
+const EmailChangeTTL = 15 * time.Minute
+
+// emailChange is stored under change_email:<token> until the new address is confirmed
+type emailChange struct {
+	UserID string `json:"user_id"`
+	Email  string `json:"email"`
+}
+
+// UpdateProfile changes the name, address or phone of the current user
+func (s *Service) UpdateProfile(ctx context.Context, userID string, input dto.UpdateProfileInput) (*dto.CurrentUserResponse, *errors.AppError) {
+	input.Name = trimmed(input.Name)
+	input.Address = trimmed(input.Address)
+	input.Phone = trimmed(input.Phone)
+	if input.Name != nil && *input.Name == "" {
+		return nil, errors.ValidationError("Name is required")
+	}
+	if appErr := validateInput(input); appErr != nil {
+		return nil, appErr
+	}
+
+	user, appErr := s.getUserByID(ctx, userID)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	columns := []string{"updated_at"}
+	if input.Name != nil {
+		user.Name = *input.Name
+		columns = append(columns, "name")
+	}
+	if input.Address != nil {
+		user.Address = *input.Address
+		columns = append(columns, "address")
+	}
+	if input.Phone != nil {
+		user.Phone = *input.Phone
+		columns = append(columns, "phone")
+	}
+	user.UpdatedAt = s.clock.Now()
+
+	if _, err := s.db.NewUpdate().Model(user).
+		Column(columns...).
+		WherePK().
+		Exec(ctx); err != nil {
+		s.logger(ctx).Error("failed to update profile", zap.Error(err), zap.String("user_id", userID))
+		return nil, errors.InternalError(err)
+	}
+	return currentUserResponse(user), nil
+}
+
+// ChangePassword replaces the password of the current user after checking the
+// current one, and signs them out of every session but currentSessionID.
+func (s *Service) ChangePassword(ctx context.Context, userID, currentSessionID string, input dto.ChangePasswordInput) *errors.AppError {
+	if appErr := validateInput(input); appErr != nil {
+		return appErr
+	}
+
+	user, appErr := s.getUserByID(ctx, userID)
+	if appErr != nil {
+		return appErr
+	}
+	if !verifyPassword(input.CurrentPassword, user.Password) {
+		s.logger(ctx).Warn("password change with a wrong current password", zap.String("user_id", userID))
+		return errors.ValidationError("current password is incorrect")
+	}
+	if verifyPassword(input.Password, user.Password) {
+		return errors.ValidationError("password must differ from the current password")
+	}
+
+	hashedPwd, err := hashPassword(input.Password)
+	if err != nil {
+		return errors.InternalError(err)
+	}
+	user.Password = hashedPwd
+	user.UpdatedAt = s.clock.Now()
+	if _, err := s.db.NewUpdate().Model(user).
+		Column("password", "updated_at").
+		WherePK().
+		Exec(ctx); err != nil {
+		s.logger(ctx).Error("failed to change password", zap.Error(err), zap.String("user_id", userID))
+		return errors.InternalError(err)
+	}
+	if appErr := s.revokeOtherSessions(ctx, userID, currentSessionID); appErr != nil {
+		return appErr
+	}
+
+	html := s.PasswordChangedHTML(user.Name)
+	s.runInBackground(func() {
+		if err := s.mailer.Send(user.Email, "Your password was changed", html); err != nil {
+			s.logger(ctx).Error("failed to send password changed email", zap.Error(err), zap.String("email", user.Email))
+		}
+	})
+
+	s.logger(ctx).Info("password changed", zap.String("user_id", userID))
+	return nil
+}
+
+// RequestEmailChange emails a confirmation link to the new address. The
+// account keeps its current email until ConfirmEmailChange consumes the token.
+func (s *Service) RequestEmailChange(ctx context.Context, userID string, input dto.ChangeEmailInput) *errors.AppError {
+	input.Email = strings.TrimSpace(input.Email)
+	if appErr := validateInput(input); appErr != nil {
+		return appErr
+	}
+
+	user, appErr := s.getUserByID(ctx, userID)
+	if appErr != nil {
+		return appErr
+	}
+	if !verifyPassword(input.Password, user.Password) {
+		s.logger(ctx).Warn("email change with a wrong password", zap.String("user_id", userID))
+		return errors.ValidationError("password is incorrect")
+	}
+	if strings.EqualFold(input.Email, user.Email) {
+		return errors.ValidationError("email must differ from the current email")
+	}
+
+	exists, err := s.db.NewSelect().Model((*models.User)(nil)).
+		Where("email = ?", input.Email).
+		Exists(ctx)
+	if err != nil {
+		return errors.InternalError(err)
+	}
+	if exists {
+		return errors.DuplicateError("email")
+	}
+
+	token, err := utils.GenerateToken()
+	if err != nil {
+		return errors.InternalError(err)
+	}
+	payload, err := json.Marshal(emailChange{UserID: user.ID, Email: input.Email})
+	if err != nil {
+		return errors.InternalError(err)
+	}
+
+	// Only the latest link works: drop the token issued by a previous request.
+	userKey := "change_email_user:" + user.ID
+	if previous, err := s.cache.Get(ctx, userKey).Result(); err == nil {
+		_ = s.cache.Del(ctx, "change_email:"+previous).Err()
+	}
+
+	// the token first: a user key never points at a token that is missing
+	if err := s.cache.Set(ctx, "change_email:"+token, payload, EmailChangeTTL).Err(); err != nil {
+		return errors.InternalError(err)
+	}
+	if err := s.cache.Set(ctx, userKey, token, EmailChangeTTL).Err(); err != nil {
+		return errors.InternalError(err)
+	}
+
+	cfg := s.cfg
+	confirmURL := fmt.Sprintf("%s/api/v1/auth/verify-email?token=%s", cfg.FRONTEND_URL, token)
+	html := s.EmailChangeHTML(user.Name, input.Email, confirmURL)
+	s.runInBackground(func() {
+		if err := s.mailer.Send(input.Email, "Confirm your new email", html); err != nil {
+			s.logger(ctx).Error("failed to send email change confirmation",
+				zap.Error(err),
+				zap.String("email", input.Email),
+			)
+		}
+	})
+
+	return nil
+}
+
+// ConfirmEmailChange consumes an email change token and switches the account
+// to the confirmed address. The previous address is told about the change.
+func (s *Service) ConfirmEmailChange(ctx context.Context, token string) (*dto.CurrentUserResponse, *errors.AppError) {
+	// GETDEL makes the token single-use even under concurrent requests
+	data, err := s.cache.GetDel(ctx, "change_email:"+strings.TrimSpace(token)).Bytes()
+	if err == redisPkg.Nil {
+		return nil, errors.ValidationError("invalid or expired token")
+	}
+	if err != nil {
+		return nil, errors.InternalError(err)
+	}
+
+	var change emailChange
+	if err := json.Unmarshal(data, &change); err != nil {
+		return nil, errors.InternalError(err)
+	}
+	_ = s.cache.Del(ctx, "change_email_user:"+change.UserID).Err()
+
+	user, appErr := s.getUserByID(ctx, change.UserID)
+	if appErr != nil {
+		return nil, errors.ValidationError("invalid or expired token")
+	}
+
+	previous := user.Email
+	user.Email = change.Email
+	user.UpdatedAt = s.clock.Now()
+	if _, err := s.db.NewUpdate().Model(user).
+		Column("email", "updated_at").
+		WherePK().
+		Exec(ctx); err != nil {
+		// someone signed up with the address since the link was sent
+		if pgErrorCode(err) == pgUniqueViolation {
+			return nil, errors.DuplicateError("email")
+		}
+		s.logger(ctx).Error("failed to change email", zap.Error(err), zap.String("user_id", user.ID))
+		return nil, errors.InternalError(err)
+	}
+
+	html := s.EmailChangedHTML(user.Name, user.Email)
+	s.runInBackground(func() {
+		if err := s.mailer.Send(previous, "Your email was changed", html); err != nil {
+			s.logger(ctx).Error("failed to send email changed notice", zap.Error(err), zap.String("email", previous))
+		}
+	})
+
+	s.logger(ctx).Info("email changed", zap.String("user_id", user.ID))
+	return currentUserResponse(user), nil
+}
+
+// revokeOtherSessions signs the user out of every session except keepSessionID
+func (s *Service) revokeOtherSessions(ctx context.Context, userID, keepSessionID string) *errors.AppError {
+	sessions, err := s.refreshTokens.ListUserSessions(ctx, userID)
+	if err != nil {
+		s.logger(ctx).Error("failed to list sessions", zap.Error(err), zap.String("user_id", userID))
+		return errors.InternalError(err)
+	}
+	for _, session := range sessions {
+		if session.Token.FamilyID == keepSessionID {
+			continue
+		}
+		if err := s.refreshTokens.DeleteTokenFamily(ctx, session.Token.FamilyID); err != nil {
+			s.logger(ctx).Error("failed to revoke session", zap.Error(err), zap.String("session_id", session.Token.FamilyID))
+			return errors.InternalError(err)
+		}
+		s.revokeSessionAccessTokens(ctx, session.Token.FamilyID)
+	}
+	return nil
+}
+
+// trimmed returns value with surrounding spaces removed, keeping nil as nil
+func trimmed(value *string) *string {
+	if value == nil {
+		return nil
+	}
+	trimmedValue := strings.TrimSpace(*value)
+	return &trimmedValue
+}
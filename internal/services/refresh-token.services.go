@@ -0,0 +1,300 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	stdErrors "errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/uptrace/bun"
+
+	"github.com/alibaba0010/postgres-api/internal/clock"
+	"github.com/alibaba0010/postgres-api/internal/models"
+)
+
+var (
+	// ErrRefreshTokenNotFound is returned for tokens that were never issued or have been revoked
+	ErrRefreshTokenNotFound = stdErrors.New("refresh token not found")
+	// ErrRefreshTokenReused is returned when a token that was already rotated is presented again
+	ErrRefreshTokenReused = stdErrors.New("refresh token already used")
+)
+
+// PostgresRefreshTokenStorage keeps refresh tokens in the refresh_tokens table
+type PostgresRefreshTokenStorage struct {
+	db bun.IDB
+}
+
+func NewPostgresRefreshTokenStorage(db bun.IDB) *PostgresRefreshTokenStorage {
+	return &PostgresRefreshTokenStorage{db: db}
+}
+
+func (s *PostgresRefreshTokenStorage) StoreRefreshToken(ctx context.Context, token string, data RefreshTokenClaims) error {
+	_, err := s.db.NewInsert().Model(refreshTokenRow(token, data)).Exec(ctx)
+	return err
+}
+
+func (s *PostgresRefreshTokenStorage) GetRefreshToken(ctx context.Context, token string) (*models.RefreshToken, error) {
+	rt := &models.RefreshToken{}
+	err := s.db.NewSelect().Model(rt).Where("token = ?", token).Scan(ctx)
+	if err == sql.ErrNoRows {
+		return nil, ErrRefreshTokenNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return rt, nil
+}
+
+// RotateRefreshToken marks oldToken as used at the time its successor was
+// issued and stores the successor in one transaction. Only one caller can
+// rotate a given token; the others get ErrRefreshTokenReused.
+func (s *PostgresRefreshTokenStorage) RotateRefreshToken(ctx context.Context, oldToken, newToken string, data RefreshTokenClaims) error {
+	return s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		result, err := tx.NewUpdate().Model((*models.RefreshToken)(nil)).
+			Set("rotated_at = ?", data.IssuedAt.Time).
+			Where("token = ?", oldToken).
+			Where("rotated_at IS NULL").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		if rows, _ := result.RowsAffected(); rows == 0 {
+			return ErrRefreshTokenReused
+		}
+
+		_, err = tx.NewInsert().Model(refreshTokenRow(newToken, data)).Exec(ctx)
+		return err
+	})
+}
+
+func (s *PostgresRefreshTokenStorage) GetLiveRefreshToken(ctx context.Context, familyID string) (*models.RefreshToken, error) {
+	rt := &models.RefreshToken{}
+	err := s.db.NewSelect().Model(rt).
+		Where("family_id = ?", familyID).
+		Where("rotated_at IS NULL").
+		Scan(ctx)
+	if err == sql.ErrNoRows {
+		return nil, ErrRefreshTokenNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return rt, nil
+}
+
+// DeleteRefreshToken revokes the session the token belongs to
+func (s *PostgresRefreshTokenStorage) DeleteRefreshToken(ctx context.Context, token string) error {
+	_, err := s.db.NewDelete().Model((*models.RefreshToken)(nil)).
+		Where("family_id IN (SELECT family_id FROM refresh_tokens WHERE token = ?)", token).
+		Exec(ctx)
+	return err
+}
+
+func (s *PostgresRefreshTokenStorage) DeleteTokenFamily(ctx context.Context, familyID string) error {
+	_, err := s.db.NewDelete().Model((*models.RefreshToken)(nil)).
+		Where("family_id = ?", familyID).
+		Exec(ctx)
+	return err
+}
+
+func (s *PostgresRefreshTokenStorage) DeleteUserRefreshTokens(ctx context.Context, userID string) error {
+	_, err := s.db.NewDelete().Model((*models.RefreshToken)(nil)).
+		Where("user_id = ?", userID).
+		Exec(ctx)
+	return err
+}
+
+func (s *PostgresRefreshTokenStorage) DeleteExpiredRefreshTokens(ctx context.Context) (int64, error) {
+	result, err := s.db.NewDelete().Model((*models.RefreshToken)(nil)).
+		Where("expires_at < current_timestamp").
+		Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// ListUserSessions returns the user's live sessions, most recently used first
+func (s *PostgresRefreshTokenStorage) ListUserSessions(ctx context.Context, userID string) ([]Session, error) {
+	var active []models.RefreshToken
+	err := s.db.NewSelect().Model(&active).
+		Where("user_id = ?", userID).
+		Where("rotated_at IS NULL").
+		Where("expires_at > current_timestamp").
+		Order("created_at DESC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(active) == 0 {
+		return []Session{}, nil
+	}
+
+	familyIDs := make([]string, 0, len(active))
+	for _, rt := range active {
+		familyIDs = append(familyIDs, rt.FamilyID)
+	}
+	var starts []struct {
+		FamilyID   string    `bun:"family_id"`
+		SignedInAt time.Time `bun:"signed_in_at"`
+	}
+	err = s.db.NewSelect().Model((*models.RefreshToken)(nil)).
+		Column("family_id").
+		ColumnExpr("min(created_at) AS signed_in_at").
+		Where("family_id IN (?)", bun.In(familyIDs)).
+		Group("family_id").
+		Scan(ctx, &starts)
+	if err != nil {
+		return nil, err
+	}
+	signedInAt := make(map[string]time.Time, len(starts))
+	for _, start := range starts {
+		signedInAt[start.FamilyID] = start.SignedInAt
+	}
+
+	sessions := make([]Session, 0, len(active))
+	for _, rt := range active {
+		sessions = append(sessions, Session{Token: rt, SignedInAt: signedInAt[rt.FamilyID]})
+	}
+	return sessions, nil
+}
+
+// MemoryRefreshTokenStorage keeps refresh tokens in process memory. It follows
+// the same rotation rules as the Postgres storage and suits single-instance
+// development setups and tests.
+type MemoryRefreshTokenStorage struct {
+	mu     sync.Mutex
+	clock  clock.Clock
+	tokens map[string]models.RefreshToken
+}
+
+func NewMemoryRefreshTokenStorage(c clock.Clock) *MemoryRefreshTokenStorage {
+	return &MemoryRefreshTokenStorage{clock: c, tokens: make(map[string]models.RefreshToken)}
+}
+
+func (s *MemoryRefreshTokenStorage) StoreRefreshToken(ctx context.Context, token string, data RefreshTokenClaims) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	row := refreshTokenRow(token, data)
+	row.CreatedAt = s.clock.Now()
+	s.tokens[token] = *row
+	return nil
+}
+
+func (s *MemoryRefreshTokenStorage) GetRefreshToken(ctx context.Context, token string) (*models.RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rt, ok := s.tokens[token]
+	if !ok {
+		return nil, ErrRefreshTokenNotFound
+	}
+	return &rt, nil
+}
+
+func (s *MemoryRefreshTokenStorage) RotateRefreshToken(ctx context.Context, oldToken, newToken string, data RefreshTokenClaims) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old, ok := s.tokens[oldToken]
+	if !ok || !old.RotatedAt.IsZero() {
+		return ErrRefreshTokenReused
+	}
+	now := s.clock.Now()
+	old.RotatedAt = now
+	s.tokens[oldToken] = old
+
+	row := refreshTokenRow(newToken, data)
+	row.CreatedAt = now
+	s.tokens[newToken] = *row
+	return nil
+}
+
+func (s *MemoryRefreshTokenStorage) GetLiveRefreshToken(ctx context.Context, familyID string) (*models.RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, rt := range s.tokens {
+		if rt.FamilyID == familyID && rt.RotatedAt.IsZero() {
+			return &rt, nil
+		}
+	}
+	return nil, ErrRefreshTokenNotFound
+}
+
+func (s *MemoryRefreshTokenStorage) DeleteRefreshToken(ctx context.Context, token string) error {
+	s.mu.Lock()
+	rt, ok := s.tokens[token]
+	s.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	return s.DeleteTokenFamily(ctx, rt.FamilyID)
+}
+
+func (s *MemoryRefreshTokenStorage) DeleteTokenFamily(ctx context.Context, familyID string) error {
+	s.deleteWhere(func(rt models.RefreshToken) bool { return rt.FamilyID == familyID })
+	return nil
+}
+
+func (s *MemoryRefreshTokenStorage) DeleteUserRefreshTokens(ctx context.Context, userID string) error {
+	s.deleteWhere(func(rt models.RefreshToken) bool { return rt.UserID == userID })
+	return nil
+}
+
+func (s *MemoryRefreshTokenStorage) DeleteExpiredRefreshTokens(ctx context.Context) (int64, error) {
+	now := s.clock.Now()
+	return s.deleteWhere(func(rt models.RefreshToken) bool { return rt.ExpiresAt.Before(now) }), nil
+}
+
+func (s *MemoryRefreshTokenStorage) ListUserSessions(ctx context.Context, userID string) ([]Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.clock.Now()
+	signedInAt := make(map[string]time.Time)
+	for _, rt := range s.tokens {
+		if rt.UserID != userID {
+			continue
+		}
+		if start, ok := signedInAt[rt.FamilyID]; !ok || rt.CreatedAt.Before(start) {
+			signedInAt[rt.FamilyID] = rt.CreatedAt
+		}
+	}
+	sessions := []Session{}
+	for _, rt := range s.tokens {
+		if rt.UserID == userID && rt.RotatedAt.IsZero() && rt.ExpiresAt.After(now) {
+			sessions = append(sessions, Session{Token: rt, SignedInAt: signedInAt[rt.FamilyID]})
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].Token.CreatedAt.After(sessions[j].Token.CreatedAt)
+	})
+	return sessions, nil
+}
+
+func (s *MemoryRefreshTokenStorage) deleteWhere(match func(models.RefreshToken) bool) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var deleted int64
+	for token, rt := range s.tokens {
+		if match(rt) {
+			delete(s.tokens, token)
+			deleted++
+		}
+	}
+	return deleted
+}
+
+func refreshTokenRow(token string, data RefreshTokenClaims) *models.RefreshToken {
+	rt := &models.RefreshToken{
+		ID:        data.ID,
+		UserID:    data.UserID,
+		FamilyID:  data.FamilyID,
+		Token:     token,
+		IPAddress: data.IPAddress,
+		UserAgent: data.UserAgent,
+	}
+	if data.ExpiresAt != nil {
+		rt.ExpiresAt = data.ExpiresAt.Time
+	}
+	return rt
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/alibaba0010/postgres-api/internal/clock"
+)
+
+func refreshClaims(id, familyID string, issuedAt time.Time) RefreshTokenClaims {
+	return RefreshTokenClaims{
+		UserID:   "user",
+		FamilyID: familyID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        id,
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
+			ExpiresAt: jwt.NewNumericDate(issuedAt.Add(RefreshTokenDuration)),
+		},
+	}
+}
+
+func TestMemoryStorageRotatesOnce(t *testing.T) {
+	ctx := context.Background()
+	fake := clock.NewFake(time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC))
+	storage := NewMemoryRefreshTokenStorage(fake)
+
+	if err := storage.StoreRefreshToken(ctx, "first", refreshClaims("1", "family", fake.Now())); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.RotateRefreshToken(ctx, "first", "second", refreshClaims("2", "family", fake.Now())); err != nil {
+		t.Fatalf("first rotation: %v", err)
+	}
+	if err := storage.RotateRefreshToken(ctx, "first", "third", refreshClaims("3", "family", fake.Now())); err != ErrRefreshTokenReused {
+		t.Fatalf("second rotation of the same token: %v, want ErrRefreshTokenReused", err)
+	}
+
+	rotated, err := storage.GetRefreshToken(ctx, "first")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rotated.RotatedAt.Equal(fake.Now()) {
+		t.Fatalf("rotated at %v, want %v", rotated.RotatedAt, fake.Now())
+	}
+	live, err := storage.GetLiveRefreshToken(ctx, "family")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if live.Token != "second" {
+		t.Fatalf("live token %q, want the successor", live.Token)
+	}
+}
+
+func TestMemoryStorageLiveTokenOfRevokedFamily(t *testing.T) {
+	ctx := context.Background()
+	storage := NewMemoryRefreshTokenStorage(clock.NewFake(time.Now()))
+
+	if err := storage.StoreRefreshToken(ctx, "first", refreshClaims("1", "family", time.Now())); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.DeleteRefreshToken(ctx, "first"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := storage.GetLiveRefreshToken(ctx, "family"); err != ErrRefreshTokenNotFound {
+		t.Fatalf("live token of a revoked family: %v, want ErrRefreshTokenNotFound", err)
+	}
+}
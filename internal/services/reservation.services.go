@@ -0,0 +1,686 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	stdErrors "errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/uptrace/bun"
+	"go.uber.org/zap"
+
+	"github.com/alibaba0010/postgres-api/internal/dto"
+	"github.com/alibaba0010/postgres-api/internal/errors"
+	"github.com/alibaba0010/postgres-api/internal/models"
+	"github.com/alibaba0010/postgres-api/internal/types"
+	"github.com/alibaba0010/postgres-api/internal/utils"
+)
+
+const (
+	// ReservationReminderLeadTime is how long before a booking the reminder email goes out
+	ReservationReminderLeadTime = 3 * time.Hour
+	// reservationSlotInterval is the spacing of the start times offered by GetAvailability
+	reservationSlotInterval = 30 * time.Minute
+
+	pgExclusionViolation  = "23P01"
+	pgForeignKeyViolation = "23503"
+	pgUniqueViolation     = "23505"
+)
+
+// ListTables returns all tables of a restaurant managed by the caller
+func (s *Service) ListTables(ctx context.Context, restaurantID, userID, role string) ([]models.RestaurantTable, *errors.AppError) {
+	if _, appErr := s.authorizeRestaurant(ctx, restaurantID, userID, role, types.PermTableManage); appErr != nil {
+		return nil, appErr
+	}
+
+	tables := make([]models.RestaurantTable, 0)
+	if err := s.db.NewSelect().Model(&tables).
+		Where("restaurant_id = ?", restaurantID).
+		OrderExpr("section ASC NULLS FIRST, label ASC").
+		Scan(ctx); err != nil {
+		s.logger(ctx).Error("failed to list tables", zap.Error(err), zap.String("restaurant_id", restaurantID))
+		return nil, errors.InternalError(err)
+	}
+	return tables, nil
+}
+
+// CreateTable adds a bookable table to a restaurant managed by the caller
+func (s *Service) CreateTable(ctx context.Context, restaurantID, userID, role string, input dto.CreateTableInput) (*models.RestaurantTable, *errors.AppError) {
+	if appErr := validateInput(input); appErr != nil {
+		return nil, appErr
+	}
+	if _, appErr := s.authorizeRestaurant(ctx, restaurantID, userID, role, types.PermTableManage); appErr != nil {
+		return nil, appErr
+	}
+
+	newUUID, err := utils.GenerateUUIDv7()
+	if err != nil {
+		return nil, errors.InternalError(err)
+	}
+
+	table := &models.RestaurantTable{
+		ID:           newUUID.String(),
+		RestaurantID: restaurantID,
+		Label:        strings.TrimSpace(input.Label),
+		Capacity:     input.Capacity,
+		Section:      strings.TrimSpace(input.Section),
+		IsActive:     true,
+	}
+	if input.IsActive != nil {
+		table.IsActive = *input.IsActive
+	}
+
+	if _, err := s.db.NewInsert().Model(table).
+		Returning("*").
+		Exec(ctx); err != nil {
+		if pgErrorCode(err) == pgUniqueViolation {
+			return nil, errors.DuplicateError("label")
+		}
+		s.logger(ctx).Error("failed to create table", zap.Error(err), zap.String("restaurant_id", restaurantID))
+		return nil, errors.InternalError(err)
+	}
+	return table, nil
+}
+
+// UpdateTable applies a partial update to a table of a restaurant managed by the caller
+func (s *Service) UpdateTable(ctx context.Context, restaurantID, tableID, userID, role string, input dto.UpdateTableInput) (*models.RestaurantTable, *errors.AppError) {
+	if appErr := validateInput(input); appErr != nil {
+		return nil, appErr
+	}
+	table, appErr := s.authorizeTable(ctx, restaurantID, tableID, userID, role)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	columns := []string{"updated_at"}
+	if input.Label != nil {
+		table.Label = strings.TrimSpace(*input.Label)
+		columns = append(columns, "label")
+	}
+	if input.Capacity != nil {
+		table.Capacity = *input.Capacity
+		columns = append(columns, "capacity")
+	}
+	if input.Section != nil {
+		table.Section = strings.TrimSpace(*input.Section)
+		columns = append(columns, "section")
+	}
+	if input.IsActive != nil {
+		table.IsActive = *input.IsActive
+		columns = append(columns, "is_active")
+	}
+	table.UpdatedAt = s.clock.Now()
+
+	if _, err := s.db.NewUpdate().Model(table).
+		Column(columns...).
+		WherePK().
+		Exec(ctx); err != nil {
+		if pgErrorCode(err) == pgUniqueViolation {
+			return nil, errors.DuplicateError("label")
+		}
+		s.logger(ctx).Error("failed to update table", zap.Error(err), zap.String("table_id", tableID))
+		return nil, errors.InternalError(err)
+	}
+	return table, nil
+}
+
+// DeleteTable removes a table that has never been booked. Tables with
+// reservation history must be deactivated instead.
+func (s *Service) DeleteTable(ctx context.Context, restaurantID, tableID, userID, role string) *errors.AppError {
+	table, appErr := s.authorizeTable(ctx, restaurantID, tableID, userID, role)
+	if appErr != nil {
+		return appErr
+	}
+
+	if _, err := s.db.NewDelete().Model(table).
+		WherePK().
+		Exec(ctx); err != nil {
+		if pgErrorCode(err) == pgForeignKeyViolation {
+			return errors.ConflictError("table has reservations; set is_active to false instead")
+		}
+		s.logger(ctx).Error("failed to delete table", zap.Error(err), zap.String("table_id", tableID))
+		return errors.InternalError(err)
+	}
+	return nil
+}
+
+// GetOpeningHours returns the weekly schedule of a restaurant
+func (s *Service) GetOpeningHours(ctx context.Context, restaurantID string) ([]models.OpeningHour, *errors.AppError) {
+	if _, appErr := s.GetRestaurantByID(ctx, restaurantID); appErr != nil {
+		return nil, appErr
+	}
+	return s.loadOpeningHours(ctx, restaurantID)
+}
+
+// SetOpeningHours atomically replaces the weekly schedule of a restaurant
+// managed by the caller. Each window must open before it closes; a restaurant
+// open past midnight declares the remainder on the following weekday.
+func (s *Service) SetOpeningHours(ctx context.Context, restaurantID, userID, role string, input dto.SetOpeningHoursInput) ([]models.OpeningHour, *errors.AppError) {
+	if appErr := validateInput(input); appErr != nil {
+		return nil, appErr
+	}
+	if _, appErr := s.authorizeRestaurant(ctx, restaurantID, userID, role, types.PermRestaurantWrite); appErr != nil {
+		return nil, appErr
+	}
+
+	hours := make([]models.OpeningHour, 0, len(input.Hours))
+	for _, window := range input.Hours {
+		if window.OpensAt >= window.ClosesAt {
+			return nil, errors.ValidationError(fmt.Sprintf("opening window %s-%s must open before it closes", window.OpensAt, window.ClosesAt))
+		}
+		newUUID, err := utils.GenerateUUIDv7()
+		if err != nil {
+			return nil, errors.InternalError(err)
+		}
+		hours = append(hours, models.OpeningHour{
+			ID:           newUUID.String(),
+			RestaurantID: restaurantID,
+			Weekday:      window.Weekday,
+			OpensAt:      window.OpensAt,
+			ClosesAt:     window.ClosesAt,
+		})
+	}
+
+	err := s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.NewDelete().Model((*models.OpeningHour)(nil)).
+			Where("restaurant_id = ?", restaurantID).
+			Exec(ctx); err != nil {
+			return err
+		}
+		if len(hours) == 0 {
+			return nil
+		}
+		_, err := tx.NewInsert().Model(&hours).Exec(ctx)
+		return err
+	})
+	if err != nil {
+		s.logger(ctx).Error("failed to set opening hours", zap.Error(err), zap.String("restaurant_id", restaurantID))
+		return nil, errors.InternalError(err)
+	}
+	return s.loadOpeningHours(ctx, restaurantID)
+}
+
+// GetAvailability lists the start times on a date (YYYY-MM-DD, in the
+// restaurant's timezone) at which a party of the given size can be seated.
+func (s *Service) GetAvailability(ctx context.Context, restaurantID, date string, partySize, durationMinutes int) ([]dto.AvailabilitySlot, *errors.AppError) {
+	if partySize < 1 {
+		return nil, errors.ValidationError("party_size must be at least 1")
+	}
+	if durationMinutes == 0 {
+		durationMinutes = dto.DefaultReservationMinutes
+	}
+	if durationMinutes < 30 || durationMinutes > 360 {
+		return nil, errors.ValidationError("duration_minutes must be between 30 and 360")
+	}
+
+	restaurant, appErr := s.GetRestaurantByID(ctx, restaurantID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	loc, err := time.LoadLocation(restaurant.Timezone)
+	if err != nil {
+		return nil, errors.InternalError(err)
+	}
+	day, err := time.ParseInLocation("2006-01-02", date, loc)
+	if err != nil {
+		return nil, errors.ValidationError("date must be in YYYY-MM-DD format")
+	}
+	duration := time.Duration(durationMinutes) * time.Minute
+
+	hours, appErr := s.loadOpeningHours(ctx, restaurantID)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	tables := make([]models.RestaurantTable, 0)
+	if err := s.db.NewSelect().Model(&tables).
+		Where("restaurant_id = ?", restaurantID).
+		Where("is_active = TRUE").
+		Where("capacity >= ?", partySize).
+		Scan(ctx); err != nil {
+		return nil, errors.InternalError(err)
+	}
+
+	dayEnd := day.AddDate(0, 0, 1)
+	booked := make([]models.Reservation, 0)
+	if err := s.db.NewSelect().Model(&booked).
+		Where("restaurant_id = ?", restaurantID).
+		Where("status = ?", models.ReservationStatusConfirmed).
+		Where("starts_at < ? AND ends_at > ?", dayEnd.Add(duration), day).
+		Scan(ctx); err != nil {
+		return nil, errors.InternalError(err)
+	}
+
+	now := s.clock.Now()
+	slots := make([]dto.AvailabilitySlot, 0)
+	for _, window := range hours {
+		if time.Weekday(window.Weekday) != day.Weekday() {
+			continue
+		}
+		opens, closes := windowBounds(day, window, loc)
+		for start := opens; !start.Add(duration).After(closes); start = start.Add(reservationSlotInterval) {
+			if !start.After(now) {
+				continue
+			}
+			end := start.Add(duration)
+			free := 0
+			for _, table := range tables {
+				if !tableBusy(booked, table.ID, start, end) {
+					free++
+				}
+			}
+			if free > 0 {
+				slots = append(slots, dto.AvailabilitySlot{StartsAt: start, EndsAt: end, AvailableTables: free})
+			}
+		}
+	}
+	return slots, nil
+}
+
+// CreateReservation books the smallest free table that fits the party.
+// Overlapping bookings are ultimately rejected by the reservations_no_overlap
+// exclusion constraint, so two concurrent requests can never both win a table.
+func (s *Service) CreateReservation(ctx context.Context, restaurantID, customerID string, input dto.CreateReservationInput) (*models.Reservation, *errors.AppError) {
+	if appErr := validateInput(input); appErr != nil {
+		return nil, appErr
+	}
+	if input.DurationMinutes == 0 {
+		input.DurationMinutes = dto.DefaultReservationMinutes
+	}
+
+	restaurant, appErr := s.GetRestaurantByID(ctx, restaurantID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	loc, err := time.LoadLocation(restaurant.Timezone)
+	if err != nil {
+		return nil, errors.InternalError(err)
+	}
+
+	startsAt := input.StartsAt.In(loc)
+	endsAt := startsAt.Add(time.Duration(input.DurationMinutes) * time.Minute)
+	if !startsAt.After(s.clock.Now()) {
+		return nil, errors.ValidationError("starts_at must be in the future")
+	}
+
+	hours, appErr := s.loadOpeningHours(ctx, restaurantID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if !withinOpeningHours(hours, startsAt, endsAt, loc) {
+		return nil, errors.ValidationError("the restaurant is not open for the whole requested time")
+	}
+
+	// Over-capacity requests are a validation problem, not a conflict
+	fits, err := models.Count(ctx, s.db.NewSelect().Model((*models.RestaurantTable)(nil)).
+		Where("restaurant_id = ?", restaurantID).
+		Where("is_active = TRUE").
+		Where("capacity >= ?", input.PartySize))
+	if err != nil {
+		return nil, errors.InternalError(err)
+	}
+	if fits == 0 {
+		return nil, errors.ValidationError(fmt.Sprintf("no table can seat a party of %d", input.PartySize))
+	}
+
+	candidates := make([]models.RestaurantTable, 0)
+	if err := s.db.NewSelect().Model(&candidates).
+		Where("restaurant_id = ?", restaurantID).
+		Where("is_active = TRUE").
+		Where("capacity >= ?", input.PartySize).
+		Where(`NOT EXISTS (
+			SELECT 1 FROM reservations AS r
+			WHERE r.table_id = restaurant_table.id
+			  AND r.status = ?
+			  AND tstzrange(r.starts_at, r.ends_at) && tstzrange(?, ?)
+		)`, models.ReservationStatusConfirmed, startsAt, endsAt).
+		OrderExpr("capacity ASC, label ASC").
+		Scan(ctx); err != nil {
+		return nil, errors.InternalError(err)
+	}
+
+	newUUID, err := utils.GenerateUUIDv7()
+	if err != nil {
+		return nil, errors.InternalError(err)
+	}
+	reservation := &models.Reservation{
+		ID:           newUUID.String(),
+		RestaurantID: restaurantID,
+		CustomerID:   customerID,
+		PartySize:    input.PartySize,
+		StartsAt:     startsAt,
+		EndsAt:       endsAt,
+		Status:       models.ReservationStatusConfirmed,
+		Notes:        strings.TrimSpace(input.Notes),
+	}
+
+	booked := false
+	for i := range candidates {
+		reservation.TableID = candidates[i].ID
+		_, err := s.db.NewInsert().Model(reservation).
+			Returning("created_at, updated_at").
+			Exec(ctx)
+		if err == nil {
+			reservation.Table = &candidates[i]
+			booked = true
+			break
+		}
+		// Someone else took this table in the meantime; try the next one
+		if pgErrorCode(err) == pgExclusionViolation {
+			continue
+		}
+		s.logger(ctx).Error("failed to create reservation", zap.Error(err), zap.String("restaurant_id", restaurantID))
+		return nil, errors.InternalError(err)
+	}
+	if !booked {
+		return nil, errors.ConflictError("no table is available for that time")
+	}
+
+	s.logger(ctx).Info("reservation created",
+		zap.String("reservation_id", reservation.ID),
+		zap.String("restaurant_id", restaurantID),
+		zap.String("table_id", reservation.TableID))
+
+	s.sendReservationConfirmation(restaurant, reservation, loc)
+	return reservation, nil
+}
+
+// GetReservation returns a reservation to its customer or to staff with reservation:manage
+func (s *Service) GetReservation(ctx context.Context, id, userID, role string) (*models.Reservation, *errors.AppError) {
+	reservation := &models.Reservation{}
+	err := s.db.NewSelect().Model(reservation).
+		Relation("Table").
+		Where("?TableAlias.id = ?", id).
+		Scan(ctx)
+	if err == sql.ErrNoRows {
+		return nil, errors.NotFoundError("reservation not found")
+	}
+	if err != nil {
+		s.logger(ctx).Error("failed to fetch reservation", zap.Error(err), zap.String("reservation_id", id))
+		return nil, errors.InternalError(err)
+	}
+
+	if reservation.CustomerID == userID {
+		return reservation, nil
+	}
+	allowed, appErr := s.HasPermission(ctx, userID, role, reservation.RestaurantID, types.PermReservationManage)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if !allowed {
+		return nil, errors.NotFoundError("reservation not found")
+	}
+	return reservation, nil
+}
+
+// ListCustomerReservations returns the caller's own reservations, soonest first
+func (s *Service) ListCustomerReservations(ctx context.Context, customerID string, filter dto.ReservationFilter) ([]models.Reservation, *errors.AppError) {
+	reservations := make([]models.Reservation, 0)
+	query := s.db.NewSelect().Model(&reservations).
+		Relation("Table").
+		Where("?TableAlias.customer_id = ?", customerID)
+	query = applyReservationFilter(query, filter)
+	if err := query.Scan(ctx); err != nil {
+		s.logger(ctx).Error("failed to list customer reservations", zap.Error(err), zap.String("customer_id", customerID))
+		return nil, errors.InternalError(err)
+	}
+	return reservations, nil
+}
+
+// ListRestaurantReservations returns the bookings of a restaurant managed by the caller
+func (s *Service) ListRestaurantReservations(ctx context.Context, restaurantID, userID, role string, filter dto.ReservationFilter) ([]models.Reservation, *errors.AppError) {
+	if _, appErr := s.authorizeRestaurant(ctx, restaurantID, userID, role, types.PermReservationManage); appErr != nil {
+		return nil, appErr
+	}
+
+	reservations := make([]models.Reservation, 0)
+	query := s.db.NewSelect().Model(&reservations).
+		Relation("Table").
+		Where("?TableAlias.restaurant_id = ?", restaurantID)
+	query = applyReservationFilter(query, filter)
+	if err := query.Scan(ctx); err != nil {
+		s.logger(ctx).Error("failed to list restaurant reservations", zap.Error(err), zap.String("restaurant_id", restaurantID))
+		return nil, errors.InternalError(err)
+	}
+	return reservations, nil
+}
+
+// UpdateReservationStatus closes a confirmed reservation. Customers may only
+// cancel their own upcoming bookings; management may also mark them completed
+// or as a no-show.
+func (s *Service) UpdateReservationStatus(ctx context.Context, id, actorID, actorRole, status string) (*models.Reservation, *errors.AppError) {
+	switch status {
+	case models.ReservationStatusCancelled, models.ReservationStatusCompleted, models.ReservationStatusNoShow:
+	default:
+		return nil, errors.ValidationError("status must be one of: cancelled completed no_show")
+	}
+
+	reservation, appErr := s.GetReservation(ctx, id, actorID, actorRole)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	// GetReservation let the caller through as either the customer or a manager
+	manages, appErr := s.HasPermission(ctx, actorID, actorRole, reservation.RestaurantID, types.PermReservationManage)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if !manages {
+		if status != models.ReservationStatusCancelled {
+			return nil, errors.ForbiddenError("customers can only cancel reservations")
+		}
+		if !reservation.StartsAt.After(s.clock.Now()) {
+			return nil, errors.ConflictError("reservations can only be cancelled before they start")
+		}
+	}
+
+	reservation.Status = status
+	reservation.UpdatedAt = s.clock.Now()
+	res, err := s.db.NewUpdate().Model(reservation).
+		Column("status", "updated_at").
+		WherePK().
+		Where("status = ?", models.ReservationStatusConfirmed).
+		Exec(ctx)
+	if err != nil {
+		s.logger(ctx).Error("failed to update reservation", zap.Error(err), zap.String("reservation_id", id))
+		return nil, errors.InternalError(err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, errors.ConflictError("only confirmed reservations can be changed")
+	}
+	return reservation, nil
+}
+
+// SendReservationReminders emails every customer whose booking starts within
+// ReservationReminderLeadTime and has not been reminded yet. Rows are claimed
+// with SKIP LOCKED so several API instances never send the same reminder twice;
+// a reminder that could not be sent is released for the next run to retry.
+func (s *Service) SendReservationReminders(ctx context.Context) error {
+	now := s.clock.Now()
+	claim := s.db.NewSelect().Model((*models.Reservation)(nil)).
+		Column("id").
+		Where("status = ?", models.ReservationStatusConfirmed).
+		Where("reminder_sent_at IS NULL").
+		Where("starts_at > ? AND starts_at <= ?", now, now.Add(ReservationReminderLeadTime)).
+		Limit(100).
+		For("UPDATE SKIP LOCKED")
+
+	due := make([]models.Reservation, 0)
+	if err := s.db.NewUpdate().Model((*models.Reservation)(nil)).
+		Set("reminder_sent_at = ?", now).
+		Where("id IN (?)", claim).
+		Returning("*").
+		Scan(ctx, &due); err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	for i := range due {
+		reservation := &due[i]
+		restaurant, appErr := s.GetRestaurantByID(ctx, reservation.RestaurantID)
+		if appErr != nil {
+			s.logger(ctx).Warn("reminder skipped: restaurant unavailable", zap.String("reservation_id", reservation.ID))
+			s.releaseReminder(ctx, reservation.ID, now)
+			continue
+		}
+		customer := &models.User{}
+		if err := s.db.NewSelect().Model(customer).Where("id = ?", reservation.CustomerID).Scan(ctx); err != nil {
+			s.logger(ctx).Warn("reminder skipped: customer unavailable", zap.String("reservation_id", reservation.ID), zap.Error(err))
+			s.releaseReminder(ctx, reservation.ID, now)
+			continue
+		}
+		loc, err := time.LoadLocation(restaurant.Timezone)
+		if err != nil {
+			loc = time.UTC
+		}
+		html := s.ReservationReminderHTML(customer.Name, restaurant.Name, restaurant.Address, formatReservationTime(reservation.StartsAt, loc), reservation.PartySize)
+		if err := s.mailer.Send(customer.Email, "Reminder: your reservation at "+restaurant.Name, html); err != nil {
+			s.logger(ctx).Error("failed to send reservation reminder", zap.Error(err), zap.String("reservation_id", reservation.ID))
+			s.releaseReminder(ctx, reservation.ID, now)
+		}
+	}
+	if len(due) > 0 {
+		s.logger(ctx).Info("reservation reminders processed", zap.Int("count", len(due)))
+	}
+	return nil
+}
+
+// releaseReminder clears the claim of this run on a reminder it did not send,
+// so the next run picks the reservation up again
+func (s *Service) releaseReminder(ctx context.Context, reservationID string, claimedAt time.Time) {
+	if _, err := s.db.NewUpdate().Model((*models.Reservation)(nil)).
+		Set("reminder_sent_at = NULL").
+		Where("id = ? AND reminder_sent_at = ?", reservationID, claimedAt).
+		Exec(ctx); err != nil {
+		s.logger(ctx).Error("failed to release reservation reminder", zap.Error(err), zap.String("reservation_id", reservationID))
+	}
+}
+
+// StartReservationReminder runs SendReservationReminders every few minutes in
+// the background, in the same spirit as the Redis janitor.
+func (s *Service) StartReservationReminder() {
+	s.startJob("reservation reminder", 5*time.Minute, func(ctx context.Context) {
+		if err := s.SendReservationReminders(ctx); err != nil {
+			s.log.Error("reservation reminder run failed", zap.Error(err))
+		}
+	})
+}
+
+func (s *Service) authorizeTable(ctx context.Context, restaurantID, tableID, userID, role string) (*models.RestaurantTable, *errors.AppError) {
+	if _, appErr := s.authorizeRestaurant(ctx, restaurantID, userID, role, types.PermTableManage); appErr != nil {
+		return nil, appErr
+	}
+	table := &models.RestaurantTable{}
+	err := s.db.NewSelect().Model(table).
+		Where("id = ? AND restaurant_id = ?", tableID, restaurantID).
+		Scan(ctx)
+	if err == sql.ErrNoRows {
+		return nil, errors.NotFoundError("table not found")
+	}
+	if err != nil {
+		return nil, errors.InternalError(err)
+	}
+	return table, nil
+}
+
+func (s *Service) loadOpeningHours(ctx context.Context, restaurantID string) ([]models.OpeningHour, *errors.AppError) {
+	hours := make([]models.OpeningHour, 0)
+	if err := s.db.NewSelect().Model(&hours).
+		Where("restaurant_id = ?", restaurantID).
+		OrderExpr("weekday ASC, opens_at ASC").
+		Scan(ctx); err != nil {
+		s.logger(ctx).Error("failed to load opening hours", zap.Error(err), zap.String("restaurant_id", restaurantID))
+		return nil, errors.InternalError(err)
+	}
+	return hours, nil
+}
+
+// windowBounds turns an "HH:MM" opening window into absolute times on day
+func windowBounds(day time.Time, window models.OpeningHour, loc *time.Location) (time.Time, time.Time) {
+	opens, _ := time.Parse("15:04", window.OpensAt)
+	closes, _ := time.Parse("15:04", window.ClosesAt)
+	y, m, d := day.Date()
+	return time.Date(y, m, d, opens.Hour(), opens.Minute(), 0, 0, loc),
+		time.Date(y, m, d, closes.Hour(), closes.Minute(), 0, 0, loc)
+}
+
+// withinOpeningHours reports whether [start, end) fits entirely inside one opening window
+func withinOpeningHours(hours []models.OpeningHour, start, end time.Time, loc *time.Location) bool {
+	for _, window := range hours {
+		if time.Weekday(window.Weekday) != start.Weekday() {
+			continue
+		}
+		opens, closes := windowBounds(start, window, loc)
+		if !start.Before(opens) && !end.After(closes) {
+			return true
+		}
+	}
+	return false
+}
+
+// tableBusy reports whether a table has a booking overlapping [start, end)
+func tableBusy(booked []models.Reservation, tableID string, start, end time.Time) bool {
+	for _, r := range booked {
+		if r.TableID == tableID && r.StartsAt.Before(end) && r.EndsAt.After(start) {
+			return true
+		}
+	}
+	return false
+}
+
+func applyReservationFilter(query *bun.SelectQuery, filter dto.ReservationFilter) *bun.SelectQuery {
+	if filter.Status != "" {
+		query = query.Where("?TableAlias.status = ?", filter.Status)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("?TableAlias.starts_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("?TableAlias.starts_at < ?", filter.To)
+	}
+	return query.
+		OrderExpr("?TableAlias.starts_at ASC").
+		Limit(filter.Limit).
+		Offset(filter.Offset)
+}
+
+// sendReservationConfirmation emails the customer in the background, like the
+// verification email in RegisterUser.
+func (s *Service) sendReservationConfirmation(restaurant *models.Restaurant, reservation *models.Reservation, loc *time.Location) {
+	s.runInBackground(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		customer := &models.User{}
+		if err := s.db.NewSelect().Model(customer).Where("id = ?", reservation.CustomerID).Scan(ctx); err != nil {
+			s.log.Error("failed to load customer for reservation email", zap.Error(err), zap.String("reservation_id", reservation.ID))
+			return
+		}
+		tableLabel := ""
+		if reservation.Table != nil {
+			tableLabel = reservation.Table.Label
+		}
+		html := s.ReservationConfirmedHTML(customer.Name, restaurant.Name, restaurant.Address, formatReservationTime(reservation.StartsAt, loc), reservation.PartySize, tableLabel)
+		if err := s.mailer.Send(customer.Email, "Your reservation at "+restaurant.Name+" is confirmed", html); err != nil {
+			s.log.Error("failed to send reservation confirmation",
+				zap.Error(err),
+				zap.String("reservation_id", reservation.ID),
+			)
+		}
+	})
+}
+
+func formatReservationTime(t time.Time, loc *time.Location) string {
+	return t.In(loc).Format("Monday, 02 January 2006 at 15:04 MST")
+}
+
+// pgErrorCode returns the SQLSTATE of a Postgres error, or "" for anything else
+func pgErrorCode(err error) string {
+	var pgErr *pgconn.PgError
+	if stdErrors.As(err, &pgErr) {
+		return pgErr.Code
+	}
+	return ""
+}
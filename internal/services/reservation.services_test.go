@@ -0,0 +1,102 @@
+package services_test
+
+import (
+	"context"
+	stdErrors "errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/alibaba0010/postgres-api/internal/apptest"
+	"github.com/alibaba0010/postgres-api/internal/models"
+	"github.com/alibaba0010/postgres-api/internal/services"
+)
+
+// reservationSoon inserts a confirmed booking starting within the reminder
+// lead time
+func reservationSoon(t *testing.T, env *apptest.Env) *models.Reservation {
+	t.Helper()
+	ctx := context.Background()
+	restaurant := env.CreateRestaurant(t, env.CreateUser(t, "management"))
+	table := &models.RestaurantTable{
+		ID:           uuid.Must(uuid.NewV7()).String(),
+		RestaurantID: restaurant.ID,
+		Label:        "T1",
+		Capacity:     4,
+		IsActive:     true,
+	}
+	if _, err := env.DB.NewInsert().Model(table).Exec(ctx); err != nil {
+		t.Fatalf("insert table: %v", err)
+	}
+	startsAt := env.Clock.Now().Add(services.ReservationReminderLeadTime / 2)
+	reservation := &models.Reservation{
+		ID:           uuid.Must(uuid.NewV7()).String(),
+		RestaurantID: restaurant.ID,
+		TableID:      table.ID,
+		CustomerID:   env.CreateUser(t, "user").ID,
+		PartySize:    2,
+		StartsAt:     startsAt,
+		EndsAt:       startsAt.Add(90 * time.Minute),
+		Status:       models.ReservationStatusConfirmed,
+	}
+	if _, err := env.DB.NewInsert().Model(reservation).Exec(ctx); err != nil {
+		t.Fatalf("insert reservation: %v", err)
+	}
+	return reservation
+}
+
+func reminded(t *testing.T, env *apptest.Env, reservation *models.Reservation) bool {
+	t.Helper()
+	stored := &models.Reservation{}
+	if err := env.DB.NewSelect().Model(stored).Where("id = ?", reservation.ID).Scan(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return !stored.ReminderSentAt.IsZero()
+}
+
+func TestReminderSentOnce(t *testing.T) {
+	env := apptest.NewWithDB(t)
+	ctx := context.Background()
+	reservation := reservationSoon(t, env)
+
+	if err := env.Services.SendReservationReminders(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !reminded(t, env, reservation) {
+		t.Fatal("reminder not marked as sent")
+	}
+	env.Clock.Advance(5 * time.Minute)
+	if err := env.Services.SendReservationReminders(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if sent := len(env.Mailer.Sent()); sent != 1 {
+		t.Fatalf("%d reminders sent, want 1", sent)
+	}
+}
+
+func TestFailedReminderIsRetried(t *testing.T) {
+	env := apptest.NewWithDB(t)
+	ctx := context.Background()
+	reservation := reservationSoon(t, env)
+
+	env.Mailer.Fail(stdErrors.New("smtp: connection refused"))
+	if err := env.Services.SendReservationReminders(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if reminded(t, env, reservation) {
+		t.Fatal("reminder that failed to send marked as sent")
+	}
+
+	env.Mailer.Fail(nil)
+	env.Clock.Advance(5 * time.Minute)
+	if err := env.Services.SendReservationReminders(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !reminded(t, env, reservation) {
+		t.Fatal("reminder not marked as sent after the retry")
+	}
+	if sent := len(env.Mailer.Sent()); sent != 1 {
+		t.Fatalf("%d reminders sent after the retry, want 1", sent)
+	}
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/alibaba0010/postgres-api/internal/dto"
+	"github.com/alibaba0010/postgres-api/internal/errors"
+	"github.com/alibaba0010/postgres-api/internal/models"
+	"github.com/alibaba0010/postgres-api/internal/types"
+	"github.com/alibaba0010/postgres-api/internal/utils"
+)
+
+// ListRestaurants returns restaurants matching the filter, newest first
+func (s *Service) ListRestaurants(ctx context.Context, filter dto.RestaurantFilter) ([]models.Restaurant, *errors.AppError) {
+	restaurants := make([]models.Restaurant, 0)
+	query := s.db.NewSelect().Model(&restaurants).
+		OrderExpr("created_at DESC").
+		Limit(filter.Limit).
+		Offset(filter.Offset)
+
+	if filter.CuisineType != "" {
+		query = query.Where("cuisine_type ILIKE ?", filter.CuisineType)
+	}
+	if filter.OwnerID != "" {
+		query = query.Where("owner_id = ?", filter.OwnerID)
+	}
+
+	if err := query.Scan(ctx); err != nil {
+		s.logger(ctx).Error("failed to list restaurants", zap.Error(err))
+		return nil, errors.InternalError(err)
+	}
+	return restaurants, nil
+}
+
+// GetRestaurantByID fetches a single restaurant
+func (s *Service) GetRestaurantByID(ctx context.Context, id string) (*models.Restaurant, *errors.AppError) {
+	restaurant := &models.Restaurant{}
+	err := s.db.NewSelect().Model(restaurant).
+		Where("id = ?", id).
+		Scan(ctx)
+	if err == sql.ErrNoRows {
+		return nil, errors.NotFoundError("restaurant not found")
+	}
+	if err != nil {
+		s.logger(ctx).Error("failed to fetch restaurant", zap.Error(err), zap.String("restaurant_id", id))
+		return nil, errors.InternalError(err)
+	}
+	return restaurant, nil
+}
+
+// CreateRestaurant validates the input and stores a restaurant owned by ownerID
+func (s *Service) CreateRestaurant(ctx context.Context, ownerID string, input dto.CreateRestaurantInput) (*models.Restaurant, *errors.AppError) {
+	if appErr := validateInput(input); appErr != nil {
+		return nil, appErr
+	}
+
+	newUUID, err := utils.GenerateUUIDv7()
+	if err != nil {
+		return nil, errors.InternalError(err)
+	}
+
+	restaurant := &models.Restaurant{
+		ID:          newUUID.String(),
+		OwnerID:     ownerID,
+		Name:        strings.TrimSpace(input.Name),
+		Description: strings.TrimSpace(input.Description),
+		Address:     strings.TrimSpace(input.Address),
+		CuisineType: strings.TrimSpace(input.CuisineType),
+		Timezone:    input.Timezone,
+	}
+
+	if _, err := s.db.NewInsert().Model(restaurant).
+		Returning("*").
+		Exec(ctx); err != nil {
+		s.logger(ctx).Error("failed to create restaurant", zap.Error(err))
+		return nil, errors.InternalError(err)
+	}
+
+	s.logger(ctx).Info("restaurant created", zap.String("restaurant_id", restaurant.ID), zap.String("owner_id", ownerID))
+	return restaurant, nil
+}
+
+// UpdateRestaurant applies a partial update. The caller needs restaurant:write.
+func (s *Service) UpdateRestaurant(ctx context.Context, id, userID, role string, input dto.UpdateRestaurantInput) (*models.Restaurant, *errors.AppError) {
+	if appErr := validateInput(input); appErr != nil {
+		return nil, appErr
+	}
+
+	restaurant, appErr := s.authorizeRestaurant(ctx, id, userID, role, types.PermRestaurantWrite)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	columns := []string{"updated_at"}
+	if input.Name != nil {
+		restaurant.Name = strings.TrimSpace(*input.Name)
+		columns = append(columns, "name")
+	}
+	if input.Description != nil {
+		restaurant.Description = strings.TrimSpace(*input.Description)
+		columns = append(columns, "description")
+	}
+	if input.Address != nil {
+		restaurant.Address = strings.TrimSpace(*input.Address)
+		columns = append(columns, "address")
+	}
+	if input.CuisineType != nil {
+		restaurant.CuisineType = strings.TrimSpace(*input.CuisineType)
+		columns = append(columns, "cuisine_type")
+	}
+	if input.Rating != nil {
+		restaurant.Rating = *input.Rating
+		columns = append(columns, "rating")
+	}
+	if input.Timezone != nil {
+		restaurant.Timezone = *input.Timezone
+		columns = append(columns, "timezone")
+	}
+	restaurant.UpdatedAt = s.clock.Now()
+
+	if _, err := s.db.NewUpdate().Model(restaurant).
+		Column(columns...).
+		WherePK().
+		Exec(ctx); err != nil {
+		s.logger(ctx).Error("failed to update restaurant", zap.Error(err), zap.String("restaurant_id", id))
+		return nil, errors.InternalError(err)
+	}
+	return restaurant, nil
+}
+
+// DeleteRestaurant soft-deletes a restaurant. The caller needs restaurant:delete.
+func (s *Service) DeleteRestaurant(ctx context.Context, id, userID, role string) *errors.AppError {
+	restaurant, appErr := s.authorizeRestaurant(ctx, id, userID, role, types.PermRestaurantDelete)
+	if appErr != nil {
+		return appErr
+	}
+
+	if _, err := s.db.NewDelete().Model(restaurant).
+		WherePK().
+		Exec(ctx); err != nil {
+		s.logger(ctx).Error("failed to delete restaurant", zap.Error(err), zap.String("restaurant_id", id))
+		return errors.InternalError(err)
+	}
+
+	s.logger(ctx).Info("restaurant deleted", zap.String("restaurant_id", id), zap.String("user_id", userID))
+	return nil
+}
+
+// authorizeRestaurant loads a restaurant and checks the caller holds permission in it
+func (s *Service) authorizeRestaurant(ctx context.Context, restaurantID, userID, role string, permission types.Permission) (*models.Restaurant, *errors.AppError) {
+	restaurant, appErr := s.GetRestaurantByID(ctx, restaurantID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if appErr := s.authorizeRestaurantPermission(ctx, restaurant, userID, role, permission); appErr != nil {
+		return nil, appErr
+	}
+	return restaurant, nil
+}
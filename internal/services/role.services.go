@@ -0,0 +1,284 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/alibaba0010/postgres-api/internal/dto"
+	"github.com/alibaba0010/postgres-api/internal/errors"
+	"github.com/alibaba0010/postgres-api/internal/models"
+	"github.com/alibaba0010/postgres-api/internal/types"
+	"github.com/alibaba0010/postgres-api/internal/utils"
+)
+
+// ListPermissions returns every known permission and where it can be granted
+func ListPermissions() []dto.PermissionResponse {
+	permissions := make([]dto.PermissionResponse, 0, len(types.AllPermissions()))
+	for _, permission := range types.AllPermissions() {
+		permissions = append(permissions, dto.PermissionResponse{
+			Name:             permission.String(),
+			RestaurantScoped: permission.RestaurantScoped(),
+		})
+	}
+	return permissions
+}
+
+// ListRoles returns every role, built-in roles first
+func (s *Service) ListRoles(ctx context.Context) ([]models.Role, *errors.AppError) {
+	roles := []models.Role{}
+	err := s.db.NewSelect().Model(&roles).
+		OrderExpr("built_in DESC, name ASC").
+		Scan(ctx)
+	if err != nil {
+		s.logger(ctx).Error("failed to list roles", zap.Error(err))
+		return nil, errors.InternalError(err)
+	}
+	return roles, nil
+}
+
+// GetRoleByID returns a role or a not found error
+func (s *Service) GetRoleByID(ctx context.Context, id string) (*models.Role, *errors.AppError) {
+	role := &models.Role{}
+	err := s.db.NewSelect().Model(role).
+		Where("id = ?", id).
+		Scan(ctx)
+	if err == sql.ErrNoRows {
+		return nil, errors.NotFoundError("role not found")
+	}
+	if err != nil {
+		s.logger(ctx).Error("failed to fetch role", zap.Error(err), zap.String("role_id", id))
+		return nil, errors.InternalError(err)
+	}
+	return role, nil
+}
+
+// CreateRole stores a custom role
+func (s *Service) CreateRole(ctx context.Context, input dto.CreateRoleInput) (*models.Role, *errors.AppError) {
+	input.Name = strings.ToLower(strings.TrimSpace(input.Name))
+	if appErr := validateInput(input); appErr != nil {
+		return nil, appErr
+	}
+	permissions, appErr := normalizePermissions(input.Permissions)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	newUUID, err := utils.GenerateUUIDv7()
+	if err != nil {
+		return nil, errors.InternalError(err)
+	}
+	role := &models.Role{
+		ID:          newUUID.String(),
+		Name:        input.Name,
+		Description: strings.TrimSpace(input.Description),
+		Permissions: permissions,
+	}
+	if _, err := s.db.NewInsert().Model(role).Exec(ctx); err != nil {
+		if pgErrorCode(err) == pgUniqueViolation {
+			return nil, errors.DuplicateError("name")
+		}
+		s.logger(ctx).Error("failed to create role", zap.Error(err))
+		return nil, errors.InternalError(err)
+	}
+
+	s.logger(ctx).Info("role created", zap.String("role_id", role.ID), zap.String("name", role.Name))
+	return role, nil
+}
+
+// UpdateRole applies a partial update to a custom role
+func (s *Service) UpdateRole(ctx context.Context, id string, input dto.UpdateRoleInput) (*models.Role, *errors.AppError) {
+	if appErr := validateInput(input); appErr != nil {
+		return nil, appErr
+	}
+	role, appErr := s.GetRoleByID(ctx, id)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if role.BuiltIn {
+		return nil, errors.ForbiddenError("built-in roles cannot be changed")
+	}
+
+	columns := []string{"updated_at"}
+	if input.Name != nil {
+		role.Name = strings.ToLower(strings.TrimSpace(*input.Name))
+		columns = append(columns, "name")
+	}
+	if input.Description != nil {
+		role.Description = strings.TrimSpace(*input.Description)
+		columns = append(columns, "description")
+	}
+	if input.Permissions != nil {
+		permissions, appErr := normalizePermissions(input.Permissions)
+		if appErr != nil {
+			return nil, appErr
+		}
+		role.Permissions = permissions
+		columns = append(columns, "permissions")
+	}
+	role.UpdatedAt = s.clock.Now()
+
+	if _, err := s.db.NewUpdate().Model(role).
+		Column(columns...).
+		WherePK().
+		Exec(ctx); err != nil {
+		if pgErrorCode(err) == pgUniqueViolation {
+			return nil, errors.DuplicateError("name")
+		}
+		s.logger(ctx).Error("failed to update role", zap.Error(err), zap.String("role_id", id))
+		return nil, errors.InternalError(err)
+	}
+	return role, nil
+}
+
+// DeleteRole removes a custom role together with its assignments
+func (s *Service) DeleteRole(ctx context.Context, id string) *errors.AppError {
+	role, appErr := s.GetRoleByID(ctx, id)
+	if appErr != nil {
+		return appErr
+	}
+	if role.BuiltIn {
+		return errors.ForbiddenError("built-in roles cannot be deleted")
+	}
+
+	if _, err := s.db.NewDelete().Model(role).WherePK().Exec(ctx); err != nil {
+		s.logger(ctx).Error("failed to delete role", zap.Error(err), zap.String("role_id", id))
+		return errors.InternalError(err)
+	}
+	s.logger(ctx).Info("role deleted", zap.String("role_id", id), zap.String("name", role.Name))
+	return nil
+}
+
+// ListRestaurantStaff returns the role assignments of a restaurant
+func (s *Service) ListRestaurantStaff(ctx context.Context, restaurantID string) ([]dto.StaffMemberResponse, *errors.AppError) {
+	var assignments []models.RoleAssignment
+	err := s.db.NewSelect().Model(&assignments).
+		Relation("User").
+		Relation("Role").
+		Where("?TableAlias.restaurant_id = ?", restaurantID).
+		OrderExpr("?TableAlias.created_at ASC").
+		Scan(ctx)
+	if err != nil {
+		s.logger(ctx).Error("failed to list staff", zap.Error(err), zap.String("restaurant_id", restaurantID))
+		return nil, errors.InternalError(err)
+	}
+
+	staff := make([]dto.StaffMemberResponse, 0, len(assignments))
+	for _, assignment := range assignments {
+		staff = append(staff, staffMemberResponse(&assignment))
+	}
+	return staff, nil
+}
+
+// AssignStaffRole grants a role to the user with the given email within a
+// restaurant. The grantor can only hand out permissions they hold there
+// themselves (see assignableRole).
+func (s *Service) AssignStaffRole(ctx context.Context, restaurantID, grantedBy, grantorRole string, input dto.AssignStaffInput) (*dto.StaffMemberResponse, *errors.AppError) {
+	input.Email = strings.TrimSpace(input.Email)
+	input.RoleID = strings.TrimSpace(input.RoleID)
+	if appErr := validateInput(input); appErr != nil {
+		return nil, appErr
+	}
+
+	restaurant, appErr := s.GetRestaurantByID(ctx, restaurantID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	role, appErr := s.assignableRole(ctx, restaurant, grantedBy, grantorRole, input.RoleID)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	user := &models.User{}
+	err := s.db.NewSelect().Model(user).
+		Where("email = ?", input.Email).
+		Scan(ctx)
+	if err == sql.ErrNoRows {
+		return nil, errors.NotFoundError("no account uses that email")
+	}
+	if err != nil {
+		return nil, errors.InternalError(err)
+	}
+
+	newUUID, err := utils.GenerateUUIDv7()
+	if err != nil {
+		return nil, errors.InternalError(err)
+	}
+	assignment := &models.RoleAssignment{
+		ID:           newUUID.String(),
+		UserID:       user.ID,
+		RoleID:       role.ID,
+		RestaurantID: restaurantID,
+		GrantedBy:    grantedBy,
+		CreatedAt:    s.clock.Now(),
+	}
+	if _, err := s.db.NewInsert().Model(assignment).Exec(ctx); err != nil {
+		if pgErrorCode(err) == pgUniqueViolation {
+			return nil, errors.ConflictError("the user already has this role here")
+		}
+		s.logger(ctx).Error("failed to assign role", zap.Error(err), zap.String("restaurant_id", restaurantID))
+		return nil, errors.InternalError(err)
+	}
+
+	s.logger(ctx).Info("staff role assigned",
+		zap.String("restaurant_id", restaurantID),
+		zap.String("user_id", user.ID),
+		zap.String("role", role.Name),
+		zap.String("granted_by", grantedBy))
+	assignment.User = user
+	assignment.Role = role
+	response := staffMemberResponse(assignment)
+	return &response, nil
+}
+
+// RemoveStaffRole revokes one role assignment of a restaurant
+func (s *Service) RemoveStaffRole(ctx context.Context, restaurantID, assignmentID string) *errors.AppError {
+	result, err := s.db.NewDelete().Model((*models.RoleAssignment)(nil)).
+		Where("id = ?", assignmentID).
+		Where("restaurant_id = ?", restaurantID).
+		Exec(ctx)
+	if err != nil {
+		s.logger(ctx).Error("failed to remove role assignment", zap.Error(err), zap.String("assignment_id", assignmentID))
+		return errors.InternalError(err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return errors.NotFoundError("staff member not found")
+	}
+	return nil
+}
+
+// normalizePermissions rejects unknown permissions and drops duplicates
+func normalizePermissions(names []string) ([]string, *errors.AppError) {
+	seen := make(map[string]bool, len(names))
+	permissions := make([]string, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if !types.Permission(name).IsValid() {
+			return nil, errors.ValidationError("unknown permission: " + name)
+		}
+		if !seen[name] {
+			seen[name] = true
+			permissions = append(permissions, name)
+		}
+	}
+	return permissions, nil
+}
+
+func staffMemberResponse(assignment *models.RoleAssignment) dto.StaffMemberResponse {
+	response := dto.StaffMemberResponse{
+		ID:        assignment.ID,
+		UserID:    assignment.UserID,
+		RoleID:    assignment.RoleID,
+		CreatedAt: assignment.CreatedAt,
+	}
+	if assignment.User != nil {
+		response.Name = assignment.User.Name
+		response.Email = assignment.User.Email
+	}
+	if assignment.Role != nil {
+		response.RoleName = assignment.Role.Name
+	}
+	return response
+}
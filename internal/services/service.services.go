@@ -0,0 +1,70 @@
+package services
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+	"go.uber.org/zap"
+
+	"github.com/alibaba0010/postgres-api/internal/cache"
+	"github.com/alibaba0010/postgres-api/internal/clock"
+	"github.com/alibaba0010/postgres-api/internal/config"
+	"github.com/alibaba0010/postgres-api/internal/logger"
+	"github.com/alibaba0010/postgres-api/internal/mailer"
+)
+
+// Deps are what the services run on. Every field but RefreshTokens is
+// required; tests pass a bun.Tx they roll back, cache.NewMemory,
+// mailer.NewMemory, a clock.Fake and NewEphemeralAccessTokenSigner.
+type Deps struct {
+	Config config.Config
+	Log    *zap.Logger
+	DB     bun.IDB
+	Cache  cache.Cache
+	Mailer mailer.Mailer
+	Clock  clock.Clock
+	Signer AccessTokenSigner
+	// RefreshTokens defaults to the refresh_tokens table of DB
+	RefreshTokens RefreshTokenStorage
+}
+
+// Service holds the business logic of the API. Its dependencies are set once
+// by New, so separate instances share nothing and tests can run in parallel.
+type Service struct {
+	cfg           config.Config
+	log           *zap.Logger
+	db            bun.IDB
+	cache         cache.Cache
+	mailer        mailer.Mailer
+	clock         clock.Clock
+	signer        AccessTokenSigner
+	refreshTokens RefreshTokenStorage
+	background    *backgroundWork
+	oidcProviders *oidcProviderCache
+}
+
+// New returns the services running on deps
+func New(deps Deps) *Service {
+	refreshTokens := deps.RefreshTokens
+	if refreshTokens == nil {
+		refreshTokens = NewPostgresRefreshTokenStorage(deps.DB)
+	}
+	return &Service{
+		cfg:           deps.Config,
+		log:           deps.Log,
+		db:            deps.DB,
+		cache:         deps.Cache,
+		mailer:        deps.Mailer,
+		clock:         deps.Clock,
+		signer:        deps.Signer,
+		refreshTokens: refreshTokens,
+		background:    newBackgroundWork(),
+		oidcProviders: &oidcProviderCache{byName: make(map[string]*oidcProvider)},
+	}
+}
+
+// logger returns the logger of the request ctx belongs to, so entries carry
+// its ID and caller, or the service logger outside a request
+func (s *Service) logger(ctx context.Context) *zap.Logger {
+	return logger.FromContextOr(ctx, s.log)
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"github.com/alibaba0010/postgres-api/internal/dto"
+	"github.com/alibaba0010/postgres-api/internal/errors"
+	"github.com/alibaba0010/postgres-api/internal/utils"
+)
+
+// ListUserSessions returns the user's signed-in devices. currentSessionID marks
+// the session the request was made from; pass "" when listing for someone else.
+func (s *Service) ListUserSessions(ctx context.Context, userID, currentSessionID string) ([]dto.SessionResponse, *errors.AppError) {
+	sessions, err := s.refreshTokens.ListUserSessions(ctx, userID)
+	if err != nil {
+		s.logger(ctx).Error("failed to list sessions", zap.Error(err), zap.String("user_id", userID))
+		return nil, errors.InternalError(err)
+	}
+
+	response := make([]dto.SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		device := utils.ParseUserAgent(session.Token.UserAgent)
+		response = append(response, dto.SessionResponse{
+			ID:         session.Token.FamilyID,
+			Device:     device.Summary(),
+			Browser:    device.Browser,
+			OS:         device.OS,
+			DeviceType: device.DeviceType,
+			IPAddress:  session.Token.IPAddress,
+			UserAgent:  session.Token.UserAgent,
+			SignedInAt: session.SignedInAt,
+			LastUsedAt: session.Token.CreatedAt,
+			ExpiresAt:  session.Token.ExpiresAt,
+			Current:    currentSessionID != "" && session.Token.FamilyID == currentSessionID,
+		})
+	}
+	return response, nil
+}
+
+// RevokeUserSession signs the user out of one session. The session must belong
+// to userID so that a session ID alone cannot be used to sign out someone else.
+func (s *Service) RevokeUserSession(ctx context.Context, userID, sessionID string) *errors.AppError {
+	sessions, err := s.refreshTokens.ListUserSessions(ctx, userID)
+	if err != nil {
+		s.logger(ctx).Error("failed to list sessions", zap.Error(err), zap.String("user_id", userID))
+		return errors.InternalError(err)
+	}
+
+	for _, session := range sessions {
+		if session.Token.FamilyID != sessionID {
+			continue
+		}
+		if err := s.refreshTokens.DeleteTokenFamily(ctx, sessionID); err != nil {
+			s.logger(ctx).Error("failed to revoke session", zap.Error(err), zap.String("session_id", sessionID))
+			return errors.InternalError(err)
+		}
+		s.revokeSessionAccessTokens(ctx, sessionID)
+		s.logger(ctx).Info("session revoked", zap.String("user_id", userID), zap.String("session_id", sessionID))
+		return nil
+	}
+	return errors.NotFoundError("session not found")
+}
@@ -0,0 +1,279 @@
+package services
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+
+	"github.com/alibaba0010/postgres-api/internal/config"
+	"github.com/alibaba0010/postgres-api/internal/dto"
+)
+
+// Access tokens are signed with one private key and verified with any key of
+// the set, so keys rotate without signing anyone out:
+//
+//  1. add the new key to JWT_VERIFICATION_KEY_FILES, so every instance and
+//     every service reading the JWKS accepts it;
+//  2. make it JWT_SIGNING_KEY_FILE and move the old key to the verification list;
+//  3. drop the old key once the last token it signed has expired
+//     (AccessTokenDuration).
+//
+// Key IDs are RFC 7638 thumbprints, so every instance derives the same kid
+// from the same key.
+
+// minRSAKeyBits is the smallest RSA key accepted for signing or verification
+const minRSAKeyBits = 2048
+
+// accessKey is a key of the access token key set; private is nil for keys
+// that only verify
+type accessKey struct {
+	id      string
+	method  jwt.SigningMethod
+	private crypto.Signer
+	public  crypto.PublicKey
+	jwk     dto.JWK
+}
+
+// accessKeySet is the signing key and every key tokens are verified with:
+// the signing key first, then the others in configuration order
+type accessKeySet struct {
+	signing *accessKey
+	keys    []*accessKey
+	byID    map[string]*accessKey
+}
+
+// AccessTokenSigner signs access tokens and holds the keys they are verified
+// with. The key set read from JWT_SIGNING_KEY_FILE implements it; tests use
+// NewEphemeralAccessTokenSigner.
+type AccessTokenSigner interface {
+	Sign(claims jwt.Claims) (string, error)
+	// VerificationKey is the jwt.Keyfunc picking the key a token names
+	VerificationKey(token *jwt.Token) (any, error)
+	JWKS() *dto.JWKS
+}
+
+// AccessTokenJWKS returns the public keys access tokens are verified with
+func (s *Service) AccessTokenJWKS() *dto.JWKS {
+	return s.signer.JWKS()
+}
+
+// JWKS returns the public keys of the set
+func (s *accessKeySet) JWKS() *dto.JWKS {
+	jwks := &dto.JWKS{Keys: make([]dto.JWK, 0, len(s.keys))}
+	for _, key := range s.keys {
+		jwks.Keys = append(jwks.Keys, key.jwk)
+	}
+	return jwks
+}
+
+// NewEphemeralAccessTokenSigner signs with an Ed25519 key generated on the
+// spot. Tokens it signs do not survive a restart.
+func NewEphemeralAccessTokenSigner() (AccessTokenSigner, error) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	signing, err := newAccessKey(private)
+	if err != nil {
+		return nil, err
+	}
+	return &accessKeySet{
+		signing: signing,
+		keys:    []*accessKey{signing},
+		byID:    map[string]*accessKey{signing.id: signing},
+	}, nil
+}
+
+// LoadAccessTokenSigner reads the access token keys. The server calls it at
+// startup so a missing or broken key file stops it before it serves requests.
+func LoadAccessTokenSigner(cfg config.Config, log *zap.Logger) (AccessTokenSigner, error) {
+	return loadAccessKeySet(cfg, log)
+}
+
+func loadAccessKeySet(cfg config.Config, log *zap.Logger) (*accessKeySet, error) {
+	var signing *accessKey
+	var err error
+	if cfg.JWT_SIGNING_KEY_FILE != "" {
+		signing, err = readAccessKey(cfg.JWT_SIGNING_KEY_FILE)
+		if err != nil {
+			return nil, err
+		}
+		if signing.private == nil {
+			return nil, fmt.Errorf("%s: the signing key must be a private key", cfg.JWT_SIGNING_KEY_FILE)
+		}
+	} else {
+		if !cfg.AllowsInsecureDefaults() {
+			return nil, fmt.Errorf("JWT_SIGNING_KEY_FILE must be set when APP_ENV is %q", cfg.APP_ENV)
+		}
+		// tokens signed with a throwaway key do not survive a restart, which
+		// is fine on a developer's machine
+		_, private, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		signing, err = newAccessKey(private)
+		if err != nil {
+			return nil, err
+		}
+		log.Warn("JWT_SIGNING_KEY_FILE is not set, signing access tokens with a temporary key",
+			zap.String("kid", signing.id))
+	}
+
+	set := &accessKeySet{
+		signing: signing,
+		keys:    []*accessKey{signing},
+		byID:    map[string]*accessKey{signing.id: signing},
+	}
+	for _, path := range cfg.JWT_VERIFICATION_KEY_FILES {
+		key, err := readAccessKey(path)
+		if err != nil {
+			return nil, err
+		}
+		// the same key listed twice, or the signing key listed again
+		if _, ok := set.byID[key.id]; ok {
+			continue
+		}
+		set.keys = append(set.keys, key)
+		set.byID[key.id] = key
+	}
+
+	log.Info("access token keys loaded",
+		zap.String("signing_kid", signing.id),
+		zap.Int("verification_keys", len(set.keys)))
+	return set, nil
+}
+
+// readAccessKey reads a PEM file holding a PKCS#8 or PKCS#1 private key or a
+// PKIX public key
+func readAccessKey(path string) (*accessKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading access token key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM block found", path)
+	}
+
+	var parsed any
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		err = fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	key, err := newAccessKey(parsed)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return key, nil
+}
+
+// newAccessKey describes an RSA or Ed25519 key, private or public
+func newAccessKey(parsed any) (*accessKey, error) {
+	key := &accessKey{}
+	if signer, ok := parsed.(crypto.Signer); ok {
+		key.private = signer
+		parsed = signer.Public()
+	}
+
+	switch public := parsed.(type) {
+	case *rsa.PublicKey:
+		if public.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("RSA keys must have at least %d bits", minRSAKeyBits)
+		}
+		key.method = jwt.SigningMethodRS256
+		key.public = public
+		key.jwk = dto.JWK{
+			Kty: "RSA",
+			N:   base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+		}
+	case ed25519.PublicKey:
+		key.method = jwt.SigningMethodEdDSA
+		key.public = public
+		key.jwk = dto.JWK{
+			Kty: "OKP",
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(public),
+		}
+	default:
+		return nil, fmt.Errorf("unsupported key type %T, use RSA or Ed25519", parsed)
+	}
+
+	id, err := jwkThumbprint(key.jwk)
+	if err != nil {
+		return nil, err
+	}
+	key.id = id
+	key.jwk.Kid = id
+	key.jwk.Use = "sig"
+	key.jwk.Alg = key.method.Alg()
+	return key, nil
+}
+
+// jwkThumbprint is the RFC 7638 thumbprint of a public key: the SHA-256 of
+// its required members in lexicographic order
+func jwkThumbprint(jwk dto.JWK) (string, error) {
+	var members any
+	if jwk.Kty == "RSA" {
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.Kty, jwk.N}
+	} else {
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Crv, jwk.Kty, jwk.X}
+	}
+	canonical, err := json.Marshal(members)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(canonical)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// Sign signs claims with the signing key, naming it in the kid header
+func (s *accessKeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(s.signing.method, claims)
+	token.Header["kid"] = s.signing.id
+	return token.SignedString(s.signing.private)
+}
+
+// VerificationKey is the jwt.Keyfunc picking the key a token names
+func (s *accessKeySet) VerificationKey(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := s.byID[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	// the algorithm must be the key's own, so a token cannot pick how its key is used
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("signing method %s does not match key %q", token.Method.Alg(), kid)
+	}
+	return key.public, nil
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"strconv"
+
+	"go.uber.org/zap"
+
+	"github.com/alibaba0010/postgres-api/internal/errors"
+)
+
+// Access tokens are not stored, so revoking one means remembering it until it
+// would have expired anyway. Three Redis markers cover the ways to sign out:
+//
+//	revoked_token:<jti>          one access token (logout)
+//	revoked_session:<sid>        every access token of a session (session revocation)
+//	tokens_revoked_before:<uid>  every access token the user got before a time
+//	                             (sign out everywhere, password reset, suspension)
+//
+// No access token outlives AccessTokenDuration, so neither do the markers.
+
+// RevokeAccessToken denylists one access token for the rest of its lifetime
+func (s *Service) RevokeAccessToken(ctx context.Context, claims *AccessTokenClaims) *errors.AppError {
+	if s.cache == nil || claims.ID == "" || claims.ExpiresAt == nil {
+		return nil
+	}
+	ttl := claims.ExpiresAt.Time.Sub(s.clock.Now())
+	if ttl <= 0 {
+		return nil
+	}
+	if err := s.cache.Set(ctx, revokedTokenKey(claims.ID), 1, ttl).Err(); err != nil {
+		s.logger(ctx).Error("failed to revoke access token", zap.Error(err), zap.String("user_id", claims.UserID))
+		return errors.InternalError(err)
+	}
+	return nil
+}
+
+// CheckAccessTokenRevoked rejects an access token that was revoked by itself,
+// with its session or with every token of its user
+func (s *Service) CheckAccessTokenRevoked(ctx context.Context, claims *AccessTokenClaims) *errors.AppError {
+	if s.cache == nil {
+		return nil
+	}
+	values, err := s.cache.MGet(ctx,
+		revokedTokenKey(claims.ID),
+		revokedSessionKey(claims.SessionID),
+		revokedBeforeKey(claims.UserID),
+	).Result()
+	if err != nil {
+		// Fail open like the rate limiter: the markers only shorten the
+		// lifetime of tokens that expire within minutes anyway
+		s.logger(ctx).Error("access token revocation check failed", zap.Error(err), zap.String("user_id", claims.UserID))
+		return nil
+	}
+
+	revoked := (claims.ID != "" && values[0] != nil) || (claims.SessionID != "" && values[1] != nil)
+	if cutoff, ok := values[2].(string); ok && claims.IssuedAt != nil {
+		// IssuedAt has second precision; tokens issued in the second of the
+		// cutoff stay valid so that a refresh right after it works
+		if seconds, err := strconv.ParseInt(cutoff, 10, 64); err == nil && claims.IssuedAt.Unix() < seconds {
+			revoked = true
+		}
+	}
+	if revoked {
+		s.logger(ctx).Debug("revoked access token presented",
+			zap.String("user_id", claims.UserID),
+			zap.String("session_id", claims.SessionID))
+		return errors.UnauthorizedError("access token revoked; please login again")
+	}
+	return nil
+}
+
+// revokeSessionAccessTokens rejects the access tokens already issued to a
+// session whose refresh tokens are being deleted
+func (s *Service) revokeSessionAccessTokens(ctx context.Context, sessionID string) {
+	if s.cache == nil || sessionID == "" {
+		return
+	}
+	if err := s.cache.Set(ctx, revokedSessionKey(sessionID), 1, AccessTokenDuration).Err(); err != nil {
+		s.logger(ctx).Error("failed to revoke session access tokens", zap.Error(err), zap.String("session_id", sessionID))
+	}
+}
+
+// revokeUserAccessTokens rejects every access token issued to the user until now
+func (s *Service) revokeUserAccessTokens(ctx context.Context, userID string) {
+	if s.cache == nil {
+		return
+	}
+	cutoff := strconv.FormatInt(s.clock.Now().Unix(), 10)
+	if err := s.cache.Set(ctx, revokedBeforeKey(userID), cutoff, AccessTokenDuration).Err(); err != nil {
+		s.logger(ctx).Error("failed to revoke user access tokens", zap.Error(err), zap.String("user_id", userID))
+	}
+}
+
+func revokedTokenKey(tokenID string) string {
+	return "revoked_token:" + tokenID
+}
+
+func revokedSessionKey(sessionID string) string {
+	return "revoked_session:" + sessionID
+}
+
+func revokedBeforeKey(userID string) string {
+	return "tokens_revoked_before:" + userID
+}
@@ -0,0 +1,358 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	redisPkg "github.com/redis/go-redis/v9"
+	"github.com/uptrace/bun"
+	"go.uber.org/zap"
+
+	"github.com/alibaba0010/postgres-api/internal/dto"
+	"github.com/alibaba0010/postgres-api/internal/errors"
+	"github.com/alibaba0010/postgres-api/internal/models"
+)
+
+// User statuses as reported to admins and filtered on by GET /users
+const (
+	UserStatusActive    = "active"
+	UserStatusSuspended = "suspended"
+)
+
+// blocked user markers, kept for as long as access tokens issued before the
+// block can still be valid. userActive records a database check that found
+// the user active, for userActiveTTL.
+const (
+	userBlockedSuspended = "suspended"
+	userBlockedDeleted   = "deleted"
+	userActive           = "active"
+
+	userActiveTTL = 30 * time.Second
+)
+
+// userSortColumns maps the sort query parameter of GET /users to columns
+var userSortColumns = map[string]string{
+	"created_at": "created_at",
+	"name":       "name",
+	"email":      "email",
+	"role":       "role",
+}
+
+// ListUsers returns a page of users matching the filter together with the
+// number of matching users
+func (s *Service) ListUsers(ctx context.Context, filter dto.UserFilter) (*dto.UserListResponse, *errors.AppError) {
+	column, ok := userSortColumns[filter.Sort]
+	if filter.Sort == "" {
+		column, ok = "created_at", true
+	}
+	if !ok {
+		return nil, errors.ValidationError("sort must be one of: created_at, name, email, role")
+	}
+	order := strings.ToUpper(filter.Order)
+	if order == "" {
+		order = "DESC"
+	}
+	if order != "ASC" && order != "DESC" {
+		return nil, errors.ValidationError("order must be one of: asc, desc")
+	}
+	if filter.Role != "" {
+		if _, appErr := s.ValidateUserRole(filter.Role); appErr != nil {
+			return nil, appErr
+		}
+	}
+	if filter.Status != "" && filter.Status != UserStatusActive && filter.Status != UserStatusSuspended {
+		return nil, errors.ValidationError("status must be one of: active, suspended")
+	}
+
+	users := make([]models.User, 0)
+	query := s.db.NewSelect().Model(&users).
+		OrderExpr("? "+order+", id "+order, bun.Ident(column)).
+		Limit(filter.Limit).
+		Offset(filter.Offset)
+
+	if filter.Role != "" {
+		query = query.Where("role = ?", filter.Role)
+	}
+	if filter.Email != "" {
+		query = query.Where("email ILIKE ?", "%"+escapeLike(filter.Email)+"%")
+	}
+	switch filter.Status {
+	case UserStatusActive:
+		query = query.Where("suspended_at IS NULL")
+	case UserStatusSuspended:
+		query = query.Where("suspended_at IS NOT NULL")
+	}
+	if !filter.CreatedFrom.IsZero() {
+		query = query.Where("created_at >= ?", filter.CreatedFrom)
+	}
+	if !filter.CreatedTo.IsZero() {
+		query = query.Where("created_at < ?", filter.CreatedTo)
+	}
+
+	total, err := query.ScanAndCount(ctx)
+	if err != nil {
+		s.logger(ctx).Error("failed to list users", zap.Error(err))
+		return nil, errors.InternalError(err)
+	}
+
+	response := &dto.UserListResponse{
+		Title:      "Success",
+		Data:       make([]dto.AdminUserResponse, 0, len(users)),
+		Pagination: dto.Pagination{Total: total, Limit: filter.Limit, Offset: filter.Offset},
+	}
+	for i := range users {
+		response.Data = append(response.Data, adminUserResponse(&users[i]))
+	}
+	return response, nil
+}
+
+// GetUserForAdmin returns one user as seen by admins
+func (s *Service) GetUserForAdmin(ctx context.Context, userID string) (*dto.AdminUserResponse, *errors.AppError) {
+	user, appErr := s.getUserByID(ctx, userID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	response := adminUserResponse(user)
+	return &response, nil
+}
+
+// ChangeUserRole sets the account role of a user. Their sessions are revoked
+// so the new role applies from their next sign-in.
+func (s *Service) ChangeUserRole(ctx context.Context, adminID, userID string, input dto.UpdateUserRoleInput) (*dto.AdminUserResponse, *errors.AppError) {
+	input.Role = strings.TrimSpace(input.Role)
+	if appErr := validateInput(input); appErr != nil {
+		return nil, appErr
+	}
+	if adminID == userID {
+		return nil, errors.ForbiddenError("you cannot change your own role")
+	}
+
+	user, appErr := s.getUserByID(ctx, userID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if user.Role == input.Role {
+		response := adminUserResponse(user)
+		return &response, nil
+	}
+
+	previous := user.Role
+	user.Role = input.Role
+	user.UpdatedAt = s.clock.Now()
+	if _, err := s.db.NewUpdate().Model(user).
+		Column("role", "updated_at").
+		WherePK().
+		Exec(ctx); err != nil {
+		s.logger(ctx).Error("failed to change user role", zap.Error(err), zap.String("user_id", userID))
+		return nil, errors.InternalError(err)
+	}
+	if appErr := s.RevokeAllRefreshTokens(ctx, userID); appErr != nil {
+		return nil, appErr
+	}
+
+	s.logger(ctx).Info("user role changed",
+		zap.String("user_id", userID),
+		zap.String("from", previous),
+		zap.String("to", input.Role),
+		zap.String("admin_id", adminID))
+	response := adminUserResponse(user)
+	return &response, nil
+}
+
+// SuspendUser blocks a user from signing in, signs them out everywhere and
+// revokes their API keys. Access tokens they still hold are rejected by
+// CheckUserActive.
+func (s *Service) SuspendUser(ctx context.Context, adminID, userID string, input dto.SuspendUserInput) (*dto.AdminUserResponse, *errors.AppError) {
+	input.Reason = strings.TrimSpace(input.Reason)
+	if appErr := validateInput(input); appErr != nil {
+		return nil, appErr
+	}
+	if adminID == userID {
+		return nil, errors.ForbiddenError("you cannot suspend your own account")
+	}
+
+	user, appErr := s.getUserByID(ctx, userID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if user.IsSuspended() {
+		return nil, errors.ConflictError("the user is already suspended")
+	}
+
+	user.SuspendedAt = s.clock.Now()
+	user.SuspendedReason = input.Reason
+	user.UpdatedAt = user.SuspendedAt
+	if _, err := s.db.NewUpdate().Model(user).
+		Column("suspended_at", "suspended_reason", "updated_at").
+		WherePK().
+		Exec(ctx); err != nil {
+		s.logger(ctx).Error("failed to suspend user", zap.Error(err), zap.String("user_id", userID))
+		return nil, errors.InternalError(err)
+	}
+	if appErr := s.blockUser(ctx, userID, userBlockedSuspended); appErr != nil {
+		return nil, appErr
+	}
+
+	s.logger(ctx).Info("user suspended", zap.String("user_id", userID), zap.String("admin_id", adminID))
+	response := adminUserResponse(user)
+	return &response, nil
+}
+
+// ReactivateUser lifts a suspension
+func (s *Service) ReactivateUser(ctx context.Context, adminID, userID string) (*dto.AdminUserResponse, *errors.AppError) {
+	user, appErr := s.getUserByID(ctx, userID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if !user.IsSuspended() {
+		return nil, errors.ConflictError("the user is not suspended")
+	}
+
+	user.SuspendedAt = time.Time{}
+	user.SuspendedReason = ""
+	user.UpdatedAt = s.clock.Now()
+	if _, err := s.db.NewUpdate().Model(user).
+		Column("suspended_at", "suspended_reason", "updated_at").
+		WherePK().
+		Exec(ctx); err != nil {
+		s.logger(ctx).Error("failed to reactivate user", zap.Error(err), zap.String("user_id", userID))
+		return nil, errors.InternalError(err)
+	}
+	if s.cache != nil {
+		if err := s.cache.Del(ctx, userBlockedKey(userID)).Err(); err != nil {
+			s.logger(ctx).Warn("failed to clear blocked user marker", zap.Error(err), zap.String("user_id", userID))
+		}
+	}
+
+	s.logger(ctx).Info("user reactivated", zap.String("user_id", userID), zap.String("admin_id", adminID))
+	response := adminUserResponse(user)
+	return &response, nil
+}
+
+// DeleteUser soft-deletes a user and signs them out everywhere. The row is
+// kept for the records that reference it; the email can sign up again.
+func (s *Service) DeleteUser(ctx context.Context, adminID, userID string) *errors.AppError {
+	if adminID == userID {
+		return errors.ForbiddenError("you cannot delete your own account here")
+	}
+
+	user, appErr := s.getUserByID(ctx, userID)
+	if appErr != nil {
+		return appErr
+	}
+	if _, err := s.db.NewDelete().Model(user).WherePK().Exec(ctx); err != nil {
+		s.logger(ctx).Error("failed to delete user", zap.Error(err), zap.String("user_id", userID))
+		return errors.InternalError(err)
+	}
+	if appErr := s.blockUser(ctx, userID, userBlockedDeleted); appErr != nil {
+		return appErr
+	}
+
+	s.logger(ctx).Info("user deleted", zap.String("user_id", userID), zap.String("admin_id", adminID))
+	return nil
+}
+
+// CheckUserActive rejects users that were suspended or deleted after their
+// access token was issued. It looks for the marker left by SuspendUser and
+// DeleteUser and, when there is none, asks the database and remembers an
+// active user for userActiveTTL. A marker that was never written or was
+// evicted therefore delays a block by userActiveTTL at most.
+func (s *Service) CheckUserActive(ctx context.Context, userID string) *errors.AppError {
+	if s.cache != nil {
+		state, err := s.cache.Get(ctx, userBlockedKey(userID)).Result()
+		switch {
+		case err == nil && state == userActive:
+			return nil
+		case err == nil:
+			return blockedUserError(state)
+		case err != redisPkg.Nil:
+			s.logger(ctx).Warn("blocked user lookup failed, falling back to the database", zap.Error(err))
+		}
+	}
+
+	user := &models.User{}
+	err := s.db.NewSelect().Model(user).
+		Column("id", "suspended_at", "deleted_at").
+		WhereAllWithDeleted().
+		Where("id = ?", userID).
+		Scan(ctx)
+	if err == sql.ErrNoRows {
+		return blockedUserError(userBlockedDeleted)
+	}
+	if err != nil {
+		return errors.InternalError(err)
+	}
+	if !user.DeletedAt.IsZero() {
+		return blockedUserError(userBlockedDeleted)
+	}
+	if user.IsSuspended() {
+		return blockedUserError(userBlockedSuspended)
+	}
+	if s.cache != nil {
+		// SetNX, so a block stored meanwhile is not overwritten
+		if err := s.cache.SetNX(ctx, userBlockedKey(userID), userActive, userActiveTTL).Err(); err != nil {
+			s.logger(ctx).Warn("failed to remember active user", zap.Error(err), zap.String("user_id", userID))
+		}
+	}
+	return nil
+}
+
+// blockUser revokes the user's refresh tokens and API keys and marks them
+// blocked until every access token they may hold has expired
+func (s *Service) blockUser(ctx context.Context, userID, state string) *errors.AppError {
+	if appErr := s.RevokeAllRefreshTokens(ctx, userID); appErr != nil {
+		return appErr
+	}
+	if appErr := s.revokeUserAPIKeys(ctx, userID); appErr != nil {
+		return appErr
+	}
+	if s.cache == nil {
+		return nil
+	}
+	if err := s.cache.Set(ctx, userBlockedKey(userID), state, AccessTokenDuration).Err(); err != nil {
+		// CheckUserActive asks the database once the active entry, if any,
+		// has lapsed
+		s.logger(ctx).Warn("failed to store blocked user marker", zap.Error(err), zap.String("user_id", userID))
+	}
+	return nil
+}
+
+func blockedUserError(state string) *errors.AppError {
+	if state == userBlockedDeleted {
+		return errors.UnauthorizedError("this account no longer exists")
+	}
+	return errors.ForbiddenError("this account is suspended")
+}
+
+func userBlockedKey(userID string) string {
+	return "user_blocked:" + userID
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
+
+func adminUserResponse(user *models.User) dto.AdminUserResponse {
+	response := dto.AdminUserResponse{
+		ID:         user.ID,
+		Name:       user.Name,
+		Email:      user.Email,
+		Address:    user.Address,
+		Phone:      user.Phone,
+		Role:       user.Role,
+		Status:     UserStatusActive,
+		MFAEnabled: user.MFAEnabled,
+		CreatedAt:  user.CreatedAt,
+		UpdatedAt:  user.UpdatedAt,
+	}
+	if user.IsSuspended() {
+		suspendedAt := user.SuspendedAt
+		response.Status = UserStatusSuspended
+		response.SuspendedAt = &suspendedAt
+		response.SuspendedReason = user.SuspendedReason
+	}
+	return response
+}
@@ -0,0 +1,61 @@
+package services_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/alibaba0010/postgres-api/internal/apptest"
+	"github.com/alibaba0010/postgres-api/internal/dto"
+	"github.com/alibaba0010/postgres-api/internal/models"
+)
+
+func TestSuspensionWithoutMarkerBlocksUser(t *testing.T) {
+	env := apptest.NewWithDB(t)
+	ctx := context.Background()
+	user := env.CreateUser(t, "user")
+
+	// suspended while Redis could not take the marker
+	if _, err := env.DB.NewUpdate().Model((*models.User)(nil)).
+		Set("suspended_at = ?", env.Clock.Now()).
+		Where("id = ?", user.ID).
+		Exec(ctx); err != nil {
+		t.Fatal(err)
+	}
+	appErr := env.Services.CheckUserActive(ctx, user.ID)
+	if appErr == nil || appErr.Status != http.StatusForbidden {
+		t.Fatalf("CheckUserActive = %v, want 403 for a suspended user without a marker", appErr)
+	}
+}
+
+func TestSuspensionOverridesRememberedActiveUser(t *testing.T) {
+	env := apptest.NewWithDB(t)
+	ctx := context.Background()
+	user := env.CreateUser(t, "user")
+	admin := env.CreateUser(t, "admin")
+
+	if appErr := env.Services.CheckUserActive(ctx, user.ID); appErr != nil {
+		t.Fatalf("active user rejected: %v", appErr)
+	}
+	if _, appErr := env.Services.SuspendUser(ctx, admin.ID, user.ID, dto.SuspendUserInput{Reason: "chargebacks"}); appErr != nil {
+		t.Fatalf("suspend: %v", appErr)
+	}
+	if appErr := env.Services.CheckUserActive(ctx, user.ID); appErr == nil {
+		t.Fatal("suspended user still remembered as active")
+	}
+
+	// the marker is evicted; the database still knows
+	if err := env.Cache.Del(ctx, "user_blocked:"+user.ID).Err(); err != nil {
+		t.Fatal(err)
+	}
+	if appErr := env.Services.CheckUserActive(ctx, user.ID); appErr == nil {
+		t.Fatal("suspended user accepted once the marker was gone")
+	}
+
+	if _, appErr := env.Services.ReactivateUser(ctx, admin.ID, user.ID); appErr != nil {
+		t.Fatalf("reactivate: %v", appErr)
+	}
+	if appErr := env.Services.CheckUserActive(ctx, user.ID); appErr != nil {
+		t.Fatalf("reactivated user rejected: %v", appErr)
+	}
+}
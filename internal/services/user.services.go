@@ -3,9 +3,7 @@ package services
 import (
 	"context"
 
-	"github.com/alibaba0010/postgres-api/internal/database"
 	"github.com/alibaba0010/postgres-api/internal/errors"
-	"github.com/alibaba0010/postgres-api/internal/logger"
 	"github.com/alibaba0010/postgres-api/internal/models"
 	"github.com/alibaba0010/postgres-api/internal/types"
 	"github.com/alibaba0010/postgres-api/internal/dto"
@@ -15,36 +13,47 @@ import (
 
 
 // GetCurrentUserByID retrieves a user from the database by ID and returns formatted response
-func GetCurrentUserByID(ctx context.Context, userID string) (*dto.CurrentUserResponse, *errors.AppError) {
+func (s *Service) GetCurrentUserByID(ctx context.Context, userID string) (*dto.CurrentUserResponse, *errors.AppError) {
 	user := &models.User{}
-	err := database.DB.NewSelect().Model(user).
+	err := s.db.NewSelect().Model(user).
 		Where("id = ?", userID).
 		Scan(ctx)
 
 	if err != nil {
-		logger.Log.Error("failed to fetch user from database", zap.Error(err), zap.String("user_id", userID))
+		s.logger(ctx).Error("failed to fetch user from database", zap.Error(err), zap.String("user_id", userID))
 		return nil, errors.InternalError(err)
 	}
 
-	response := &dto.CurrentUserResponse{
-		ID:        user.ID,
-		Name:      user.Name,
-		Email:     user.Email,
-		Address:   user.Address,
-		Role:      user.Role,
-		CreatedAt: user.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt: user.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-	}
+	response := currentUserResponse(user)
 
-	logger.Log.Debug("user retrieved from database", zap.String("user_id", userID), zap.String("role", user.Role))
+	s.logger(ctx).Debug("user retrieved from database", zap.String("user_id", userID), zap.String("role", user.Role))
 	return response, nil
 }
 
+// currentUserResponse formats a user for the current user endpoints
+func currentUserResponse(user *models.User) *dto.CurrentUserResponse {
+	response := &dto.CurrentUserResponse{
+		ID:         user.ID,
+		Name:       user.Name,
+		Email:      user.Email,
+		Address:    user.Address,
+		Phone:      user.Phone,
+		Role:       user.Role,
+		MFAEnabled: user.MFAEnabled,
+		CreatedAt:  user.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:  user.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if !user.DeletionScheduledAt.IsZero() {
+		response.DeletionScheduledAt = user.DeletionScheduledAt.Format("2006-01-02T15:04:05Z07:00")
+	}
+	return response
+}
+
 // ValidateUserRole checks if a user role is valid
-func ValidateUserRole(roleStr string) (types.UserRole, *errors.AppError) {
+func (s *Service) ValidateUserRole(roleStr string) (types.UserRole, *errors.AppError) {
 	role, isValid := types.ToUserRole(roleStr)
 	if !isValid {
-		logger.Log.Warn("invalid user role", zap.String("role", roleStr))
+		s.log.Warn("invalid user role", zap.String("role", roleStr))
 		return "", errors.ValidationError("invalid user role: " + roleStr)
 	}
 	return role, nil
@@ -52,17 +61,17 @@ func ValidateUserRole(roleStr string) (types.UserRole, *errors.AppError) {
 
 // CheckRolePermission verifies if a user role has permission to access a required role
 // Returns true if the user's role meets or exceeds the required role
-func CheckRolePermission(userRole string, requiredRoles ...string) bool {
+func (s *Service) CheckRolePermission(userRole string, requiredRoles ...string) bool {
 	userRoleEnum, isValid := types.ToUserRole(userRole)
 	if !isValid {
-		logger.Log.Warn("invalid user role in permission check", zap.String("role", userRole))
+		s.log.Warn("invalid user role in permission check", zap.String("role", userRole))
 		return false
 	}
 
 	for _, requiredRole := range requiredRoles {
 		requiredRoleEnum, isValid := types.ToUserRole(requiredRole)
 		if !isValid {
-			logger.Log.Warn("invalid required role", zap.String("role", requiredRole))
+			s.log.Warn("invalid required role", zap.String("role", requiredRole))
 			continue
 		}
 
@@ -75,14 +84,14 @@ func CheckRolePermission(userRole string, requiredRoles ...string) bool {
 }
 
 // GetUserByEmail retrieves a user by email address
-func GetUserByEmail(ctx context.Context, email string) (*models.User, *errors.AppError) {
+func (s *Service) GetUserByEmail(ctx context.Context, email string) (*models.User, *errors.AppError) {
 	user := &models.User{}
-	err := database.DB.NewSelect().Model(user).
+	err := s.db.NewSelect().Model(user).
 		Where("email = ?", email).
 		Scan(ctx)
 
 	if err != nil {
-		logger.Log.Debug("user not found by email", zap.String("email", email))
+		s.logger(ctx).Debug("user not found by email", zap.String("email", email))
 		return nil, errors.InternalError(err)
 	}
 
@@ -90,11 +99,11 @@ func GetUserByEmail(ctx context.Context, email string) (*models.User, *errors.Ap
 }
 
 // LogResponse logs a response with status code and message
-func LogResponse(status int, title, message string) {
+func (s *Service) LogResponse(status int, title, message string) {
 	if status >= 500 {
-		logger.Log.Error(title, zap.Int("status", status), zap.String("message", message))
+		s.log.Error(title, zap.Int("status", status), zap.String("message", message))
 	} else {
-		logger.Log.Info(title, zap.Int("status", status), zap.String("message", message))
+		s.log.Info(title, zap.Int("status", status), zap.String("message", message))
 	}
 }
 
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/alibaba0010/postgres-api/internal/dto"
+	"github.com/alibaba0010/postgres-api/internal/errors"
+)
+
+// validateInput runs struct validation on a DTO and converts validator errors
+// into the same friendly messages RegisterUser returns.
+func validateInput(input any) *errors.AppError {
+	validate := validator.New()
+	dto.RegisterValidators(validate)
+
+	err := validate.Struct(input)
+	if err == nil {
+		return nil
+	}
+	ves, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return errors.ValidationError(err.Error())
+	}
+
+	var messages []string
+	for _, fe := range ves {
+		field := fe.Field()
+		var msg string
+		switch fe.Tag() {
+		case "required":
+			msg = fmt.Sprintf("%s is required", field)
+		case "min":
+			msg = fmt.Sprintf("%s must be at least %s", field, fe.Param())
+		case "max":
+			msg = fmt.Sprintf("%s must be at most %s", field, fe.Param())
+		case "email":
+			msg = fmt.Sprintf("%s must be a valid email address", field)
+		case "oneof":
+			msg = fmt.Sprintf("%s must be one of: %s", field, fe.Param())
+		case "password_special":
+			msg = "password must contain at least one uppercase letter, one lowercase letter, one digit, and one special character"
+		case "time_of_day":
+			msg = fmt.Sprintf("%s must be a time in HH:MM format", field)
+		case "timezone":
+			msg = fmt.Sprintf("%s must be a valid IANA timezone such as Africa/Lagos", field)
+		case "len":
+			msg = fmt.Sprintf("%s must be exactly %s characters", field, fe.Param())
+		case "e164":
+			msg = fmt.Sprintf("%s must be an international phone number such as +2348012345678", field)
+		case "numeric":
+			msg = fmt.Sprintf("%s must contain only digits", field)
+		case "uuid":
+			msg = fmt.Sprintf("%s must be a valid ID", field)
+		case "eqfield":
+			msg = fmt.Sprintf("%s must match %s", field, fe.Param())
+		default:
+			msg = fmt.Sprintf("%s is invalid", field)
+		}
+		messages = append(messages, msg)
+	}
+	return errors.ValidationErrors(messages)
+}
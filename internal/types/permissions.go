@@ -0,0 +1,67 @@
+package types
+
+// Permission names a single action a role can grant
+type Permission string
+
+const (
+	// Platform permissions only take effect through a user's account role
+	PermRestaurantCreate Permission = "restaurant:create"
+	PermUserManage       Permission = "user:manage"
+	PermRoleManage       Permission = "role:manage"
+
+	// Restaurant permissions can also be granted for a single restaurant
+	PermRestaurantWrite   Permission = "restaurant:write"
+	PermRestaurantDelete  Permission = "restaurant:delete"
+	PermMenuWrite         Permission = "menu:write"
+	PermOrderRead         Permission = "order:read"
+	PermOrderAdvance      Permission = "order:advance"
+	PermReservationManage Permission = "reservation:manage"
+	PermTableManage       Permission = "table:manage"
+	PermStaffManage       Permission = "staff:manage"
+	PermReportRead        Permission = "report:read"
+)
+
+// String returns the string representation of the permission
+func (p Permission) String() string {
+	return string(p)
+}
+
+// IsValid checks if the permission is a known permission
+func (p Permission) IsValid() bool {
+	for _, known := range AllPermissions() {
+		if p == known {
+			return true
+		}
+	}
+	return false
+}
+
+// RestaurantScoped reports whether the permission applies within a single
+// restaurant. Only these permissions are granted by per-restaurant role
+// assignments; platform permissions in such a role are ignored.
+func (p Permission) RestaurantScoped() bool {
+	switch p {
+	case PermRestaurantCreate, PermUserManage, PermRoleManage:
+		return false
+	default:
+		return p.IsValid()
+	}
+}
+
+// AllPermissions returns every known permission
+func AllPermissions() []Permission {
+	return []Permission{
+		PermRestaurantCreate,
+		PermUserManage,
+		PermRoleManage,
+		PermRestaurantWrite,
+		PermRestaurantDelete,
+		PermMenuWrite,
+		PermOrderRead,
+		PermOrderAdvance,
+		PermReservationManage,
+		PermTableManage,
+		PermStaffManage,
+		PermReportRead,
+	}
+}
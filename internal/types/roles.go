@@ -29,6 +29,9 @@ func (r UserRole) IsValid() bool {
 	}
 }
 
+// HasPermission compares account roles on the user < management < admin
+// ladder. Routes should prefer guards.RequirePermission, which resolves the
+// named permissions of the user's roles.
 func (r UserRole) HasPermission(requiredRole UserRole) bool {
 	switch r {
 	case RoleAdmin:
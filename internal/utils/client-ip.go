@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// TrustedProxies are the networks whose X-Forwarded-For and X-Real-Ip
+// headers are believed. Any other peer could put anything in them.
+type TrustedProxies []*net.IPNet
+
+// ParseTrustedProxies reads IP addresses and CIDR ranges such as "10.0.0.0/8"
+func ParseTrustedProxies(entries []string) (TrustedProxies, error) {
+	var proxies TrustedProxies
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("%q is not an IP address or CIDR range", entry)
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an IP address or CIDR range", entry)
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies, nil
+}
+
+func (p TrustedProxies) trusts(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, network := range p {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the address of the client behind the request. The
+// forwarded headers only count when the peer is a trusted proxy; then the
+// client is the last address in X-Forwarded-For that is not a trusted proxy
+// itself.
+func (p TrustedProxies) ClientIP(request *http.Request) string {
+	peer := remoteIP(request)
+	if !p.trusts(peer) {
+		return peer
+	}
+	if forwarded := request.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+		hops := strings.Split(strings.Join(forwarded, ","), ",")
+		client := peer
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if net.ParseIP(hop) == nil {
+				break
+			}
+			client = hop
+			if !p.trusts(hop) {
+				break
+			}
+		}
+		return client
+	}
+	if realIP := strings.TrimSpace(request.Header.Get("X-Real-Ip")); net.ParseIP(realIP) != nil {
+		return realIP
+	}
+	return peer
+}
+
+// Middleware resolves the client IP of every request once, for
+// ExtractClientIP to read
+func (p TrustedProxies) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		ctx := context.WithValue(request.Context(), clientIPKey{}, p.ClientIP(request))
+		next.ServeHTTP(writer, request.WithContext(ctx))
+	})
+}
+
+type clientIPKey struct{}
+
+// ExtractClientIP returns the client IP resolved by TrustedProxies.Middleware,
+// or the peer address of a request that did not pass through it. Forwarded
+// headers are never read here.
+func ExtractClientIP(request *http.Request) string {
+	if ip, ok := request.Context().Value(clientIPKey{}).(string); ok {
+		return ip
+	}
+	return remoteIP(request)
+}
+
+func remoteIP(request *http.Request) string {
+	host, _, err := net.SplitHostPort(request.RemoteAddr)
+	if err != nil {
+		return request.RemoteAddr
+	}
+	return host
+}
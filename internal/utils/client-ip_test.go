@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	proxies, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.0.2.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		realIP     string
+		want       string
+	}{
+		{"untrusted peer forging X-Forwarded-For", "203.0.113.7:4000", "198.51.100.1", "", "203.0.113.7"},
+		{"untrusted peer forging X-Real-Ip", "203.0.113.7:4000", "", "198.51.100.1", "203.0.113.7"},
+		{"trusted proxy", "10.0.0.5:80", "198.51.100.1", "", "198.51.100.1"},
+		{"client forging hops before the proxy", "10.0.0.5:80", "1.2.3.4, 198.51.100.1", "", "198.51.100.1"},
+		{"chain of trusted proxies", "10.0.0.5:80", "198.51.100.1, 192.0.2.1, 10.1.1.1", "", "198.51.100.1"},
+		{"garbage hop", "10.0.0.5:80", "198.51.100.1, not-an-ip", "", "10.0.0.5"},
+		{"trusted proxy with X-Real-Ip", "192.0.2.1:80", "", "198.51.100.1", "198.51.100.1"},
+		{"trusted proxy without headers", "10.0.0.5:80", "", "", "10.0.0.5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			request.RemoteAddr = tt.remoteAddr
+			if tt.forwarded != "" {
+				request.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			if tt.realIP != "" {
+				request.Header.Set("X-Real-Ip", tt.realIP)
+			}
+			var got string
+			proxies.Middleware(http.HandlerFunc(func(_ http.ResponseWriter, request *http.Request) {
+				got = ExtractClientIP(request)
+			})).ServeHTTP(httptest.NewRecorder(), request)
+			if got != tt.want {
+				t.Fatalf("ExtractClientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractClientIPIgnoresHeadersWithoutMiddleware(t *testing.T) {
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.RemoteAddr = "203.0.113.7:4000"
+	request.Header.Set("X-Forwarded-For", "198.51.100.1")
+	request.Header.Set("X-Real-Ip", "198.51.100.2")
+	if got := ExtractClientIP(request); got != "203.0.113.7" {
+		t.Fatalf("ExtractClientIP() = %q, want the peer address", got)
+	}
+}
+
+func TestParseTrustedProxiesRejectsHostnames(t *testing.T) {
+	for _, entry := range []string{"lb.internal", "10.0.0.0/33", ""} {
+		if _, err := ParseTrustedProxies([]string{entry}); err == nil {
+			t.Errorf("ParseTrustedProxies(%q) succeeded", entry)
+		}
+	}
+}
@@ -2,7 +2,7 @@ package utils
 
 import (
 	"net/http"
-	"strings"
+	"strconv"
 	"crypto/rand"
 	"encoding/hex"
 	"github.com/google/uuid"
@@ -21,23 +21,19 @@ func GenerateToken() (string, error) {
 	return hex.EncodeToString(bytes), nil
 }
 
-// extractClientIP extracts the client IP from request headers with fallbacks.
-func ExtractClientIP(request *http.Request) string {
-	// Check X-Forwarded-For header (may be comma-separated)
-	if xf := request.Header.Get("X-Forwarded-For"); xf != "" {
-		parts := strings.Split(xf, ",")
-		return strings.TrimSpace(parts[0])
+// ParsePagination reads limit/offset query parameters, applying a default
+// page size and clamping the limit to maxLimit.
+func ParsePagination(request *http.Request, defaultLimit, maxLimit int) (int, int) {
+	limit := defaultLimit
+	if v, err := strconv.Atoi(request.URL.Query().Get("limit")); err == nil && v > 0 {
+		limit = v
 	}
-
-	// Check X-Real-IP header
-	if xr := request.Header.Get("X-Real-Ip"); xr != "" {
-		return xr
+	if limit > maxLimit {
+		limit = maxLimit
 	}
-
-	// Fall back to RemoteAddr
-	remote := request.RemoteAddr
-	if i := strings.LastIndex(remote, ":"); i != -1 {
-		return remote[:i]
+	offset := 0
+	if v, err := strconv.Atoi(request.URL.Query().Get("offset")); err == nil && v > 0 {
+		offset = v
 	}
-	return remote
-}
\ No newline at end of file
+	return limit, offset
+}
@@ -0,0 +1,17 @@
+package utils
+
+import "context"
+
+type tenantKey struct{}
+
+// WithTenant returns a context scoped to one restaurant. Queries on
+// restaurant-owned models run with it only see and touch that restaurant's rows.
+func WithTenant(ctx context.Context, restaurantID string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, restaurantID)
+}
+
+// TenantFromContext returns the restaurant the context is scoped to, if any
+func TenantFromContext(ctx context.Context) (string, bool) {
+	restaurantID, ok := ctx.Value(tenantKey{}).(string)
+	return restaurantID, ok && restaurantID != ""
+}
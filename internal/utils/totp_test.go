@@ -0,0 +1,155 @@
+package utils
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA-1 key of the RFC 6238 test vectors
+var rfc6238Secret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+// TestTOTPCodeRFC6238 checks the SHA-1 vectors of RFC 6238 Appendix B. The
+// RFC lists 8-digit codes; a 6-digit code is the same number modulo 10^6,
+// its last six digits.
+func TestTOTPCodeRFC6238(t *testing.T) {
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+	for _, tt := range tests {
+		got, err := TOTPCode(rfc6238Secret, TOTPStep(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("T=%d: %v", tt.unix, err)
+		}
+		if want := tt.code[len(tt.code)-TOTPDigits:]; got != want {
+			t.Errorf("T=%d: code %s, want %s", tt.unix, got, want)
+		}
+	}
+}
+
+func TestTOTPCodeAcceptsLowercaseSecret(t *testing.T) {
+	upper, err := TOTPCode(rfc6238Secret, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lower, err := TOTPCode(" "+strings.ToLower(rfc6238Secret)+" ", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if upper != lower {
+		t.Fatalf("lowercase secret gave %s, want %s", lower, upper)
+	}
+}
+
+func TestTOTPCodeRejectsInvalidSecret(t *testing.T) {
+	if _, err := TOTPCode("not base32!", 1); err == nil {
+		t.Fatal("invalid secret accepted")
+	}
+}
+
+func TestTOTPStepBoundaries(t *testing.T) {
+	tests := []struct {
+		unix int64
+		step int64
+	}{
+		{0, 0},
+		{29, 0},
+		{30, 1},
+		{59, 1},
+		{60, 2},
+	}
+	for _, tt := range tests {
+		if got := TOTPStep(time.Unix(tt.unix, 0)); got != tt.step {
+			t.Errorf("T=%d: step %d, want %d", tt.unix, got, tt.step)
+		}
+	}
+}
+
+func TestValidateTOTPSkew(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := TOTPStep(now)
+	codeAt := func(step int64) string {
+		code, err := TOTPCode(rfc6238Secret, step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return code
+	}
+
+	tests := []struct {
+		name   string
+		offset int64
+		skew   int64
+		ok     bool
+	}{
+		{"current step", 0, 0, true},
+		{"previous step without skew", -1, 0, false},
+		{"previous step", -1, 1, true},
+		{"next step", 1, 1, true},
+		{"two steps behind", -2, 1, false},
+		{"two steps ahead", 2, 1, false},
+		{"two steps behind with skew 2", -2, 2, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := ValidateTOTP(rfc6238Secret, codeAt(current+tt.offset), now, tt.skew)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			if ok && step != current+tt.offset {
+				t.Fatalf("matched step %d, want %d", step, current+tt.offset)
+			}
+		})
+	}
+}
+
+func TestValidateTOTPRejectsMalformedCodes(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	code, err := TOTPCode(rfc6238Secret, TOTPStep(now))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, candidate := range []string{"", "12345", "1234567", code + "0", "abcdef"} {
+		if _, ok := ValidateTOTP(rfc6238Secret, candidate, now, 1); ok {
+			t.Errorf("code %q accepted", candidate)
+		}
+	}
+	if _, ok := ValidateTOTP(rfc6238Secret, " "+code+" ", now, 0); !ok {
+		t.Error("code with surrounding spaces rejected")
+	}
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil {
+		t.Fatalf("secret is not base32: %v", err)
+	}
+	if len(key) != 20 {
+		t.Fatalf("secret of %d bytes, want 20", len(key))
+	}
+	other, _ := GenerateTOTPSecret()
+	if other == secret {
+		t.Fatal("two secrets are equal")
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri := TOTPURI("Restaurant Platform", "diner@example.com", "SECRET")
+	want := "otpauth://totp/Restaurant%20Platform:diner@example.com?algorithm=SHA1&digits=6&issuer=Restaurant%20Platform&period=30&secret=SECRET"
+	if uri != want {
+		t.Fatalf("uri\n%s\nwant\n%s", uri, want)
+	}
+}
@@ -0,0 +1,76 @@
+package utils
+
+import "strings"
+
+// DeviceInfo is a coarse description of the client behind a User-Agent header
+type DeviceInfo struct {
+	Browser    string `json:"browser"`
+	OS         string `json:"os"`
+	DeviceType string `json:"device_type"`
+}
+
+// Summary returns a short label such as "Chrome on Windows"
+func (d DeviceInfo) Summary() string {
+	return d.Browser + " on " + d.OS
+}
+
+// ParseUserAgent recognises the common browsers, operating systems and API
+// clients. It is deliberately simple: it only needs to be good enough for a
+// user to tell their own sessions apart.
+func ParseUserAgent(userAgent string) DeviceInfo {
+	ua := strings.ToLower(userAgent)
+	info := DeviceInfo{Browser: "Unknown browser", OS: "Unknown OS", DeviceType: "desktop"}
+	if ua == "" {
+		info.DeviceType = "unknown"
+		return info
+	}
+
+	// Order matters: Edge and Opera also claim to be Chrome, Chrome claims to be Safari
+	switch {
+	case strings.Contains(ua, "edg/") || strings.Contains(ua, "edge/"):
+		info.Browser = "Edge"
+	case strings.Contains(ua, "opr/") || strings.Contains(ua, "opera"):
+		info.Browser = "Opera"
+	case strings.Contains(ua, "samsungbrowser"):
+		info.Browser = "Samsung Internet"
+	case strings.Contains(ua, "firefox/") || strings.Contains(ua, "fxios"):
+		info.Browser = "Firefox"
+	case strings.Contains(ua, "chrome/") || strings.Contains(ua, "crios"):
+		info.Browser = "Chrome"
+	case strings.Contains(ua, "safari/"):
+		info.Browser = "Safari"
+	case strings.Contains(ua, "postmanruntime"):
+		info.Browser = "Postman"
+	case strings.Contains(ua, "curl/"):
+		info.Browser = "curl"
+	case strings.Contains(ua, "okhttp"), strings.Contains(ua, "dart:io"), strings.Contains(ua, "cfnetwork"):
+		info.Browser = "Mobile app"
+	}
+
+	switch {
+	case strings.Contains(ua, "windows"):
+		info.OS = "Windows"
+	case strings.Contains(ua, "iphone"), strings.Contains(ua, "ipad"), strings.Contains(ua, "ios"), strings.Contains(ua, "cfnetwork"):
+		info.OS = "iOS"
+	case strings.Contains(ua, "android"):
+		info.OS = "Android"
+	case strings.Contains(ua, "mac os"), strings.Contains(ua, "macintosh"):
+		info.OS = "macOS"
+	case strings.Contains(ua, "cros"):
+		info.OS = "ChromeOS"
+	case strings.Contains(ua, "linux"):
+		info.OS = "Linux"
+	}
+
+	switch {
+	case strings.Contains(ua, "ipad"), strings.Contains(ua, "tablet"),
+		info.OS == "Android" && !strings.Contains(ua, "mobile"):
+		info.DeviceType = "tablet"
+	case strings.Contains(ua, "mobile"), strings.Contains(ua, "iphone"), info.Browser == "Mobile app":
+		info.DeviceType = "mobile"
+	case info.Browser == "Postman", info.Browser == "curl":
+		info.DeviceType = "api_client"
+	}
+
+	return info
+}
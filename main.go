@@ -1,38 +1,121 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"flag"
 	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 
 	_ "github.com/alibaba0010/postgres-api/docs" // swag doc
+	"github.com/alibaba0010/postgres-api/internal/app"
 	"github.com/alibaba0010/postgres-api/internal/config"
-	"github.com/alibaba0010/postgres-api/internal/database"
 	"github.com/alibaba0010/postgres-api/internal/logger"
-	"github.com/alibaba0010/postgres-api/internal/routes"
 	"go.uber.org/zap"
 )
 
 
 func main(){
 
-	logger.InitLogger()
+	// a console logger reports problems with the configuration itself
+	log := logger.Bootstrap()
+
+	// load the configuration once, from defaults, config.yaml, the
+	// environment and the flags, refusing to run a real deployment with
+	// development secrets
+	cfg, err := config.Load(os.Args[1:], log)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		log.Fatal("Invalid configuration", zap.Error(err))
+	}
+
+	// then the configured one takes over: JSON and sampled in staging and
+	// production
+	log, err = logger.New(logger.Options{
+		Level:    cfg.LOG_LEVEL,
+		Format:   cfg.LOG_FORMAT,
+		Sampling: cfg.LOG_SAMPLING,
+	})
+	if err != nil {
+		logger.Bootstrap().Fatal("Unable to build the logger", zap.Error(err))
+	}
 	// defer sync to flush logs on program exit
-	defer logger.Sync()
+	defer log.Sync()
+
+	// the access token keys are loaded before serving so a broken key file
+	// stops the start, then Postgres and Redis are connected
+	application, err := app.New(cfg, log)
+	if err != nil {
+		log.Fatal("Unable to start", zap.Error(err))
+	}
+
+	// background jobs: reminder emails for upcoming reservations, removal of
+	// expired refresh tokens and completion of requested account deletions
+	application.Services.StartReservationReminder()
+	application.Services.StartRefreshTokenJanitor()
+	application.Services.StartAccountDeletionJob()
+
+	server := &http.Server{
+		Addr:              ":" + strconv.Itoa(cfg.Port),
+		Handler:           application.HTTPHandler(),
+		ReadHeaderTimeout: cfg.HTTP_READ_HEADER_TIMEOUT,
+		ReadTimeout:       cfg.HTTP_READ_TIMEOUT,
+		WriteTimeout:      cfg.HTTP_WRITE_TIMEOUT,
+		IdleTimeout:       cfg.HTTP_IDLE_TIMEOUT,
+	}
+
+	stop, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
 
-	database.ConnectDB()
-	defer database.CloseDB()
+	serveErr := make(chan error, 1)
+	go func() {
+		log.Info("🚀 Server starting", zap.String("url", "http://localhost:"+strconv.Itoa(cfg.Port)+"/swagger/index.html"))
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serveErr <- err
+		}
+	}()
+
+	failed := false
+	select {
+	case <-stop.Done():
+		log.Info("Shutdown signal received, draining requests", zap.Duration("timeout", cfg.SHUTDOWN_TIMEOUT))
+	case err := <-serveErr:
+		log.Error("Server stopped", zap.Error(err))
+		failed = true
+	}
+	// a second signal kills the process instead of waiting for the drain
+	cancel()
 
-	database.ConnectRedis()
-	defer database.CloseRedis()
+	shutdown(application, server, cfg.SHUTDOWN_TIMEOUT)
+	if failed {
+		log.Sync()
+		os.Exit(1)
+	}
+}
 
-	port := config.LoadConfig().Port
-	route := routes.ApiRouter()
+// shutdown stops accepting connections, waits for in-flight requests and
+// then for background work, and closes Postgres and Redis last, once nothing
+// can use them anymore. Everything shares one deadline.
+func shutdown(application *app.App, server *http.Server, timeout time.Duration) {
+	log := application.Log
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
 
-	
-	logger.Log.Info("🚀 Server starting", zap.String("url", "http://localhost:"+port+"/swagger/index.html"))
-	if  err:= http.ListenAndServe(":"+port, route); err != nil {
-		log.Fatal(err)
+	if err := server.Shutdown(ctx); err != nil {
+		log.Error("Requests still running at the shutdown deadline", zap.Error(err))
 	}
+	if err := application.Services.StopBackgroundWork(ctx); err != nil {
+		log.Error("Background work still running at the shutdown deadline", zap.Error(err))
+	}
+
+	application.Close()
+	log.Info("👋 Server stopped")
 }
 
 // http.HandleFunc("/getUser", getUserHandler)